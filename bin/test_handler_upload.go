@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
@@ -93,7 +94,7 @@ func main() {
 	}
 
 	// 3. 初始化 Service
-	savedVideoService := services.NewSavedVideoService(db)
+	savedVideoService := services.NewSavedVideoService(db, nil)
 
 	// 4. 确保数据库中有该视频记录 (Mock Data)
 	if db != nil {
@@ -104,10 +105,10 @@ func main() {
 				VideoID:       *videoID,
 				Title:         fmt.Sprintf("测试视频 %s", *videoID),
 				Description:   "这是一个用于测试 UploadToBilibili Handler 的视频描述。\n包含多行文本。\n测试结束。",
-				Status:        "001",
+				Status:        model.VideoStatusPending,
 				URL:           "https://www.youtube.com/watch?v=dQw4w9WgXcQ", // Dummy
 			}
-			if err := savedVideoService.CreateVideo(newVideo); err != nil {
+			if err := savedVideoService.CreateVideo(newVideo, "test_handler_upload", "创建测试视频记录"); err != nil {
 				logger.Errorf("❌ 创建临时视频记录失败: %v", err)
 				os.Exit(1)
 			}
@@ -126,6 +127,7 @@ func main() {
 	stateManager := &manager.StateManager{
 		VideoID:    *videoID,
 		CurrentDir: videoDir,
+		Artifacts:  manager.NewArtifactStore(*videoID, nil),
 		// 其他字段根据handler需要可能要填充，但主要用到的是 VideoID 和 CurrentDir (在 findVideoFiles 中使用)
 	}
 
@@ -160,17 +162,14 @@ func main() {
 	handler.LoginStore = loginStore
 
 	// 8. 执行
-	context := make(map[string]interface{})
-	// 如果有封面，可以在这里通过 context 传入，或者 args
-	// context["cover_image_path"] = "/path/to/cover.jpg"
+	// 如果有封面，可以在这里通过产物仓库传入，或者 args
+	// stateManager.Artifacts.SetPath(manager.ArtifactCover, "/path/to/cover.jpg")
 
 	logger.Info("🚀 开始执行 UploadToBilibili Handler...")
-	success := handler.Execute(context)
-
-	if success {
-		logger.Info("🎉 Handler 执行成功！")
-	} else {
-		logger.Errorf("❌ Handler 执行失败: %v", context["error"])
+	if err := handler.Execute(context.Background()); err != nil {
+		logger.Errorf("❌ Handler 执行失败: %v", err)
 		os.Exit(1)
 	}
+
+	logger.Info("🎉 Handler 执行成功！")
 }
@@ -27,6 +27,7 @@ require (
 	github.com/difyz9/go-auth v0.0.8
 	github.com/golang-jwt/jwt/v5 v5.2.3
 	github.com/google/generative-ai-go v0.20.1
+	github.com/google/uuid v1.6.0
 	google.golang.org/api v0.186.0
 )
 
@@ -59,7 +60,6 @@ require (
 	github.com/golang/protobuf v1.5.4 // indirect
 	github.com/google/go-querystring v1.1.0 // indirect
 	github.com/google/s2a-go v0.1.7 // indirect
-	github.com/google/uuid v1.6.0 // indirect
 	github.com/googleapis/enterprise-certificate-proxy v0.3.2 // indirect
 	github.com/googleapis/gax-go/v2 v2.12.5 // indirect
 	github.com/jackc/pgpassfile v1.0.0 // indirect
@@ -3,6 +3,7 @@ package base
 import (
 	"github.com/difyz9/ytb2bili/internal/chain_task/manager"
 	"github.com/difyz9/ytb2bili/pkg/cos"
+	"github.com/difyz9/ytb2bili/pkg/events"
 )
 
 // BaseTask 基础任务实现
@@ -38,3 +39,17 @@ func (t *BaseTask) UpdateStatus(status, message string) error {
 	//return t.StateManager.UpdateTBVideo(t.Name, status, message)
 	return nil
 }
+
+// ReportProgress 发布任务进度事件，total 未知时传 0
+func (t *BaseTask) ReportProgress(current, total int64, message string) {
+	if t.StateManager == nil || t.StateManager.Events == nil {
+		return
+	}
+	t.StateManager.Events.Publish(events.Event{
+		Type:     events.StepProgress,
+		VideoID:  t.StateManager.VideoID,
+		Step:     t.Name,
+		Message:  message,
+		Progress: events.NewProgress(current, total),
+	})
+}
@@ -1,12 +1,16 @@
 package chain_task
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"time"
 
-	"github.com/difyz9/ytb2bili/internal/chain_task/handlers"
 	"github.com/difyz9/ytb2bili/internal/chain_task/manager"
+	"github.com/difyz9/ytb2bili/internal/chain_task/steps"
 	"github.com/difyz9/ytb2bili/internal/core"
 	models2 "github.com/difyz9/ytb2bili/internal/core/models"
 	"github.com/difyz9/ytb2bili/internal/core/services"
@@ -21,240 +25,590 @@ import (
 	"gorm.io/gorm"
 )
 
+var (
+	// ErrVideoCancelled 用户取消了视频的处理任务
+	ErrVideoCancelled = errors.New("任务已被用户取消")
+	// ErrShuttingDown 服务关闭，中断正在执行的任务
+	ErrShuttingDown = errors.New("服务正在关闭")
+)
+
+// cancelCause 用户取消视频时中断任务的原因，记录请求取消的执行者
+type cancelCause struct {
+	actor string
+}
+
+func (c *cancelCause) Error() string {
+	return ErrVideoCancelled.Error()
+}
+
+func (c *cancelCause) Unwrap() error {
+	return ErrVideoCancelled
+}
+
 // ChainTaskHandler 任务链执行器的实现
 type ChainTaskHandler struct {
 	App *core.AppServer
 
 	SavedVideoService *services.SavedVideoService
 	TaskStepService   *services.TaskStepService
+	ArtifactService   *services.ArtifactService
+	JobService        *services.JobService
 
-	isRunning bool
-	Task      *cron.Cron
-	Db        *gorm.DB
-	mutex     sync.Mutex
+	// Limiter 下载、ffmpeg、语音识别、大模型调用的并发限制，所有视频共享
+	Limiter *manager.ResourceLimiter
+
+	Task    *cron.Cron
+	Db      *gorm.DB
+	workers chan struct{}                      // 工作池槽位，容量即同时处理的视频数
+	active  map[string]context.CancelCauseFunc // 正在处理的视频ID及其取消函数
+	mutex   sync.Mutex
+
+	workerID string        // 实例标识，作为任务租约的持有者
+	lease    time.Duration // 任务租约时长，每三分之一租约时长续约一次
+
+	// 根上下文，服务关闭时以 ErrShuttingDown 取消
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	wg     sync.WaitGroup
 }
 
-func NewChainTaskHandler(app *core.AppServer, task *cron.Cron, db *gorm.DB, savedVideoService *services.SavedVideoService, taskStepService *services.TaskStepService) *ChainTaskHandler {
+func NewChainTaskHandler(app *core.AppServer, task *cron.Cron, db *gorm.DB, savedVideoService *services.SavedVideoService, taskStepService *services.TaskStepService, artifactService *services.ArtifactService, jobService *services.JobService) *ChainTaskHandler {
+	workerConfig := app.Config.WorkerConfig
+	if workerConfig == nil {
+		workerConfig = types.NewDefaultConfig().WorkerConfig
+	}
+	workers := workerConfig.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	lease := time.Duration(workerConfig.LeaseTimeout) * time.Second
+	if lease <= 0 {
+		lease = time.Minute
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+
 	return &ChainTaskHandler{
 		App:               app,
 		Task:              task,
 		Db:                db,
 		SavedVideoService: savedVideoService,
 		TaskStepService:   taskStepService,
-		mutex:             sync.Mutex{},
-		isRunning:         false,
+		ArtifactService:   artifactService,
+		JobService:        jobService,
+		Limiter: manager.NewResourceLimiter(map[manager.ResourceClass]int{
+			manager.ResourceDownload: workerConfig.MaxDownloads,
+			manager.ResourceFFmpeg:   workerConfig.MaxFFmpeg,
+			manager.ResourceASR:      workerConfig.MaxASR,
+			manager.ResourceLLM:      workerConfig.MaxLLM,
+		}),
+		workers:  make(chan struct{}, workers),
+		active:   make(map[string]context.CancelCauseFunc),
+		mutex:    sync.Mutex{},
+		workerID: workerIdentity(workerConfig),
+		lease:    lease,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// workerIdentity 实例标识，未配置时使用主机名和进程号
+func workerIdentity(config *types.WorkerConfig) string {
+	if config.InstanceID != "" {
+		return config.InstanceID
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
 	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
 }
 
 // SetUp 启动任务消费者
 func (h *ChainTaskHandler) SetUp() {
-	// 应用启动时重置所有"运行中"的任务步骤
-	h.resetRunningTasksOnStartup()
+	// 为旧版本创建的任务步骤补全步骤标识
+	if err := h.TaskStepService.BackfillStepKeys(steps.KeysByName()); err != nil {
+		h.App.Logger.Errorf("补全任务步骤标识失败: %v", err)
+	}
 
-	// 添加定时任务
-	h.Task.AddFunc("*/5 * * * * *", func() {
+	// 检查配置的处理流程，无效的流程在视频使用时会直接失败
+	for _, name := range steps.Names(h.App.Config) {
+		if _, err := steps.Resolve(h.App.Config, name); err != nil {
+			h.App.Logger.Warnf("⚠️ %v", err)
+		}
+	}
 
-		h.mutex.Lock()
-		defer h.mutex.Unlock()
+	// 恢复旧版本遗留的处理中视频和运行中步骤
+	// 其他实例（或本实例上次运行）持有的任务不在这里重置，租约过期后会被重新领取
+	h.recoverOrphans()
 
-		if h.isRunning {
-			h.App.Logger.Debug("当前有任务正在执行，跳过本次请求")
-			return
-		}
+	// 添加定时任务，每次调度把空闲的工作者分配给待处理的任务
+	h.Task.AddFunc("*/5 * * * * *", h.dispatch)
+	h.Task.AddFunc("0 * * * * *", h.recoverOrphans)
 
-		// 1. 优先处理重试的任务步骤
-		retrySteps, err := h.getRetrySteps()
-		if err != nil {
-			h.App.Logger.Errorf("查询重试步骤失败: %v", err)
-		} else if len(retrySteps) > 0 {
-			h.App.Logger.Infof("发现 %d 个待重试的步骤", len(retrySteps))
-			h.isRunning = true
-
-			// 执行重试步骤
-			for _, step := range retrySteps {
-				h.App.Logger.Infof("🔄 开始重试步骤: %s - %s", step.VideoID, step.StepName)
-				if err := h.RunSingleTaskStep(step.VideoID, step.StepName); err != nil {
-					h.App.Logger.Errorf("重试步骤失败: %v", err)
-				}
+	// 启动 cron 调度器
+	h.Task.Start()
+	h.App.Logger.Infof("✓ Cron scheduler started, checking for tasks every 5 seconds (%d workers, instance %s)", cap(h.workers), h.workerID)
+}
+
+// dispatch 将待处理的视频和待重试的步骤加入任务队列，并为空闲的工作者领取任务
+func (h *ChainTaskHandler) dispatch() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	// 服务正在关闭，不再领取新任务
+	if h.ctx.Err() != nil {
+		return
+	}
+
+	h.enqueuePending()
+
+	free := cap(h.workers) - len(h.workers)
+	if free <= 0 {
+		h.App.Logger.Debug("没有空闲的工作者，等待下次调度")
+		return
+	}
+
+	// 原子领取任务，多个实例共享数据库时不会领取到同一任务
+	jobs, err := h.JobService.Claim(h.workerID, free, h.lease)
+	if err != nil {
+		h.App.Logger.Errorf("领取任务失败: %v", err)
+		return
+	}
+
+	if len(jobs) == 0 {
+		h.App.Logger.Debug("没有待处理的任务")
+		return
+	}
+
+	for _, job := range jobs {
+		// 本实例仍在处理该视频（租约过期后被重新领取），等待原任务退出
+		if _, running := h.active[job.VideoID]; running || !h.acquireWorker() {
+			if err := h.JobService.Release(job, h.workerID); err != nil {
+				h.App.Logger.Errorf("释放任务失败: %v", err)
 			}
+			continue
+		}
+		h.runJob(job)
+	}
+}
 
-			h.isRunning = false
-			return
+// enqueuePending 将待处理的视频和待重试的步骤加入任务队列
+// 同一视频同时只有一个排队或执行中的任务，重复加入会被忽略
+func (h *ChainTaskHandler) enqueuePending() {
+	// 1. 重试的任务步骤
+	retrySteps, err := h.getRetrySteps()
+	if err != nil {
+		h.App.Logger.Errorf("查询重试步骤失败: %v", err)
+	}
+	for _, step := range retrySteps {
+		if _, err := h.JobService.Enqueue(step.VideoID, model.JobKindStep, step.StepKey); err != nil {
+			h.App.Logger.Errorf("重试步骤加入任务队列失败: %v", err)
 		}
+	}
+
+	// 2. 新的视频任务（状态为 '001'）
+	if _, err := h.JobService.EnqueuePendingVideos(cap(h.workers) * 2); err != nil {
+		h.App.Logger.Errorf("待处理任务加入任务队列失败: %v", err)
+	}
+}
+
+// runJob 执行领取的任务，调用方需持有锁并已占用槽位
+func (h *ChainTaskHandler) runJob(job *model.Job) {
+	switch job.Kind {
+	case model.JobKindChain:
+		// 状态流转
+
+		// 001 (待处理) → 002 (处理中) → 200 (准备完成)、900 (需要人工处理) 或 999 (失败)
 
-		// 2. 处理新的视频任务
-		// 查询状态为 '001' 的任务
-		pendingTasks, err := h.getPendingTasks()
+		savedVideo, err := h.SavedVideoService.GetVideoByVideoID(job.VideoID)
 		if err != nil {
-			h.App.Logger.Errorf("查询待处理任务失败: %v", err)
+			h.skipJob(job, fmt.Sprintf("获取视频信息失败: %v", err))
+			return
+		}
+		// 视频已被取消或删除时跳过
+		claimed, err := h.SavedVideoService.ClaimPendingVideo(savedVideo.ID, model.StatusActorScheduler)
+		if err != nil || !claimed {
+			h.skipJob(job, fmt.Sprintf("视频状态为 %s，无需处理 (err: %v)", savedVideo.Status, err))
 			return
 		}
 
-		if len(pendingTasks) == 0 {
-			h.App.Logger.Debug("没有待处理的任务")
+		video := models2.TbVideo{
+			Id:        savedVideo.ID,
+			URL:       savedVideo.URL,
+			Title:     savedVideo.Title,
+			VideoId:   savedVideo.VideoID,
+			Status:    string(model.VideoStatusProcessing),
+			CreatedAt: savedVideo.CreatedAt,
+			UpdatedAt: savedVideo.UpdatedAt,
+		}
+		h.App.Logger.Infof("找到待处理任务，VideoId: %s", video.VideoId)
+		h.startWorker(job, func(ctx context.Context) {
+			h.App.Logger.Debug("开始执行任务链")
+			h.RunTaskChain(ctx, video)
+			h.App.Logger.Debug("任务链执行完成")
+		})
+
+	case model.JobKindStep:
+		// 步骤已被取消或已由其他方式完成时跳过
+		step, err := h.TaskStepService.GetTaskStepByKey(job.VideoID, job.StepKey)
+		if err != nil || (step.Status != model.TaskStepStatusPending && step.Status != model.TaskStepStatusRunning) {
+			h.skipJob(job, fmt.Sprintf("步骤 %s 无需重试 (err: %v)", job.StepKey, err))
 			return
 		}
 
-		// 状态流转
+		videoID, stepKey := job.VideoID, steps.Key(job.StepKey)
+		h.App.Logger.Infof("🔄 开始重试步骤: %s - %s", videoID, stepKey)
+		h.startWorker(job, func(ctx context.Context) {
+			if err := h.RunSingleTaskStep(ctx, videoID, stepKey); err != nil {
+				h.App.Logger.Errorf("重试步骤失败: %v", err)
+			}
+		})
 
-		// 001 (待处理) → 002 (处理中) → 100 (完成) 或 999 (失败)
+	default:
+		h.skipJob(job, fmt.Sprintf("未知的任务类型: %s", job.Kind))
+	}
+}
 
-		// 执行第一个待处理任务
-		task := pendingTasks[0]
-		h.App.Logger.Infof("找到待处理任务，VideoId: %s", task.VideoId)
+// skipJob 取消无需执行的任务并释放槽位
+func (h *ChainTaskHandler) skipJob(job *model.Job, reason string) {
+	h.App.Logger.Warnf("跳过任务 %d (VideoID: %s): %s", job.ID, job.VideoID, reason)
+	if err := h.JobService.Cancel(job, h.workerID); err != nil {
+		h.App.Logger.Errorf("取消任务失败: %v", err)
+	}
+	h.releaseWorker()
+}
 
-		// 更新任务状态为处理中
-		if err := h.updateSavedVideoStatus(task.Id, "002"); err != nil {
-			h.App.Logger.Errorf("更新任务状态为处理中时出错: %v", err)
-			return
-		}
+// acquireWorker 尝试占用一个工作者槽位，不阻塞
+func (h *ChainTaskHandler) acquireWorker() bool {
+	select {
+	case h.workers <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
 
-		h.isRunning = true
-		h.App.Logger.Debug("开始执行任务链")
+// releaseWorker 释放工作者槽位
+func (h *ChainTaskHandler) releaseWorker() {
+	<-h.workers
+}
 
-		// 执行任务链
-		h.RunTaskChain(*task)
+// startWorker 在新的工作者中执行任务，调用方需持有锁并已占用槽位
+// 任务的上下文在视频被取消、服务关闭或租约失效时取消，执行期间定期续约
+func (h *ChainTaskHandler) startWorker(job *model.Job, run func(ctx context.Context)) {
+	videoID := job.VideoID
+	ctx, cancel := context.WithCancelCause(h.ctx)
+	h.active[videoID] = cancel
+	h.wg.Add(1)
+
+	go func() {
+		stopHeartbeat := h.keepAlive(job, cancel)
+		defer func() {
+			if r := recover(); r != nil {
+				h.App.Logger.Errorf("视频 %s 的任务发生异常: %v", videoID, r)
+			}
+			stopHeartbeat()
+			h.finishJob(ctx, job)
+			cancel(nil)
+			h.mutex.Lock()
+			delete(h.active, videoID)
+			h.mutex.Unlock()
+			h.releaseWorker()
+			h.wg.Done()
+		}()
+
+		run(ctx)
+	}()
+}
 
-		h.isRunning = false
-		h.App.Logger.Debug("任务链执行完成")
-	})
+// keepAlive 定期为任务续约，租约失效或其他实例请求取消时取消任务，返回停止续约的函数
+// 任务被取消后仍继续续约，直到任务退出
+func (h *ChainTaskHandler) keepAlive(job *model.Job, cancel context.CancelCauseFunc) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(h.lease / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				cancelledBy, err := h.JobService.Heartbeat(job, h.workerID, h.lease)
+				if errors.Is(err, services.ErrLeaseLost) {
+					h.App.Logger.Warnf("视频 %s 的任务租约已失效，停止处理", job.VideoID)
+					cancel(services.ErrLeaseLost)
+					return
+				}
+				if err != nil {
+					// 数据库暂时不可用时继续执行，租约到期前恢复即可
+					h.App.Logger.Errorf("视频 %s 的任务续约失败: %v", job.VideoID, err)
+					continue
+				}
+				if cancelledBy != "" {
+					cancel(&cancelCause{actor: cancelledBy})
+				}
+			}
+		}
+	}()
 
-	// 启动 cron 调度器
-	h.Task.Start()
-	h.App.Logger.Info("✓ Cron scheduler started, checking for tasks every 5 seconds")
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// finishJob 结束任务
+// 服务关闭时释放租约，任务重新排队以便其他实例立即接手；租约失效时由新的持有者负责
+func (h *ChainTaskHandler) finishJob(ctx context.Context, job *model.Job) {
+	var err error
+	switch cause := context.Cause(ctx); {
+	case errors.Is(cause, services.ErrLeaseLost):
+		return
+	case errors.Is(cause, ErrShuttingDown):
+		err = h.JobService.Release(job, h.workerID)
+	default:
+		err = h.JobService.Complete(job, h.workerID)
+	}
+	if err != nil {
+		h.App.Logger.Errorf("结束任务 %d 失败: %v", job.ID, err)
+	}
 }
 
-// resetRunningTasksOnStartup 应用启动时重置所有"运行中"的任务步骤
-func (h *ChainTaskHandler) resetRunningTasksOnStartup() {
-	h.App.Logger.Info("🔄 正在重置应用重启前的运行中任务...")
+// CancelVideo 取消视频的处理任务，actor 为请求取消的执行者，记录到状态历史
+// 正在执行的任务会被中断（结束 yt-dlp、ffmpeg 等子进程），等待处理的视频直接标记为已取消
+func (h *ChainTaskHandler) CancelVideo(videoID, actor string) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if cancel, running := h.active[videoID]; running {
+		h.App.Logger.Infof("🛑 取消正在执行的视频任务: %s", videoID)
+		cancel(&cancelCause{actor: actor})
+		return nil
+	}
 
-	// 重置所有"运行中"状态的任务步骤为"待执行"
-	err := h.TaskStepService.ResetAllRunningTasks()
+	video, err := h.SavedVideoService.GetVideoByVideoID(videoID)
 	if err != nil {
-		h.App.Logger.Errorf("❌ 重置运行中任务失败: %v", err)
-		return
+		return fmt.Errorf("获取视频信息失败: %v", err)
+	}
+
+	// 已被其他实例领取的视频状态已不是 001，不会被取消
+	cancelled, err := h.SavedVideoService.CompareAndSetStatus(video.ID, model.VideoStatusPending, model.VideoStatusCancelled, actor, "用户取消")
+	if err != nil {
+		return fmt.Errorf("更新视频状态失败: %v", err)
+	}
+	if !cancelled {
+		// 视频可能正在其他实例上处理，由持有租约的实例在下次续约时取消
+		requested, err := h.JobService.RequestCancel(videoID, actor)
+		if err != nil {
+			return err
+		}
+		if requested {
+			h.App.Logger.Infof("🛑 已请求取消其他实例正在执行的视频任务: %s", videoID)
+			return nil
+		}
+		return fmt.Errorf("视频当前状态为 %s，没有可取消的任务", video.Status)
+	}
+
+	if _, err := h.JobService.CancelQueued(videoID); err != nil {
+		h.App.Logger.Errorf("取消排队的任务失败: %v", err)
+	}
+	if err := h.TaskStepService.CancelPendingSteps(videoID); err != nil {
+		h.App.Logger.Errorf("取消待执行步骤失败: %v", err)
 	}
+	h.App.Logger.Infof("🛑 已取消等待处理的视频: %s", videoID)
+	return nil
+}
 
-	h.App.Logger.Info("✅ 已重置所有运行中的任务步骤，它们将在下次调度时重新执行")
+// Shutdown 停止调度并中断正在执行的任务，等待工作者退出
+// 被中断的视频重置为待处理，下次启动时重新执行
+func (h *ChainTaskHandler) Shutdown(ctx context.Context) error {
+	cronCtx := h.Task.Stop()
+	h.cancel(ErrShuttingDown)
+
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		<-cronCtx.Done()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		h.App.Logger.Info("✓ 所有任务已停止")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("等待任务停止超时: %v", ctx.Err())
+	}
 }
 
-// getPendingTasks 获取状态为 '001' 的待处理任务（从 SavedVideo 表查询）
-func (h *ChainTaskHandler) getPendingTasks() ([]*models2.TbVideo, error) {
-	// 使用 SavedVideoService 查询状态为 '001' 的任务
-	savedVideos, err := h.SavedVideoService.GetPendingVideos(10)
+// recoverOrphans 恢复没有任务在执行的处理中视频和运行中步骤
+// 通常是旧版本遗留，或任务结束前服务异常退出；正在执行的任务由租约保护，不受影响
+func (h *ChainTaskHandler) recoverOrphans() {
+	videoIDs, err := h.JobService.GetOrphanedVideoIDs()
 	if err != nil {
-		return nil, err
+		h.App.Logger.Errorf("❌ %v", err)
 	}
-
-	// 将 SavedVideo 转换为 TbVideo 格式
-	var tasks []*models2.TbVideo
-	for _, sv := range savedVideos {
-		task := &models2.TbVideo{
-			Id:        sv.ID,
-			URL:       sv.URL,
-			Title:     sv.Title,
-			VideoId:   sv.VideoID,
-			Status:    sv.Status,
-			CreatedAt: sv.CreatedAt,
-			UpdatedAt: sv.UpdatedAt,
+	for _, videoID := range videoIDs {
+		enqueued, err := h.JobService.Enqueue(videoID, model.JobKindChain, "")
+		if err != nil {
+			h.App.Logger.Errorf("❌ 视频 %s 重新加入任务队列失败: %v", videoID, err)
+		} else if enqueued {
+			h.App.Logger.Infof("♻️ 视频 %s 处于处理中但没有任务在执行，已重新加入任务队列", videoID)
 		}
-		tasks = append(tasks, task)
 	}
 
-	return tasks, nil
+	if err := h.TaskStepService.ResetOrphanedSteps(steps.KeysOf(steps.StagePrepare)); err != nil {
+		h.App.Logger.Errorf("❌ 重置运行中任务步骤失败: %v", err)
+	}
 }
 
-// getRetrySteps 获取状态为 'pending' 的重试步骤
+// getRetrySteps 获取状态为 'pending' 的重试步骤，上传阶段的步骤由上传调度器执行
 func (h *ChainTaskHandler) getRetrySteps() ([]*model.TaskStep, error) {
-	return h.TaskStepService.GetPendingSteps()
+	return h.TaskStepService.GetPendingSteps(steps.KeysOf(steps.StagePrepare))
 }
-func (h *ChainTaskHandler) RunTaskChain(video models2.TbVideo) {
+func (h *ChainTaskHandler) RunTaskChain(ctx context.Context, video models2.TbVideo) {
 
 	currentDir, err := filepath.Abs(h.App.Config.FileUpDir)
 	if err != nil {
 		h.App.Logger.Errorf("获取文件上传目录失败: %v", err)
 		// 任务失败，更新状态为失败
-		if updateErr := h.SavedVideoService.UpdateStatus(video.Id, "999"); updateErr != nil {
+		if updateErr := h.updateSavedVideoStatus(video.Id, model.VideoStatusFailed, fmt.Sprintf("获取文件上传目录失败: %v", err)); updateErr != nil {
 			h.App.Logger.Errorf("更新任务状态为失败时出错: %v", updateErr)
 		}
 		return
 
 	}
 
+	// 获取视频使用的处理流程
+	pipeline, err := h.resolvePipeline(video.VideoId)
+	if err != nil {
+		h.App.Logger.Errorf("任务 %s 获取处理流程失败: %v", video.VideoId, err)
+		if updateErr := h.updateSavedVideoStatus(video.Id, model.VideoStatusFailed, fmt.Sprintf("获取处理流程失败: %v", err)); updateErr != nil {
+			h.App.Logger.Errorf("更新任务状态为失败时出错: %v", updateErr)
+		}
+		return
+	}
+	h.App.Logger.Infof("任务 %s 使用处理流程: %s", video.VideoId, pipelineName(pipeline))
+
 	// 初始化任务步骤
-	if err := h.TaskStepService.InitTaskSteps(video.VideoId); err != nil {
+	if err := h.TaskStepService.InitTaskSteps(video.VideoId, pipeline.Specs()); err != nil {
 		h.App.Logger.Errorf("初始化任务步骤失败: %v", err)
 	}
 
-	stateManager := manager.NewStateManager(video.Id, video.VideoId, currentDir, video.CreatedAt)
-	chain := manager.NewTaskChain()
-
-
-	//// 任务1: 下载视频
-	downloadTask := handlers.NewDownloadVideo("下载视频", h.App, stateManager, h.App.CosClient, h.SavedVideoService)
-	chain.AddTask(h.wrapTaskWithStepTracking(downloadTask, video.VideoId))
-
-	// 任务2: 生成字幕文件
-	extractAudioTask := handlers.NewExtractAudio("分离音频", h.App, stateManager, h.App.CosClient)
-	chain.AddTask(h.wrapTaskWithStepTracking(extractAudioTask, video.VideoId))
-
-	// 任务3: 使用 B站必剪 转录生成字幕（如果启用）
-	if h.App.Config.WhisperConfig != nil && h.App.Config.WhisperConfig.Enabled {
-		h.App.Logger.Info("✓ B站必剪 已启用，将使用 B站必剪 进行语音转录")
-		whisperTask := handlers.NewBcutHandler(
-			"B站必剪转录",
-			h.App,
-			stateManager,
-			h.App.CosClient,
-			h.App.Config.WhisperConfig.Language,
-		)
-		chain.AddTask(h.wrapTaskWithStepTracking(whisperTask, video.VideoId))
-	} else {
-		// 备用方案：使用原有的字幕生成方法
-		h.App.Logger.Info("使用默认字幕生成方法")
-		subtitleTask := handlers.NewGenerateSubtitles("生成字幕", h.App, stateManager, h.App.CosClient, h.SavedVideoService)
-		chain.AddTask(h.wrapTaskWithStepTracking(subtitleTask, video.VideoId))
+	stateManager := manager.NewStateManager(video.Id, video.VideoId, currentDir, video.CreatedAt, h.ArtifactService)
+	stateManager.Limiter = h.Limiter
+	stateManager.Events = h.App.Events
+	graph := manager.NewTaskGraph()
+
+	// 任务按所需/产出的产物组成依赖图，互不依赖的分支并发执行：
+	//
+	//   下载视频 → 分离音频 → 字幕转录 → 翻译字幕
+	//                                 ↘ 生成视频元数据
+	//   下载封面（独立执行）
+	//
+	// 流程包含检查视频信息的步骤时，下载等步骤在其之后执行，视频不符合过滤规则时不再下载
+	// 启用 B站必剪 时通过语音识别生成字幕，否则使用插件提交的字幕，不依赖音频
+	// 视频指定了处理流程时只执行流程中的步骤
+	for _, step := range pipeline.Steps {
+		if step.Stage != steps.StagePrepare {
+			continue
+		}
+		deps := steps.Deps{App: h.App, State: stateManager, DB: h.Db, SavedVideoService: h.SavedVideoService, Options: step.Options}
+		graph.AddTask(h.wrapTaskWithStepTracking(step.New(deps), stateManager, step.Outputs()), step.Dependencies(), step.Produces)
 	}
-	chain.AddTask(handlers.NewDownloadImgHandler("下载封面", h.App, stateManager, h.App.CosClient))
-	// 任务3: 翻译字幕（动态检查配置）
-	translateTask := handlers.NewTranslateSubtitle("翻译字幕", h.App, stateManager, h.App.CosClient, h.Db, "")
-	chain.AddTask(h.wrapTaskWithStepTracking(translateTask, video.VideoId))
-
-	// 任务4: 生成视频标题和描述（动态检查配置）
-	metadataTask := handlers.NewGenerateMetadata("生成视频元数据", h.App, stateManager, h.App.CosClient, "", h.Db, h.SavedVideoService)
-	chain.AddTask(h.wrapTaskWithStepTracking(metadataTask, video.VideoId))
 
 	// 注意: 上传任务已移至 UploadScheduler 定时执行
 	// - 视频上传: 每小时上传一个视频
 	// - 字幕上传: 视频上传后1小时再上传字幕
 
-	h.App.Logger.Info("开始执行任务链（准备阶段）")
+	h.App.Logger.Info("开始执行任务图（准备阶段）")
 	startTime := time.Now()
 
-	// 执行任务链
-	result := chain.Run(true)
+	// 执行任务图
+	result, err := graph.Run(ctx)
+
+	// 租约失效，视频已由其他工作者接手，不再更新状态
+	if errors.Is(context.Cause(ctx), services.ErrLeaseLost) {
+		h.App.Logger.Warnf("任务 %s 的租约已失效，已由其他工作者接手", video.VideoId)
+		return
+	}
 
 	duration := time.Since(startTime)
-	h.App.Logger.Infof("任务链执行完成, 耗时: %v", duration)
+	h.App.Logger.Infof("任务图执行完成, 耗时: %v", duration)
 
-	// 检查任务链是否成功执行（如果context中有错误信息，则认为失败）
-	success := true
-	if errorMsg, exists := result["error"]; exists && errorMsg != nil {
-		success = false
-		h.App.Logger.Errorf("任务链执行过程中发生错误: %v", errorMsg)
+	success := err == nil && result.Success()
+	attention := false
+	filtered := false
+	reason := ""
+	if err != nil {
+		h.App.Logger.Errorf("任务图构建失败: %v", err)
+		reason = fmt.Sprintf("任务图构建失败: %v", err)
+	} else {
+		for name, status := range result.Statuses {
+			switch status {
+			case manager.NodeFailed:
+				h.App.Logger.Errorf("任务 %s 执行失败: %v", name, result.Errors[name])
+				if needsAttention(result.Errors[name]) {
+					attention = true
+				}
+				if isFiltered(result.Errors[name]) {
+					filtered = true
+					reason = result.Errors[name].Error()
+					continue
+				}
+				if !filtered {
+					reason = fmt.Sprintf("任务 %s 执行失败: %v", name, result.Errors[name])
+				}
+			case manager.NodeSkipped:
+				// 因依赖失败而未执行的步骤标记为跳过
+				if err := h.TaskStepService.UpdateTaskStepStatus(video.VideoId, name, model.TaskStepStatusSkipped, result.Errors[name].Error()); err != nil {
+					h.App.Logger.Errorf("更新任务步骤状态失败: %v", err)
+				}
+			case manager.NodeCancelled:
+				if err := h.TaskStepService.UpdateTaskStepStatus(video.VideoId, name, model.TaskStepStatusCancelled, context.Cause(ctx).Error()); err != nil {
+					h.App.Logger.Errorf("更新任务步骤状态失败: %v", err)
+				}
+			}
+		}
 	}
 
 	// 根据执行结果更新任务状态
-	if success {
-		// 任务成功完成，更新状态为完成
-		if err := h.updateSavedVideoStatus(video.Id, "200"); err != nil {
+	if ctx.Err() != nil {
+		h.finishCancelledVideo(ctx, video)
+	} else if success {
+		// 任务成功完成，等待上传；流程中没有上传步骤时直接标记为全部完成
+		status, reason := model.VideoStatusReady, "处理完成，等待上传"
+		if !pipeline.Has(steps.UploadVideo) {
+			status, reason = model.VideoStatusCompleted, "处理完成，流程中没有上传步骤"
+		}
+		if err := h.updateSavedVideoStatus(video.Id, status, reason); err != nil {
 			h.App.Logger.Errorf("更新任务状态为完成时出错: %v", err)
 		} else {
-			h.App.Logger.Infof("任务 %s 执行成功，状态已更新为完成", video.VideoId)
+			h.App.Logger.Infof("任务 %s 执行成功，状态已更新为 %s", video.VideoId, status)
+		}
+	} else if filtered {
+		// 不符合过滤规则，其余步骤不再执行
+		if err := h.updateSavedVideoStatus(video.Id, model.VideoStatusFiltered, reason); err != nil {
+			h.App.Logger.Errorf("更新任务状态为已过滤时出错: %v", err)
+		} else {
+			h.App.Logger.Infof("任务 %s 已过滤: %s", video.VideoId, reason)
+		}
+	} else if attention {
+		// 认证失效或额度不足，重试无效，等待人工处理后再重试
+		if err := h.updateSavedVideoStatus(video.Id, model.VideoStatusNeedsAttention, reason); err != nil {
+			h.App.Logger.Errorf("更新任务状态为需要处理时出错: %v", err)
+		} else {
+			h.App.Logger.Warnf("任务 %s 需要人工处理（cookies/API Key 失效或额度不足），状态已更新为 900", video.VideoId)
 		}
 	} else {
 		// 任务失败，更新状态为失败
-		if err := h.updateSavedVideoStatus(video.Id, "999"); err != nil {
+		if err := h.updateSavedVideoStatus(video.Id, model.VideoStatusFailed, reason); err != nil {
 			h.App.Logger.Errorf("更新任务状态为失败时出错: %v", err)
 		} else {
 			h.App.Logger.Errorf("任务 %s 执行失败，状态已更新为失败", video.VideoId)
@@ -263,9 +617,42 @@ func (h *ChainTaskHandler) RunTaskChain(video models2.TbVideo) {
 
 }
 
+// finishCancelledVideo 处理被中断的视频
+// 服务关闭时重置为待处理以便重启后继续，用户取消时标记为已取消
+func (h *ChainTaskHandler) finishCancelledVideo(ctx context.Context, video models2.TbVideo) {
+	if errors.Is(context.Cause(ctx), ErrShuttingDown) {
+		if err := h.updateSavedVideoStatus(video.Id, model.VideoStatusPending, "服务关闭，等待重新处理"); err != nil {
+			h.App.Logger.Errorf("重置任务状态为待处理时出错: %v", err)
+		} else {
+			h.App.Logger.Infof("任务 %s 因服务关闭被中断，将在下次启动时重新执行", video.VideoId)
+		}
+		return
+	}
+
+	if err := h.TaskStepService.CancelPendingSteps(video.VideoId); err != nil {
+		h.App.Logger.Errorf("取消待执行步骤失败: %v", err)
+	}
+	actor := model.StatusActorScheduler
+	var cause *cancelCause
+	if errors.As(context.Cause(ctx), &cause) {
+		actor = cause.actor
+	}
+	if err := h.SavedVideoService.Transition(video.Id, model.VideoStatusCancelled, actor, "用户取消"); err != nil {
+		h.App.Logger.Errorf("更新任务状态为已取消时出错: %v", err)
+	} else {
+		h.App.Logger.Infof("任务 %s 已取消", video.VideoId)
+	}
+}
+
 // RunSingleTaskStep 执行单个任务步骤
-func (h *ChainTaskHandler) RunSingleTaskStep(videoID, stepName string) error {
-	// 注意：此方法假设调用方已经获得了锁，因此不在这里加锁
+func (h *ChainTaskHandler) RunSingleTaskStep(ctx context.Context, videoID string, stepKey steps.Key) error {
+	// 注意：调用方需保证同一视频没有其他工作者在处理
+
+	step, ok := steps.Get(stepKey)
+	if !ok {
+		return fmt.Errorf("未知的任务步骤: %s", stepKey)
+	}
+	key := string(step.Key)
 
 	// 获取视频信息
 	savedVideo, err := h.SavedVideoService.GetVideoByVideoID(videoID)
@@ -279,7 +666,7 @@ func (h *ChainTaskHandler) RunSingleTaskStep(videoID, stepName string) error {
 		URL:       savedVideo.URL,
 		Title:     savedVideo.Title,
 		VideoId:   savedVideo.VideoID,
-		Status:    savedVideo.Status,
+		Status:    string(savedVideo.Status),
 		CreatedAt: savedVideo.CreatedAt,
 		UpdatedAt: savedVideo.UpdatedAt,
 	}
@@ -290,103 +677,173 @@ func (h *ChainTaskHandler) RunSingleTaskStep(videoID, stepName string) error {
 		return fmt.Errorf("获取文件上传目录失败: %v", err)
 	}
 
-	// 创建状态管理器
-	stateManager := manager.NewStateManager(video.Id, video.VideoId, currentDir, video.CreatedAt)
+	// 创建状态管理器（从数据库恢复之前步骤的产物）
+	stateManager := manager.NewStateManager(video.Id, video.VideoId, currentDir, video.CreatedAt, h.ArtifactService)
+	stateManager.Limiter = h.Limiter
+	stateManager.Events = h.App.Events
 
 	// 重置步骤状态
-	if err := h.TaskStepService.ResetTaskStep(videoID, stepName); err != nil {
+	if err := h.TaskStepService.ResetTaskStep(videoID, key); err != nil {
 		h.App.Logger.Errorf("重置任务步骤失败: %v", err)
 	}
 
 	// 更新步骤状态为运行中
-	if err := h.TaskStepService.UpdateTaskStepStatus(videoID, stepName, "running"); err != nil {
+	if err := h.TaskStepService.UpdateTaskStepStatus(videoID, key, "running"); err != nil {
 		h.App.Logger.Errorf("更新任务步骤状态失败: %v", err)
 	}
 
 	// 创建单个任务的链
 	chain := manager.NewTaskChain()
-	var task types.Task
-
-	// 根据步骤名称创建对应的任务
-	switch stepName {
-	case "下载视频":
-		task = handlers.NewDownloadVideo("下载视频", h.App, stateManager, h.App.CosClient, h.SavedVideoService)
-	case "分离音频":
-		task = handlers.NewExtractAudio("分离音频", h.App, stateManager, h.App.CosClient)
-	case "Whisper转录":
-		// 从配置中读取 Whisper 参数
-		if h.App.Config.WhisperConfig != nil && h.App.Config.WhisperConfig.Enabled {
-			task = handlers.NewBcutHandler(
-				"B站必剪转录",
-				h.App,
-				stateManager,
-				h.App.CosClient,
-				h.App.Config.WhisperConfig.Language,
-			)
-		} else {
-			return fmt.Errorf("B站必剪 未启用或配置不完整")
-		}
-	case "生成字幕":
-		task = handlers.NewGenerateSubtitles("生成字幕", h.App, stateManager, h.App.CosClient, h.SavedVideoService)
-	case "翻译字幕":
-		// 不再在这里检查配置，让任务运行时动态检查最新配置
-		task = handlers.NewTranslateSubtitle("翻译字幕", h.App, stateManager, h.App.CosClient, h.Db, "")
-	case "生成元数据":
-		// 不再在这里检查配置，让任务运行时动态检查最新配置
-		task = handlers.NewGenerateMetadata("生成元数据", h.App, stateManager, h.App.CosClient, "", h.Db, h.SavedVideoService)
-	case "上传到Bilibili":
-		task = handlers.NewUploadToBilibili("上传到Bilibili", h.App, stateManager, h.App.CosClient, h.SavedVideoService)
-	case "上传字幕到Bilibili":
-		fmt.Printf("注意: '上传字幕到Bilibili' 任务步骤已被注释掉，如需启用请取消注释相关代码。\n")
-		//task = handlers.NewUploadSubtitleToBilibili("上传字幕到Bilibili", h.App, stateManager, h.App.CosClient, h.SavedVideoService)
-	default:
-		return fmt.Errorf("未知的任务步骤: %s", stepName)
+	deps := steps.Deps{App: h.App, State: stateManager, DB: h.Db, SavedVideoService: h.SavedVideoService}
+	if pipeline, err := steps.Resolve(h.App.Config, savedVideo.Pipeline); err == nil {
+		deps.Options = pipeline.Options(step.Key)
 	}
+	chain.AddTask(step.New(deps))
+
+	h.App.Logger.Infof("开始执行单个任务步骤: %s (VideoID: %s)", key, videoID)
+
+	// 执行任务，临时错误自动重试
+	before := stateManager.Artifacts.Snapshot()
+	result := runStep(ctx, newStepPolicy(h.App.Config.WorkerConfig, key), func(ctx context.Context) error {
+		return chain.Run(ctx, false)
+	}, func(attempt int, err error, delay time.Duration) {
+		h.App.Logger.Warnf("任务步骤 %s 第 %d 次执行失败，%v 后自动重试: %v", key, attempt, delay, err)
+		if err := recordStepRetry(h.TaskStepService, videoID, key, attempt, err, delay); err != nil {
+			h.App.Logger.Errorf("更新任务步骤重试信息失败: %v", err)
+		}
+	})
+	status, runErr := result.Status, result.Err
 
-	// 添加任务到链
-	if task != nil {
-		chain.AddTask(task)
+	// 租约失效，步骤已由其他工作者接手，不再更新状态
+	if errors.Is(context.Cause(ctx), services.ErrLeaseLost) {
+		h.App.Logger.Warnf("任务步骤 %s 的租约已失效，已由其他工作者接手", key)
+		return runErr
 	}
 
-	h.App.Logger.Infof("开始执行单个任务步骤: %s (VideoID: %s)", stepName, videoID)
-
-	// 执行任务
-	result := chain.Run(false)
-
-	// 检查执行结果
-	success := true
-	var errorMsg string
-	if errorMsgInterface, exists := result["error"]; exists && errorMsgInterface != nil {
-		success = false
-		errorMsg = fmt.Sprintf("%v", errorMsgInterface)
+	if err := recordStepResult(h.TaskStepService, videoID, key, result); err != nil {
+		h.App.Logger.Errorf("更新任务步骤重试信息失败: %v", err)
 	}
 
 	// 更新步骤状态
-	if success {
-		if err := h.TaskStepService.UpdateTaskStepStatus(videoID, stepName, "completed"); err != nil {
+	if status == model.TaskStepStatusCancelled {
+		// 服务关闭时步骤恢复为待执行，重启后继续重试
+		if errors.Is(context.Cause(ctx), ErrShuttingDown) {
+			status = model.TaskStepStatusPending
+		}
+		if err := h.TaskStepService.UpdateTaskStepStatus(videoID, key, status, runErr.Error()); err != nil {
 			h.App.Logger.Errorf("更新任务步骤状态失败: %v", err)
 		}
-		if err := h.TaskStepService.UpdateTaskStepResult(videoID, stepName, result); err != nil {
+		h.App.Logger.Warnf("任务步骤 %s 已中断: %v", key, runErr)
+		return runErr
+	}
+	if runErr == nil {
+		if err := h.TaskStepService.UpdateTaskStepStatus(videoID, key, "completed"); err != nil {
+			h.App.Logger.Errorf("更新任务步骤状态失败: %v", err)
+		}
+		if err := h.TaskStepService.UpdateTaskStepResult(videoID, key, changedArtifacts(before, stateManager.Artifacts.Snapshot(), step.Outputs())); err != nil {
 			h.App.Logger.Errorf("更新任务步骤结果失败: %v", err)
 		}
-		h.App.Logger.Infof("任务步骤 %s 执行成功", stepName)
+		h.App.Logger.Infof("任务步骤 %s 执行成功", key)
+		h.resumeAfterRetry(videoID, step)
 	} else {
-		if err := h.TaskStepService.UpdateTaskStepStatus(videoID, stepName, "failed", errorMsg); err != nil {
+		if err := h.TaskStepService.UpdateTaskStepStatus(videoID, key, "failed", runErr.Error()); err != nil {
 			h.App.Logger.Errorf("更新任务步骤状态失败: %v", err)
 		}
-		h.App.Logger.Errorf("任务步骤 %s 执行失败: %s", stepName, errorMsg)
-		return fmt.Errorf("任务执行失败: %s", errorMsg)
+		h.App.Logger.Errorf("任务步骤 %s 执行失败: %v", key, runErr)
+
+		// 不符合过滤规则时视频转为已过滤，认证失效或额度不足时转为需要人工处理
+		if isFiltered(runErr) {
+			if err := h.updateSavedVideoStatus(video.Id, model.VideoStatusFiltered, runErr.Error()); err != nil {
+				h.App.Logger.Errorf("更新任务状态为已过滤时出错: %v", err)
+			}
+		} else if needsAttention(runErr) {
+			if err := h.updateSavedVideoStatus(video.Id, model.VideoStatusNeedsAttention, fmt.Sprintf("任务步骤 %s 执行失败: %v", key, runErr)); err != nil {
+				h.App.Logger.Errorf("更新任务状态为需要处理时出错: %v", err)
+			}
+		}
+		return fmt.Errorf("任务执行失败: %w", runErr)
 	}
 
 	return nil
 }
 
+// resumeAfterRetry 重试的准备步骤成功后，将因失败停止的视频恢复到流程中的下一个状态并记录状态历史
+// 准备阶段的步骤都已完成时等待上传（流程中没有上传步骤时标记为全部完成），否则重新处理以执行剩余的步骤
+// 上传阶段失败的视频由上传步骤的重试恢复状态，这里不处理
+func (h *ChainTaskHandler) resumeAfterRetry(videoID string, retried *steps.Step) {
+	savedVideo, err := h.SavedVideoService.GetVideoByVideoID(videoID)
+	if err != nil {
+		h.App.Logger.Errorf("获取视频信息失败: %v", err)
+		return
+	}
+	if savedVideo.Status != model.VideoStatusNeedsAttention && savedVideo.Status != model.VideoStatusFailed {
+		return
+	}
+	pipeline, err := steps.Resolve(h.App.Config, savedVideo.Pipeline)
+	if err != nil {
+		h.App.Logger.Errorf("视频 %s 获取处理流程失败: %v", videoID, err)
+		return
+	}
+	taskSteps, err := h.TaskStepService.GetTaskStepsByVideoID(videoID)
+	if err != nil {
+		h.App.Logger.Errorf("获取任务步骤失败: %v", err)
+		return
+	}
+
+	statuses := make(map[string]string, len(taskSteps))
+	for _, taskStep := range taskSteps {
+		statuses[taskStep.StepKey] = taskStep.Status
+	}
+	prepared := true
+	for _, step := range pipeline.Steps {
+		status := statuses[string(step.Key)]
+		if step.Stage == steps.StageUpload && status == model.TaskStepStatusFailed {
+			return
+		}
+		if step.Stage == steps.StagePrepare && status != model.TaskStepStatusCompleted {
+			prepared = false
+		}
+	}
+
+	status, reason := model.VideoStatusPending, fmt.Sprintf("重试任务步骤 %s 成功，重新处理剩余的步骤", retried.Key)
+	if prepared {
+		status, reason = model.VideoStatusReady, fmt.Sprintf("重试任务步骤 %s 成功，处理完成，等待上传", retried.Key)
+		if !pipeline.Has(steps.UploadVideo) {
+			status, reason = model.VideoStatusCompleted, fmt.Sprintf("重试任务步骤 %s 成功，处理完成，流程中没有上传步骤", retried.Key)
+		}
+	}
+	if err := h.updateSavedVideoStatus(savedVideo.ID, status, reason); err != nil {
+		h.App.Logger.Errorf("恢复视频 %s 的状态失败: %v", videoID, err)
+		return
+	}
+	h.App.Logger.Infof("视频 %s 的状态已从 %s 恢复为 %s", videoID, savedVideo.Status, status)
+}
+
+// resolvePipeline 获取视频提交时指定的处理流程
+func (h *ChainTaskHandler) resolvePipeline(videoID string) (*steps.Pipeline, error) {
+	savedVideo, err := h.SavedVideoService.GetVideoByVideoID(videoID)
+	if err != nil {
+		return nil, fmt.Errorf("获取视频信息失败: %v", err)
+	}
+	return steps.Resolve(h.App.Config, savedVideo.Pipeline)
+}
 
-// wrapTaskWithStepTracking 包装任务以添加步骤跟踪
-func (h *ChainTaskHandler) wrapTaskWithStepTracking(task types.Task, videoID string) types.Task {
+// pipelineName 处理流程的显示名称
+func pipelineName(pipeline *steps.Pipeline) string {
+	if pipeline.Name == "" {
+		return "默认"
+	}
+	return pipeline.Name
+}
+
+// wrapTaskWithStepTracking 包装任务以添加步骤跟踪，outputs 为步骤结果中记录的产物
+func (h *ChainTaskHandler) wrapTaskWithStepTracking(task types.Task, stateManager *manager.StateManager, outputs []manager.ArtifactKind) types.Task {
 	return &TaskStepWrapper{
 		task:            task,
-		videoID:         videoID,
+		outputs:         outputs,
+		policy:          newStepPolicy(h.App.Config.WorkerConfig, task.GetName()),
+		videoID:         stateManager.VideoID,
+		artifacts:       stateManager.Artifacts,
 		taskStepService: h.TaskStepService,
 		logger:          h.App.Logger,
 	}
@@ -395,7 +852,10 @@ func (h *ChainTaskHandler) wrapTaskWithStepTracking(task types.Task, videoID str
 // TaskStepWrapper 任务步骤包装器
 type TaskStepWrapper struct {
 	task            types.Task
+	outputs         []manager.ArtifactKind // 步骤声明的产物，并发执行的其他步骤写入的产物不计入本步骤
+	policy          stepPolicy             // 超时和自动重试策略
 	videoID         string
+	artifacts       *manager.ArtifactStore
 	taskStepService *services.TaskStepService
 	logger          *zap.SugaredLogger
 }
@@ -412,7 +872,7 @@ func (w *TaskStepWrapper) UpdateStatus(status, message string) error {
 	return w.task.UpdateStatus(status, message)
 }
 
-func (w *TaskStepWrapper) Execute(context map[string]interface{}) bool {
+func (w *TaskStepWrapper) Execute(ctx context.Context) error {
 	stepName := w.task.GetName()
 
 	// 更新步骤状态为运行中
@@ -420,40 +880,89 @@ func (w *TaskStepWrapper) Execute(context map[string]interface{}) bool {
 		w.logger.Errorf("更新任务步骤状态失败: %v", err)
 	}
 
-	// 执行原始任务
-	success := w.task.Execute(context)
+	// 执行原始任务，临时错误自动重试
+	before := w.artifacts.Snapshot()
+	result := runStep(ctx, w.policy, w.task.Execute, func(attempt int, err error, delay time.Duration) {
+		w.logger.Warnf("任务步骤 %s 第 %d 次执行失败，%v 后自动重试: %v", stepName, attempt, delay, err)
+		if err := recordStepRetry(w.taskStepService, w.videoID, stepName, attempt, err, delay); err != nil {
+			w.logger.Errorf("更新任务步骤重试信息失败: %v", err)
+		}
+	})
+	status, taskErr := result.Status, result.Err
+
+	// 租约失效，步骤已由其他工作者接手，不再更新状态
+	if errors.Is(context.Cause(ctx), services.ErrLeaseLost) {
+		return taskErr
+	}
+
+	if err := recordStepResult(w.taskStepService, w.videoID, stepName, result); err != nil {
+		w.logger.Errorf("更新任务步骤重试信息失败: %v", err)
+	}
 
 	// 更新步骤状态
-	if success {
+	if status == model.TaskStepStatusCancelled {
+		if err := w.taskStepService.UpdateTaskStepStatus(w.videoID, stepName, status, taskErr.Error()); err != nil {
+			w.logger.Errorf("更新任务步骤状态失败: %v", err)
+		}
+	} else if taskErr == nil {
 		if err := w.taskStepService.UpdateTaskStepStatus(w.videoID, stepName, "completed"); err != nil {
 			w.logger.Errorf("更新任务步骤状态失败: %v", err)
 		}
 
-		// 保存执行结果
-		result := map[string]interface{}{}
-		for k, v := range context {
-			if k != "error" { // 排除错误信息
-				result[k] = v
-			}
-		}
+		// 保存本步骤产出的产物
+		result := changedArtifacts(before, w.artifacts.Snapshot(), w.outputs)
 		if err := w.taskStepService.UpdateTaskStepResult(w.videoID, stepName, result); err != nil {
 			w.logger.Errorf("更新任务步骤结果失败: %v", err)
 		}
 	} else {
-		errorMsg := ""
-		if err, exists := context["error"]; exists {
-			errorMsg = fmt.Sprintf("%v", err)
-		}
-
-		if err := w.taskStepService.UpdateTaskStepStatus(w.videoID, stepName, "failed", errorMsg); err != nil {
+		if err := w.taskStepService.UpdateTaskStepStatus(w.videoID, stepName, "failed", taskErr.Error()); err != nil {
 			w.logger.Errorf("更新任务步骤状态失败: %v", err)
 		}
 	}
 
-	return success
+	return taskErr
+}
+
+// withStepTimeout 为步骤创建带超时的上下文，timeout 为 0 时不限制
+func withStepTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// stepOutcome 根据任务错误和上下文状态确定步骤的最终状态
+// 视频被取消或服务关闭时为 cancelled，步骤超时时返回可重试的超时错误
+func stepOutcome(ctx, stepCtx context.Context, taskErr error) (string, error) {
+	if taskErr == nil {
+		return model.TaskStepStatusCompleted, nil
+	}
+	if ctx.Err() != nil {
+		return model.TaskStepStatusCancelled, context.Cause(ctx)
+	}
+	if errors.Is(stepCtx.Err(), context.DeadlineExceeded) {
+		return model.TaskStepStatusFailed, types.NewStepError(types.ErrorTransient, fmt.Errorf("步骤执行超时: %v", taskErr))
+	}
+	return model.TaskStepStatusFailed, taskErr
+}
+
+// changedArtifacts 对比步骤执行前后的产物，返回本步骤声明的产物中新增或更新的产物
+// 依赖图中的步骤并发执行，产物存储是共享的，只对比步骤声明的产物
+func changedArtifacts(before, after map[manager.ArtifactKind]json.RawMessage, kinds []manager.ArtifactKind) map[manager.ArtifactKind]json.RawMessage {
+	changed := make(map[manager.ArtifactKind]json.RawMessage)
+	for _, kind := range kinds {
+		value, ok := after[kind]
+		if !ok {
+			continue
+		}
+		if old, exists := before[kind]; !exists || string(old) != string(value) {
+			changed[kind] = value
+		}
+	}
+	return changed
 }
 
 // updateSavedVideoStatus 更新 SavedVideo 的状态
-func (h *ChainTaskHandler) updateSavedVideoStatus(id uint, status string) error {
-	return h.SavedVideoService.UpdateStatus(id, status)
+func (h *ChainTaskHandler) updateSavedVideoStatus(id uint, status model.VideoStatus, reason string) error {
+	return h.SavedVideoService.Transition(id, status, model.StatusActorScheduler, reason)
 }
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"gorm.io/gorm"
 	"github.com/difyz9/ytb2bili/internal/chain_task/manager"
 	"github.com/difyz9/ytb2bili/internal/core"
@@ -28,11 +29,11 @@ func NewVidm3u8Handler(name string, app *core.AppServer, stateManager *manager.S
 	}
 }
 
-func (t *VidM3u8Handler) Execute(context map[string]interface{}) bool {
+func (t *VidM3u8Handler) Execute(ctx context.Context) error {
 
-	err := utils.ConvertToHLS(t.StateManager.InputVideoPath, t.StateManager.M3u8FileDir)
+	err := utils.ConvertToHLS(ctx, t.StateManager.InputVideoPath, t.StateManager.M3u8FileDir)
 	if err != nil {
-		return false
+		return err
 	}
 
 	tbVideo := &models.TbVideo{
@@ -45,5 +46,5 @@ func (t *VidM3u8Handler) Execute(context map[string]interface{}) bool {
 
 	}
 
-	return true
+	return nil
 }
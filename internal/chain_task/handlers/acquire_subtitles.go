@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/difyz9/ytb2bili/internal/chain_task/base"
+	"github.com/difyz9/ytb2bili/internal/chain_task/manager"
+	"github.com/difyz9/ytb2bili/internal/core"
+	"github.com/difyz9/ytb2bili/internal/core/services"
+	"github.com/difyz9/ytb2bili/internal/core/types"
+	"github.com/difyz9/ytb2bili/pkg/asr"
+	"github.com/difyz9/ytb2bili/pkg/cos"
+	"github.com/difyz9/ytb2bili/pkg/source"
+	"github.com/difyz9/ytb2bili/pkg/store/model"
+	"github.com/difyz9/ytb2bili/pkg/subtitle"
+)
+
+// AcquireSubtitles 按配置的优先级获取原语言字幕
+// 依次尝试上传者提供的目标语言字幕、原语言字幕、平台自动字幕、插件提交的字幕和语音识别，记录使用的字幕来源
+type AcquireSubtitles struct {
+	base.BaseTask
+	App               *core.AppServer
+	SavedVideoService *services.SavedVideoService
+	ASR               bool // 是否可以使用语音识别，为 false 时跳过优先级中的 asr
+}
+
+func NewAcquireSubtitles(name string, app *core.AppServer, stateManager *manager.StateManager, client *cos.CosClient, savedVideoService *services.SavedVideoService, useASR bool) *AcquireSubtitles {
+	return &AcquireSubtitles{
+		BaseTask: base.BaseTask{
+			Name:         name,
+			StateManager: stateManager,
+			Client:       client,
+		},
+		App:               app,
+		SavedVideoService: savedVideoService,
+		ASR:               useASR,
+	}
+}
+
+// platformSubtitles 视频平台自带的字幕及下载使用的设置
+type platformSubtitles struct {
+	videoURL string
+	tracks   []source.SubtitleTrack
+	opts     source.Options
+}
+
+func (t *AcquireSubtitles) Execute(ctx context.Context) error {
+	savedVideo, err := t.SavedVideoService.GetVideoByVideoID(t.StateManager.VideoID)
+	if err != nil {
+		return fmt.Errorf("查询视频信息失败: %v", err)
+	}
+	config := t.App.Config.SubtitleSourceConfig
+
+	var platform *platformSubtitles
+	var sourceLanguages []string
+	for _, name := range config.GetPriority() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var cues []subtitle.Cue
+		var language string
+		switch name {
+		case model.SubtitleSourceManualTarget, model.SubtitleSourceManualSource, model.SubtitleSourceAuto:
+			if platform == nil {
+				platform, sourceLanguages = t.listPlatformSubtitles(ctx, savedVideo)
+			}
+			track, ok := chooseTrack(name, platform.tracks, config.GetTargetLanguages(), sourceLanguages)
+			if !ok {
+				t.App.Logger.Infof("没有 %s 字幕", name)
+				continue
+			}
+			cues, err = t.downloadTrack(ctx, platform, track)
+			language = strings.TrimSuffix(track.Language, "-orig")
+		case model.SubtitleSourceExtension:
+			cues, language, err = extensionCues(savedVideo)
+		case model.SubtitleSourceASR:
+			if !t.ASR {
+				t.App.Logger.Infof("未启用语音识别，跳过 %s", name)
+				continue
+			}
+			language, err = t.transcribe(ctx, sourceLanguages)
+			if err == nil {
+				return t.record(savedVideo, name, language)
+			}
+		default:
+			t.App.Logger.Warnf("⚠️ 未知的字幕来源: %s", name)
+			continue
+		}
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			t.App.Logger.Warnf("⚠️ 获取 %s 字幕失败: %v", name, err)
+			continue
+		}
+		if len(cues) == 0 {
+			t.App.Logger.Infof("没有 %s 字幕", name)
+			continue
+		}
+		if err := t.saveCues(cues); err != nil {
+			return err
+		}
+		t.App.Logger.Infof("✓ 使用 %s 字幕（%s），共 %d 条", name, language, len(cues))
+		return t.record(savedVideo, name, language)
+	}
+
+	return types.NewStepError(types.ErrorPermanent, fmt.Errorf("没有可用的字幕，已尝试: %s", strings.Join(config.GetPriority(), "、")))
+}
+
+// listPlatformSubtitles 列出平台自带的字幕，同时获取视频的原语言
+// 获取失败时返回空列表，继续尝试其他字幕来源
+func (t *AcquireSubtitles) listPlatformSubtitles(ctx context.Context, video *model.SavedVideo) (*platformSubtitles, []string) {
+	platform := &platformSubtitles{videoURL: videoURLOf(video, t.StateManager.VideoID)}
+	var languages []string
+	if config := t.App.Config.SubtitleSourceConfig; config != nil {
+		languages = config.SourceLanguages
+	}
+	if info, ok := t.StateManager.Artifacts.SourceInfo(); ok && len(languages) == 0 && info.Language != "" {
+		languages = []string{info.Language}
+	}
+
+	src, _, err := source.ForURL(platform.videoURL)
+	if err != nil {
+		t.App.Logger.Warnf("⚠️ 无法识别视频来源: %v", err)
+		return platform, languages
+	}
+	// 本地文件随附的字幕已作为插件字幕保存
+	if src.Platform() == source.PlatformLocal {
+		return platform, languages
+	}
+	ytdlpPath, err := findYtDlp(t.App)
+	if err != nil {
+		t.App.Logger.Warnf("⚠️ %v，无法获取平台字幕", err)
+		return platform, languages
+	}
+
+	// 没有用户提交的 cookies 时从浏览器读取
+	cookiesFile := latestCookiesFile(t.App)
+	platform.opts = sourceOptions(t.App, ytdlpPath, cookiesFile, true)
+	platform.tracks, err = src.ListSubtitles(ctx, platform.opts, platform.videoURL)
+	if err != nil && platform.opts.Proxy != "" && ctx.Err() == nil {
+		t.App.Logger.Warnf("⚠️ 使用代理获取字幕列表失败，尝试不使用代理...")
+		platform.opts = sourceOptions(t.App, ytdlpPath, cookiesFile, false)
+		platform.tracks, err = src.ListSubtitles(ctx, platform.opts, platform.videoURL)
+	}
+	if err != nil {
+		if !errors.Is(err, source.ErrNotSupported) {
+			t.App.Logger.Warnf("⚠️ 获取平台字幕列表失败: %v", err)
+		}
+		return platform, languages
+	}
+	t.App.Logger.Infof("📋 平台字幕 %d 种", len(platform.tracks))
+
+	// 没有配置原语言且检查视频信息的步骤未执行时，从视频信息中获取
+	if len(languages) == 0 {
+		if metadata, err := src.FetchMetadata(ctx, platform.opts, platform.videoURL); err == nil && metadata.Language != "" {
+			languages = []string{metadata.Language}
+		}
+	}
+	return platform, languages
+}
+
+// downloadTrack 下载平台字幕并转换为字幕条目
+func (t *AcquireSubtitles) downloadTrack(ctx context.Context, platform *platformSubtitles, track source.SubtitleTrack) ([]subtitle.Cue, error) {
+	downloader := subtitle.NewYtdlpSubtitleDownloaderWithOptions(t.App.Logger, subtitle.Options{
+		Binary:             platform.opts.YtDlpPath,
+		CookiesFile:        platform.opts.CookiesFile,
+		CookiesFromBrowser: platform.opts.CookiesFromBrowser,
+		Proxy:              platform.opts.Proxy,
+	})
+	kind := "manual"
+	if track.Automatic {
+		kind = "auto"
+	}
+	file, err := downloader.DownloadTrack(ctx, platform.videoURL, track.Language, track.Automatic,
+		filepath.Join(t.StateManager.CurrentDir, "subtitles", kind))
+	if err != nil {
+		return nil, err
+	}
+	sub, err := subtitle.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return sub.Cues, nil
+}
+
+// transcribe 通过语音识别生成字幕，需要先下载视频
+// 流程中没有分离音频的步骤时先分离音频，音频记录为本步骤的产物
+func (t *AcquireSubtitles) transcribe(ctx context.Context, sourceLanguages []string) (string, error) {
+	if _, ok := t.StateManager.Artifacts.Path(manager.ArtifactSourceVideo); !ok {
+		return "", fmt.Errorf("流程中没有下载视频的步骤，无法进行语音识别")
+	}
+	if _, ok := t.StateManager.Artifacts.Path(manager.ArtifactAudioWAV); !ok {
+		if err := NewExtractAudio(t.Name, t.App, t.StateManager, t.Client).Execute(ctx); err != nil {
+			return "", err
+		}
+	}
+
+	language := ""
+	if t.App.Config.ASRConfig != nil {
+		language = t.App.Config.ASRConfig.Language
+	}
+	if asr.IsAutoLanguage(language) && len(sourceLanguages) > 0 {
+		language = sourceLanguages[0]
+	}
+	result, err := NewTranscribeAudio(t.Name, t.App, t.StateManager, t.Client, language).Transcribe(ctx)
+	if err != nil {
+		return "", err
+	}
+	return result.Language, nil
+}
+
+// saveCues 重新分段后保存原语言字幕并记录产物
+func (t *AcquireSubtitles) saveCues(cues []subtitle.Cue) error {
+	if err := os.MkdirAll(t.StateManager.CurrentDir, 0755); err != nil {
+		return fmt.Errorf("创建任务目录失败: %v", err)
+	}
+	cues = segmentCues(t.App, cues, false)
+	if err := subtitle.WriteFile(t.StateManager.OriginalSRT, &subtitle.Subtitle{Cues: cues}); err != nil {
+		return fmt.Errorf("写入字幕文件失败: %v", err)
+	}
+	if err := t.StateManager.Artifacts.SetPath(manager.ArtifactOriginalSRT, t.StateManager.OriginalSRT); err != nil {
+		t.App.Logger.Warnf("⚠️ 记录字幕产物失败: %v", err)
+	}
+	return nil
+}
+
+// record 在视频记录中保存使用的字幕来源和语言
+func (t *AcquireSubtitles) record(video *model.SavedVideo, name, language string) error {
+	video.SubtitleSource = name
+	video.SubtitleLanguage = language
+	if err := t.SavedVideoService.UpdateVideo(video); err != nil {
+		t.App.Logger.Errorf("❌ 保存字幕来源失败: %v", err)
+	}
+	return nil
+}
+
+// extensionCues 插件提交的字幕，语言取自字幕条目
+func extensionCues(video *model.SavedVideo) ([]subtitle.Cue, string, error) {
+	if video.Subtitles == "" || video.Subtitles == "null" {
+		return nil, "", nil
+	}
+	var subtitles []model.SavedVideoSubtitle
+	if err := json.Unmarshal([]byte(video.Subtitles), &subtitles); err != nil {
+		return nil, "", fmt.Errorf("解析字幕数据失败: %v", err)
+	}
+	cues := make([]subtitle.Cue, 0, len(subtitles))
+	language := ""
+	for _, sub := range subtitles {
+		if language == "" {
+			language = sub.Lang
+		}
+		cues = append(cues, subtitle.Cue{Start: subtitle.Seconds(sub.Offset), End: subtitle.Seconds(sub.Offset + sub.Duration), Text: sub.Text})
+	}
+	return cues, language, nil
+}
+
+// chooseTrack 根据字幕来源从平台字幕中选择一种
+// 原语言未知时，只有一种上传者字幕时视为原语言字幕，自动字幕使用平台标记的原语言（如 en-orig）
+func chooseTrack(name string, tracks []source.SubtitleTrack, targetLanguages, sourceLanguages []string) (source.SubtitleTrack, bool) {
+	var candidates []source.SubtitleTrack
+	for _, track := range tracks {
+		if track.Automatic == (name == model.SubtitleSourceAuto) {
+			candidates = append(candidates, track)
+		}
+	}
+
+	switch name {
+	case model.SubtitleSourceManualTarget:
+		return matchTrack(candidates, targetLanguages)
+	case model.SubtitleSourceManualSource:
+		if len(sourceLanguages) > 0 {
+			return matchTrack(candidates, sourceLanguages)
+		}
+		var others []source.SubtitleTrack
+		for _, track := range candidates {
+			if _, ok := matchTrack([]source.SubtitleTrack{track}, targetLanguages); !ok {
+				others = append(others, track)
+			}
+		}
+		if len(others) == 1 {
+			return others[0], true
+		}
+	case model.SubtitleSourceAuto:
+		// 自动字幕包含翻译成各种语言的字幕，只使用原语言字幕
+		for _, track := range candidates {
+			if strings.HasSuffix(track.Language, "-orig") {
+				if len(sourceLanguages) == 0 || languageIn(strings.TrimSuffix(track.Language, "-orig"), sourceLanguages) {
+					return track, true
+				}
+			}
+		}
+		if len(sourceLanguages) > 0 {
+			return matchTrack(candidates, sourceLanguages)
+		}
+	}
+	return source.SubtitleTrack{}, false
+}
+
+// matchTrack 按语言的顺序选择字幕，优先完全相同的语言代码
+func matchTrack(tracks []source.SubtitleTrack, languages []string) (source.SubtitleTrack, bool) {
+	for _, language := range languages {
+		for _, track := range tracks {
+			if strings.EqualFold(track.Language, language) {
+				return track, true
+			}
+		}
+		for _, track := range tracks {
+			if languageIn(track.Language, []string{language}) {
+				return track, true
+			}
+		}
+	}
+	return source.SubtitleTrack{}, false
+}
+
+// languageIn 语言是否在列表中，en 可以匹配 en-US、en_GB
+func languageIn(language string, languages []string) bool {
+	language = strings.ToLower(strings.ReplaceAll(language, "_", "-"))
+	for _, item := range languages {
+		item = strings.ToLower(strings.ReplaceAll(item, "_", "-"))
+		if item != "" && (language == item || strings.HasPrefix(language, item+"-")) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,12 +2,15 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/difyz9/ytb2bili/internal/core/types"
 )
 
 // DeepSeekClient DeepSeek API客户端
@@ -77,32 +80,41 @@ func NewDeepSeekClient(apiKey string) *DeepSeekClient {
 }
 
 // ChatCompletion 执行对话补全（带重试机制）
-func (c *DeepSeekClient) ChatCompletion(systemPrompt, userPrompt string) (string, error) {
+func (c *DeepSeekClient) ChatCompletion(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
 	var lastErr error
 
 	for attempt := 0; attempt <= c.MaxRetries; attempt++ {
 		if attempt > 0 {
-			time.Sleep(c.RetryDelay * time.Duration(attempt))
+			if err := sleepContext(ctx, c.RetryDelay*time.Duration(attempt)); err != nil {
+				return "", err
+			}
 		}
 
-		result, err := c.doRequest(systemPrompt, userPrompt)
+		result, err := c.doRequest(ctx, systemPrompt, userPrompt)
 		if err == nil {
 			return result, nil
 		}
 
 		lastErr = err
 
+		// 认证、余额等错误重试无效
+		if types.ClassifyError(err) != types.ErrorTransient {
+			return "", err
+		}
+
 		// 如果是API限制错误，延长等待时间
 		if strings.Contains(err.Error(), "rate limit") || strings.Contains(err.Error(), "429") {
-			time.Sleep(time.Duration(attempt+1) * 5 * time.Second)
+			if err := sleepContext(ctx, time.Duration(attempt+1)*5*time.Second); err != nil {
+				return "", err
+			}
 		}
 	}
 
-	return "", fmt.Errorf("重试 %d 次后仍然失败: %v", c.MaxRetries, lastErr)
+	return "", fmt.Errorf("重试 %d 次后仍然失败: %w", c.MaxRetries, lastErr)
 }
 
 // doRequest 执行单次API请求
-func (c *DeepSeekClient) doRequest(systemPrompt, userPrompt string) (string, error) {
+func (c *DeepSeekClient) doRequest(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
 	request := DeepSeekRequest{
 		Model: "deepseek-chat",
 		Messages: []DeepSeekMessage{
@@ -127,7 +139,7 @@ func (c *DeepSeekClient) doRequest(systemPrompt, userPrompt string) (string, err
 		return "", fmt.Errorf("序列化请求失败: %v", err)
 	}
 
-	req, err := http.NewRequest("POST", c.BaseURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("创建请求失败: %v", err)
 	}
@@ -137,7 +149,7 @@ func (c *DeepSeekClient) doRequest(systemPrompt, userPrompt string) (string, err
 
 	resp, err := c.Client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("发送请求失败: %v", err)
+		return "", types.NewStepError(types.ErrorTransient, fmt.Errorf("发送请求失败: %v", err))
 	}
 	defer resp.Body.Close()
 
@@ -147,7 +159,7 @@ func (c *DeepSeekClient) doRequest(systemPrompt, userPrompt string) (string, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API返回错误 (状态码: %d): %s", resp.StatusCode, string(body))
+		return "", apiStatusError(resp.StatusCode, body)
 	}
 
 	var response DeepSeekResponse
@@ -163,7 +175,7 @@ func (c *DeepSeekClient) doRequest(systemPrompt, userPrompt string) (string, err
 }
 
 // ChatCompletionWithUsage 执行对话补全并返回使用量统计
-func (c *DeepSeekClient) ChatCompletionWithUsage(systemPrompt, userPrompt string) (string, *DeepSeekUsage, error) {
+func (c *DeepSeekClient) ChatCompletionWithUsage(ctx context.Context, systemPrompt, userPrompt string) (string, *DeepSeekUsage, error) {
 	request := DeepSeekRequest{
 		Model: "deepseek-chat",
 		Messages: []DeepSeekMessage{
@@ -184,7 +196,7 @@ func (c *DeepSeekClient) ChatCompletionWithUsage(systemPrompt, userPrompt string
 		return "", nil, fmt.Errorf("序列化请求失败: %v", err)
 	}
 
-	req, err := http.NewRequest("POST", c.BaseURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", nil, fmt.Errorf("创建请求失败: %v", err)
 	}
@@ -194,7 +206,7 @@ func (c *DeepSeekClient) ChatCompletionWithUsage(systemPrompt, userPrompt string
 
 	resp, err := c.Client.Do(req)
 	if err != nil {
-		return "", nil, fmt.Errorf("发送请求失败: %v", err)
+		return "", nil, types.NewStepError(types.ErrorTransient, fmt.Errorf("发送请求失败: %v", err))
 	}
 	defer resp.Body.Close()
 
@@ -204,7 +216,7 @@ func (c *DeepSeekClient) ChatCompletionWithUsage(systemPrompt, userPrompt string
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", nil, fmt.Errorf("API返回错误 (状态码: %d): %s", resp.StatusCode, string(body))
+		return "", nil, apiStatusError(resp.StatusCode, body)
 	}
 
 	var response DeepSeekResponse
@@ -218,3 +230,28 @@ func (c *DeepSeekClient) ChatCompletionWithUsage(systemPrompt, userPrompt string
 
 	return response.Choices[0].Message.Content, &response.Usage, nil
 }
+
+// apiStatusError 根据 HTTP 状态码生成带分类的错误
+func apiStatusError(statusCode int, body []byte) error {
+	err := fmt.Errorf("API返回错误 (状态码: %d): %s", statusCode, string(body))
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return types.NewStepError(types.ErrorAuth, err)
+	case statusCode == http.StatusPaymentRequired:
+		return types.NewStepError(types.ErrorQuota, err)
+	case statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError:
+		return types.NewStepError(types.ErrorTransient, err)
+	default:
+		return types.NewStepError(types.ErrorPermanent, err)
+	}
+}
+
+// sleepContext 等待指定时间，上下文取消时提前返回
+func sleepContext(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
@@ -2,19 +2,25 @@ package handlers
 
 import (
 	"bufio"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/difyz9/ytb2bili/internal/chain_task/base"
 	"github.com/difyz9/ytb2bili/internal/chain_task/manager"
 	"github.com/difyz9/ytb2bili/internal/core"
 	"github.com/difyz9/ytb2bili/internal/core/services"
+	"github.com/difyz9/ytb2bili/internal/core/types"
 	"github.com/difyz9/ytb2bili/pkg/cos"
+	"github.com/difyz9/ytb2bili/pkg/download"
+	"github.com/difyz9/ytb2bili/pkg/source"
+	"github.com/difyz9/ytb2bili/pkg/store/model"
 	"github.com/difyz9/ytb2bili/pkg/utils"
 	"gorm.io/gorm"
 )
@@ -24,9 +30,10 @@ type DownloadVideo struct {
 	App               *core.AppServer
 	DB                *gorm.DB
 	SavedVideoService *services.SavedVideoService
+	ProfileName       string // 流程指定的下载配置名称，为空时使用默认配置
 }
 
-func NewDownloadVideo(name string, app *core.AppServer, stateManager *manager.StateManager, client *cos.CosClient, savedVideoService *services.SavedVideoService) *DownloadVideo {
+func NewDownloadVideo(name string, app *core.AppServer, stateManager *manager.StateManager, client *cos.CosClient, savedVideoService *services.SavedVideoService, profileName string) *DownloadVideo {
 	return &DownloadVideo{
 		BaseTask: base.BaseTask{
 			Name:         name,
@@ -35,24 +42,30 @@ func NewDownloadVideo(name string, app *core.AppServer, stateManager *manager.St
 		},
 		App:               app,
 		SavedVideoService: savedVideoService,
+		ProfileName:       profileName,
 	}
 }
 
 // findYtDlp 查找系统中的 yt-dlp 可执行文件
 func (t *DownloadVideo) findYtDlp() (string, error) {
+	return findYtDlp(t.App)
+}
+
+// findYtDlp 查找系统中的 yt-dlp 可执行文件
+func findYtDlp(app *core.AppServer) (string, error) {
 	// 从配置中获取安装目录
 	var installDir string
-	if t.App.Config != nil && t.App.Config.YtDlpPath != "" {
-		installDir = t.App.Config.YtDlpPath
+	if app.Config != nil && app.Config.YtDlpPath != "" {
+		installDir = app.Config.YtDlpPath
 	}
 
 	// 创建 yt-dlp 管理器
-	manager := utils.NewYtDlpManager(t.App.Logger, installDir)
+	manager := utils.NewYtDlpManager(app.Logger, installDir)
 
 	// 检查是否已安装
 	if manager.IsInstalled() {
 		path := manager.GetBinaryPath()
-		t.App.Logger.Debugf("找到 yt-dlp: %s", path)
+		app.Logger.Debugf("找到 yt-dlp: %s", path)
 		return path, nil
 	}
 
@@ -61,8 +74,13 @@ func (t *DownloadVideo) findYtDlp() (string, error) {
 
 // findLatestCookiesFile 查找最新的 cookies 文件
 func (t *DownloadVideo) findLatestCookiesFile() string {
+	return latestCookiesFile(t.App)
+}
+
+// latestCookiesFile 查找最新的 cookies 文件
+func latestCookiesFile(app *core.AppServer) string {
 	// 1. 优先查找 data/cookies/ 目录下最新的用户提交的 cookies
-	cookiesDir := filepath.Join(t.App.Config.DataPath, "cookies")
+	cookiesDir := filepath.Join(app.Config.DataPath, "cookies")
 	
 	// 确保路径是绝对路径
 	if !filepath.IsAbs(cookiesDir) {
@@ -96,15 +114,15 @@ func (t *DownloadVideo) findLatestCookiesFile() string {
 		}
 		
 		if latestFile != "" {
-			t.App.Logger.Infof("🍪 找到用户提交的最新 cookies 文件: %s", latestFile)
+			app.Logger.Infof("🍪 找到用户提交的最新 cookies 文件: %s", latestFile)
 			return latestFile
 		}
 	} else {
-		t.App.Logger.Warnf("⚠️ 无法读取 cookies 目录 %s: %v", cookiesDir, err)
+		app.Logger.Warnf("⚠️ 无法读取 cookies 目录 %s: %v", cookiesDir, err)
 	}
 	
 	// 2. 兼容旧逻辑：查找配置文件目录下的 cookies.txt
-	configDir := filepath.Dir(t.App.Config.Path)
+	configDir := filepath.Dir(app.Config.Path)
 	cookiesPath := filepath.Join(configDir, "cookies.txt")
 	
 	// 确保是绝对路径
@@ -116,7 +134,7 @@ func (t *DownloadVideo) findLatestCookiesFile() string {
 	}
 	
 	if _, err := os.Stat(cookiesPath); err == nil {
-		t.App.Logger.Infof("🍪 找到配置目录下的 cookies 文件: %s", cookiesPath)
+		app.Logger.Infof("🍪 找到配置目录下的 cookies 文件: %s", cookiesPath)
 		return cookiesPath
 	}
 	
@@ -125,39 +143,25 @@ func (t *DownloadVideo) findLatestCookiesFile() string {
 	if _, err := os.Stat(currentCookies); err == nil {
 		absPath, err := filepath.Abs(currentCookies)
 		if err == nil {
-			t.App.Logger.Infof("🍪 找到当前目录的 cookies 文件: %s", absPath)
+			app.Logger.Infof("🍪 找到当前目录的 cookies 文件: %s", absPath)
 			return absPath
 		}
 	}
 	
-	t.App.Logger.Warn("⚠️ 未找到任何可用的 cookies 文件")
+	app.Logger.Warn("⚠️ 未找到任何可用的 cookies 文件")
 	return ""
 }
 
-// getVideoURL 根据 VideoID 构建完整的视频 URL
+// getVideoURL 获取视频的下载链接
 func (t *DownloadVideo) getVideoURL() string {
-	videoID := t.StateManager.VideoID
-
-	// 如果已经是完整 URL，直接返回
-	if strings.HasPrefix(videoID, "http://") || strings.HasPrefix(videoID, "https://") {
-		return videoID
-	}
-
-	// YouTube 短 ID 格式
-	if len(videoID) == 11 && !strings.Contains(videoID, "/") {
-		return fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID)
-	}
-
-	// Bilibili BV 号
-	if strings.HasPrefix(videoID, "BV") {
-		return fmt.Sprintf("https://www.bilibili.com/video/%s", videoID)
+	var video *model.SavedVideo
+	if t.SavedVideoService != nil {
+		video, _ = t.SavedVideoService.GetVideoByVideoID(t.StateManager.VideoID)
 	}
-
-	// 默认作为 YouTube ID 处理
-	return fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID)
+	return videoURLOf(video, t.StateManager.VideoID)
 }
 
-func (t *DownloadVideo) Execute(context map[string]interface{}) bool {
+func (t *DownloadVideo) Execute(ctx context.Context) error {
 	t.App.Logger.Info("========================================")
 	t.App.Logger.Info("DownloadVideo Handler Version: with-cookies-support-v3") // 版本标记
 	t.App.Logger.Infof("开始下载视频: %s", t.StateManager.VideoID)
@@ -167,18 +171,31 @@ func (t *DownloadVideo) Execute(context map[string]interface{}) bool {
 	ytdlpPath, err := t.findYtDlp()
 	if err != nil {
 		t.App.Logger.Errorf("❌ %v", err)
-		context["error"] = err.Error()
-		return false
+		return err
+	}
+
+	// 2. 确定下载配置
+	profile, err := t.resolveProfile()
+	if err != nil {
+		t.App.Logger.Errorf("❌ %v", err)
+		return types.NewStepError(types.ErrorPermanent, err)
 	}
+	t.App.Logger.Infof("🎞️ 下载参数: %s", strings.Join(profile.Args(), " "))
 
-	// 2. 确保下载目录存在
+	// 3. 确保下载目录存在
 	if err := os.MkdirAll(t.StateManager.CurrentDir, 0755); err != nil {
 		t.App.Logger.Errorf("❌ 创建下载目录失败: %v", err)
-		context["error"] = err.Error()
-		return false
+		return err
 	}
 
-	// 3. 尝试下载（先用代理，失败后不用代理重试）
+	// 4. 占用下载槽位，限制同时进行的下载数
+	release, err := t.StateManager.Limiter.Acquire(ctx, manager.ResourceDownload)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	// 5. 尝试下载（先用代理，失败后不用代理重试）
 	videoURL := t.getVideoURL()
 	useProxy := t.App.Config != nil && t.App.Config.ProxyConfig != nil && 
 		t.App.Config.ProxyConfig.UseProxy && t.App.Config.ProxyConfig.ProxyHost != ""
@@ -186,26 +203,32 @@ func (t *DownloadVideo) Execute(context map[string]interface{}) bool {
 	// 第一次尝试：使用代理（如果配置了）
 	if useProxy {
 		t.App.Logger.Info("🔄 尝试使用代理下载...")
-		if t.executeDownload(ytdlpPath, videoURL, true, context) {
-			return true
+		if err := t.executeDownload(ctx, ytdlpPath, videoURL, profile, true); err == nil {
+			return nil
+		} else if ctx.Err() != nil {
+			return err
 		}
 		t.App.Logger.Warn("⚠️ 代理下载失败，尝试不使用代理重试...")
 	}
 
 	// 第二次尝试：不使用代理
 	t.App.Logger.Info("🔄 尝试不使用代理下载...")
-	return t.executeDownload(ytdlpPath, videoURL, false, context)
+	return t.executeDownload(ctx, ytdlpPath, videoURL, profile, false)
 }
 
 // executeDownload 执行实际的下载操作
-func (t *DownloadVideo) executeDownload(ytdlpPath, videoURL string, useProxy bool, context map[string]interface{}) bool {
+func (t *DownloadVideo) executeDownload(ctx context.Context, ytdlpPath, videoURL string, profile *download.Profile, useProxy bool) error {
 	// 构建下载命令
+	formatFile := filepath.Join(t.StateManager.CurrentDir, downloadFormatFile)
+	os.Remove(formatFile)
 	command := []string{
 		ytdlpPath,
 		"-P", t.StateManager.CurrentDir,
 		"-o", "%(id)s.%(ext)s",
-		"--merge-output-format", "mp4",
+		"--newline", // 每条进度单独输出一行，便于解析下载百分比
+		"--print-to-file", downloadFormatTemplate, formatFile, // 记录实际下载的格式
 	}
+	command = append(command, profile.Args()...)
 
 	// 查找最新的 cookies 文件（优先使用用户提交的）
 	cookiesPath := t.findLatestCookiesFile()
@@ -232,97 +255,131 @@ func (t *DownloadVideo) executeDownload(ytdlpPath, videoURL string, useProxy boo
 	t.App.Logger.Infof("下载目录: %s", t.StateManager.CurrentDir)
 	t.App.Logger.Infof("视频URL: %s", videoURL)
 
-	// 创建命令并设置输出管道
-	cmd := exec.Command(command[0], command[1:]...)
+	// 创建命令并设置输出管道，任务取消或超时时结束 yt-dlp 及其子进程
+	cmd := utils.CommandContext(ctx, command[0], command[1:]...)
 	cmd.Dir = t.StateManager.CurrentDir
 
 	// 捕获标准输出和标准错误
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		t.App.Logger.Errorf("❌ 创建标准输出管道失败: %v", err)
-		context["error"] = err.Error()
-		return false
+		return err
 	}
 
 	stderr, err := cmd.StderrPipe()
 	if err != nil {
 		t.App.Logger.Errorf("❌ 创建标准错误管道失败: %v", err)
-		context["error"] = err.Error()
-		return false
+		return err
 	}
 
 	// 启动命令
 	if err := cmd.Start(); err != nil {
 		t.App.Logger.Errorf("❌ 启动下载命令失败: %v", err)
-		context["error"] = err.Error()
-		return false
+		return err
 	}
 
-	// 实时读取输出
+	// 实时读取输出，记录 yt-dlp 最后输出的错误信息用于判断错误类型
+	errorLine := make(chan string, 1)
 	go t.logOutput(stdout, "INFO")
-	go t.logOutput(stderr, "ERROR")
+	go func() {
+		errorLine <- t.logOutput(stderr, "ERROR")
+	}()
+	lastError := <-errorLine
 
 	// 等待命令完成
 	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			t.App.Logger.Warnf("⚠️ 视频下载已中止: %v", ctx.Err())
+			return ctx.Err()
+		}
 		t.App.Logger.Errorf("❌ 视频下载失败: %v", err)
-		context["error"] = fmt.Sprintf("下载失败: %v", err)
-		return false
+		return downloadError(lastError, err)
 	}
 
 	// 10. 验证下载的文件
 	downloadedFile := t.findDownloadedFile()
 	if downloadedFile == "" {
+		if profile.MaxFileSize > 0 {
+			// 超过大小限制时 yt-dlp 跳过下载但不报错
+			errMsg := fmt.Sprintf("未找到视频文件，可能所有格式都超过了 %d MB 的大小限制", profile.MaxFileSize)
+			t.App.Logger.Error("❌ " + errMsg)
+			return types.NewStepError(types.ErrorPermanent, errors.New(errMsg))
+		}
 		errMsg := "下载完成但未找到视频文件"
 		t.App.Logger.Error("❌ " + errMsg)
-		context["error"] = errMsg
-		return false
+		return errors.New(errMsg)
 	}
 
-	// 11. 保存文件信息到 context
-	context["downloaded_file"] = downloadedFile
+	// 11. 记录源视频产物
+	if err := t.StateManager.Artifacts.SetPath(manager.ArtifactSourceVideo, downloadedFile); err != nil {
+		t.App.Logger.Warnf("⚠️ 记录视频文件产物失败: %v", err)
+	}
 	t.App.Logger.Infof("✓ 视频下载成功: %s", downloadedFile)
 
+	format, ok := readDownloadFormat(formatFile)
+	if ok {
+		t.App.Logger.Infof("✓ 下载格式: %s, 分辨率: %s, 编码: %s", format.FormatID, format.Resolution, format.Codec)
+	}
+
 	// 12. 获取视频元数据（标题、描述等）
 	t.App.Logger.Info("📋 获取视频元数据...")
-	metadata, err := t.getVideoMetadata(ytdlpPath)
+	metadata, fetched, err := t.getVideoMetadata(ctx, ytdlpPath)
 	if err != nil {
 		t.App.Logger.Warnf("⚠️ 获取视频元数据失败: %v，将使用默认值", err)
 	} else {
-		context["original_title"] = metadata.Title
-		context["original_description"] = metadata.Description
 		t.App.Logger.Infof("✓ 原始标题: %s", metadata.Title)
 		if metadata.Description != "" {
 			t.App.Logger.Infof("✓ 原始描述: %s", t.truncateString(metadata.Description, 100))
 		}
+		if fetched {
+			if err := archiveSourceInfo(t.StateManager.CurrentDir, metadata); err != nil {
+				t.App.Logger.Warnf("⚠️ 保存视频完整信息失败: %v", err)
+			}
+		}
+	}
 
-		// 保存到数据库
-		if t.SavedVideoService != nil {
-			savedVideo, err := t.SavedVideoService.GetVideoByVideoID(t.StateManager.VideoID)
-			if err == nil {
+	// 保存到数据库
+	if t.SavedVideoService != nil && (metadata != nil || ok) {
+		savedVideo, err := t.SavedVideoService.GetVideoByVideoID(t.StateManager.VideoID)
+		if err == nil {
+			if metadata != nil {
 				savedVideo.Title = metadata.Title
 				savedVideo.Description = metadata.Description
-				if err := t.SavedVideoService.UpdateVideo(savedVideo); err != nil {
-					t.App.Logger.Errorf("❌ 保存原始元数据到数据库失败: %v", err)
-				} else {
-					t.App.Logger.Info("✅ 原始元数据已保存到数据库")
+				if fetched {
+					applySourceMetadata(savedVideo, metadata)
 				}
 			}
+			if ok {
+				savedVideo.FormatID = format.FormatID
+				savedVideo.Resolution = format.Resolution
+				savedVideo.Codec = format.Codec
+			}
+			if err := t.SavedVideoService.UpdateVideo(savedVideo); err != nil {
+				t.App.Logger.Errorf("❌ 保存原始元数据到数据库失败: %v", err)
+			} else {
+				t.App.Logger.Info("✅ 原始元数据已保存到数据库")
+			}
 		}
 	}
 
 	t.App.Logger.Info("========================================")
 
-	return true
+	return nil
 }
 
-// logOutput 实时输出日志
-func (t *DownloadVideo) logOutput(reader io.Reader, level string) {
+// logOutput 实时输出日志，返回最后一条 yt-dlp 错误信息
+func (t *DownloadVideo) logOutput(reader io.Reader, level string) string {
+	var lastError string
+	lastPercent := -1
 	scanner := bufio.NewScanner(reader)
 	for scanner.Scan() {
 		line := scanner.Text()
 		if line == "" {
 			continue
 		}
+		if strings.HasPrefix(line, "ERROR:") {
+			lastError = strings.TrimSpace(strings.TrimPrefix(line, "ERROR:"))
+		}
 
 		// 解析进度信息
 		if strings.Contains(line, "[download]") {
@@ -331,6 +388,12 @@ func (t *DownloadVideo) logOutput(reader io.Reader, level string) {
 			} else if strings.Contains(line, "%") {
 				// 进度信息，使用 Debug 级别避免日志过多
 				t.App.Logger.Debugf("⏳ %s", line)
+
+				// 百分比变化时发布进度事件
+				if percent, ok := parseDownloadPercent(line); ok && int(percent) != lastPercent {
+					lastPercent = int(percent)
+					t.ReportProgress(int64(percent), 100, strings.TrimSpace(strings.TrimPrefix(line, "[download]")))
+				}
 			} else {
 				t.App.Logger.Infof("📥 %s", line)
 			}
@@ -344,6 +407,36 @@ func (t *DownloadVideo) logOutput(reader io.Reader, level string) {
 			}
 		}
 	}
+	return lastError
+}
+
+// downloadPercentPattern yt-dlp 进度行中的百分比，如 "[download]  45.3% of 120.50MiB at 2.10MiB/s ETA 00:40"
+var downloadPercentPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)%`)
+
+// parseDownloadPercent 解析 yt-dlp 进度行中的下载百分比
+func parseDownloadPercent(line string) (float64, bool) {
+	match := downloadPercentPattern.FindStringSubmatch(line)
+	if match == nil {
+		return 0, false
+	}
+	percent, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, false
+	}
+	return percent, true
+}
+
+// downloadError 根据 yt-dlp 输出的错误信息生成带分类的下载错误
+// 无法识别的错误多为网络问题，按临时错误处理
+func downloadError(message string, err error) error {
+	if message == "" {
+		return types.NewStepError(types.ErrorTransient, fmt.Errorf("下载失败: %v", err))
+	}
+	class, ok := types.ClassifyMessage(message)
+	if !ok {
+		class = types.ErrorTransient
+	}
+	return types.NewStepError(class, fmt.Errorf("下载失败: %s", message))
 }
 
 // findDownloadedFile 查找下载的视频文件
@@ -352,7 +445,7 @@ func (t *DownloadVideo) findDownloadedFile() string {
 	files, err := filepath.Glob(filepath.Join(t.StateManager.CurrentDir, "*.mp4"))
 	if err != nil || len(files) == 0 {
 		// 尝试查找其他视频格式
-		for _, ext := range []string{"*.webm", "*.mkv", "*.flv"} {
+		for _, ext := range []string{"*.webm", "*.mkv", "*.flv", "*.m4a", "*.mp3", "*.opus"} {
 			files, err = filepath.Glob(filepath.Join(t.StateManager.CurrentDir, ext))
 			if err == nil && len(files) > 0 {
 				break
@@ -382,68 +475,96 @@ func (t *DownloadVideo) findDownloadedFile() string {
 	return ""
 }
 
-// VideoMetadataInfo 视频元数据信息
-type VideoMetadataInfo struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Uploader    string `json:"uploader"`
-	Duration    int    `json:"duration"`
+// resolveProfile 获取视频使用的下载配置
+// 视频指定的配置优先于流程指定的配置，都未指定时使用默认配置，然后用视频的设置覆盖其中的项
+func (t *DownloadVideo) resolveProfile() (*download.Profile, error) {
+	var overrides model.DownloadOverrides
+	if t.SavedVideoService != nil {
+		if video, err := t.SavedVideoService.GetVideoByVideoID(t.StateManager.VideoID); err == nil {
+			if overrides, err = video.GetDownloadOverrides(); err != nil {
+				return nil, fmt.Errorf("视频的下载设置无效: %v", err)
+			}
+		}
+	}
+
+	name := t.ProfileName
+	if overrides.ProfileName != "" {
+		name = overrides.ProfileName
+	}
+	var config *types.DownloadConfig
+	if t.App.Config != nil {
+		config = t.App.Config.DownloadConfig
+	}
+	profile, err := config.GetProfile(name)
+	if err != nil {
+		return nil, err
+	}
+	profile = profile.Merge(&overrides.Profile)
+	if err := profile.Validate(); err != nil {
+		return nil, fmt.Errorf("下载配置无效: %v", err)
+	}
+	return profile, nil
 }
 
-// getVideoMetadata 使用 yt-dlp 获取视频元数据（带代理回退）
-func (t *DownloadVideo) getVideoMetadata(ytdlpPath string) (*VideoMetadataInfo, error) {
-	videoURL := t.getVideoURL()
+// downloadFormatFile 记录实际下载格式的文件，每次下载前删除
+const downloadFormatFile = "download_format.txt"
 
-	// 构建基础命令参数
-	args := []string{"--dump-json", "--no-download"}
-	
-	// 添加 cookies 支持（使用最新的用户提交的 cookies）
-	cookiesPath := t.findLatestCookiesFile()
-	
-	if cookiesPath != "" {
-		args = append(args, "--cookies", cookiesPath)
-		t.App.Logger.Debugf("🍪 使用 Cookies 文件获取元数据: %s", cookiesPath)
-	} else {
-		// 从浏览器读取 cookies
-		args = append(args, "--cookies-from-browser", "chrome")
-		t.App.Logger.Debug("🍪 从 Chrome 浏览器读取 cookies 获取元数据")
+// downloadFormatTemplate 视频移动到下载目录后输出的格式信息，以制表符分隔
+const downloadFormatTemplate = "after_move:%(format_id)s\t%(width)s\t%(height)s\t%(vcodec)s\t%(acodec)s"
+
+// downloadFormat yt-dlp 实际下载的格式
+type downloadFormat struct {
+	FormatID   string
+	Resolution string
+	Codec      string
+}
+
+// readDownloadFormat 读取 yt-dlp 输出的格式信息，缺少的字段 yt-dlp 输出为 NA
+func readDownloadFormat(path string) (*downloadFormat, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
 	}
-	
-	// 尝试使用代理
-	useProxy := t.App.Config != nil && t.App.Config.ProxyConfig != nil && 
-		t.App.Config.ProxyConfig.UseProxy && t.App.Config.ProxyConfig.ProxyHost != ""
-	
-	if useProxy {
-		args = append(args, "--proxy", t.App.Config.ProxyConfig.ProxyHost)
-		t.App.Logger.Debugf("📡 使用代理获取元数据: %s", t.App.Config.ProxyConfig.ProxyHost)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	fields := strings.Split(lines[len(lines)-1], "\t")
+	if len(fields) != 5 || fields[0] == "" || fields[0] == "NA" {
+		return nil, false
 	}
-	
-	args = append(args, videoURL)
-	
-	// 第一次尝试（可能带代理）
-	cmd := exec.Command(ytdlpPath, args...)
-	output, err := cmd.Output()
-	
-	// 如果使用代理失败，尝试不使用代理
-	if err != nil && useProxy {
-		t.App.Logger.Warnf("⚠️ 使用代理获取元数据失败，尝试不使用代理...")
-		argsNoProxy := []string{"--dump-json", "--no-download", videoURL}
-		cmd = exec.Command(ytdlpPath, argsNoProxy...)
-		output, err = cmd.Output()
-		if err != nil {
-			return nil, fmt.Errorf("获取元数据失败: %v", err)
+	known := func(value string) bool { return value != "" && value != "NA" && value != "none" }
+
+	format := &downloadFormat{FormatID: fields[0], Resolution: "audio only", Codec: fields[4]}
+	if known(fields[2]) {
+		format.Resolution = fields[2] + "p"
+		if known(fields[1]) {
+			format.Resolution = fields[1] + "x" + fields[2]
 		}
-		t.App.Logger.Info("✓ 不使用代理成功获取元数据")
-	} else if err != nil {
-		return nil, fmt.Errorf("获取元数据失败: %v", err)
 	}
+	if known(fields[3]) {
+		format.Codec = fields[3]
+	}
+	if !known(format.Codec) {
+		format.Codec = ""
+	}
+	return format, true
+}
 
-	var metadata VideoMetadataInfo
-	if err := json.Unmarshal(output, &metadata); err != nil {
-		return nil, fmt.Errorf("解析元数据失败: %v", err)
+// getVideoMetadata 通过视频来源获取元数据（带代理回退）
+// 检查视频信息的步骤已获取并保存时直接使用其中的标题和描述，不再重复请求，此时 fetched 为 false
+func (t *DownloadVideo) getVideoMetadata(ctx context.Context, ytdlpPath string) (metadata *source.Metadata, fetched bool, err error) {
+	if info, ok := t.StateManager.Artifacts.SourceInfo(); ok {
+		return &source.Metadata{
+			Title:       info.Title,
+			Description: info.Description,
+			Uploader:    info.Uploader,
+			Duration:    info.Duration,
+		}, false, nil
 	}
 
-	return &metadata, nil
+	metadata, err = fetchMetadata(ctx, t.App, ytdlpPath, t.getVideoURL())
+	if err != nil {
+		return nil, false, err
+	}
+	return metadata, true, nil
 }
 
 // truncateString 截断字符串用于日志显示
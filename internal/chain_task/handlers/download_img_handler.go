@@ -1,91 +1,82 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
+
 	"github.com/difyz9/ytb2bili/internal/chain_task/base"
 	"github.com/difyz9/ytb2bili/internal/chain_task/manager"
 	"github.com/difyz9/ytb2bili/internal/core"
-	"github.com/difyz9/ytb2bili/internal/core/models"
+	"github.com/difyz9/ytb2bili/internal/core/services"
 	"github.com/difyz9/ytb2bili/pkg/cos"
+	"github.com/difyz9/ytb2bili/pkg/source"
+	"github.com/difyz9/ytb2bili/pkg/store/model"
 	"github.com/difyz9/ytb2bili/pkg/utils"
-	"fmt"
 	"gorm.io/gorm"
-	"time"
 )
 
 type DownloadImgHandler struct {
 	base.BaseTask
-	App *core.AppServer
-	DB  *gorm.DB
+	App               *core.AppServer
+	DB                *gorm.DB
+	SavedVideoService *services.SavedVideoService
 }
 
-func NewDownloadImgHandler(name string, app *core.AppServer, stateManager *manager.StateManager, client *cos.CosClient) *DownloadImgHandler {
+func NewDownloadImgHandler(name string, app *core.AppServer, stateManager *manager.StateManager, client *cos.CosClient, savedVideoService *services.SavedVideoService) *DownloadImgHandler {
 	return &DownloadImgHandler{
 		BaseTask: base.BaseTask{
 			Name:         name,
 			StateManager: stateManager,
 			Client:       client,
 		},
-		App: app,
+		App:               app,
+		SavedVideoService: savedVideoService,
 	}
 
 }
 
-func (t *DownloadImgHandler) Execute(context map[string]interface{}) bool {
-
-	opt := utils.DownloadOptions{
-		SavePath:         t.StateManager.CurrentDir,
-		FilenameTemplate: "{quality}",
-		Timeout:          10 * time.Second,
-		MaxRetries:       3,
-		QualityFallback:  true,
-		CreateDirs:       true,
-		Overwrite:        false,
+// Execute 通过视频来源下载封面，封面下载失败不影响后续步骤
+func (t *DownloadImgHandler) Execute(ctx context.Context) error {
+	var video *model.SavedVideo
+	if t.SavedVideoService != nil {
+		video, _ = t.SavedVideoService.GetVideoByVideoID(t.StateManager.VideoID)
+	}
+	videoURL := videoURLOf(video, t.StateManager.VideoID)
+	src, _, err := source.ForURL(videoURL)
+	if err != nil {
+		t.App.Logger.Warnf("⚠️ 无法识别视频来源，跳过下载封面: %v", err)
+		return nil
 	}
 
-	//utils.QualityMax,
-	qualities := []utils.ImageQuality{utils.QualityMax, utils.QualityStandard}
-	results := utils.DownloadYouTubeThumbnail(t.StateManager.VideoID, qualities, opt, "").(map[string]utils.DownloadResult)
-
-	var maxQualityCoverPath string
-
-	for k, v := range results {
-		if v.Success {
-			fmt.Printf("下载成功: %s - %s (%d bytes)\n", k, v.FilePath, v.FileSize)
-			cosKeyName, _ := t.Client.UploadImageToCOS(v.FilePath, "")
-
-			// 如果是最高质量的封面，保存到context中供后续上传使用
-			if k == string(utils.QualityMax) {
-				maxQualityCoverPath = v.FilePath
-				context["cover_image_path"] = v.FilePath
-				t.App.Logger.Infof("✓ 最高质量封面已下载: %s", v.FilePath)
-			}
-
-			// 更新数据库记录
-			tbVideo := &models.TbVideo{
-				Id:      t.StateManager.Id,
-				VideoId: t.StateManager.VideoID,
-				ImgURL:  cosKeyName,
-				Status:  "img",
-			}
-			err := t.StateManager.UpdateTBVideo(tbVideo)
-			if err != nil {
+	// YouTube 封面直接下载，其他来源需要通过 yt-dlp 获取封面地址
+	ytdlpPath := ""
+	if ytdlp := utils.NewYtDlpManager(t.App.Logger, t.App.Config.YtDlpPath); ytdlp.IsInstalled() {
+		ytdlpPath = ytdlp.GetBinaryPath()
+	}
 
-			}
+	coverPath, err := src.FetchThumbnail(ctx, sourceOptions(t.App, ytdlpPath, "", true), videoURL, t.StateManager.CurrentDir)
+	if err != nil {
+		if errors.Is(err, source.ErrNotSupported) {
+			t.App.Logger.Infof("视频来源 %s 没有封面，将使用默认截屏封面", src.Platform())
 		} else {
-			fmt.Printf("下载失败: %s - %s\n", k, v.ErrorMessage)
+			t.App.Logger.Warnf("⚠️ 下载封面失败: %v", err)
 		}
+		return nil
 	}
+	fmt.Printf("下载成功: %s\n", coverPath)
+	t.App.Logger.Infof("✓ 封面已下载: %s", coverPath)
 
-	// 如果没有下载到最高质量的封面，使用其他质量的封面
-	if maxQualityCoverPath == "" {
-		for _, v := range results {
-			if v.Success {
-				context["cover_image_path"] = v.FilePath
-				t.App.Logger.Infof("✓ 备用质量封面已设置: %s", v.FilePath)
-				break
-			}
+	if t.Client != nil {
+		if _, err := t.Client.UploadImageToCOS(coverPath, ""); err != nil {
+			fmt.Printf("上传封面到COS失败: %v\n", err)
 		}
 	}
 
-	return true
+	// 记录封面产物，供上传步骤使用
+	if err := t.StateManager.Artifacts.SetPath(manager.ArtifactCover, coverPath); err != nil {
+		t.App.Logger.Warnf("⚠️ 记录封面产物失败: %v", err)
+	}
+
+	return nil
 }
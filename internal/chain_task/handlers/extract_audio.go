@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"github.com/difyz9/ytb2bili/internal/chain_task/base"
 	"github.com/difyz9/ytb2bili/internal/chain_task/manager"
@@ -27,11 +28,31 @@ func NewExtractAudio(name string, app *core.AppServer, stateManager *manager.Sta
 	}
 }
 
-func (t *ExtractAudio) Execute(context map[string]interface{}) bool {
+func (t *ExtractAudio) Execute(ctx context.Context) error {
 	fmt.Println("开始分离音频")
-	if err := utils.ExtractWaveAudio(t.StateManager.InputVideoPath, t.StateManager.OriginalMP3); err != nil {
+	videoPath, ok := t.StateManager.Artifacts.Path(manager.ArtifactSourceVideo)
+	if !ok {
+		videoPath = t.StateManager.InputVideoPath
+	}
+	audioPath := t.StateManager.OriginalWAV
+
+	release, err := t.StateManager.Limiter.Acquire(ctx, manager.ResourceFFmpeg)
+	if err != nil {
+		return err
+	}
+	err = utils.ExtractWaveAudio(ctx, videoPath, audioPath)
+	release()
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// 分离失败不中断任务，依赖音频的步骤会因缺少产物而失败
 		fmt.Println("--- 分离音频失败-----")
+		return nil
+	}
+	if err := t.StateManager.Artifacts.SetPath(manager.ArtifactAudioWAV, audioPath); err != nil {
+		t.App.Logger.Warnf("⚠️  记录音频产物失败: %v", err)
 	}
 	fmt.Println("分离音频完成")
-	return true
+	return nil
 }
@@ -136,8 +136,8 @@ func (g *GeminiClient) GenerateMetadataFromVideo(ctx context.Context, videoFile
 	return parseMetadataJSON(content)
 }
 
-// GenerateMetadataFromText 从文本生成元数据（用于字幕）
-func (g *GeminiClient) GenerateMetadataFromText(ctx context.Context, subtitleText string) (*VideoMetadata, error) {
+// GenerateMetadataFromText 从文本生成元数据（用于字幕），sourceInfo 为原视频信息，可以为空
+func (g *GeminiClient) GenerateMetadataFromText(ctx context.Context, sourceInfo, subtitleText string) (*VideoMetadata, error) {
 	// 直接使用模型名称，SDK会自动处理
 	model := g.client.GenerativeModel(g.model)
 
@@ -147,7 +147,7 @@ func (g *GeminiClient) GenerateMetadataFromText(ctx context.Context, subtitleTex
 
 	prompt := fmt.Sprintf(`请根据以下视频字幕内容，生成一个吸引人的视频标题、精炼介绍和3-5个相关标签。
 
-字幕内容：
+%s字幕内容：
 %s
 
 要求：
@@ -162,7 +162,7 @@ func (g *GeminiClient) GenerateMetadataFromText(ctx context.Context, subtitleTex
   "tags": ["标签1", "标签2", "标签3"]
 }
 
-请直接返回JSON格式的结果，不要包含任何其他说明文字。`, subtitleText)
+请直接返回JSON格式的结果，不要包含任何其他说明文字。`, sourceInfo, subtitleText)
 
 	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
 	if err != nil {
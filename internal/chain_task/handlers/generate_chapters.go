@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/difyz9/ytb2bili/internal/chain_task/base"
+	"github.com/difyz9/ytb2bili/internal/chain_task/manager"
+	"github.com/difyz9/ytb2bili/internal/core"
+	"github.com/difyz9/ytb2bili/internal/core/services"
+	"github.com/difyz9/ytb2bili/internal/core/types"
+	"github.com/difyz9/ytb2bili/pkg/cos"
+	"github.com/difyz9/ytb2bili/pkg/source"
+	"github.com/difyz9/ytb2bili/pkg/store/model"
+	"github.com/difyz9/ytb2bili/pkg/subtitle"
+)
+
+// maxAIChapters AI 生成的最多章节数
+const maxAIChapters = 12
+
+// minAIChapterDuration 生成章节需要的最短视频时长（秒），太短的视频不需要章节
+const minAIChapterDuration = 180
+
+// GenerateChapters 获取视频章节
+// 优先使用来源提供的章节，没有时从原视频描述中解析时间戳，仍然没有时可以根据字幕时间轴用 AI 生成
+type GenerateChapters struct {
+	base.BaseTask
+	App               *core.AppServer
+	SavedVideoService *services.SavedVideoService
+}
+
+func NewGenerateChapters(name string, app *core.AppServer, stateManager *manager.StateManager, client *cos.CosClient, savedVideoService *services.SavedVideoService) *GenerateChapters {
+	return &GenerateChapters{
+		BaseTask: base.BaseTask{
+			Name:         name,
+			StateManager: stateManager,
+			Client:       client,
+		},
+		App:               app,
+		SavedVideoService: savedVideoService,
+	}
+}
+
+func (t *GenerateChapters) Execute(ctx context.Context) error {
+	savedVideo, err := t.SavedVideoService.GetVideoByVideoID(t.StateManager.VideoID)
+	if err != nil {
+		return fmt.Errorf("查询视频信息失败: %v", err)
+	}
+	config := t.App.Config.ChapterConfig
+	if config == nil {
+		config = &types.ChapterConfig{}
+	}
+
+	// 已有章节时直接使用，保留之前翻译的标题
+	chapters := savedVideo.GetChapters()
+	chapterSource := savedVideo.ChapterSource
+	if len(chapters) > 0 && chapterSource == "" {
+		chapterSource = model.ChapterSourcePlatform
+	}
+	changed := false
+
+	if len(chapters) == 0 && config.ParseDescription {
+		for _, chapter := range source.ParseDescriptionChapters(savedVideo.Description, savedVideo.Duration) {
+			chapters = append(chapters, model.Chapter{Title: chapter.Title, StartTime: chapter.StartTime, EndTime: chapter.EndTime})
+		}
+		chapterSource, changed = model.ChapterSourceDescription, len(chapters) > 0
+	}
+
+	if len(chapters) == 0 && config.AIGenerate {
+		chapters, err = t.generateWithAI(ctx, savedVideo)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			// 生成章节失败不影响上传
+			t.App.Logger.Warnf("⚠️ AI 生成章节失败: %v", err)
+		}
+		chapterSource, changed = model.ChapterSourceAI, len(chapters) > 0
+	}
+
+	if len(chapters) == 0 {
+		t.App.Logger.Info("视频没有章节")
+		chapterSource = ""
+	} else {
+		t.App.Logger.Infof("📑 获取到 %d 个章节，来源: %s", len(chapters), chapterSource)
+	}
+
+	if changed || chapterSource != savedVideo.ChapterSource {
+		savedVideo.SetChapters(chapters)
+		savedVideo.ChapterSource = chapterSource
+		if err := t.SavedVideoService.UpdateVideo(savedVideo); err != nil {
+			return fmt.Errorf("保存章节失败: %v", err)
+		}
+	}
+
+	return t.StateManager.Artifacts.SetChapters(&manager.ChaptersArtifact{Source: chapterSource, Count: len(chapters)})
+}
+
+// aiChapter 大模型返回的章节
+type aiChapter struct {
+	Start float64 `json:"start"`
+	Title string  `json:"title"`
+}
+
+// generateWithAI 根据原语言字幕的时间轴用 AI 生成章节
+func (t *GenerateChapters) generateWithAI(ctx context.Context, video *model.SavedVideo) ([]model.Chapter, error) {
+	srtPath, ok := t.StateManager.Artifacts.Path(manager.ArtifactOriginalSRT)
+	if !ok {
+		srtPath = t.StateManager.OriginalSRT
+	}
+	sub, err := subtitle.ReadFile(srtPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取原语言字幕失败: %v", err)
+	}
+	cues := sub.Cues
+	if len(cues) == 0 {
+		return nil, nil
+	}
+	duration := float64(video.Duration)
+	if last := cues[len(cues)-1].End.Seconds(); last > duration {
+		duration = last
+	}
+	if duration < minAIChapterDuration {
+		t.App.Logger.Info("视频太短，不生成章节")
+		return nil, nil
+	}
+
+	deepSeek := t.App.Config.DeepSeekTransConfig
+	if deepSeek == nil || !deepSeek.Enabled || deepSeek.ApiKey == "" {
+		return nil, fmt.Errorf("DeepSeek 服务未启用")
+	}
+
+	// 占用大模型请求槽位
+	release, err := t.StateManager.Limiter.Acquire(ctx, manager.ResourceLLM)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	systemPrompt := fmt.Sprintf(`你是视频编辑，根据带时间的字幕把视频分成 3 到 %d 个章节。
+第一个章节从 0 秒开始，章节标题使用字幕的语言，简短概括这一段的内容。
+只返回 JSON 数组，格式: [{"start": 开始秒数, "title": "章节标题"}]`, maxAIChapters)
+	response, err := NewDeepSeekClient(deepSeek.ApiKey).ChatCompletion(ctx, systemPrompt, timelineText(cues, 12000))
+	if err != nil {
+		return nil, err
+	}
+
+	response = strings.TrimSpace(response)
+	if start, end := strings.Index(response, "["), strings.LastIndex(response, "]"); start >= 0 && end > start {
+		response = response[start : end+1]
+	}
+	var items []aiChapter
+	if err := json.Unmarshal([]byte(response), &items); err != nil {
+		return nil, fmt.Errorf("解析章节失败: %v", err)
+	}
+	return normalizeAIChapters(items, duration), nil
+}
+
+// timelineText 字幕时间轴文本，每行一条字幕，超过 maxLength 字符时截断
+func timelineText(cues []subtitle.Cue, maxLength int) string {
+	var sb strings.Builder
+	for _, cue := range cues {
+		line := fmt.Sprintf("[%d] %s\n", int(cue.Start.Seconds()), strings.ReplaceAll(cue.Text, "\n", " "))
+		if sb.Len()+len(line) > maxLength {
+			break
+		}
+		sb.WriteString(line)
+	}
+	return sb.String()
+}
+
+// normalizeAIChapters 排序并去掉无效的章节，第一个章节从 0 秒开始，最后一个章节在视频结束时结束
+func normalizeAIChapters(items []aiChapter, duration float64) []model.Chapter {
+	sort.Slice(items, func(i, j int) bool { return items[i].Start < items[j].Start })
+
+	var chapters []model.Chapter
+	for _, item := range items {
+		title := strings.TrimSpace(item.Title)
+		if title == "" || item.Start < 0 || item.Start >= duration {
+			continue
+		}
+		if n := len(chapters); n > 0 && item.Start <= chapters[n-1].StartTime {
+			continue
+		}
+		if len(chapters) == 0 {
+			item.Start = 0
+		}
+		chapters = append(chapters, model.Chapter{Title: title, StartTime: item.Start})
+		if len(chapters) == maxAIChapters {
+			break
+		}
+	}
+	if len(chapters) < 3 {
+		return nil
+	}
+	for i := range chapters {
+		if i+1 < len(chapters) {
+			chapters[i].EndTime = chapters[i+1].StartTime
+		} else {
+			chapters[i].EndTime = duration
+		}
+	}
+	return chapters
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -14,6 +15,8 @@ import (
 	"github.com/difyz9/ytb2bili/internal/core"
 	"github.com/difyz9/ytb2bili/internal/core/services"
 	"github.com/difyz9/ytb2bili/pkg/cos"
+	"github.com/difyz9/ytb2bili/pkg/subtitle"
+	"github.com/difyz9/ytb2bili/pkg/utils"
 	"gorm.io/gorm"
 )
 
@@ -58,11 +61,18 @@ type VideoMetadata struct {
 	Tags        []string `json:"tags"`
 }
 
-func (g *GenerateMetadata) Execute(context map[string]interface{}) bool {
+func (g *GenerateMetadata) Execute(ctx context.Context) error {
 	g.App.Logger.Info("========================================")
 	g.App.Logger.Infof("开始生成视频标题和描述: VideoID=%s", g.StateManager.VideoID)
 	g.App.Logger.Info("========================================")
 
+	// 占用大模型请求槽位
+	release, err := g.StateManager.Limiter.Acquire(ctx, manager.ResourceLLM)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// 0. 检查是否使用 Gemini
 	useGemini := false
 	if g.App.Config.GeminiConfig != nil && g.App.Config.GeminiConfig.Enabled && g.App.Config.GeminiConfig.UseForMetadata {
@@ -71,15 +81,18 @@ func (g *GenerateMetadata) Execute(context map[string]interface{}) bool {
 
 		// 如果配置了视频分析，尝试使用视频文件
 		if g.App.Config.GeminiConfig.AnalyzeVideo {
-			if success := g.executeWithGeminiVideo(context); success {
-				return true
+			if success := g.executeWithGeminiVideo(ctx); success {
+				return nil
 			}
 			g.App.Logger.Warn("⚠️ Gemini 视频分析失败，回退到文本模式")
 		}
 
 		// 使用 Gemini 处理字幕文本
-		if success := g.executeWithGeminiText(context); success {
-			return true
+		if success := g.executeWithGeminiText(ctx); success {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
 		}
 		g.App.Logger.Warn("⚠️ Gemini 文本分析失败，回退到 DeepSeek")
 		useGemini = false
@@ -87,53 +100,45 @@ func (g *GenerateMetadata) Execute(context map[string]interface{}) bool {
 
 	// 使用 DeepSeek（默认或回退）
 	if !useGemini {
-		return g.executeWithDeepSeek(context)
+		return g.executeWithDeepSeek(ctx)
 	}
 
-	return false
+	return nil
 }
 
 // executeWithDeepSeek 使用 DeepSeek 生成元数据
-func (g *GenerateMetadata) executeWithDeepSeek(context map[string]interface{}) bool {
+func (g *GenerateMetadata) executeWithDeepSeek(ctx context.Context) error {
 	// 0. 动态获取最新的DeepSeek客户端
 	client, err := g.getCurrentDeepSeekClient()
 	if err != nil {
 		g.App.Logger.Errorf("❌ %v", err)
-		// 使用默认值而不是失败
-		context["video_title"] = g.StateManager.VideoID
-		context["video_description"] = "包含字幕的视频"
-		return true
+		// 使用默认标题和描述而不是失败
+		return nil
 	}
 
 	g.App.Logger.Infof("🔑 使用 DeepSeek 配置生成元数据")
 	// 更新当前使用的客户端
 	g.DeepSeekClient = client
 
-	// 1. 检查中文字幕文件是否存在
-	zhSRTPath := filepath.Join(g.StateManager.CurrentDir, "zh.srt")
-	if _, err := os.Stat(zhSRTPath); os.IsNotExist(err) {
-		g.App.Logger.Warn("⚠️  中文字幕文件不存在，使用默认标题和描述")
-		// 使用默认值
-		context["video_title"] = g.StateManager.VideoID
-		context["video_description"] = fmt.Sprintf("包含字幕的视频")
-		return true // 没有字幕文件不算失败
+	// 1. 检查原语言字幕文件是否存在（元数据生成与翻译并行执行，不依赖中文字幕）
+	srtPath := g.originalSRTPath()
+	if _, err := os.Stat(srtPath); os.IsNotExist(err) {
+		g.App.Logger.Warn("⚠️  字幕文件不存在，使用默认标题和描述")
+		return nil // 没有字幕文件不算失败
 	}
 
-	// 2. 读取中文字幕内容
-	srtContent, err := os.ReadFile(zhSRTPath)
+	// 2. 读取字幕内容
+	srtContent, err := os.ReadFile(srtPath)
 	if err != nil {
-		g.App.Logger.Errorf("❌ 读取中文字幕文件失败: %v", err)
-		context["error"] = "读取翻译字幕失败，请确保字幕翻译步骤已完成"
-		return false
+		g.App.Logger.Errorf("❌ 读取字幕文件失败: %v", err)
+		return errors.New("读取字幕失败，请确保字幕生成步骤已完成")
 	}
 
 	// 3. 解析字幕提取文本
 	subtitleText := g.extractTextFromSRT(string(srtContent))
 	if subtitleText == "" {
 		g.App.Logger.Warn("⚠️  字幕内容为空，使用默认标题和描述")
-		context["video_title"] = g.StateManager.VideoID
-		context["video_description"] = fmt.Sprintf("包含字幕的视频")
-		return true
+		return nil
 	}
 
 	g.App.Logger.Infof("📝 提取到字幕文本，总长度: %d 字符", len(subtitleText))
@@ -146,14 +151,11 @@ func (g *GenerateMetadata) executeWithDeepSeek(context map[string]interface{}) b
 
 	// 5. 调用 DeepSeek API 生成标题和描述
 	g.App.Logger.Info("🤖 调用 DeepSeek API 生成标题和描述...")
-	metadata, err := g.generateMetadataFromDeepSeek(subtitleText)
+	metadata, err := g.generateMetadataFromDeepSeek(ctx, subtitleText)
 	if err != nil {
 		g.App.Logger.Errorf("❌ 生成标题和描述失败: %v", err)
 		g.App.Logger.Warn("⚠️  将使用默认标题和描述，不影响视频上传")
-		// 使用默认值
-		context["video_title"] = g.StateManager.VideoID
-		context["video_description"] = fmt.Sprintf("包含字幕的视频")
-		return true // API调用失败不算整个任务失败
+		return nil // API调用失败不算整个任务失败
 	}
 
 	// 6. 验证标题长度（Bilibili限制80字符）
@@ -163,10 +165,8 @@ func (g *GenerateMetadata) executeWithDeepSeek(context map[string]interface{}) b
 		g.App.Logger.Warnf("⚠️  标题过长，已截断为80字符")
 	}
 
-	// 7. 保存到 context
-	context["video_title"] = metadata.Title
-	context["video_description"] = metadata.Description
-	context["video_tags"] = metadata.Tags
+	// 7. 记录元数据产物
+	g.saveMetadataArtifact(metadata)
 
 	// 8. 保存到 meta.json 文件
 	g.App.Logger.Info("💾 保存元数据到 meta.json 文件...")
@@ -204,41 +204,47 @@ func (g *GenerateMetadata) executeWithDeepSeek(context map[string]interface{}) b
 	g.App.Logger.Infof("🏷️  标签: %v", metadata.Tags)
 	g.App.Logger.Info("========================================")
 
-	return true
+	return nil
 }
 
-// extractTextFromSRT 从SRT内容中提取纯文本
-func (g *GenerateMetadata) extractTextFromSRT(srtContent string) string {
-	lines := strings.Split(srtContent, "\n")
-	var textLines []string
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		// 跳过空行、序号行、时间码行
-		if line == "" || isNumber(line) || strings.Contains(line, "-->") {
-			continue
-		}
-		textLines = append(textLines, line)
+// originalSRTPath 获取原语言字幕路径
+func (g *GenerateMetadata) originalSRTPath() string {
+	if path, ok := g.StateManager.Artifacts.Path(manager.ArtifactOriginalSRT); ok {
+		return path
 	}
+	return g.StateManager.OriginalSRT
+}
 
-	return strings.Join(textLines, " ")
+// saveMetadataArtifact 记录生成的元数据产物
+func (g *GenerateMetadata) saveMetadataArtifact(metadata *VideoMetadata) {
+	err := g.StateManager.Artifacts.SetMetadata(&manager.MetadataArtifact{
+		Title:       metadata.Title,
+		Description: metadata.Description,
+		Tags:        metadata.Tags,
+	})
+	if err != nil {
+		g.App.Logger.Warnf("⚠️  记录元数据产物失败: %v", err)
+	}
 }
 
-// isNumber 检查字符串是否为数字
-func isNumber(s string) bool {
-	for _, c := range s {
-		if c < '0' || c > '9' {
-			return false
-		}
+// extractTextFromSRT 从SRT内容中提取纯文本
+func (g *GenerateMetadata) extractTextFromSRT(srtContent string) string {
+	sub, err := subtitle.ParseSRT([]byte(srtContent))
+	if err != nil {
+		return ""
+	}
+	texts := make([]string, 0, len(sub.Cues))
+	for _, cue := range sub.Cues {
+		texts = append(texts, strings.ReplaceAll(cue.Text, "\n", " "))
 	}
-	return len(s) > 0
+	return strings.Join(texts, " ")
 }
 
 // generateMetadataFromDeepSeek 调用 DeepSeek API 生成标题和描述
-func (g *GenerateMetadata) generateMetadataFromDeepSeek(subtitleText string) (*VideoMetadata, error) {
+func (g *GenerateMetadata) generateMetadataFromDeepSeek(ctx context.Context, subtitleText string) (*VideoMetadata, error) {
 	prompt := fmt.Sprintf(`请根据以下视频字幕内容，生成一个吸引人的视频标题、详细描述和3-5个相关标签。
 
-字幕内容：
+%s字幕内容：
 %s
 
 要求：
@@ -253,10 +259,10 @@ func (g *GenerateMetadata) generateMetadataFromDeepSeek(subtitleText string) (*V
   "tags": ["标签1", "标签2", "标签3"]
 }
 
-请直接返回JSON格式的结果，不要包含任何其他说明文字。`, subtitleText)
+请直接返回JSON格式的结果，不要包含任何其他说明文字。`, g.sourceInfo(), subtitleText)
 
 	// 使用 DeepSeekClient 调用 API
-	content, usage, err := g.DeepSeekClient.ChatCompletionWithUsage("你是一个专业的视频内容分析助手，擅长根据视频字幕生成吸引人的标题和描述。", prompt)
+	content, usage, err := g.DeepSeekClient.ChatCompletionWithUsage(ctx, "你是一个专业的视频内容分析助手，擅长根据视频字幕生成吸引人的标题和描述。", prompt)
 	if err != nil {
 		return nil, fmt.Errorf("调用 DeepSeek API 失败: %v", err)
 	}
@@ -296,6 +302,37 @@ func (g *GenerateMetadata) generateMetadataFromDeepSeek(subtitleText string) (*V
 	return &metadata, nil
 }
 
+// sourceInfo 原视频的标题、作者、分类、标签和章节，作为生成元数据的参考，没有信息时返回空字符串
+func (g *GenerateMetadata) sourceInfo() string {
+	if g.SavedVideoService == nil {
+		return ""
+	}
+	video, err := g.SavedVideoService.GetVideoByVideoID(g.StateManager.VideoID)
+	if err != nil {
+		return ""
+	}
+
+	var lines []string
+	add := func(name, value string) {
+		if value != "" {
+			lines = append(lines, name+"："+value)
+		}
+	}
+	add("原标题", video.Title)
+	add("作者", video.Uploader)
+	add("分类", strings.Join(video.GetCategories(), "、"))
+	add("标签", strings.Join(video.GetSourceTags(), "、"))
+	var chapters []string
+	for _, chapter := range video.GetChapters() {
+		chapters = append(chapters, utils.FormatClock(chapter.StartTime)+" "+chapter.Title)
+	}
+	add("章节", strings.Join(chapters, "；"))
+	if len(lines) == 0 {
+		return ""
+	}
+	return "原视频信息（仅供参考）：\n" + strings.Join(lines, "\n") + "\n\n"
+}
+
 // saveMetadataToFile 保存元数据到 meta.json 文件
 func (g *GenerateMetadata) saveMetadataToFile(metadata *VideoMetadata) error {
 	// 构建文件路径
@@ -335,7 +372,7 @@ func (g *GenerateMetadata) truncateString(s string, maxLen int) string {
 }
 
 // executeWithGeminiVideo 使用 Gemini 分析视频文件生成元数据
-func (g *GenerateMetadata) executeWithGeminiVideo(taskContext map[string]interface{}) bool {
+func (g *GenerateMetadata) executeWithGeminiVideo(ctx context.Context) bool {
 	g.App.Logger.Info("🎬 使用 Gemini 多模态分析视频文件...")
 
 	// 1. 创建 Gemini 客户端
@@ -361,7 +398,7 @@ func (g *GenerateMetadata) executeWithGeminiVideo(taskContext map[string]interfa
 	g.App.Logger.Infof("📹 找到视频文件: %s", filepath.Base(videoPath))
 
 	// 3. 上传视频到 Gemini
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(g.App.Config.GeminiConfig.Timeout)*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(g.App.Config.GeminiConfig.Timeout)*time.Second)
 	defer cancel()
 
 	g.App.Logger.Info("⏫ 上传视频到 Gemini...")
@@ -389,22 +426,22 @@ func (g *GenerateMetadata) executeWithGeminiVideo(taskContext map[string]interfa
 	}
 
 	// 6. 保存结果
-	return g.saveMetadataResults(metadata, taskContext)
+	return g.saveMetadataResults(metadata)
 }
 
 // executeWithGeminiText 使用 Gemini 分析字幕文本生成元数据
-func (g *GenerateMetadata) executeWithGeminiText(taskContext map[string]interface{}) bool {
+func (g *GenerateMetadata) executeWithGeminiText(ctx context.Context) bool {
 	g.App.Logger.Info("📝 使用 Gemini 分析字幕文本...")
 
-	// 1. 检查中文字幕文件
-	zhSRTPath := filepath.Join(g.StateManager.CurrentDir, "zh.srt")
-	if _, err := os.Stat(zhSRTPath); os.IsNotExist(err) {
-		g.App.Logger.Warn("⚠️ 中文字幕文件不存在")
+	// 1. 检查原语言字幕文件
+	srtPath := g.originalSRTPath()
+	if _, err := os.Stat(srtPath); os.IsNotExist(err) {
+		g.App.Logger.Warn("⚠️ 字幕文件不存在")
 		return false
 	}
 
 	// 2. 读取字幕内容
-	srtContent, err := os.ReadFile(zhSRTPath)
+	srtContent, err := os.ReadFile(srtPath)
 	if err != nil {
 		g.App.Logger.Errorf("❌ 读取字幕文件失败: %v", err)
 		return false
@@ -439,22 +476,22 @@ func (g *GenerateMetadata) executeWithGeminiText(taskContext map[string]interfac
 	defer client.Close()
 
 	// 6. 生成元数据
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(g.App.Config.GeminiConfig.Timeout)*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(g.App.Config.GeminiConfig.Timeout)*time.Second)
 	defer cancel()
 
 	g.App.Logger.Info("🤖 调用 Gemini 生成元数据...")
-	metadata, err := client.GenerateMetadataFromText(ctx, subtitleText)
+	metadata, err := client.GenerateMetadataFromText(ctx, g.sourceInfo(), subtitleText)
 	if err != nil {
 		g.App.Logger.Errorf("❌ 生成元数据失败: %v", err)
 		return false
 	}
 
 	// 7. 保存结果
-	return g.saveMetadataResults(metadata, taskContext)
+	return g.saveMetadataResults(metadata)
 }
 
-// saveMetadataResults 保存元数据结果到context和数据库
-func (g *GenerateMetadata) saveMetadataResults(metadata *VideoMetadata, taskContext map[string]interface{}) bool {
+// saveMetadataResults 保存元数据结果到产物仓库和数据库
+func (g *GenerateMetadata) saveMetadataResults(metadata *VideoMetadata) bool {
 	// 1. 验证标题长度
 	if len([]rune(metadata.Title)) > 80 {
 		runes := []rune(metadata.Title)
@@ -462,10 +499,8 @@ func (g *GenerateMetadata) saveMetadataResults(metadata *VideoMetadata, taskCont
 		g.App.Logger.Warnf("⚠️ 标题过长，已截断为80字符")
 	}
 
-	// 2. 保存到 context
-	taskContext["video_title"] = metadata.Title
-	taskContext["video_description"] = metadata.Description
-	taskContext["video_tags"] = metadata.Tags
+	// 2. 记录元数据产物
+	g.saveMetadataArtifact(metadata)
 
 	// 3. 保存到 meta.json 文件
 	g.App.Logger.Info("💾 保存元数据到 meta.json 文件...")
@@ -1,18 +1,20 @@
 package handlers
 
 import (
+	"context"
 	"github.com/difyz9/ytb2bili/internal/chain_task/base"
 	"github.com/difyz9/ytb2bili/internal/chain_task/manager"
 	"github.com/difyz9/ytb2bili/internal/core"
 	"github.com/difyz9/ytb2bili/internal/core/services"
 	"github.com/difyz9/ytb2bili/pkg/cos"
 	"github.com/difyz9/ytb2bili/pkg/store/model"
+	"github.com/difyz9/ytb2bili/pkg/subtitle"
 	"github.com/difyz9/ytb2bili/pkg/utils"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 )
 
 type GenerateSubtitles struct {
@@ -33,38 +35,20 @@ func NewGenerateSubtitles(name string, app *core.AppServer, stateManager *manage
 	}
 }
 
-// formatTime 将秒数转换为 SRT 时间格式 (HH:MM:SS,mmm)
-func (t *GenerateSubtitles) formatTime(seconds float64) string {
-	hours := int(seconds / 3600)
-	minutes := int((seconds - float64(hours*3600)) / 60)
-	secs := int(seconds - float64(hours*3600) - float64(minutes*60))
-	milliseconds := int((seconds - float64(int(seconds))) * 1000)
-
-	return fmt.Sprintf("%02d:%02d:%02d,%03d", hours, minutes, secs, milliseconds)
-}
-
-// generateSRT 生成 SRT 格式字幕内容
-func (t *GenerateSubtitles) generateSRT(subtitles []model.SavedVideoSubtitle) string {
-	var srtContent strings.Builder
-
-	for i, subtitle := range subtitles {
-		// SRT 序号（从1开始）
-		srtContent.WriteString(fmt.Sprintf("%d\n", i+1))
-
-		// 时间轴
-		startTime := t.formatTime(subtitle.Offset)
-		endTime := t.formatTime(subtitle.Offset + subtitle.Duration)
-		srtContent.WriteString(fmt.Sprintf("%s --> %s\n", startTime, endTime))
-
-		// 字幕文本
-		srtContent.WriteString(subtitle.Text)
-		srtContent.WriteString("\n\n")
+// subtitleCues 将插件提交的字幕转换为字幕条目
+func (t *GenerateSubtitles) subtitleCues(subtitles []model.SavedVideoSubtitle) []subtitle.Cue {
+	cues := make([]subtitle.Cue, 0, len(subtitles))
+	for _, sub := range subtitles {
+		cues = append(cues, subtitle.Cue{
+			Start: subtitle.Seconds(sub.Offset),
+			End:   subtitle.Seconds(sub.Offset + sub.Duration),
+			Text:  sub.Text,
+		})
 	}
-
-	return srtContent.String()
+	return cues
 }
 
-func (t *GenerateSubtitles) Execute(context map[string]interface{}) bool {
+func (t *GenerateSubtitles) Execute(ctx context.Context) error {
 	t.App.Logger.Info("========================================")
 	t.App.Logger.Info("开始生成字幕文件")
 	t.App.Logger.Info("========================================")
@@ -73,46 +57,42 @@ func (t *GenerateSubtitles) Execute(context map[string]interface{}) bool {
 	savedVideo, err := t.SavedVideoService.GetVideoByID(t.StateManager.Id)
 	if err != nil {
 		t.App.Logger.Errorf("❌ 查询视频信息失败: %v", err)
-		context["error"] = err.Error()
-		return false
+		return err
 	}
 
 	if savedVideo == nil {
 		errMsg := "视频信息不存在"
 		t.App.Logger.Error("❌ " + errMsg)
-		context["error"] = errMsg
-		return false
+		return errors.New(errMsg)
 	}
 
 	// 2. 检查字幕数据是否存在
 	if savedVideo.Subtitles == "" || savedVideo.Subtitles == "null" {
 		t.App.Logger.Warn("⚠️  视频没有字幕数据，跳过字幕生成")
-		return true // 没有字幕不算错误，继续执行后续任务
+		return nil // 没有字幕不算错误，继续执行后续任务
 	}
 
 	// 3. 解析字幕 JSON 数据
 	var subtitles []model.SavedVideoSubtitle
 	if err := json.Unmarshal([]byte(savedVideo.Subtitles), &subtitles); err != nil {
 		t.App.Logger.Errorf("❌ 解析字幕数据失败: %v", err)
-		context["error"] = fmt.Sprintf("解析字幕数据失败: %v", err)
-		return false
+		return fmt.Errorf("解析字幕数据失败: %v", err)
 	}
 
 	if len(subtitles) == 0 {
 		t.App.Logger.Warn("⚠️  字幕数据为空，跳过字幕生成")
-		return true
+		return nil
 	}
 
 	t.App.Logger.Infof("📝 找到 %d 条字幕", len(subtitles))
 
-	// 4. 生成 SRT 内容
-	srtContent := t.generateSRT(subtitles)
+	// 4. 转换为字幕条目并重新分段
+	cues := segmentCues(t.App, t.subtitleCues(subtitles), false)
 
 	// 5. 确保输出目录存在
 	if err := os.MkdirAll(t.StateManager.CurrentDir, 0755); err != nil {
 		t.App.Logger.Errorf("❌ 创建字幕目录失败: %v", err)
-		context["error"] = err.Error()
-		return false
+		return err
 	}
 
 	// 6. 生成字幕文件路径
@@ -120,18 +100,16 @@ func (t *GenerateSubtitles) Execute(context map[string]interface{}) bool {
 	srtFilePath := filepath.Join(t.StateManager.CurrentDir, srtFileName)
 
 	// 7. 写入 SRT 文件
-	if err := os.WriteFile(srtFilePath, []byte(srtContent), 0644); err != nil {
+	if err := subtitle.WriteFile(srtFilePath, &subtitle.Subtitle{Cues: cues}); err != nil {
 		t.App.Logger.Errorf("❌ 写入字幕文件失败: %v", err)
-		context["error"] = fmt.Sprintf("写入字幕文件失败: %v", err)
-		return false
+		return fmt.Errorf("写入字幕文件失败: %v", err)
 	}
 
 	// 8. 验证文件是否创建成功
 	if _, err := os.Stat(srtFilePath); os.IsNotExist(err) {
 		errMsg := "字幕文件创建失败"
 		t.App.Logger.Error("❌ " + errMsg)
-		context["error"] = errMsg
-		return false
+		return errors.New(errMsg)
 	}
 
 	enSrtFileName := fmt.Sprintf("%s.srt", "en")
@@ -139,12 +117,13 @@ func (t *GenerateSubtitles) Execute(context map[string]interface{}) bool {
 
 	if err := utils.CopyFile(srtFilePath, enSrtFilePath); err != nil {
 		t.App.Logger.Errorf("❌ 复制英文字幕文件失败: %v", err)
-		context["error"] = fmt.Sprintf("复制英文字幕文件失败: %v", err)
+		return fmt.Errorf("复制英文字幕文件失败: %v", err)
 	}
 
-	// 9. 保存字幕文件路径到 context，供后续任务使用
-	context["subtitle_file"] = srtFilePath
-	context["subtitle_count"] = len(subtitles)
+	// 9. 记录原语言字幕产物，供后续任务使用
+	if err := t.StateManager.Artifacts.SetPath(manager.ArtifactOriginalSRT, enSrtFilePath); err != nil {
+		t.App.Logger.Warnf("⚠️  记录字幕产物失败: %v", err)
+	}
 
 	// 10. 显示字幕预览（前3条）
 	previewCount := 3
@@ -165,7 +144,7 @@ func (t *GenerateSubtitles) Execute(context map[string]interface{}) bool {
 	t.App.Logger.Infof("✓ 共生成 %d 条字幕", len(subtitles))
 	t.App.Logger.Info("========================================")
 
-	return true
+	return nil
 }
 
 // truncateString 截断字符串，避免日志过长
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
@@ -67,21 +68,21 @@ func NewTask03Handler(name string, app *core.AppServer, db *gorm.DB, stateManage
 }
 
 // Execute 执行任务
-func (t *Task03Handler) Execute(context map[string]interface{}) bool {
+func (t *Task03Handler) Execute(ctx context.Context) error {
 	videoID := t.StateManager.VideoID
 
 	// 获取字幕 URL
 	srtURL, err := t.getVideoSrtURL(videoID)
 	if err != nil {
 		fmt.Printf("获取字幕 URL 失败: %v\n", err)
-		return false
+		return fmt.Errorf("获取字幕 URL 失败: %v", err)
 	}
 
 	// 获取字幕内容
 	transcript, err := t.getSrtFile(srtURL)
 	if err != nil {
 		fmt.Printf("获取字幕内容失败: %v\n", err)
-		return false
+		return fmt.Errorf("获取字幕内容失败: %v", err)
 	}
 
 	// 保存字幕到文件
@@ -89,19 +90,16 @@ func (t *Task03Handler) Execute(context map[string]interface{}) bool {
 	data, err := json.MarshalIndent(transcript, "", "  ")
 	if err != nil {
 		fmt.Printf("序列化字幕数据失败: %v\n", err)
-		return false
+		return fmt.Errorf("序列化字幕数据失败: %v", err)
 	}
 	//print(transcriptFile)
 	if err := os.WriteFile(t.StateManager.OriginalJSON, data, 0644); err != nil {
 		fmt.Printf("保存字幕文件失败: %v\n", err)
-		return false
+		return fmt.Errorf("保存字幕文件失败: %v", err)
 	}
 
-	// 将字幕数据添加到上下文
-	context["transcript"] = transcript
-
 	fmt.Println("字幕获取成功")
-	return true
+	return nil
 }
 
 // getVideoSrtURL 获取视频字幕 URL
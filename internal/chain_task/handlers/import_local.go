@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/difyz9/ytb2bili/internal/chain_task/base"
+	"github.com/difyz9/ytb2bili/internal/chain_task/manager"
+	"github.com/difyz9/ytb2bili/internal/core"
+	"github.com/difyz9/ytb2bili/internal/core/services"
+	"github.com/difyz9/ytb2bili/internal/core/types"
+	"github.com/difyz9/ytb2bili/pkg/cos"
+	"github.com/difyz9/ytb2bili/pkg/source"
+	"github.com/difyz9/ytb2bili/pkg/utils"
+)
+
+// ImportLocal 导入本地视频文件，代替下载步骤
+// 文件链接（优先）或复制到任务目录，标题和描述取自与视频同名的 JSON 文件
+type ImportLocal struct {
+	base.BaseTask
+	App               *core.AppServer
+	SavedVideoService *services.SavedVideoService
+}
+
+func NewImportLocal(name string, app *core.AppServer, stateManager *manager.StateManager, client *cos.CosClient, savedVideoService *services.SavedVideoService) *ImportLocal {
+	return &ImportLocal{
+		BaseTask: base.BaseTask{
+			Name:         name,
+			StateManager: stateManager,
+			Client:       client,
+		},
+		App:               app,
+		SavedVideoService: savedVideoService,
+	}
+}
+
+func (t *ImportLocal) Execute(ctx context.Context) error {
+	savedVideo, err := t.SavedVideoService.GetVideoByVideoID(t.StateManager.VideoID)
+	if err != nil {
+		return fmt.Errorf("查询视频信息失败: %v", err)
+	}
+	filePath, err := source.LocalFilePath(savedVideo.URL)
+	if err != nil {
+		return types.NewStepError(types.ErrorPermanent, err)
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return types.NewStepError(types.ErrorPermanent, fmt.Errorf("本地文件不可用: %v", err))
+	}
+	t.App.Logger.Infof("📂 导入本地文件: %s", filePath)
+
+	if err := os.MkdirAll(t.StateManager.CurrentDir, 0755); err != nil {
+		return fmt.Errorf("创建任务目录失败: %v", err)
+	}
+
+	// 重试时目标文件已存在且大小相同则不再复制
+	target := filepath.Join(t.StateManager.CurrentDir, t.StateManager.VideoID+strings.ToLower(filepath.Ext(filePath)))
+	if existing, err := os.Stat(target); err != nil || existing.Size() != info.Size() {
+		os.Remove(target)
+		if err := os.Link(filePath, target); err != nil {
+			// 不在同一文件系统时无法创建硬链接，复制文件
+			if err := utils.CopyFile(filePath, target); err != nil {
+				os.Remove(target)
+				return fmt.Errorf("复制本地文件失败: %v", err)
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if err := t.StateManager.Artifacts.SetPath(manager.ArtifactSourceVideo, target); err != nil {
+		t.App.Logger.Warnf("⚠️ 记录视频文件产物失败: %v", err)
+	}
+	t.App.Logger.Infof("✓ 本地文件已导入: %s", target)
+
+	// 保存 JSON 文件中的标题和描述，供生成元数据和上传步骤使用
+	sidecar, err := source.ReadSidecar(filePath)
+	if err != nil {
+		t.App.Logger.Warnf("⚠️ 读取视频信息文件失败: %v，将使用文件名作为标题", err)
+		return nil
+	}
+	savedVideo.Title = sidecar.Title
+	savedVideo.Description = sidecar.Description
+	if err := t.SavedVideoService.UpdateVideo(savedVideo); err != nil {
+		t.App.Logger.Errorf("❌ 保存视频标题和描述失败: %v", err)
+	}
+	return nil
+}
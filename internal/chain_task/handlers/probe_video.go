@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/difyz9/ytb2bili/internal/chain_task/base"
+	"github.com/difyz9/ytb2bili/internal/chain_task/manager"
+	"github.com/difyz9/ytb2bili/internal/core"
+	"github.com/difyz9/ytb2bili/internal/core/services"
+	"github.com/difyz9/ytb2bili/internal/core/types"
+	"github.com/difyz9/ytb2bili/pkg/cos"
+	"github.com/difyz9/ytb2bili/pkg/filter"
+	"github.com/difyz9/ytb2bili/pkg/source"
+	"github.com/difyz9/ytb2bili/pkg/store/model"
+
+	"gorm.io/gorm"
+)
+
+// ProbeVideo 下载前获取视频信息并检查过滤规则
+// 使用全局过滤规则，来自订阅的视频同时使用订阅的规则；没有任何规则时不获取视频信息
+type ProbeVideo struct {
+	base.BaseTask
+	App               *core.AppServer
+	DB                *gorm.DB
+	SavedVideoService *services.SavedVideoService
+}
+
+func NewProbeVideo(name string, app *core.AppServer, stateManager *manager.StateManager, client *cos.CosClient, db *gorm.DB, savedVideoService *services.SavedVideoService) *ProbeVideo {
+	return &ProbeVideo{
+		BaseTask: base.BaseTask{
+			Name:         name,
+			StateManager: stateManager,
+			Client:       client,
+		},
+		App:               app,
+		DB:                db,
+		SavedVideoService: savedVideoService,
+	}
+}
+
+func (t *ProbeVideo) Execute(ctx context.Context) error {
+	savedVideo, err := t.SavedVideoService.GetVideoByVideoID(t.StateManager.VideoID)
+	if err != nil {
+		return fmt.Errorf("查询视频信息失败: %v", err)
+	}
+	rules, err := t.rulesFor(savedVideo)
+	if err != nil {
+		return types.NewStepError(types.ErrorPermanent, err)
+	}
+	if rules.IsEmpty() {
+		t.App.Logger.Info("未配置过滤规则，跳过检查")
+		return nil
+	}
+	if err := rules.Validate(); err != nil {
+		return types.NewStepError(types.ErrorPermanent, fmt.Errorf("过滤规则无效: %v", err))
+	}
+
+	videoURL := videoURLOf(savedVideo, t.StateManager.VideoID)
+	src, _, err := source.ForURL(videoURL)
+	if err != nil {
+		return types.NewStepError(types.ErrorPermanent, err)
+	}
+	var ytdlpPath string
+	if src.Platform() != source.PlatformLocal {
+		if ytdlpPath, err = findYtDlp(t.App); err != nil {
+			return err
+		}
+	}
+
+	t.App.Logger.Infof("🔍 获取视频信息: %s", videoURL)
+	metadata, err := fetchMetadata(ctx, t.App, ytdlpPath, videoURL)
+	if err != nil {
+		return err
+	}
+
+	info := &manager.SourceInfoArtifact{
+		Title:       metadata.Title,
+		Description: metadata.Description,
+		Uploader:    metadata.Uploader,
+		UploaderID:  metadata.UploaderID,
+		ChannelID:   metadata.ChannelID,
+		Duration:    metadata.Duration,
+		Language:    metadata.Language,
+		LiveStatus:  metadata.LiveStatus,
+	}
+	if !metadata.UploadDate.IsZero() {
+		info.UploadDate = metadata.UploadDate.Format(filter.DateLayout)
+	}
+	if err := t.StateManager.Artifacts.SetSourceInfo(info); err != nil {
+		t.App.Logger.Warnf("⚠️ 记录视频信息产物失败: %v", err)
+	}
+	if err := archiveSourceInfo(t.StateManager.CurrentDir, metadata); err != nil {
+		t.App.Logger.Warnf("⚠️ 保存视频完整信息失败: %v", err)
+	}
+	applySourceMetadata(savedVideo, metadata)
+	if err := t.SavedVideoService.UpdateVideo(savedVideo); err != nil {
+		t.App.Logger.Warnf("⚠️ 保存视频信息到数据库失败: %v", err)
+	}
+
+	reason, ok := rules.Check(filter.Video{
+		Title:      metadata.Title,
+		Duration:   metadata.Duration,
+		Uploader:   metadata.Uploader,
+		UploaderID: metadata.UploaderID,
+		ChannelID:  metadata.ChannelID,
+		Language:   metadata.Language,
+		UploadDate: metadata.UploadDate,
+		LiveStatus: metadata.LiveStatus,
+		ViewCount:  metadata.ViewCount,
+		Tags:       metadata.Tags,
+		Categories: metadata.Categories,
+	}, time.Now())
+	if !ok {
+		t.App.Logger.Infof("🚫 视频不符合过滤规则: %s", reason)
+		return types.NewStepError(types.ErrorFiltered, fmt.Errorf("不符合过滤规则: %s", reason))
+	}
+	t.App.Logger.Infof("✓ 视频符合过滤规则: %s", metadata.Title)
+	return nil
+}
+
+// rulesFor 视频使用的过滤规则，订阅的规则覆盖全局规则中的同一项
+func (t *ProbeVideo) rulesFor(video *model.SavedVideo) (*filter.Rules, error) {
+	rules := t.App.Config.FilterConfig.Merge(nil)
+	if video.SubscriptionID == nil {
+		return rules, nil
+	}
+
+	var subscription model.Subscription
+	if err := t.DB.First(&subscription, *video.SubscriptionID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			// 订阅已删除，只使用全局规则
+			return rules, nil
+		}
+		return nil, fmt.Errorf("查询订阅失败: %v", err)
+	}
+	override, err := subscription.GetFilterRules()
+	if err != nil {
+		return nil, fmt.Errorf("订阅 %d 的过滤规则无效: %v", subscription.ID, err)
+	}
+	return rules.Merge(override), nil
+}
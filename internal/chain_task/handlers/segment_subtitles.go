@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"github.com/difyz9/ytb2bili/internal/core"
+	"github.com/difyz9/ytb2bili/pkg/subtitle"
+)
+
+// segmentCues 按配置重新分段字幕，translated 为 true 时使用翻译后字幕的限制
+// 没有配置或未启用时原样返回
+func segmentCues(app *core.AppServer, cues []subtitle.Cue, translated bool) []subtitle.Cue {
+	config := app.Config.SegmentConfig
+	if config == nil || !config.Enabled || len(cues) == 0 {
+		return cues
+	}
+	opts := config.Source
+	if translated {
+		opts = config.Translated
+	}
+	result := subtitle.Resegment(cues, opts)
+	app.Logger.Infof("✂️  字幕重新分段: %d 条 -> %d 条", len(cues), len(result))
+	return result
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/difyz9/ytb2bili/internal/chain_task/base"
+	"github.com/difyz9/ytb2bili/internal/chain_task/manager"
+	"github.com/difyz9/ytb2bili/internal/core"
+	"github.com/difyz9/ytb2bili/internal/core/types"
+	"github.com/difyz9/ytb2bili/pkg/asr"
+	"github.com/difyz9/ytb2bili/pkg/cos"
+	"github.com/difyz9/ytb2bili/pkg/subtitle"
+)
+
+// TranscribeAudio 语音识别生成原语言字幕
+// 按 ASRConfig 中的顺序尝试各个语音识别服务，记录实际生成字幕的服务
+type TranscribeAudio struct {
+	base.BaseTask
+	App      *core.AppServer
+	Language string // 识别语言，为空时使用配置中的语言
+}
+
+// NewTranscribeAudio 创建语音识别任务
+func NewTranscribeAudio(name string, app *core.AppServer, stateManager *manager.StateManager, client *cos.CosClient, language string) *TranscribeAudio {
+	return &TranscribeAudio{
+		BaseTask: base.BaseTask{
+			Name:         name,
+			StateManager: stateManager,
+			Client:       client,
+		},
+		App:      app,
+		Language: language,
+	}
+}
+
+func (t *TranscribeAudio) Execute(ctx context.Context) error {
+	_, err := t.Transcribe(ctx)
+	return err
+}
+
+// Transcribe 识别音频并保存原语言字幕，返回识别结果
+func (t *TranscribeAudio) Transcribe(ctx context.Context) (*asr.Result, error) {
+	// 检查音频文件是否存在（由分离音频步骤产出）
+	audioPath, ok := t.StateManager.Artifacts.Path(manager.ArtifactAudioWAV)
+	if !ok {
+		audioPath = t.StateManager.OriginalWAV
+	}
+	if _, err := os.Stat(audioPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("音频文件不存在: %s", audioPath)
+	}
+
+	config := t.App.Config.ASRConfig
+	if config == nil {
+		config = &types.ASRConfig{Providers: []string{asr.ProviderBcut}}
+	}
+	provider, err := asr.New(config)
+	if err != nil {
+		return nil, types.NewStepError(types.ErrorPermanent, fmt.Errorf("语音识别配置无效: %v", err))
+	}
+	language := t.Language
+	if language == "" {
+		language = config.Language
+	}
+
+	// 占用语音识别槽位，限制同时进行的转录数
+	release, err := t.StateManager.Limiter.Acquire(ctx, manager.ResourceASR)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	t.App.Logger.Infof("📝 语音识别: %s，服务: %s，语言: %s", audioPath, provider.Name(), language)
+	result, err := provider.Transcribe(ctx, audioPath, asr.Options{
+		Language: language,
+		Progress: t.ReportProgress,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, skipped := range result.Skipped {
+		t.App.Logger.Warnf("⚠️ 跳过语音识别服务 %s", skipped)
+	}
+	if result.Language == "" && !asr.IsAutoLanguage(language) {
+		result.Language = language
+	}
+
+	cues := segmentCues(t.App, result.Cues(), false)
+	if err := subtitle.WriteFile(t.StateManager.OriginalSRT, &subtitle.Subtitle{Cues: cues}); err != nil {
+		return nil, fmt.Errorf("保存字幕失败: %v", err)
+	}
+	if err := t.StateManager.Artifacts.SetPath(manager.ArtifactOriginalSRT, t.StateManager.OriginalSRT); err != nil {
+		t.App.Logger.Warnf("⚠️ 记录字幕产物失败: %v", err)
+	}
+	err = t.StateManager.Artifacts.SetTranscript(&manager.TranscriptArtifact{
+		Provider: result.Provider,
+		Language: result.Language,
+		Segments: len(cues),
+		Skipped:  result.Skipped,
+	})
+	if err != nil {
+		t.App.Logger.Warnf("⚠️ 记录语音识别结果失败: %v", err)
+	}
+
+	t.App.Logger.Infof("✅ %s 识别完成，%d 条字幕，语言: %s，保存至: %s", result.Provider, len(cues), result.Language, t.StateManager.OriginalSRT)
+	return result, nil
+}
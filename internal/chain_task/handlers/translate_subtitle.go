@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,7 +12,10 @@ import (
 	"github.com/difyz9/ytb2bili/internal/chain_task/base"
 	"github.com/difyz9/ytb2bili/internal/chain_task/manager"
 	"github.com/difyz9/ytb2bili/internal/core"
+	"github.com/difyz9/ytb2bili/internal/core/types"
 	"github.com/difyz9/ytb2bili/pkg/cos"
+	"github.com/difyz9/ytb2bili/pkg/store/model"
+	"github.com/difyz9/ytb2bili/pkg/subtitle"
 	"github.com/difyz9/ytb2bili/pkg/utils"
 	"gorm.io/gorm"
 )
@@ -53,55 +58,48 @@ func (t *TranslateSubtitle) getCurrentAPIKey() (string, error) {
 	return apiKey, nil
 }
 
-// SRTEntry SRT字幕条目
-type SRTEntry struct {
-	Index    int
-	TimeCode string
-	Text     string
-}
-
-func (t *TranslateSubtitle) Execute(context map[string]interface{}) bool {
+func (t *TranslateSubtitle) Execute(ctx context.Context) error {
 	t.App.Logger.Info("========================================")
 	t.App.Logger.Infof("开始翻译字幕: VideoID=%s", t.StateManager.VideoID)
 	t.App.Logger.Info("========================================")
 
+	// 获取字幕时使用了上传者提供的目标语言字幕，不需要翻译
+	if done, err := t.useTargetLanguageSubtitles(); done || err != nil {
+		return err
+	}
+
 	// 0. 动态获取最新的API Key配置
 	currentAPIKey, err := t.getCurrentAPIKey()
 	if err != nil {
 		t.App.Logger.Errorf("❌ %v", err)
-		context["error"] = t.getTranslationError(err)
-		return false
+		return t.getTranslationError(err)
 	}
 
 	t.App.Logger.Infof("🔑 使用DeepSeek API Key: %s", maskAPIKey(currentAPIKey))
 	// 更新当前使用的API Key
 	t.APIKey = currentAPIKey
 
-	// 1. 检查英文字幕文件是否存在（由 GenerateSubtitles 任务生成）
-	enSRTPath := filepath.Join(t.StateManager.CurrentDir, fmt.Sprintf("%s.srt", t.StateManager.VideoID))
+	// 1. 检查原语言字幕文件是否存在（由字幕生成或转录任务产出）
+	enSRTPath, ok := t.StateManager.Artifacts.Path(manager.ArtifactOriginalSRT)
+	if !ok {
+		enSRTPath = t.StateManager.OriginalSRT
+	}
 	if _, err := os.Stat(enSRTPath); os.IsNotExist(err) {
 		t.App.Logger.Warn("⚠️  英文字幕文件不存在，跳过翻译")
-		return true // 没有字幕文件不算失败
+		return nil // 没有字幕文件不算失败
 	}
 
 	// 2. 读取并解析英文字幕文件
-	srtContent, err := os.ReadFile(enSRTPath)
-	if err != nil {
-		t.App.Logger.Errorf("❌ 读取英文字幕文件失败: %v", err)
-		context["error"] = "字幕文件读取失败，请确认字幕生成步骤已完成"
-		return false
-	}
-
-	srtEntries, err := t.parseSRTContent(string(srtContent))
+	original, err := subtitle.ReadFile(enSRTPath)
 	if err != nil {
 		t.App.Logger.Errorf("❌ 解析SRT文件失败: %v", err)
-		context["error"] = "字幕文件格式错误，无法解析SRT内容"
-		return false
+		return errors.New("字幕文件格式错误，无法解析SRT内容")
 	}
+	srtEntries := original.Cues
 
 	if len(srtEntries) == 0 {
 		t.App.Logger.Warn("⚠️  字幕内容为空，跳过翻译")
-		return true
+		return nil
 	}
 
 	t.App.Logger.Infof("📝 找到 %d 条字幕", len(srtEntries))
@@ -116,22 +114,30 @@ func (t *TranslateSubtitle) Execute(context map[string]interface{}) bool {
 	totalGroups := (len(texts) + t.GroupSize - 1) / t.GroupSize
 	t.App.Logger.Infof("� 开始并发翻译，每组 %d 句，共 %d 组，并发数: %d", t.GroupSize, totalGroups, t.MaxWorkers)
 
-	translatedTexts, err := t.translateTextsInGroupsConcurrent(texts)
+	translatedTexts, err := t.translateTextsInGroupsConcurrent(ctx, texts)
 	if err != nil {
 		t.App.Logger.Errorf("❌ 翻译失败: %v", err)
-		context["error"] = t.getTranslationError(err)
-		return false
+		return t.getTranslationError(err)
 	}
 
-	// 5. 生成中文字幕SRT
-	translatedSRT := t.generateTranslatedSRTContent(srtEntries, translatedTexts)
+	// 章节标题单独翻译，避免被模型当作字幕的后续内容合并或拆分，导致字幕和标题错位
+	if chapters := t.loadChapters(); len(chapters) > 0 {
+		titles, err := t.translateChapterTitles(ctx, chapters)
+		if err != nil {
+			t.App.Logger.Warnf("⚠️  翻译章节标题失败，保留原标题: %v", err)
+		} else {
+			t.saveChapterTitles(chapters, titles)
+		}
+	}
+
+	// 5. 生成中文字幕，保持原时间轴
+	translated := translatedSubtitle(srtEntries, translatedTexts)
 
 	// 6. 保存中文字幕文件
 	zhSRTPath := filepath.Join(t.StateManager.CurrentDir, "zh.srt")
-	if err := os.WriteFile(zhSRTPath, []byte(translatedSRT), 0644); err != nil {
+	if err := subtitle.WriteFile(zhSRTPath, translated); err != nil {
 		t.App.Logger.Errorf("❌ 保存中文字幕失败: %v", err)
-		context["error"] = "保存翻译字幕文件失败，请检查磁盘空间和文件权限"
-		return false
+		return errors.New("保存翻译字幕文件失败，请检查磁盘空间和文件权限")
 	}
 
 	// 7. 字幕质量校验和优化
@@ -152,96 +158,188 @@ func (t *TranslateSubtitle) Execute(context map[string]interface{}) bool {
 		}
 	}
 
-	// 8. 保存文件路径到 context
-	context["en_srt_path"] = enSRTPath
-	context["zh_srt_path"] = zhSRTPath
-	context["translated_count"] = len(translatedTexts)
+	// 8. 生成双语字幕，此时译文与原文逐条对应
+	t.writeBilingual(srtEntries, zhSRTPath)
 
-	// 添加校验结果信息
-	if validationResult != nil {
-		context["validation_result"] = map[string]interface{}{
-			"total_entries":   validationResult.TotalEntries,
-			"valid_entries":   validationResult.ValidEntries,
-			"missing_entries": validationResult.MissingEntries,
-			"fixed_entries":   len(validationResult.FixedEntries),
-		}
+	// 9. 按译文的阅读速度重新分段，需要在与原文逐条对应的校验之后进行
+	t.segmentTranslated(zhSRTPath)
+
+	// 10. 记录翻译字幕产物
+	if err := t.StateManager.Artifacts.SetPath(manager.ArtifactTranslatedSRT, zhSRTPath); err != nil {
+		t.App.Logger.Warnf("⚠️  记录翻译字幕产物失败: %v", err)
 	}
+	t.writeTranslatedVTT(zhSRTPath)
 
 	t.App.Logger.Infof("✓ 中文字幕已保存: %s", zhSRTPath)
-	t.App.Logger.Infof("✓ 翻译完成: %d/%d 条字幕", len(translatedTexts), len(texts))
+	t.App.Logger.Infof("✓ 翻译完成: %d/%d 条字幕", len(translatedTexts), len(srtEntries))
 	t.App.Logger.Info("========================================")
 
-	return true
+	return nil
 }
 
-// parseSRTContent 解析SRT文件内容
-func (t *TranslateSubtitle) parseSRTContent(content string) ([]SRTEntry, error) {
-	lines := strings.Split(content, "\n")
-	var entries []SRTEntry
-	var currentEntry SRTEntry
-	var textLines []string
-	stage := 0 // 0=等待序号, 1=等待时间码, 2=读取文本
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-
-		if line == "" {
-			// 空行表示一个条目结束
-			if stage == 2 && len(textLines) > 0 {
-				currentEntry.Text = strings.Join(textLines, "\n")
-				entries = append(entries, currentEntry)
-				textLines = nil
-				stage = 0
-			}
-			continue
-		}
-
-		switch stage {
-		case 0: // 读取序号
-			var index int
-			if _, err := fmt.Sscanf(line, "%d", &index); err == nil {
-				currentEntry = SRTEntry{Index: index}
-				stage = 1
-			}
-		case 1: // 读取时间码
-			if strings.Contains(line, "-->") {
-				currentEntry.TimeCode = line
-				stage = 2
-			}
-		case 2: // 读取文本
-			textLines = append(textLines, line)
-		}
+// useTargetLanguageSubtitles 原语言字幕已是目标语言时直接作为翻译结果，返回是否已处理
+func (t *TranslateSubtitle) useTargetLanguageSubtitles() (bool, error) {
+	var video model.SavedVideo
+	if err := t.DB.Select("subtitle_source").Where("video_id = ?", t.StateManager.VideoID).First(&video).Error; err != nil {
+		return false, nil
+	}
+	if video.SubtitleSource != model.SubtitleSourceManualTarget {
+		return false, nil
+	}
+	srcPath, ok := t.StateManager.Artifacts.Path(manager.ArtifactOriginalSRT)
+	if !ok {
+		return false, nil
 	}
 
-	// 处理最后一个条目（如果文件末尾没有空行）
-	if stage == 2 && len(textLines) > 0 {
-		currentEntry.Text = strings.Join(textLines, "\n")
-		entries = append(entries, currentEntry)
+	zhSRTPath := filepath.Join(t.StateManager.CurrentDir, "zh.srt")
+	if err := utils.CopyFile(srcPath, zhSRTPath); err != nil {
+		return true, fmt.Errorf("复制目标语言字幕失败: %v", err)
+	}
+	t.segmentTranslated(zhSRTPath)
+	if err := t.StateManager.Artifacts.SetPath(manager.ArtifactTranslatedSRT, zhSRTPath); err != nil {
+		t.App.Logger.Warnf("⚠️  记录翻译字幕产物失败: %v", err)
 	}
+	t.writeTranslatedVTT(zhSRTPath)
+	t.App.Logger.Infof("✓ 使用上传者提供的目标语言字幕，跳过翻译: %s", zhSRTPath)
+	return true, nil
+}
 
-	return entries, nil
+// loadChapters 读取视频的章节，没有章节或读取失败时返回空
+func (t *TranslateSubtitle) loadChapters() []model.Chapter {
+	var video model.SavedVideo
+	if err := t.DB.Select("chapters").Where("video_id = ?", t.StateManager.VideoID).First(&video).Error; err != nil {
+		return nil
+	}
+	return video.GetChapters()
 }
 
-// generateTranslatedSRTContent 生成翻译后的SRT内容（保持原时间轴）
-func (t *TranslateSubtitle) generateTranslatedSRTContent(entries []SRTEntry, translatedTexts []string) string {
-	var builder strings.Builder
+// translateChapterTitles 翻译章节标题，译文数量与章节数量不一致时返回错误
+func (t *TranslateSubtitle) translateChapterTitles(ctx context.Context, chapters []model.Chapter) ([]string, error) {
+	titles := make([]string, len(chapters))
+	for i, chapter := range chapters {
+		titles[i] = chapter.Title
+	}
+
+	systemPrompt := fmt.Sprintf(`你是一个专业的视频翻译专家。将给出的 %d 个视频章节标题翻译成中文。
+
+翻译要求：
+1. 逐条翻译：每个标题单独翻译，不要合并或拆分
+2. 简洁明了：章节标题需要简短，保留专有名词
+3. 数量严格：必须输出 %d 个标题，不多不少
+4. 分隔符：每个标题用"###SENTENCE_BREAK###"分隔
+
+注意：只返回翻译的中文标题，不要添加序号、解释或其他内容。`, len(titles), len(titles))
+
+	translatedText, err := t.callDeepSeekAPI(ctx, systemPrompt, strings.Join(titles, "\n###SENTENCE_BREAK###\n"))
+	if err != nil {
+		return nil, err
+	}
+	translated := strings.Split(translatedText, "###SENTENCE_BREAK###")
+	if len(translated) != len(titles) {
+		return nil, fmt.Errorf("章节标题数量不匹配: 期望 %d 个，实际 %d 个", len(titles), len(translated))
+	}
+	for i := range translated {
+		translated[i] = strings.TrimSpace(translated[i])
+	}
+	return translated, nil
+}
 
-	for i, entry := range entries {
-		builder.WriteString(fmt.Sprintf("%d\n", entry.Index))
-		builder.WriteString(fmt.Sprintf("%s\n", entry.TimeCode))
+// saveChapterTitles 保存翻译后的章节标题，titles 与 chapters 逐条对应
+func (t *TranslateSubtitle) saveChapterTitles(chapters []model.Chapter, titles []string) {
+	for i := range chapters {
+		chapters[i].TranslatedTitle = titles[i]
+	}
+	var video model.SavedVideo
+	video.SetChapters(chapters)
+	err := t.DB.Model(&model.SavedVideo{}).Where("video_id = ?", t.StateManager.VideoID).Update("chapters", video.Chapters).Error
+	if err != nil {
+		t.App.Logger.Warnf("⚠️  保存翻译后的章节标题失败: %v", err)
+		return
+	}
+	t.App.Logger.Infof("📑 已翻译 %d 个章节标题", len(chapters))
+}
 
+// translatedSubtitle 用翻译后的文本替换字幕文本，保持原时间轴，缺少翻译的条目保留原文
+func translatedSubtitle(cues []subtitle.Cue, translatedTexts []string) *subtitle.Subtitle {
+	translated := make([]subtitle.Cue, len(cues))
+	for i, cue := range cues {
 		if i < len(translatedTexts) {
-			builder.WriteString(fmt.Sprintf("%s\n\n", translatedTexts[i]))
+			cue.Text = translatedTexts[i]
+		}
+		translated[i] = cue
+	}
+	return &subtitle.Subtitle{Cues: translated}
+}
+
+// writeBilingual 按配置生成双语 SRT 和带样式的双语 ASS 并记录产物，失败时只记录警告
+func (t *TranslateSubtitle) writeBilingual(original []subtitle.Cue, zhSRTPath string) {
+	config := t.App.Config.SubtitleOutputConfig
+	if config == nil || (!config.Bilingual && !config.ASS) {
+		return
+	}
+	translated, err := subtitle.ReadFile(zhSRTPath)
+	if err != nil {
+		t.App.Logger.Warnf("⚠️  读取中文字幕失败，跳过双语字幕: %v", err)
+		return
+	}
+
+	if config.Bilingual {
+		bilingual := &subtitle.Subtitle{Cues: subtitle.Bilingual(translated.Cues, original)}
+		if err := subtitle.WriteFile(t.StateManager.BilingualSRT, bilingual); err != nil {
+			t.App.Logger.Warnf("⚠️  生成双语字幕失败: %v", err)
 		} else {
-			builder.WriteString(fmt.Sprintf("%s\n\n", entry.Text))
+			if err := t.StateManager.Artifacts.SetPath(manager.ArtifactBilingualSRT, t.StateManager.BilingualSRT); err != nil {
+				t.App.Logger.Warnf("⚠️  记录双语字幕产物失败: %v", err)
+			}
+			t.App.Logger.Infof("✓ 双语字幕已保存: %s", t.StateManager.BilingualSRT)
+		}
+	}
+
+	if config.ASS {
+		preset, err := config.GetPreset()
+		if err == nil {
+			err = subtitle.WriteFile(t.StateManager.TranslateASS, subtitle.BilingualASS(translated.Cues, original, preset))
+		}
+		if err != nil {
+			t.App.Logger.Warnf("⚠️  生成 ASS 字幕失败: %v", err)
+			return
+		}
+		if err := t.StateManager.Artifacts.SetPath(manager.ArtifactTranslatedASS, t.StateManager.TranslateASS); err != nil {
+			t.App.Logger.Warnf("⚠️  记录 ASS 字幕产物失败: %v", err)
 		}
+		t.App.Logger.Infof("✓ ASS 字幕已保存: %s", t.StateManager.TranslateASS)
 	}
+}
 
-	return builder.String()
+// segmentTranslated 按翻译后字幕的限制重新分段中文字幕，失败时保留原文件并记录警告
+func (t *TranslateSubtitle) segmentTranslated(zhSRTPath string) {
+	config := t.App.Config.SegmentConfig
+	if config == nil || !config.Enabled {
+		return
+	}
+	sub, err := subtitle.ReadFile(zhSRTPath)
+	if err == nil {
+		sub.Cues = segmentCues(t.App, sub.Cues, true)
+		err = subtitle.WriteFile(zhSRTPath, sub)
+	}
+	if err != nil {
+		t.App.Logger.Warnf("⚠️  中文字幕重新分段失败: %v", err)
+	}
+}
+
+// writeTranslatedVTT 将最终的中文字幕另存为 WebVTT，供网页播放器使用，失败时只记录警告
+func (t *TranslateSubtitle) writeTranslatedVTT(zhSRTPath string) {
+	sub, err := subtitle.ReadFile(zhSRTPath)
+	if err == nil {
+		err = subtitle.WriteFile(t.StateManager.TranslateVtt, sub)
+	}
+	if err != nil {
+		t.App.Logger.Warnf("⚠️  生成 WebVTT 字幕失败: %v", err)
+	}
 }
 
 // translateTextsInGroupsConcurrent 并发分组翻译文本
-func (t *TranslateSubtitle) translateTextsInGroupsConcurrent(texts []string) ([]string, error) {
+func (t *TranslateSubtitle) translateTextsInGroupsConcurrent(ctx context.Context, texts []string) ([]string, error) {
 	totalGroups := (len(texts) + t.GroupSize - 1) / t.GroupSize
 	results := make([][]string, totalGroups)
 
@@ -276,7 +374,7 @@ func (t *TranslateSubtitle) translateTextsInGroupsConcurrent(texts []string) ([]
 					workerID, task.groupIndex+1, totalGroups, len(task.texts))
 
 				// 使用简化的翻译方法
-				translated, err := t.translateGroupSimple(task.texts)
+				translated, err := t.translateGroupSimple(ctx, task.texts)
 
 				resultChannel <- struct {
 					groupIndex int
@@ -315,13 +413,17 @@ func (t *TranslateSubtitle) translateTextsInGroupsConcurrent(texts []string) ([]
 
 	// 处理结果
 	var lastErr error
+	finished := 0
 	for result := range resultChannel {
+		finished++
 		if result.err != nil {
 			t.App.Logger.Errorf("❌ 第 %d 组翻译失败: %v", result.groupIndex+1, result.err)
+			t.ReportProgress(int64(finished), int64(totalGroups), fmt.Sprintf("第 %d 组翻译失败: %v", result.groupIndex+1, result.err))
 			lastErr = result.err
 			continue
 		}
 		results[result.groupIndex] = result.result
+		t.ReportProgress(int64(finished), int64(totalGroups), fmt.Sprintf("已完成 %d/%d 组", finished, totalGroups))
 	}
 
 	if lastErr != nil {
@@ -338,7 +440,7 @@ func (t *TranslateSubtitle) translateTextsInGroupsConcurrent(texts []string) ([]
 }
 
 // translateGroupSimple 简化的组翻译（无上下文，更快速）
-func (t *TranslateSubtitle) translateGroupSimple(texts []string) ([]string, error) {
+func (t *TranslateSubtitle) translateGroupSimple(ctx context.Context, texts []string) ([]string, error) {
 	if len(texts) == 0 {
 		return []string{}, nil
 	}
@@ -361,7 +463,7 @@ func (t *TranslateSubtitle) translateGroupSimple(texts []string) ([]string, erro
 
 注意：只返回翻译的中文文本，不要添加序号、解释或其他内容。`, len(texts), len(texts))
 
-	translatedText, err := t.callDeepSeekAPI(systemPrompt, combinedText)
+	translatedText, err := t.callDeepSeekAPI(ctx, systemPrompt, combinedText)
 	if err != nil {
 		return nil, err
 	}
@@ -388,7 +490,7 @@ func (t *TranslateSubtitle) translateGroupSimple(texts []string) ([]string, erro
 }
 
 // translateTextsInGroups 分组翻译文本（带上下文）- 保留原方法作为备用
-func (t *TranslateSubtitle) translateTextsInGroups(texts []string) ([]string, error) {
+func (t *TranslateSubtitle) translateTextsInGroups(ctx context.Context, texts []string) ([]string, error) {
 	var translatedTexts []string
 	totalGroups := (len(texts) + t.GroupSize - 1) / t.GroupSize
 
@@ -427,9 +529,9 @@ func (t *TranslateSubtitle) translateTextsInGroups(texts []string) ([]string, er
 			groupNum, totalGroups, len(prevContext), len(currentGroup), len(nextContext))
 
 		// 带上下文翻译
-		groupTranslated, err := t.translateGroupWithContext(currentGroup, prevContext, nextContext)
+		groupTranslated, err := t.translateGroupWithContext(ctx, currentGroup, prevContext, nextContext)
 		if err != nil {
-			return nil, fmt.Errorf("翻译第 %d 组失败: %v", groupNum, err)
+			return nil, fmt.Errorf("翻译第 %d 组失败: %w", groupNum, err)
 		}
 
 		translatedTexts = append(translatedTexts, groupTranslated...)
@@ -442,7 +544,7 @@ func (t *TranslateSubtitle) translateTextsInGroups(texts []string) ([]string, er
 }
 
 // translateGroupWithContext 带上下文翻译一组文本
-func (t *TranslateSubtitle) translateGroupWithContext(texts []string, prevContext []string, nextContext []string) ([]string, error) {
+func (t *TranslateSubtitle) translateGroupWithContext(ctx context.Context, texts []string, prevContext []string, nextContext []string) ([]string, error) {
 	// 构建包含上下文的完整文本
 	var fullTexts []string
 	targetStartIndex := 0
@@ -494,7 +596,7 @@ func (t *TranslateSubtitle) translateGroupWithContext(texts []string, prevContex
 
 注意：只返回翻译的中文文本，不要添加序号、解释或其他内容。`, len(texts), contextInfo, len(texts))
 
-	translatedText, err := t.callDeepSeekAPI(systemPrompt, combinedText)
+	translatedText, err := t.callDeepSeekAPI(ctx, systemPrompt, combinedText)
 	if err != nil {
 		return nil, err
 	}
@@ -521,7 +623,7 @@ func (t *TranslateSubtitle) translateGroupWithContext(texts []string, prevContex
 }
 
 // callDeepSeekAPI 调用DeepSeek API（实时获取最新的API Key）
-func (t *TranslateSubtitle) callDeepSeekAPI(systemPrompt, userPrompt string) (string, error) {
+func (t *TranslateSubtitle) callDeepSeekAPI(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
 	// 实时从配置中获取最新的API Key
 	currentAPIKey, err := t.getCurrentAPIKey()
 	if err != nil {
@@ -531,57 +633,73 @@ func (t *TranslateSubtitle) callDeepSeekAPI(systemPrompt, userPrompt string) (st
 	// 添加调试日志，显示当前使用的API Key（用于验证热更新是否生效）
 	t.App.Logger.Debugf("🔑 当前使用API Key: %s", maskAPIKey(currentAPIKey))
 
+	// 占用大模型请求槽位，所有视频的翻译共享并发限制
+	release, err := t.StateManager.Limiter.Acquire(ctx, manager.ResourceLLM)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
 	client := NewDeepSeekClient(currentAPIKey)
-	response, err := client.ChatCompletion(systemPrompt, userPrompt)
+	response, err := client.ChatCompletion(ctx, systemPrompt, userPrompt)
 	if err != nil {
-		return "", fmt.Errorf("调用DeepSeek API失败: %v", err)
+		return "", fmt.Errorf("调用DeepSeek API失败: %w", err)
 	}
 
 	return response, nil
 }
 
 // getTranslationError 将翻译错误转换为用户友好的错误信息
-func (t *TranslateSubtitle) getTranslationError(err error) string {
+func (t *TranslateSubtitle) getTranslationError(err error) error {
 	errorStr := err.Error()
 
+	if strings.Contains(errorStr, "DeepSeek 翻译服务未启用") {
+		return types.NewStepError(types.ErrorPermanent, errors.New("翻译失败：DeepSeek 翻译服务未启用，请在设置中启用"))
+	}
+
 	if strings.Contains(errorStr, "DeepSeek API Key 未配置") {
-		return "翻译失败：DeepSeek API Key未配置，请在设置中配置API Key"
+		return types.NewStepError(types.ErrorAuth, errors.New("翻译失败：DeepSeek API Key未配置，请在设置中配置API Key"))
 	}
 
 	if strings.Contains(errorStr, "401") || strings.Contains(errorStr, "unauthorized") {
-		return "翻译失败：DeepSeek API Key无效或已过期，请检查API Key设置"
+		return types.NewStepError(types.ErrorAuth, errors.New("翻译失败：DeepSeek API Key无效或已过期，请检查API Key设置"))
 	}
 
 	if strings.Contains(errorStr, "429") || strings.Contains(errorStr, "rate limit") {
-		return "翻译失败：API调用频率过快，请稍后重试"
+		return types.NewStepError(types.ErrorTransient, errors.New("翻译失败：API调用频率过快，请稍后重试"))
 	}
 
-	if strings.Contains(errorStr, "insufficient_quota") || strings.Contains(errorStr, "quota") {
-		return "翻译失败：DeepSeek账户余额不足，请充值后重试"
+	if strings.Contains(errorStr, "insufficient_quota") || strings.Contains(errorStr, "quota") || strings.Contains(errorStr, "Insufficient Balance") {
+		return types.NewStepError(types.ErrorQuota, errors.New("翻译失败：DeepSeek账户余额不足，请充值后重试"))
 	}
 
 	if strings.Contains(errorStr, "timeout") || strings.Contains(errorStr, "deadline exceeded") {
-		return "翻译失败：网络超时，请检查网络连接后重试"
+		return types.NewStepError(types.ErrorTransient, errors.New("翻译失败：网络超时，请检查网络连接后重试"))
 	}
 
 	if strings.Contains(errorStr, "connection") {
-		return "翻译失败：网络连接异常，请检查网络状态"
+		return types.NewStepError(types.ErrorTransient, errors.New("翻译失败：网络连接异常，请检查网络状态"))
 	}
 
 	if strings.Contains(errorStr, "max_tokens") {
-		return "翻译失败：字幕内容过长，请尝试分段处理"
+		return types.NewStepError(types.ErrorPermanent, errors.New("翻译失败：字幕内容过长，请尝试分段处理"))
 	}
 
 	if strings.Contains(errorStr, "context_length_exceeded") {
-		return "翻译失败：单次翻译内容过多，系统将自动分批重试"
+		return types.NewStepError(types.ErrorPermanent, errors.New("翻译失败：单次翻译内容过多，请减小每组翻译的句数"))
 	}
 
 	if strings.Contains(errorStr, "API Key") {
-		return "翻译失败：API Key配置问题，请检查设置"
+		return types.NewStepError(types.ErrorAuth, errors.New("翻译失败：API Key配置问题，请检查设置"))
 	}
 
-	// 通用翻译错误
-	return "翻译失败：AI翻译服务暂时不可用，请稍后重试"
+	// 通用翻译错误，保留 API 客户端给出的分类
+	class := types.ErrorTransient
+	var stepErr *types.StepError
+	if errors.As(err, &stepErr) {
+		class = stepErr.Class
+	}
+	return types.NewStepError(class, errors.New("翻译失败：AI翻译服务暂时不可用，请稍后重试"))
 }
 
 // maskAPIKey 隐藏API Key的敏感信息用于日志显示
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"github.com/difyz9/ytb2bili/internal/chain_task/base"
 	"github.com/difyz9/ytb2bili/internal/chain_task/manager"
 	"github.com/difyz9/ytb2bili/internal/core"
@@ -30,25 +31,25 @@ func NewUploadM3u82CosHandler(name string, app *core.AppServer, stateManager *ma
 	}
 }
 
-func (t *UploadM3u82CosHandler) Execute(context map[string]interface{}) bool {
+func (t *UploadM3u82CosHandler) Execute(ctx context.Context) error {
 	//audio/mpegurl
 	m3U8Files, err2 := utils.ParseM3U8File(t.StateManager.M3u8FileName)
 
 	if err2 != nil {
-		return false
+		return fmt.Errorf("解析m3u8文件失败: %v", err2)
 	}
 	//video/mp2t
 	newKeyName, err := t.Client.UploadM3u8ToCOS(t.StateManager.M3u8FileName, "", "audio/mpegurl")
 	if err != nil {
 		fmt.Println("上传视频到cos失败")
-		return false
+		return fmt.Errorf("上传视频到cos失败: %v", err)
 	}
 
 	for _, filename := range m3U8Files {
 		_, err := t.Client.UploadM3u8ToCOS(filename, "", "video/mp2t")
 		if err != nil {
 			fmt.Println("上传视频到cos失败")
-			return false
+			return fmt.Errorf("上传视频到cos失败: %v", err)
 		}
 	}
 
@@ -64,5 +65,5 @@ func (t *UploadM3u82CosHandler) Execute(context map[string]interface{}) bool {
 	if err != nil {
 
 	}
-	return true
+	return nil
 }
@@ -1,13 +1,16 @@
 package handlers
 
 import (
+	"context"
 	"github.com/difyz9/ytb2bili/internal/chain_task/base"
 	"github.com/difyz9/ytb2bili/internal/chain_task/manager"
 	"github.com/difyz9/ytb2bili/internal/core"
 	"github.com/difyz9/ytb2bili/internal/core/services"
+	"github.com/difyz9/ytb2bili/internal/core/types"
 	"github.com/difyz9/ytb2bili/internal/storage"
 	"github.com/difyz9/bilibili-go-sdk/bilibili"
 	"github.com/difyz9/ytb2bili/pkg/cos"
+	"errors"
 	"os"
 	"path/filepath"
 )
@@ -30,19 +33,21 @@ func NewUploadSubtitleToBilibili(name string, app *core.AppServer, stateManager
 	}
 }
 
-func (t *UploadSubtitleToBilibili) Execute(context map[string]interface{}) bool {
+func (t *UploadSubtitleToBilibili) Execute(ctx context.Context) error {
 	t.App.Logger.Info("========================================")
 	t.App.Logger.Info("开始上传字幕到 Bilibili")
 	t.App.Logger.Info("========================================")
 
 	// 1. 检查是否有BVID（视频已上传成功）
-	bvid, exists := context["bili_bvid"].(string)
-	if !exists || bvid == "" {
+	var bvid string
+	if archive, ok := t.StateManager.Artifacts.BiliArchive(); ok {
+		bvid = archive.BVID
+	} else {
 		// 尝试从数据库获取BVID
 		savedVideo, err := t.SavedVideoService.GetVideoByVideoID(t.StateManager.VideoID)
 		if err != nil || savedVideo.BiliBVID == "" {
 			t.App.Logger.Warn("⚠️  没有找到BVID，跳过字幕上传")
-			return true // 不算失败，只是跳过
+			return nil // 不算失败，只是跳过
 		}
 		bvid = savedVideo.BiliBVID
 	}
@@ -53,22 +58,20 @@ func (t *UploadSubtitleToBilibili) Execute(context map[string]interface{}) bool
 	loginStore := storage.GetDefaultStore()
 	if !loginStore.IsValid() {
 		t.App.Logger.Error("❌ 没有有效的 Bilibili 登录信息，无法上传字幕")
-		context["error"] = "未登录 Bilibili"
-		return false
+		return types.NewStepError(types.ErrorAuth, errors.New("未登录 Bilibili"))
 	}
 
 	loginInfo, err := loginStore.Load()
 	if err != nil {
 		t.App.Logger.Errorf("❌ 加载登录信息失败: %v", err)
-		context["error"] = "加载登录信息失败"
-		return false
+		return types.NewStepError(types.ErrorAuth, errors.New("加载登录信息失败"))
 	}
 
 	// 3. 查找字幕文件
 	subtitleFiles := t.findSubtitleFiles()
 	if len(subtitleFiles) == 0 {
 		t.App.Logger.Warn("⚠️  未找到字幕文件，跳过字幕上传")
-		return true // 不算失败，只是跳过
+		return nil // 不算失败，只是跳过
 	}
 
 	// 4. 创建 Bilibili 客户端和字幕上传器
@@ -98,12 +101,10 @@ func (t *UploadSubtitleToBilibili) Execute(context map[string]interface{}) bool
 		t.App.Logger.Infof("  视频链接: https://www.bilibili.com/video/%s", bvid)
 		t.App.Logger.Info("========================================")
 
-		context["subtitle_upload_count"] = uploadedCount
-		return true
+		return nil
 	} else {
 		t.App.Logger.Error("❌ 没有成功上传任何字幕文件")
-		context["error"] = "字幕上传失败"
-		return false
+		return errors.New("字幕上传失败")
 	}
 }
 
@@ -113,17 +114,19 @@ type SubtitleFileInfo struct {
 	Language string
 }
 
-// findSubtitleFiles 查找字幕文件
+// findSubtitleFiles 查找字幕文件，优先使用翻译和字幕步骤记录的产物
+// 中文字幕按配置上传译文或双语字幕，没有双语字幕时上传译文
 func (t *UploadSubtitleToBilibili) findSubtitleFiles() []SubtitleFileInfo {
 	var subtitleFiles []SubtitleFileInfo
 
 	// 检查常见的字幕文件
 	subtitleFilesToCheck := []struct {
+		kind     manager.ArtifactKind
 		filename string
 		language string
 	}{
-		{"zh_optimized.srt", "zh-Hans"}, // 中文简体
-		{"en.srt", "en"},                // 英文
+		{t.chineseSubtitleKind(), "zh_optimized.srt", "zh-Hans"}, // 中文简体
+		{manager.ArtifactOriginalSRT, "en.srt", "en"},            // 英文
 		//{"zh-cn.srt", "zh-Hans"}, // 中文简体
 		//{"zh-tw.srt", "zh-Hant"}, // 中文繁体
 		//{"ja.srt", "ja"},         // 日文
@@ -131,15 +134,37 @@ func (t *UploadSubtitleToBilibili) findSubtitleFiles() []SubtitleFileInfo {
 	}
 
 	for _, item := range subtitleFilesToCheck {
-		fullPath := filepath.Join(t.StateManager.CurrentDir, item.filename)
+		fullPath, ok := t.StateManager.Artifacts.Path(item.kind)
+		if !ok {
+			fullPath = filepath.Join(t.StateManager.CurrentDir, item.filename)
+		}
 		if _, err := os.Stat(fullPath); err == nil {
 			subtitleFiles = append(subtitleFiles, SubtitleFileInfo{
 				Path:     fullPath,
 				Language: item.language,
 			})
-			t.App.Logger.Infof("🎯 找到字幕文件: %s (%s)", item.filename, item.language)
+			t.App.Logger.Infof("🎯 找到字幕文件: %s (%s)", filepath.Base(fullPath), item.language)
 		}
 	}
 
 	return subtitleFiles
 }
+
+// chineseSubtitleKind 选择上传的中文字幕产物：译文或双语字幕
+func (t *UploadSubtitleToBilibili) chineseSubtitleKind() manager.ArtifactKind {
+	switch upload := t.App.Config.SubtitleOutputConfig.GetUpload(); upload {
+	case types.SubtitleUploadTranslated:
+		return manager.ArtifactTranslatedSRT
+	case types.SubtitleUploadBilingual:
+		if path, ok := t.StateManager.Artifacts.Path(manager.ArtifactBilingualSRT); ok {
+			if _, err := os.Stat(path); err == nil {
+				return manager.ArtifactBilingualSRT
+			}
+		}
+		t.App.Logger.Warn("⚠️  没有双语字幕，上传译文字幕")
+		return manager.ArtifactTranslatedSRT
+	default:
+		t.App.Logger.Warnf("⚠️  未知的字幕上传选项 %s，上传译文字幕", upload)
+		return manager.ArtifactTranslatedSRT
+	}
+}
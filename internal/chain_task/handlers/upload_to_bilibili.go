@@ -1,12 +1,13 @@
 package handlers
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/difyz9/bilibili-go-sdk/bilibili"
@@ -14,8 +15,11 @@ import (
 	"github.com/difyz9/ytb2bili/internal/chain_task/manager"
 	"github.com/difyz9/ytb2bili/internal/core"
 	"github.com/difyz9/ytb2bili/internal/core/services"
+	"github.com/difyz9/ytb2bili/internal/core/types"
 	"github.com/difyz9/ytb2bili/internal/storage"
 	"github.com/difyz9/ytb2bili/pkg/cos"
+	"github.com/difyz9/ytb2bili/pkg/source"
+	"github.com/difyz9/ytb2bili/pkg/store/model"
 	"github.com/difyz9/ytb2bili/pkg/utils"
 )
 
@@ -24,8 +28,8 @@ import (
 // 参考分区表
 // https://github.com/biliup/biliup/wiki
 
-// fetchAndSaveMetadata 尝试从 YouTube 获取元数据并保存到数据库
-func (t *UploadToBilibili) fetchAndSaveMetadata(videoID string) error {
+// fetchAndSaveMetadata 尝试从视频来源获取元数据并保存到数据库
+func (t *UploadToBilibili) fetchAndSaveMetadata(ctx context.Context, videoID string) error {
 	t.App.Logger.Infof("🔄 尝试补充获取视频元数据: %s", videoID)
 
 	// 1. 找到 yt-dlp
@@ -39,13 +43,16 @@ func (t *UploadToBilibili) fetchAndSaveMetadata(videoID string) error {
 	}
 	ytdlpPath := manager.GetBinaryPath()
 
-	// 2. 构建命令
-	videoURL := fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID)
-	command := []string{
-		ytdlpPath,
-		"--dump-json",
-		"--no-download",
-		videoURL,
+	savedVideo, err := t.SavedVideoService.GetVideoByVideoID(videoID)
+	if err != nil {
+		return fmt.Errorf("获取视频记录失败: %v", err)
+	}
+
+	// 2. 根据视频来源获取元数据
+	videoURL := videoURLOf(savedVideo, videoID)
+	src, _, err := source.ForURL(videoURL)
+	if err != nil {
+		return err
 	}
 
 	// 添加 cookies 支持
@@ -55,38 +62,20 @@ func (t *UploadToBilibili) fetchAndSaveMetadata(videoID string) error {
 	if _, err := os.Stat(cookiesPath); err != nil {
 		cookiesPath = "cookies.txt"
 	}
+	cookiesFile := ""
 	if _, err := os.Stat(cookiesPath); err == nil {
-		absPath, _ := filepath.Abs(cookiesPath)
-		command = append(command, "--cookies", absPath)
-	}
-
-	// 添加代理
-	if t.App.Config != nil && t.App.Config.ProxyConfig != nil && t.App.Config.ProxyConfig.UseProxy && t.App.Config.ProxyConfig.ProxyHost != "" {
-		command = append(command, "--proxy", t.App.Config.ProxyConfig.ProxyHost)
-	}
-
-	// 3. 执行命令
-	cmd := exec.Command(command[0], command[1:]...)
-	output, err := cmd.Output()
-	if err != nil {
-		return fmt.Errorf("执行 yt-dlp 失败: %v", err)
-	}
-
-	// 4. 解析 JSON
-	var metadata VideoMetadataInfo
-	if err := json.Unmarshal(output, &metadata); err != nil {
-		return fmt.Errorf("解析元数据失败: %v", err)
+		cookiesFile, _ = filepath.Abs(cookiesPath)
 	}
 
-	// 5. 更新数据库
-	savedVideo, err := t.SavedVideoService.GetVideoByVideoID(videoID)
+	metadata, err := src.FetchMetadata(ctx, sourceOptions(t.App, ytdlpPath, cookiesFile, true), videoURL)
 	if err != nil {
-		return fmt.Errorf("获取视频记录失败: %v", err)
+		return err
 	}
 
+	// 3. 更新数据库
 	savedVideo.Title = metadata.Title
 	savedVideo.Description = metadata.Description
-	// 如果需要，也可以更新其他字段
+	applySourceMetadata(savedVideo, metadata)
 
 	if err := t.SavedVideoService.UpdateVideo(savedVideo); err != nil {
 		return fmt.Errorf("更新数据库失败: %v", err)
@@ -115,7 +104,7 @@ func NewUploadToBilibili(name string, app *core.AppServer, stateManager *manager
 	}
 }
 
-func (t *UploadToBilibili) Execute(context map[string]interface{}) bool {
+func (t *UploadToBilibili) Execute(ctx context.Context) error {
 	t.App.Logger.Info("========================================")
 	t.App.Logger.Info("开始上传视频到 Bilibili")
 	t.App.Logger.Info("========================================")
@@ -130,15 +119,13 @@ func (t *UploadToBilibili) Execute(context map[string]interface{}) bool {
 
 	if !loginStore.IsValid() {
 		t.App.Logger.Error("❌ 没有有效的 Bilibili 登录信息，请先扫码登录")
-		context["error"] = "未登录 Bilibili"
-		return false
+		return types.NewStepError(types.ErrorAuth, errors.New("未登录 Bilibili"))
 	}
 
 	loginInfo, err := loginStore.Load()
 	if err != nil {
 		t.App.Logger.Errorf("❌ 加载登录信息失败: %v", err)
-		context["error"] = fmt.Sprintf("加载登录信息失败: %v", err)
-		return false
+		return types.NewStepError(types.ErrorAuth, fmt.Errorf("加载登录信息失败: %v", err))
 	}
 
 	t.App.Logger.Infof("✓ 已加载登录信息，用户 MID: %d", loginInfo.TokenInfo.Mid)
@@ -147,7 +134,7 @@ func (t *UploadToBilibili) Execute(context map[string]interface{}) bool {
 	savedVideo, err := t.SavedVideoService.GetVideoByVideoID(t.StateManager.VideoID)
 	if err == nil && savedVideo != nil && savedVideo.Title == "" {
 		t.App.Logger.Info("ℹ️ 视频标题为空，尝试补充获取元数据...")
-		if err := t.fetchAndSaveMetadata(t.StateManager.VideoID); err != nil {
+		if err := t.fetchAndSaveMetadata(ctx, t.StateManager.VideoID); err != nil {
 			t.App.Logger.Warnf("⚠️ 补充获取元数据失败: %v", err)
 		} else {
 			// 重新获取最新的视频信息
@@ -157,18 +144,24 @@ func (t *UploadToBilibili) Execute(context map[string]interface{}) bool {
 		t.App.Logger.Warnf("⚠️ 无法从数据库获取视频信息: %v", err)
 	}
 
-	// 3. 查找下载的视频文件
-	videoFiles := t.findVideoFiles()
-	if len(videoFiles) == 0 {
-		errMsg := "未找到视频文件"
-		t.App.Logger.Error("❌ " + errMsg)
-		context["error"] = errMsg
-		return false
+	// 3. 查找下载的视频文件（优先使用下载步骤记录的产物）
+	videoPath, ok := t.StateManager.Artifacts.Path(manager.ArtifactSourceVideo)
+	if !ok {
+		videoFiles := t.findVideoFiles()
+		if len(videoFiles) == 0 {
+			errMsg := "未找到视频文件"
+			t.App.Logger.Error("❌ " + errMsg)
+			return errors.New(errMsg)
+		}
+		videoPath = videoFiles[0] // 使用第一个视频文件
 	}
-
-	videoPath := videoFiles[0] // 使用第一个视频文件
 	t.App.Logger.Infof("📹 找到视频文件: %s", filepath.Base(videoPath))
 
+	// 上传过程无法中断，开始上传前检查任务是否已取消
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	// 4. 创建上传客户端
 	uploadClient := bilibili.NewUploadClient(loginInfo)
 
@@ -176,10 +169,8 @@ func (t *UploadToBilibili) Execute(context map[string]interface{}) bool {
 	t.App.Logger.Info("⏫ 开始上传视频到 Bilibili...")
 	video, err := uploadClient.UploadVideo(videoPath)
 	if err != nil {
-		userFriendlyError := t.getUserFriendlyError(err, "上传视频")
 		t.App.Logger.Errorf("❌ 上传视频失败: %v", err)
-		context["error"] = userFriendlyError
-		return false
+		return t.getUserFriendlyError(err, "上传视频")
 	}
 
 	t.App.Logger.Infof("✓ 视频上传成功！")
@@ -188,7 +179,8 @@ func (t *UploadToBilibili) Execute(context map[string]interface{}) bool {
 
 	// 6. 上传封面 (如果有)
 	coverURL := ""
-	if coverImagePath, ok := context["cover_image_path"].(string); ok && coverImagePath != "" {
+	coverImagePath, hasCover := t.StateManager.Artifacts.Path(manager.ArtifactCover)
+	if hasCover {
 		t.App.Logger.Infof("📸 找到封面图片: %s", filepath.Base(coverImagePath))
 		t.App.Logger.Info("⏫ 开始上传封面...")
 		
@@ -203,7 +195,7 @@ func (t *UploadToBilibili) Execute(context map[string]interface{}) bool {
 	}
 
 	// 7. 准备投稿信息 (组装 Studio)
-	studio := t.buildStudioInfo(video, coverURL, context)
+	studio := t.buildStudioInfo(video, coverURL, coverImagePath)
 
 	// 8. 提交视频到 Bilibili
 	t.App.Logger.Info("📝 提交视频投稿信息...")
@@ -212,27 +204,20 @@ func (t *UploadToBilibili) Execute(context map[string]interface{}) bool {
 	
 	result, err := uploadClient.SubmitVideo(studio)
 	if err != nil {
-		userFriendlyError := t.getUserFriendlyError(err, "提交视频")
 		t.App.Logger.Errorf("❌ 提交视频失败: %v", err)
-		context["error"] = userFriendlyError
-		return false
+		return t.getUserFriendlyError(err, "提交视频")
 	}
 
 	// 9. 检查提交结果
 	if result.Code != 0 {
 		errMsg := fmt.Sprintf("提交失败: code=%d, message=%s", result.Code, result.Message)
 		t.App.Logger.Error("❌ " + errMsg)
-		context["error"] = errMsg
-		return false
+		return errors.New(errMsg)
 	}
 
-
-	// 9. 保存上传结果到数据库
-	context["bili_video"] = video
-	context["bili_result"] = result
-
-	// 10. 保存结果信息到数据库和context
+	// 10. 保存结果信息到数据库和产物仓库
 	t.App.Logger.Info("💾 保存上传结果到数据库...")
+	archive := &manager.BiliArchiveArtifact{}
 	savedVideo, err = t.SavedVideoService.GetVideoByVideoID(t.StateManager.VideoID)
 	if err != nil {
 		t.App.Logger.Errorf("❌ 获取视频记录失败: %v", err)
@@ -243,28 +228,32 @@ func (t *UploadToBilibili) Execute(context map[string]interface{}) bool {
 				if bvid, exists := dataMap["bvid"]; exists {
 					if bvidStr, ok := bvid.(string); ok {
 						savedVideo.BiliBVID = bvidStr
-						// 保存BVID到context供后续字幕上传使用
-						context["bili_bvid"] = bvidStr
+						archive.BVID = bvidStr
 						t.App.Logger.Infof("📺 BVID: %s", bvidStr)
 					}
 				}
 				if aid, exists := dataMap["aid"]; exists {
 					if aidFloat, ok := aid.(float64); ok {
 						savedVideo.BiliAID = int64(aidFloat)
-						// 保存AID到context
-						context["bili_aid"] = int64(aidFloat)
+						archive.AID = int64(aidFloat)
 						t.App.Logger.Infof("🆔 AID: %d", int64(aidFloat))
 					}
 				}
 			}
 		}
 
-		// 更新视频状态为 300 (已上传)
-		savedVideo.Status = "300"
+		// 视频状态由上传调度器根据处理流程更新
 		if err := t.SavedVideoService.UpdateVideo(savedVideo); err != nil {
 			t.App.Logger.Errorf("❌ 保存上传结果到数据库失败: %v", err)
 		} else {
-			t.App.Logger.Info("✅ 上传结果已保存到数据库，状态已更新为 300")
+			t.App.Logger.Info("✅ 上传结果已保存到数据库")
+		}
+	}
+
+	// 记录稿件信息，供后续字幕上传使用
+	if archive.BVID != "" {
+		if err := t.StateManager.Artifacts.SetBiliArchive(archive); err != nil {
+			t.App.Logger.Warnf("⚠️ 记录稿件产物失败: %v", err)
 		}
 	}
 
@@ -277,7 +266,7 @@ func (t *UploadToBilibili) Execute(context map[string]interface{}) bool {
 	}
 	t.App.Logger.Info("========================================")
 
-	return true
+	return nil
 }
 
 // findVideoFiles 查找下载目录中的视频文件
@@ -309,18 +298,73 @@ func (t *UploadToBilibili) findVideoFiles() []string {
 	return videoFiles
 }
 
+// sourceTemplateReplacer 替换标题和描述模板中的原视频信息变量
+// 支持 {uploader}、{channel_id}、{upload_date}、{duration}、{view_count}、{tags}、{categories}、
+// {chapters}、{language}、{thumbnail_url}，未知的信息替换为空
+func sourceTemplateReplacer(video *model.SavedVideo) *strings.Replacer {
+	duration := ""
+	if video.Duration > 0 {
+		duration = utils.FormatClock(float64(video.Duration))
+	}
+	viewCount := ""
+	if video.ViewCount > 0 {
+		viewCount = strconv.FormatInt(video.ViewCount, 10)
+	}
+	var chapters []string
+	for _, chapter := range video.GetChapters() {
+		chapters = append(chapters, utils.FormatClock(chapter.StartTime)+" "+chapter.DisplayTitle())
+	}
+	return strings.NewReplacer(
+		"{uploader}", video.Uploader,
+		"{channel_id}", video.ChannelID,
+		"{upload_date}", video.UploadDate,
+		"{duration}", duration,
+		"{view_count}", viewCount,
+		"{tags}", strings.Join(video.GetSourceTags(), ","),
+		"{categories}", strings.Join(video.GetCategories(), ","),
+		"{chapters}", strings.Join(chapters, "\n"),
+		"{language}", video.Language,
+		"{thumbnail_url}", video.ThumbnailURL,
+	)
+}
+
+// maxChapterOutlineLength 简介中章节目录的最大长度（字符数）
+const maxChapterOutlineLength = 600
+
+// chapterOutline 生成简介中的章节目录，每行一个带时间的章节，有翻译时使用翻译后的标题
+// 超过长度限制时省略之后的章节，没有章节时返回空字符串
+func chapterOutline(chapters []model.Chapter) string {
+	if len(chapters) == 0 {
+		return ""
+	}
+	outline := "\n\n📑 章节："
+	for _, chapter := range chapters {
+		line := "\n" + utils.FormatClock(chapter.StartTime) + " " + chapter.DisplayTitle()
+		if len([]rune(outline))+len([]rune(line)) > maxChapterOutlineLength {
+			break
+		}
+		outline += line
+	}
+	return outline
+}
+
 // buildStudioInfo 构建投稿信息
-func (t *UploadToBilibili) buildStudioInfo(video *bilibili.Video, coverURL string, context map[string]interface{}) *bilibili.Studio {
+func (t *UploadToBilibili) buildStudioInfo(video *bilibili.Video, coverURL, coverImagePath string) *bilibili.Studio {
 	// 默认值
 	title := t.StateManager.VideoID
 	desc := "自动上传的视频"
 	tags := "视频"
+	var overrides model.UploadOverrides
 
 	// 从数据库查询视频的标题和描述信息
 	savedVideo, err := t.SavedVideoService.GetVideoByVideoID(t.StateManager.VideoID)
 	if err != nil {
 		t.App.Logger.Warnf("⚠️ 无法从数据库获取视频信息: %v，将使用默认值", err)
 	} else {
+		// 提交视频时指定的投稿设置
+		if overrides, err = savedVideo.GetUploadOverrides(); err != nil {
+			t.App.Logger.Warnf("⚠️ 解析投稿设置失败: %v，将使用默认设置", err)
+		}
 		// 此处不再重复调用 fetchAndSaveMetadata，已在 Execute 中处理
 
 		// 清理标题中的标签（#hashtag）
@@ -345,6 +389,7 @@ func (t *UploadToBilibili) buildStudioInfo(video *bilibili.Video, coverURL strin
 			cleanedOriginalTitle := cleanTitle(savedVideo.Title)
 			title = strings.ReplaceAll(title, "{original_title}", cleanedOriginalTitle)
 			title = strings.ReplaceAll(title, "{ai_title}", savedVideo.GeneratedTitle)
+			title = sourceTemplateReplacer(savedVideo).Replace(title)
 			t.App.Logger.Infof("✓ 使用自定义标题模板: %s", title)
 		} else if biliConfig != nil && !biliConfig.UseOriginalTitle {
 			// 配置为使用AI生成标题
@@ -366,6 +411,11 @@ func (t *UploadToBilibili) buildStudioInfo(video *bilibili.Video, coverURL strin
 			}
 		}
 
+		if overrides.Title != "" {
+			title = overrides.Title
+			t.App.Logger.Infof("✓ 使用提交时指定的标题: %s", title)
+		}
+
 		// B站标题长度限制（80个字符）
 		const maxTitleLength = 80
 		titleRunes := []rune(title)
@@ -401,6 +451,7 @@ func (t *UploadToBilibili) buildStudioInfo(video *bilibili.Video, coverURL strin
 			desc = biliConfig.CustomDescTemplate
 			desc = strings.ReplaceAll(desc, "{original_desc}", savedVideo.Description)
 			desc = strings.ReplaceAll(desc, "{ai_desc}", savedVideo.GeneratedDesc)
+			desc = sourceTemplateReplacer(savedVideo).Replace(desc)
 			t.App.Logger.Infof("✓ 使用自定义描述模板")
 		} else if biliConfig != nil && biliConfig.UseOriginalDesc {
 			// 配置为使用原始描述
@@ -447,11 +498,20 @@ func (t *UploadToBilibili) buildStudioInfo(video *bilibili.Video, coverURL strin
 			}
 		}
 
+		if overrides.Description != "" {
+			desc = overrides.Description
+			t.App.Logger.Info("✓ 使用提交时指定的描述")
+		}
+
 		// 使用AI生成的标签
 		if savedVideo.GeneratedTags != "" {
 			tags = savedVideo.GeneratedTags
 			t.App.Logger.Infof("✓ 使用数据库中AI生成的标签: %s", tags)
 		}
+		if overrides.Tags != "" {
+			tags = overrides.Tags
+			t.App.Logger.Infof("✓ 使用提交时指定的标签: %s", tags)
+		}
 
 		// B站简介字数限制（2000字）
 		const maxDescLength = 2000
@@ -462,6 +522,16 @@ func (t *UploadToBilibili) buildStudioInfo(video *bilibili.Video, coverURL strin
 			linkSuffix = fmt.Sprintf("\n\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n📺 原视频链接：%s\n🔄 本视频为转载内容，仅供学习交流使用", savedVideo.URL)
 		}
 
+		// 章节目录放在链接之前，和链接一样不会被截断；自定义模板中已使用 {chapters} 时不重复添加
+		chapterConfig := t.App.Config.ChapterConfig
+		if chapterConfig != nil && chapterConfig.AddToDescription && overrides.Description == "" &&
+			(biliConfig == nil || !strings.Contains(biliConfig.CustomDescTemplate, "{chapters}")) {
+			if outline := chapterOutline(savedVideo.GetChapters()); outline != "" {
+				linkSuffix = outline + linkSuffix
+				t.App.Logger.Info("✓ 已添加章节目录到描述")
+			}
+		}
+
 		// 计算链接后缀的长度（字符数）
 		linkSuffixLength := len([]rune(linkSuffix))
 		t.App.Logger.Infof("🔗 原视频链接后缀长度: %d 字符", linkSuffixLength)
@@ -508,7 +578,7 @@ func (t *UploadToBilibili) buildStudioInfo(video *bilibili.Video, coverURL strin
 	// 封面上传已移至 Execute 方法处理，此处仅接收 coverURL
 	if coverURL != "" {
 		t.App.Logger.Infof("🖼️ 使用封面URL: %s", coverURL)
-	} else if context["cover_image_path"] != nil {
+	} else if coverImagePath != "" {
 		t.App.Logger.Warn("⚠️ 有封面图片路径但未上传成功，视频可能使用默认截屏封面")
 	}
 
@@ -557,6 +627,20 @@ func (t *UploadToBilibili) buildStudioInfo(video *bilibili.Video, coverURL strin
 		upCloseReward = t.App.Config.BilibiliConfig.UpCloseReward
 	}
 
+	// 提交视频时指定的投稿设置优先
+	if overrides.Copyright > 0 {
+		copyright = overrides.Copyright
+	}
+	if overrides.Source != "" {
+		source = overrides.Source
+	}
+	if overrides.Tid > 0 {
+		tid = overrides.Tid
+	}
+	if overrides.Dynamic != "" {
+		dynamic = overrides.Dynamic
+	}
+
 	// 如果是转载且没有提供来源，使用视频URL作为来源
 	if copyright == 2 && source == "" {
 		if savedVideo != nil {
@@ -634,66 +718,66 @@ func (t *UploadToBilibili) truncateTitle(title string, maxLen int) string {
 	return string(runes[:maxLen-3]) + "..."
 }
 
-// getUserFriendlyError 将技术错误转换为用户友好的错误信息
-func (t *UploadToBilibili) getUserFriendlyError(err error, operation string) string {
+// getUserFriendlyError 将技术错误转换为用户友好的错误信息，并按是否可重试分类
+func (t *UploadToBilibili) getUserFriendlyError(err error, operation string) error {
 	errorStr := err.Error()
 
 	// 网络相关错误
 	if strings.Contains(errorStr, "broken pipe") || strings.Contains(errorStr, "connection reset") {
-		return fmt.Sprintf("%s失败：网络连接中断，请检查网络状态后重试", operation)
+		return types.NewStepError(types.ErrorTransient, fmt.Errorf("%s失败：网络连接中断，请检查网络状态后重试", operation))
 	}
 
 	if strings.Contains(errorStr, "timeout") || strings.Contains(errorStr, "deadline exceeded") {
-		return fmt.Sprintf("%s失败：网络超时，请稍后重试", operation)
+		return types.NewStepError(types.ErrorTransient, fmt.Errorf("%s失败：网络超时，请稍后重试", operation))
 	}
 
 	if strings.Contains(errorStr, "connection refused") {
-		return fmt.Sprintf("%s失败：无法连接到B站服务器，请检查网络连接", operation)
+		return types.NewStepError(types.ErrorTransient, fmt.Errorf("%s失败：无法连接到B站服务器，请检查网络连接", operation))
 	}
 
 	if strings.Contains(errorStr, "no such host") || strings.Contains(errorStr, "dns") {
-		return fmt.Sprintf("%s失败：网络域名解析失败，请检查网络设置", operation)
+		return types.NewStepError(types.ErrorTransient, fmt.Errorf("%s失败：网络域名解析失败，请检查网络设置", operation))
 	}
 
 	// 文件相关错误
 	if strings.Contains(errorStr, "no such file") || strings.Contains(errorStr, "file not found") {
-		return fmt.Sprintf("%s失败：找不到视频文件，请确认文件已正确下载", operation)
+		return types.NewStepError(types.ErrorPermanent, fmt.Errorf("%s失败：找不到视频文件，请确认文件已正确下载", operation))
 	}
 
 	if strings.Contains(errorStr, "permission denied") {
-		return fmt.Sprintf("%s失败：文件访问权限不足", operation)
+		return types.NewStepError(types.ErrorPermanent, fmt.Errorf("%s失败：文件访问权限不足", operation))
 	}
 
 	if strings.Contains(errorStr, "file too large") {
-		return fmt.Sprintf("%s失败：文件过大，超出B站上传限制", operation)
+		return types.NewStepError(types.ErrorPermanent, fmt.Errorf("%s失败：文件过大，超出B站上传限制", operation))
 	}
 
 	// B站API相关错误
 	if strings.Contains(errorStr, "401") || strings.Contains(errorStr, "unauthorized") {
-		return fmt.Sprintf("%s失败：登录状态已过期，请重新登录", operation)
+		return types.NewStepError(types.ErrorAuth, fmt.Errorf("%s失败：登录状态已过期，请重新登录", operation))
 	}
 
 	if strings.Contains(errorStr, "403") || strings.Contains(errorStr, "forbidden") {
-		return fmt.Sprintf("%s失败：账号权限不足或被限制", operation)
+		return types.NewStepError(types.ErrorAuth, fmt.Errorf("%s失败：账号权限不足或被限制", operation))
 	}
 
 	if strings.Contains(errorStr, "429") || strings.Contains(errorStr, "rate limit") {
-		return fmt.Sprintf("%s失败：操作频率过快，请稍后再试", operation)
+		return types.NewStepError(types.ErrorTransient, fmt.Errorf("%s失败：操作频率过快，请稍后再试", operation))
 	}
 
 	if strings.Contains(errorStr, "500") || strings.Contains(errorStr, "internal server error") {
-		return fmt.Sprintf("%s失败：B站服务器临时异常，请稍后重试", operation)
+		return types.NewStepError(types.ErrorTransient, fmt.Errorf("%s失败：B站服务器临时异常，请稍后重试", operation))
 	}
 
 	if strings.Contains(errorStr, "upload chunks") {
-		return fmt.Sprintf("%s失败：视频分片上传中断，可能是网络不稳定导致，请重试", operation)
+		return types.NewStepError(types.ErrorTransient, fmt.Errorf("%s失败：视频分片上传中断，可能是网络不稳定导致，请重试", operation))
 	}
 
 	// 通用错误处理
 	if strings.Contains(errorStr, "failed to") {
-		return fmt.Sprintf("%s失败：操作执行失败，请稍后重试", operation)
+		return types.NewStepError(types.ErrorTransient, fmt.Errorf("%s失败：操作执行失败，请稍后重试", operation))
 	}
 
 	// 如果是未知错误，返回简化的错误信息
-	return fmt.Sprintf("%s失败：发生未知错误，请重试或联系技术支持", operation)
+	return types.NewStepError(types.ErrorPermanent, fmt.Errorf("%s失败：发生未知错误，请重试或联系技术支持", operation))
 }
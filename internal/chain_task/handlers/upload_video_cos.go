@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"gorm.io/gorm"
 	"github.com/difyz9/ytb2bili/internal/chain_task/base"
@@ -30,8 +31,8 @@ func NewUploadVideo2CosHandler(name string, app *core.AppServer, stateManager *m
 	}
 }
 
-func (t *UploadVideo2CosHandler) ProcessThumbnail() {
-	err := utils.ExtractThumbnail(t.StateManager.InputVideoPath, t.StateManager.ImageCover)
+func (t *UploadVideo2CosHandler) ProcessThumbnail(ctx context.Context) {
+	err := utils.ExtractThumbnail(ctx, t.StateManager.InputVideoPath, t.StateManager.ImageCover)
 	if err != nil {
 		fmt.Println("提取视频封面失败")
 		//return false
@@ -53,16 +54,16 @@ func (t *UploadVideo2CosHandler) ProcessThumbnail() {
 	}
 }
 
-func (t *UploadVideo2CosHandler) Execute(context map[string]interface{}) bool {
+func (t *UploadVideo2CosHandler) Execute(ctx context.Context) error {
 
 	fmt.Println("视频转码并上传腾讯cos")
-	t.ProcessThumbnail()
+	t.ProcessThumbnail(ctx)
 
 	fmt.Println(t.StateManager.InputVideoPath)
 	newKeyName, err := t.Client.UploadVideoToCOS(t.StateManager.InputVideoPath, "")
 	if err != nil {
 		fmt.Println("上传视频到cos失败")
-		return false
+		return fmt.Errorf("上传视频到cos失败: %v", err)
 	}
 
 	tbVideo := &models.TbVideo{
@@ -76,5 +77,5 @@ func (t *UploadVideo2CosHandler) Execute(context map[string]interface{}) bool {
 
 	}
 
-	return true
+	return nil
 }
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/difyz9/ytb2bili/internal/core"
+	"github.com/difyz9/ytb2bili/pkg/filter"
+	"github.com/difyz9/ytb2bili/pkg/source"
+	"github.com/difyz9/ytb2bili/pkg/store/model"
+)
+
+// videoURLOf 获取视频的链接，优先根据规范ID构建，无法构建时（如直链文件）使用保存的原始链接
+// 没有视频记录时按视频ID的格式推断平台
+func videoURLOf(video *model.SavedVideo, videoID string) string {
+	if video != nil {
+		if id, err := source.ParseID(video.CanonicalID); err == nil {
+			return source.VideoURL(id, video.URL)
+		}
+		if video.URL != "" {
+			return video.URL
+		}
+	}
+
+	platform := source.PlatformYouTube
+	if strings.HasPrefix(videoID, "BV") {
+		platform = source.PlatformBilibili
+	}
+	return source.VideoURL(source.ID{Platform: platform, VideoID: videoID}, "")
+}
+
+// sourceOptions 访问视频来源使用的 yt-dlp、cookies 和代理设置
+func sourceOptions(app *core.AppServer, ytdlpPath, cookiesFile string, useProxy bool) source.Options {
+	opts := source.Options{
+		YtDlpPath:   ytdlpPath,
+		CookiesFile: cookiesFile,
+	}
+	if proxy := app.Config.ProxyConfig; useProxy && proxy != nil && proxy.UseProxy && proxy.ProxyHost != "" {
+		opts.Proxy = proxy.ProxyHost
+		if proxyURL, err := url.Parse(proxy.ProxyHost); err == nil {
+			opts.HTTPClient = &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+		}
+	}
+	return opts
+}
+
+// fetchMetadata 通过视频来源获取元数据，使用代理失败时不使用代理重试
+func fetchMetadata(ctx context.Context, app *core.AppServer, ytdlpPath, videoURL string) (*source.Metadata, error) {
+	src, _, err := source.ForURL(videoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// 添加 cookies 支持（使用最新的用户提交的 cookies），没有时从浏览器读取
+	opts := sourceOptions(app, ytdlpPath, latestCookiesFile(app), true)
+	if opts.CookiesFile == "" {
+		opts.CookiesFromBrowser = "chrome"
+		app.Logger.Debug("🍪 从 Chrome 浏览器读取 cookies 获取元数据")
+	}
+
+	metadata, err := src.FetchMetadata(ctx, opts, videoURL)
+	// 如果使用代理失败，尝试不使用代理
+	if err != nil && opts.Proxy != "" && ctx.Err() == nil {
+		app.Logger.Warnf("⚠️ 使用代理获取元数据失败，尝试不使用代理...")
+		opts = sourceOptions(app, ytdlpPath, opts.CookiesFile, false)
+		metadata, err = src.FetchMetadata(ctx, opts, videoURL)
+		if err == nil {
+			app.Logger.Info("✓ 不使用代理成功获取元数据")
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取元数据失败: %w", err)
+	}
+	return metadata, nil
+}
+
+// sourceInfoFile 视频目录中存档的来源完整信息，如 yt-dlp 的 info JSON
+const sourceInfoFile = "source_info.json"
+
+// archiveSourceInfo 将来源返回的完整信息保存到视频目录，来源没有提供时不保存
+func archiveSourceInfo(dir string, metadata *source.Metadata) error {
+	if len(metadata.Raw) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, sourceInfoFile), metadata.Raw, 0644)
+}
+
+// applySourceMetadata 将来源信息中的结构化字段写入视频记录，不修改标题和描述
+func applySourceMetadata(video *model.SavedVideo, metadata *source.Metadata) {
+	video.Uploader = metadata.Uploader
+	video.ChannelID = metadata.ChannelID
+	video.UploadDate = ""
+	if !metadata.UploadDate.IsZero() {
+		video.UploadDate = metadata.UploadDate.Format(filter.DateLayout)
+	}
+	video.Duration = metadata.Duration
+	video.ViewCount = metadata.ViewCount
+	video.Language = metadata.Language
+	video.ThumbnailURL = metadata.Thumbnail
+	video.SetSourceTags(metadata.Tags)
+	video.SetCategories(metadata.Categories)
+
+	// 来源没有章节时保留之前解析或生成的章节
+	if len(metadata.Chapters) > 0 {
+		chapters := make([]model.Chapter, 0, len(metadata.Chapters))
+		for _, chapter := range metadata.Chapters {
+			chapters = append(chapters, model.Chapter{Title: chapter.Title, StartTime: chapter.StartTime, EndTime: chapter.EndTime})
+		}
+		video.SetChapters(chapters)
+		video.ChapterSource = model.ChapterSourcePlatform
+	}
+}
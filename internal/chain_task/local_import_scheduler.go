@@ -0,0 +1,163 @@
+package chain_task
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/difyz9/ytb2bili/internal/chain_task/steps"
+	"github.com/difyz9/ytb2bili/internal/core"
+	"github.com/difyz9/ytb2bili/internal/core/services"
+	"github.com/difyz9/ytb2bili/pkg/source"
+	"github.com/difyz9/ytb2bili/pkg/store/model"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+// LocalImportScheduler 监控目录调度器
+// 定时扫描监控目录，将新的视频文件加入待处理列表，由 import_local 步骤导入
+// 同一路径的文件只导入一次，删除视频记录后也不会重新导入
+type LocalImportScheduler struct {
+	App               *core.AppServer
+	Task              *cron.Cron
+	SavedVideoService *services.SavedVideoService
+	logger            *zap.SugaredLogger
+
+	// 上一轮扫描未结束时跳过本轮
+	scanning sync.Mutex
+	// 无法导入的文件及其修改时间，文件修改前不再重复提示
+	rejected map[string]time.Time
+
+	// 根上下文，服务关闭时结束正在执行的扫描
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+}
+
+// NewLocalImportScheduler 创建监控目录调度器实例
+func NewLocalImportScheduler(app *core.AppServer, task *cron.Cron, savedVideoService *services.SavedVideoService) *LocalImportScheduler {
+	ctx, cancel := context.WithCancelCause(context.Background())
+
+	return &LocalImportScheduler{
+		App:               app,
+		Task:              task,
+		SavedVideoService: savedVideoService,
+		logger:            app.Logger,
+		rejected:          make(map[string]time.Time),
+		ctx:               ctx,
+		cancel:            cancel,
+	}
+}
+
+// SetUp 启动监控目录调度器，未配置监控目录时不启动
+func (s *LocalImportScheduler) SetUp() {
+	cfg := s.App.Config.LocalImportConfig
+	if cfg == nil || cfg.WatchDir == "" {
+		s.logger.Info("监控目录未配置")
+		return
+	}
+	if err := os.MkdirAll(cfg.WatchDir, 0755); err != nil {
+		s.logger.Errorf("❌ 创建监控目录失败: %v", err)
+		return
+	}
+
+	interval := cfg.GetScanInterval()
+	s.Task.AddFunc("@every "+interval.String(), s.scan)
+	s.logger.Infof("✓ Watch folder scheduler started, scanning %s every %s", cfg.WatchDir, interval)
+}
+
+// Shutdown 结束正在执行的扫描，等待正在导入的文件完成
+func (s *LocalImportScheduler) Shutdown() {
+	s.cancel(ErrShuttingDown)
+	s.scanning.Lock()
+	defer s.scanning.Unlock()
+}
+
+// scan 扫描监控目录（包括子目录），导入修改后已稳定的视频文件
+func (s *LocalImportScheduler) scan() {
+	if !s.scanning.TryLock() {
+		return
+	}
+	defer s.scanning.Unlock()
+
+	cfg := s.App.Config.LocalImportConfig
+	if cfg == nil || cfg.WatchDir == "" || s.ctx.Err() != nil {
+		return
+	}
+	settled := time.Now().Add(-cfg.GetSettleTime())
+
+	err := filepath.WalkDir(cfg.WatchDir, func(path string, entry fs.DirEntry, err error) error {
+		// 服务关闭，不再导入剩余的文件
+		if s.ctx.Err() != nil {
+			return filepath.SkipAll
+		}
+		if err != nil {
+			s.logger.Warnf("⚠️ 读取监控目录失败: %v", err)
+			return nil
+		}
+		// 跳过隐藏文件和目录，如复制中的临时文件
+		if path != cfg.WatchDir && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() || !source.IsMediaFile(entry.Name()) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(settled) {
+			return nil
+		}
+		if modTime, ok := s.rejected[path]; ok && modTime.Equal(info.ModTime()) {
+			return nil
+		}
+
+		if err := s.importFile(path); err != nil {
+			s.logger.Warnf("⚠️ 无法导入监控目录中的文件 %s: %v", path, err)
+			s.rejected[path] = info.ModTime()
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Errorf("❌ 扫描监控目录失败: %v", err)
+	}
+}
+
+// importFile 导入单个文件，已导入过的文件直接跳过
+func (s *LocalImportScheduler) importFile(path string) error {
+	videoURL, err := source.LocalFileURL(path)
+	if err != nil {
+		return err
+	}
+	id, _, err := source.Parse(videoURL)
+	if err != nil {
+		return err
+	}
+	existing, err := s.SavedVideoService.GetExistingVideoIDs([]string{id.Key()})
+	if err != nil {
+		return err
+	}
+	if existing[id.Key()] {
+		return nil
+	}
+
+	video, hasSubtitles, err := services.NewLocalVideo(path)
+	if err != nil {
+		return err
+	}
+
+	video.Pipeline = s.App.Config.LocalImportConfig.GetPipeline(hasSubtitles)
+	if _, err := steps.ResolveLocal(s.App.Config, video.Pipeline, hasSubtitles); err != nil {
+		return err
+	}
+	if err := s.SavedVideoService.CreateVideo(video, model.StatusActorWatchFolder, "监控目录导入"); err != nil {
+		return err
+	}
+	s.logger.Infof("📥 监控目录新增待处理视频: %s（流程 %s）", path, video.Pipeline)
+	return nil
+}
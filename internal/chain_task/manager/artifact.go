@@ -0,0 +1,229 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/difyz9/ytb2bili/internal/core/services"
+)
+
+// ArtifactKind 任务产物类型，任务通过声明所需/产出的产物来确定执行顺序
+type ArtifactKind string
+
+const (
+	ArtifactSourceInfo    ArtifactKind = "source_info"    // 视频来源提供的视频信息，用于过滤规则
+	ArtifactSourceVideo   ArtifactKind = "source_video"   // 源视频文件
+	ArtifactAudioWAV      ArtifactKind = "audio_wav"      // 分离出的 WAV 音频
+	ArtifactOriginalSRT   ArtifactKind = "original_srt"   // 原语言字幕
+	ArtifactTranslatedSRT ArtifactKind = "translated_srt" // 翻译后的字幕
+	ArtifactBilingualSRT  ArtifactKind = "bilingual_srt"  // 译文在上、原文在下的双语字幕
+	ArtifactTranslatedASS ArtifactKind = "translated_ass" // 带样式的双语 ASS 字幕
+	ArtifactCover         ArtifactKind = "cover"          // 视频封面
+	ArtifactMetadata      ArtifactKind = "metadata"       // 生成的标题、描述和标签
+	ArtifactBiliArchive   ArtifactKind = "bili_archive"   // B站稿件 BVID/AID
+	ArtifactChapters      ArtifactKind = "chapters"       // 视频章节，章节内容保存在视频记录中
+	ArtifactTranscript    ArtifactKind = "transcript"     // 语音识别使用的服务和识别出的语言
+)
+
+// pathArtifacts 以文件路径表示的产物
+var pathArtifacts = map[ArtifactKind]bool{
+	ArtifactSourceVideo:   true,
+	ArtifactAudioWAV:      true,
+	ArtifactOriginalSRT:   true,
+	ArtifactTranslatedSRT: true,
+	ArtifactBilingualSRT:  true,
+	ArtifactTranslatedASS: true,
+	ArtifactCover:         true,
+}
+
+// MetadataArtifact 生成的视频元数据
+type MetadataArtifact struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+}
+
+// SourceInfoArtifact 视频来源提供的视频信息
+type SourceInfoArtifact struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Uploader    string `json:"uploader"`
+	UploaderID  string `json:"uploader_id"`
+	ChannelID   string `json:"channel_id"`
+	Duration    int    `json:"duration"`    // 时长（秒）
+	Language    string `json:"language"`    // 视频语言，未知时为空
+	UploadDate  string `json:"upload_date"` // 发布日期，格式 2006-01-02，未知时为空
+	LiveStatus  string `json:"live_status"` // 直播状态，未知时为空
+}
+
+// ChaptersArtifact 获取章节的结果
+type ChaptersArtifact struct {
+	Source string `json:"source"` // 章节的来源，没有章节时为空
+	Count  int    `json:"count"`
+}
+
+// TranscriptArtifact 语音识别的结果
+type TranscriptArtifact struct {
+	Provider string   `json:"provider"`          // 生成字幕的语音识别服务
+	Language string   `json:"language"`          // 识别出的语言
+	Segments int      `json:"segments"`          // 识别出的片段数
+	Skipped  []string `json:"skipped,omitempty"` // 失败而跳过的服务及原因
+}
+
+// BiliArchiveArtifact B站稿件信息
+type BiliArchiveArtifact struct {
+	BVID string `json:"bvid"`
+	AID  int64  `json:"aid"`
+}
+
+// ArtifactStore 单个视频的产物仓库
+// 产物写入后同步持久化到数据库，单独重试某个步骤时可从数据库恢复之前步骤的产物
+type ArtifactStore struct {
+	VideoID string
+
+	service *services.ArtifactService
+	values  map[ArtifactKind]json.RawMessage
+	mu      sync.RWMutex
+}
+
+// NewArtifactStore 创建产物仓库，service 为空时仅保存在内存中
+func NewArtifactStore(videoID string, service *services.ArtifactService) *ArtifactStore {
+	return &ArtifactStore{
+		VideoID: videoID,
+		service: service,
+		values:  make(map[ArtifactKind]json.RawMessage),
+	}
+}
+
+// Load 从数据库恢复产物
+func (s *ArtifactStore) Load() error {
+	if s.service == nil {
+		return nil
+	}
+
+	artifacts, err := s.service.GetArtifactsByVideoID(s.VideoID)
+	if err != nil {
+		return fmt.Errorf("加载视频产物失败: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, artifact := range artifacts {
+		s.values[ArtifactKind(artifact.Kind)] = json.RawMessage(artifact.Value)
+	}
+	return nil
+}
+
+// SetPath 记录文件类产物的路径
+func (s *ArtifactStore) SetPath(kind ArtifactKind, path string) error {
+	if !pathArtifacts[kind] {
+		return fmt.Errorf("产物 %s 不是文件类型", kind)
+	}
+	return s.set(kind, path)
+}
+
+// Path 获取文件类产物的路径
+func (s *ArtifactStore) Path(kind ArtifactKind) (string, bool) {
+	var path string
+	if !pathArtifacts[kind] || !s.get(kind, &path) || path == "" {
+		return "", false
+	}
+	return path, true
+}
+
+// SetMetadata 记录生成的视频元数据
+func (s *ArtifactStore) SetMetadata(metadata *MetadataArtifact) error {
+	return s.set(ArtifactMetadata, metadata)
+}
+
+// Metadata 获取生成的视频元数据
+func (s *ArtifactStore) Metadata() (*MetadataArtifact, bool) {
+	var metadata MetadataArtifact
+	if !s.get(ArtifactMetadata, &metadata) {
+		return nil, false
+	}
+	return &metadata, true
+}
+
+// SetChapters 记录获取章节的结果
+func (s *ArtifactStore) SetChapters(chapters *ChaptersArtifact) error {
+	return s.set(ArtifactChapters, chapters)
+}
+
+// SetTranscript 记录语音识别的结果
+func (s *ArtifactStore) SetTranscript(transcript *TranscriptArtifact) error {
+	return s.set(ArtifactTranscript, transcript)
+}
+
+// SetSourceInfo 记录视频来源提供的视频信息
+func (s *ArtifactStore) SetSourceInfo(info *SourceInfoArtifact) error {
+	return s.set(ArtifactSourceInfo, info)
+}
+
+// SourceInfo 获取视频来源提供的视频信息
+func (s *ArtifactStore) SourceInfo() (*SourceInfoArtifact, bool) {
+	var info SourceInfoArtifact
+	if !s.get(ArtifactSourceInfo, &info) {
+		return nil, false
+	}
+	return &info, true
+}
+
+// SetBiliArchive 记录B站稿件信息
+func (s *ArtifactStore) SetBiliArchive(archive *BiliArchiveArtifact) error {
+	return s.set(ArtifactBiliArchive, archive)
+}
+
+// BiliArchive 获取B站稿件信息
+func (s *ArtifactStore) BiliArchive() (*BiliArchiveArtifact, bool) {
+	var archive BiliArchiveArtifact
+	if !s.get(ArtifactBiliArchive, &archive) || archive.BVID == "" {
+		return nil, false
+	}
+	return &archive, true
+}
+
+// Snapshot 复制当前所有产物，用于记录步骤执行结果
+func (s *ArtifactStore) Snapshot() map[ArtifactKind]json.RawMessage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot := make(map[ArtifactKind]json.RawMessage, len(s.values))
+	for kind, value := range s.values {
+		snapshot[kind] = value
+	}
+	return snapshot
+}
+
+// set 编码并保存产物，同时写入数据库
+func (s *ArtifactStore) set(kind ArtifactKind, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("编码产物 %s 失败: %v", kind, err)
+	}
+
+	s.mu.Lock()
+	s.values[kind] = data
+	s.mu.Unlock()
+
+	if s.service == nil {
+		return nil
+	}
+	if err := s.service.SaveArtifact(s.VideoID, string(kind), string(data)); err != nil {
+		return fmt.Errorf("保存产物 %s 失败: %v", kind, err)
+	}
+	return nil
+}
+
+// get 读取并解码产物
+func (s *ArtifactStore) get(kind ArtifactKind, value interface{}) bool {
+	s.mu.RLock()
+	data, exists := s.values[kind]
+	s.mu.RUnlock()
+
+	if !exists {
+		return false
+	}
+	return json.Unmarshal(data, value) == nil
+}
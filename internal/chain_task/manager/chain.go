@@ -1,6 +1,8 @@
 package manager
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"github.com/difyz9/ytb2bili/internal/core/types"
@@ -8,15 +10,13 @@ import (
 
 // TaskChain 任务链
 type TaskChain struct {
-	Tasks   []types.Task
-	Context map[string]interface{}
+	Tasks []types.Task
 }
 
 // NewTaskChain 创建任务链
 func NewTaskChain() *TaskChain {
 	return &TaskChain{
-		Tasks:   make([]types.Task, 0),
-		Context: make(map[string]interface{}),
+		Tasks: make([]types.Task, 0),
 	}
 }
 
@@ -29,39 +29,43 @@ func (c *TaskChain) AddTask(task types.Task) *TaskChain {
 	return c
 }
 
-// Run 执行任务链
-func (c *TaskChain) Run(stopOnFailure bool) map[string]interface{} {
+// Run 执行任务链，返回执行过程中出现的错误
+// ctx 取消后不再执行后续任务
+func (c *TaskChain) Run(ctx context.Context, stopOnFailure bool) error {
+	var errs []error
 	for _, task := range c.Tasks {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+
 		taskName := task.GetName()
 		log.Printf("正在执行任务: %s", taskName)
 
 		// 执行任务
-		success := false
-		var message string
+		var err error
 
 		func() {
 			defer func() {
 				if r := recover(); r != nil {
-					message = fmt.Sprintf("任务执行异常: %v", r)
+					err = fmt.Errorf("任务执行异常: %v", r)
 					log.Printf("任务 %s 发生异常: %v", taskName, r)
 
 				}
 			}()
 
-			success = task.Execute(c.Context)
+			err = task.Execute(ctx)
 		}()
 
-		if success {
-
-		} else if message == "" { // 非异常导致的失败
-			// 更新任务状态为失败
+		if err != nil {
+			errs = append(errs, err)
 
-			log.Printf("任务 %s 执行失败，终止链", taskName)
+			log.Printf("任务 %s 执行失败: %v", taskName, err)
 			if stopOnFailure {
 				break
 			}
 		}
 	}
 
-	return c.Context
+	return errors.Join(errs...)
 }
@@ -0,0 +1,229 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/difyz9/ytb2bili/internal/core/types"
+)
+
+// NodeStatus 任务图节点的执行结果
+type NodeStatus string
+
+const (
+	NodeCompleted NodeStatus = "completed" // 执行成功
+	NodeFailed    NodeStatus = "failed"    // 执行失败
+	NodeSkipped   NodeStatus = "skipped"   // 依赖的任务失败，未执行
+	NodeCancelled NodeStatus = "cancelled" // 任务图被取消，未执行或执行被中断
+)
+
+// TaskNode 任务图中的节点
+type TaskNode struct {
+	Task     types.Task
+	Needs    []ArtifactKind // 执行前需要的产物
+	Produces []ArtifactKind // 执行后产出的产物
+
+	deps   []*TaskNode
+	done   chan struct{}
+	status NodeStatus
+	err    error
+}
+
+// TaskGraph 按产物依赖关系执行的任务图
+// 互不依赖的分支并发执行，某个任务失败只会跳过依赖它的任务
+type TaskGraph struct {
+	Nodes []*TaskNode
+}
+
+// GraphResult 任务图执行结果
+type GraphResult struct {
+	Statuses map[string]NodeStatus
+	Errors   map[string]error
+}
+
+// Success 所有节点均执行成功
+func (r *GraphResult) Success() bool {
+	for _, status := range r.Statuses {
+		if status != NodeCompleted {
+			return false
+		}
+	}
+	return true
+}
+
+// NewTaskGraph 创建任务图
+func NewTaskGraph() *TaskGraph {
+	return &TaskGraph{
+		Nodes: make([]*TaskNode, 0),
+	}
+}
+
+// AddTask 添加任务到图中
+func (g *TaskGraph) AddTask(task types.Task, needs []ArtifactKind, produces []ArtifactKind) *TaskGraph {
+	if err := task.InsertTask(); err != nil {
+		log.Printf("添加任务到数据库失败: %v", err)
+	}
+	g.Nodes = append(g.Nodes, &TaskNode{
+		Task:     task,
+		Needs:    needs,
+		Produces: produces,
+	})
+	return g
+}
+
+// resolve 根据产物建立节点间的依赖，并检查重复产出和循环依赖
+// 图中没有任何节点产出的产物视为已存在（例如上一次执行已生成）
+func (g *TaskGraph) resolve() error {
+	producers := make(map[ArtifactKind]*TaskNode)
+	names := make(map[string]bool)
+	for _, node := range g.Nodes {
+		name := node.Task.GetName()
+		if names[name] {
+			return fmt.Errorf("任务名称重复: %s", name)
+		}
+		names[name] = true
+
+		for _, kind := range node.Produces {
+			if other, exists := producers[kind]; exists {
+				return fmt.Errorf("产物 %s 同时由 %s 和 %s 产出", kind, other.Task.GetName(), name)
+			}
+			producers[kind] = node
+		}
+	}
+
+	for _, node := range g.Nodes {
+		node.deps = nil
+		seen := make(map[*TaskNode]bool)
+		for _, kind := range node.Needs {
+			producer, exists := producers[kind]
+			if !exists || producer == node || seen[producer] {
+				continue
+			}
+			seen[producer] = true
+			node.deps = append(node.deps, producer)
+		}
+		node.done = make(chan struct{})
+		node.status = ""
+		node.err = nil
+	}
+
+	// 检查循环依赖
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[*TaskNode]int)
+	var visit func(node *TaskNode) error
+	visit = func(node *TaskNode) error {
+		switch state[node] {
+		case visiting:
+			return fmt.Errorf("任务 %s 存在循环依赖", node.Task.GetName())
+		case visited:
+			return nil
+		}
+		state[node] = visiting
+		for _, dep := range node.deps {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		state[node] = visited
+		return nil
+	}
+	for _, node := range g.Nodes {
+		if err := visit(node); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Run 执行任务图，每个节点在其依赖全部成功后执行
+// ctx 取消后正在执行的任务被中断，尚未开始的任务不再执行
+func (g *TaskGraph) Run(ctx context.Context) (*GraphResult, error) {
+	if err := g.resolve(); err != nil {
+		return nil, err
+	}
+
+	var wg sync.WaitGroup
+	for _, node := range g.Nodes {
+		wg.Add(1)
+		go func(node *TaskNode) {
+			defer wg.Done()
+			defer close(node.done)
+
+			for _, dep := range node.deps {
+				<-dep.done
+				if ctx.Err() != nil {
+					break
+				}
+				if dep.status != NodeCompleted {
+					node.status = NodeSkipped
+					node.err = fmt.Errorf("依赖的任务 %s 未成功完成", dep.Task.GetName())
+					log.Printf("任务 %s 已跳过: %v", node.Task.GetName(), node.err)
+					return
+				}
+			}
+
+			if err := ctx.Err(); err != nil {
+				node.status = NodeCancelled
+				node.err = err
+				return
+			}
+
+			g.execute(ctx, node)
+		}(node)
+	}
+	wg.Wait()
+
+	result := &GraphResult{
+		Statuses: make(map[string]NodeStatus, len(g.Nodes)),
+		Errors:   make(map[string]error),
+	}
+	for _, node := range g.Nodes {
+		name := node.Task.GetName()
+		result.Statuses[name] = node.status
+		if node.err != nil {
+			result.Errors[name] = node.err
+		}
+	}
+
+	return result, nil
+}
+
+// execute 执行单个节点
+func (g *TaskGraph) execute(ctx context.Context, node *TaskNode) {
+	taskName := node.Task.GetName()
+	log.Printf("正在执行任务: %s", taskName)
+
+	var err error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("任务执行异常: %v", r)
+				log.Printf("任务 %s 发生异常: %v", taskName, r)
+			}
+		}()
+
+		err = node.Task.Execute(ctx)
+	}()
+
+	if err == nil {
+		node.status = NodeCompleted
+		return
+	}
+
+	node.err = err
+	if ctx.Err() != nil {
+		node.status = NodeCancelled
+		log.Printf("任务 %s 已中断: %v", taskName, err)
+		return
+	}
+
+	node.status = NodeFailed
+	log.Printf("任务 %s 执行失败，跳过依赖它的任务: %v", taskName, err)
+}
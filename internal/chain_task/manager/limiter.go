@@ -0,0 +1,52 @@
+package manager
+
+import "context"
+
+// ResourceClass 受并发限制的资源类型
+type ResourceClass string
+
+const (
+	ResourceDownload ResourceClass = "download" // 视频下载
+	ResourceFFmpeg   ResourceClass = "ffmpeg"   // ffmpeg 进程
+	ResourceASR      ResourceClass = "asr"      // 语音识别
+	ResourceLLM      ResourceClass = "llm"      // 大模型请求
+)
+
+// ResourceLimiter 按资源类型限制并发数，所有视频的任务共享同一个限制器
+type ResourceLimiter struct {
+	slots map[ResourceClass]chan struct{}
+}
+
+// NewResourceLimiter 创建资源限制器，limit 小于等于 0 的资源不限制并发
+func NewResourceLimiter(limits map[ResourceClass]int) *ResourceLimiter {
+	l := &ResourceLimiter{
+		slots: make(map[ResourceClass]chan struct{}, len(limits)),
+	}
+	for class, limit := range limits {
+		if limit > 0 {
+			l.slots[class] = make(chan struct{}, limit)
+		}
+	}
+	return l
+}
+
+// Acquire 占用一个资源槽位，阻塞直到有空闲槽位或上下文取消，返回释放函数
+// 限制器为空或该资源未限制时立即返回
+func (l *ResourceLimiter) Acquire(ctx context.Context, class ResourceClass) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	slots, exists := l.slots[class]
+	if !exists {
+		return func() {}, nil
+	}
+
+	select {
+	case slots <- struct{}{}:
+		return func() {
+			<-slots
+		}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -2,6 +2,8 @@ package manager
 
 import (
 	"github.com/difyz9/ytb2bili/internal/core/services"
+	"github.com/difyz9/ytb2bili/pkg/events"
+	"log"
 	"os"
 	"path/filepath"
 	"sync"
@@ -32,22 +34,36 @@ type StateManager struct {
 	M3u8FileDir     string
 	TranslateSRT    string
 	TranslateVtt    string
+	TranslateASS    string // 双语 ASS 字幕
+	BilingualSRT    string // 双语 SRT 字幕
 	TranslateTXT    string
 	// 目录路径
 	AudioDir       string
 	SaveUrlService *services.TbVideoService
 
+	// 步骤产物（持久化到数据库）
+	Artifacts *ArtifactStore
+	// 资源并发限制（为空时不限制）
+	Limiter *ResourceLimiter
+	// 进度事件总线（为空时不发布）
+	Events *events.Bus
+
 	// 内存缓存
 	cache map[string]interface{}
 	mu    sync.RWMutex
 }
 
-// NewStateManager 创建状态管理器
-func NewStateManager(Id uint, videoID, projectRoot string, createTim time.Time) *StateManager {
+// NewStateManager 创建状态管理器，并从数据库恢复之前步骤的产物
+func NewStateManager(Id uint, videoID, projectRoot string, createTim time.Time, artifactService *services.ArtifactService) *StateManager {
 	currentDir := filepath.Join(projectRoot, GetCurrentDateYYYYMMDD(createTim), videoID)
 
 	os.MkdirAll(currentDir, os.ModePerm)
 
+	artifacts := NewArtifactStore(videoID, artifactService)
+	if err := artifacts.Load(); err != nil {
+		log.Printf("恢复视频 %s 的产物失败: %v", videoID, err)
+	}
+
 	//audioDir := filepath.Join(currentDir, "audio")
 	//m8u3Dir := filepath.Join(currentDir, "m3u8")
 	//
@@ -69,11 +85,14 @@ func NewStateManager(Id uint, videoID, projectRoot string, createTim time.Time)
 		TranslateJSON:  filepath.Join(currentDir, "zh.json"),
 		TranslateSRT:   filepath.Join(currentDir, "zh.srt"),
 		TranslateVtt:   filepath.Join(currentDir, "zh.vtt"),
+		TranslateASS:   filepath.Join(currentDir, "zh.ass"),
+		BilingualSRT:   filepath.Join(currentDir, "zh-bilingual.srt"),
 		TranslateTXT:   filepath.Join(currentDir, videoID+"_trans.txt"),
 		//AudioDir:       audioDir,
 		//M3u8FileDir:    m8u3Dir,
 		//M3u8FileName:   filepath.Join(m8u3Dir, "output.m3u8"),
-		cache: make(map[string]interface{}),
+		Artifacts: artifacts,
+		cache:     make(map[string]interface{}),
 	}
 }
 
@@ -0,0 +1,126 @@
+package chain_task
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/difyz9/ytb2bili/internal/core/services"
+	"github.com/difyz9/ytb2bili/internal/core/types"
+	"github.com/difyz9/ytb2bili/pkg/events"
+	"github.com/difyz9/ytb2bili/pkg/store/model"
+)
+
+// stepPolicy 步骤的超时和自动重试策略
+type stepPolicy struct {
+	Timeout    time.Duration // 单次执行的超时时间，0 表示不限制
+	MaxRetries int           // 临时错误的自动重试次数
+	BaseDelay  time.Duration // 首次重试前的等待时间
+	MaxDelay   time.Duration // 重试等待时间上限
+}
+
+// newStepPolicy 根据配置生成步骤的执行策略
+func newStepPolicy(config *types.WorkerConfig, stepName string) stepPolicy {
+	policy := stepPolicy{
+		Timeout:    config.GetStepTimeout(stepName),
+		MaxRetries: config.GetMaxRetries(stepName),
+		BaseDelay:  10 * time.Second,
+		MaxDelay:   5 * time.Minute,
+	}
+	if config != nil {
+		if config.RetryBaseDelay > 0 {
+			policy.BaseDelay = time.Duration(config.RetryBaseDelay) * time.Second
+		}
+		if config.RetryMaxDelay > 0 {
+			policy.MaxDelay = time.Duration(config.RetryMaxDelay) * time.Second
+		}
+	}
+	return policy
+}
+
+// backoff 第 retry 次重试前的等待时间，retry 从 1 开始
+// 按指数增长，并在 [delay/2, delay] 范围内随机抖动，避免多个视频同时重试
+func (p stepPolicy) backoff(retry int) time.Duration {
+	retry = max(retry, 1)
+	delay := p.MaxDelay
+	if retry < 30 {
+		if d := p.BaseDelay << (retry - 1); d > 0 && d < p.MaxDelay {
+			delay = d
+		}
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+// stepResult 步骤的最终执行结果
+type stepResult struct {
+	Status   string // completed、failed 或 cancelled
+	Err      error
+	Attempts int // 执行次数（含自动重试）
+}
+
+// runStep 按策略执行步骤，临时错误按指数退避自动重试
+// 每次执行单独计算超时；认证、额度和永久错误不重试；ctx 取消后立即返回
+// onRetry 在等待重试前调用，attempt 为已执行的次数
+func runStep(ctx context.Context, policy stepPolicy, run func(ctx context.Context) error, onRetry func(attempt int, err error, delay time.Duration)) stepResult {
+	for attempt := 1; ; attempt++ {
+		stepCtx, cancel := withStepTimeout(ctx, policy.Timeout)
+		status, err := stepOutcome(ctx, stepCtx, run(stepCtx))
+		cancel()
+
+		if status != model.TaskStepStatusFailed || attempt > policy.MaxRetries || types.ClassifyError(err) != types.ErrorTransient {
+			return stepResult{Status: status, Err: err, Attempts: attempt}
+		}
+
+		delay := policy.backoff(attempt)
+		if onRetry != nil {
+			onRetry(attempt, err, delay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return stepResult{Status: model.TaskStepStatusCancelled, Err: context.Cause(ctx), Attempts: attempt}
+		}
+	}
+}
+
+// recordStepRetry 记录即将自动重试的步骤，错误信息中注明重试时间
+func recordStepRetry(service *services.TaskStepService, videoID, stepName string, attempt int, err error, delay time.Duration) error {
+	message := fmt.Sprintf("%v（第 %d 次执行失败，%v 后自动重试）", err, attempt, delay.Round(time.Second))
+	service.Events.Publish(events.Event{
+		Type:    events.StepRetrying,
+		VideoID: videoID,
+		Step:    stepName,
+		Message: message,
+		Data: map[string]interface{}{
+			"attempt":     attempt,
+			"delay":       delay.Seconds(),
+			"error_class": types.ClassifyError(err),
+		},
+	})
+	return service.UpdateTaskStepAttempt(videoID, stepName, attempt, string(types.ClassifyError(err)), message)
+}
+
+// recordStepResult 记录步骤最终的执行次数和错误分类
+func recordStepResult(service *services.TaskStepService, videoID, stepName string, result stepResult) error {
+	var errorClass, errorMsg string
+	if result.Status == model.TaskStepStatusFailed {
+		errorClass = string(types.ClassifyError(result.Err))
+		errorMsg = result.Err.Error()
+	}
+	return service.UpdateTaskStepAttempt(videoID, stepName, result.Attempts, errorClass, errorMsg)
+}
+
+// needsAttention 错误是否需要人工处理（cookies、API Key 失效或额度不足）
+func needsAttention(err error) bool {
+	return err != nil && types.ClassifyError(err).NeedsAttention()
+}
+
+// isFiltered 错误是否表示视频不符合过滤规则
+func isFiltered(err error) bool {
+	return err != nil && types.ClassifyError(err) == types.ErrorFiltered
+}
@@ -0,0 +1,39 @@
+package chain_task
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStepPolicyBackoff(t *testing.T) {
+	policy := stepPolicy{BaseDelay: 10 * time.Second, MaxDelay: 5 * time.Minute}
+	tests := []struct {
+		retry    int
+		min, max time.Duration
+	}{
+		{0, 5 * time.Second, 10 * time.Second},
+		{1, 5 * time.Second, 10 * time.Second},
+		{2, 10 * time.Second, 20 * time.Second},
+		{3, 20 * time.Second, 40 * time.Second},
+		{5, 80 * time.Second, 160 * time.Second},
+		{6, 150 * time.Second, 5 * time.Minute},
+		{10, 150 * time.Second, 5 * time.Minute},
+		// 移位溢出时使用上限
+		{40, 150 * time.Second, 5 * time.Minute},
+		{100, 150 * time.Second, 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			if delay := policy.backoff(tt.retry); delay < tt.min || delay > tt.max {
+				t.Fatalf("backoff(%d) = %v, want in [%v, %v]", tt.retry, delay, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestStepPolicyBackoffZeroDelay(t *testing.T) {
+	policy := stepPolicy{}
+	if delay := policy.backoff(1); delay != 0 {
+		t.Errorf("backoff(1) = %v, want 0", delay)
+	}
+}
@@ -0,0 +1,226 @@
+package steps
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/difyz9/ytb2bili/internal/chain_task/manager"
+	"github.com/difyz9/ytb2bili/internal/core/services"
+	"github.com/difyz9/ytb2bili/internal/core/types"
+)
+
+// Options 流程中为步骤设置的选项
+type Options map[string]interface{}
+
+// String 获取字符串选项，未设置时返回 def
+func (o Options) String(name, def string) string {
+	if v, ok := o[name]; ok {
+		if s := fmt.Sprint(v); s != "" {
+			return s
+		}
+	}
+	return def
+}
+
+// Int 获取整数选项，未设置、无法解析或不大于 0 时返回 def
+func (o Options) Int(name string, def int) int {
+	var n int
+	switch v := o[name].(type) {
+	case int:
+		n = v
+	case int64:
+		n = int(v)
+	case float64:
+		n = int(v)
+	case string:
+		n, _ = strconv.Atoi(v)
+	}
+	if n <= 0 {
+		return def
+	}
+	return n
+}
+
+// Bool 获取布尔选项，未设置或无法解析时返回 def
+func (o Options) Bool(name string, def bool) bool {
+	switch v := o[name].(type) {
+	case bool:
+		return v
+	case string:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return def
+}
+
+// PipelineStep 流程中的步骤及其选项
+type PipelineStep struct {
+	*Step
+	Options Options
+}
+
+// Pipeline 视频使用的处理流程
+type Pipeline struct {
+	Name  string // 流程名称，为空表示按字幕配置自动选择步骤
+	Steps []PipelineStep
+}
+
+// Resolve 根据名称获取处理流程，name 为空时使用配置的默认流程
+func Resolve(config *types.AppConfig, name string) (*Pipeline, error) {
+	resolved, definition, ok := config.PipelineConfig.GetPipeline(name)
+	if !ok {
+		return nil, fmt.Errorf("未定义的处理流程: %s", name)
+	}
+	if resolved == "" {
+		return autoPipeline(config), nil
+	}
+
+	pipeline := &Pipeline{Name: resolved}
+	for _, stepConfig := range definition.Steps {
+		step, ok := Get(Key(stepConfig.Key))
+		if !ok {
+			return nil, fmt.Errorf("处理流程 %s 包含未知的步骤: %s", resolved, stepConfig.Key)
+		}
+		if pipeline.Has(step.Key) {
+			return nil, fmt.Errorf("处理流程 %s 中步骤 %s 重复", resolved, step.Key)
+		}
+		pipeline.Steps = append(pipeline.Steps, PipelineStep{Step: step, Options: stepConfig.Options})
+	}
+	if err := pipeline.validate(); err != nil {
+		return nil, fmt.Errorf("处理流程 %s 无效: %v", resolved, err)
+	}
+	return pipeline, nil
+}
+
+// ResolveRemote 获取网络视频使用的处理流程，流程不能包含导入本地文件的步骤
+func ResolveRemote(config *types.AppConfig, name string) (*Pipeline, error) {
+	pipeline, err := Resolve(config, name)
+	if err != nil {
+		return nil, err
+	}
+	if pipeline.Has(ImportLocal) {
+		return nil, fmt.Errorf("处理流程 %s 只能用于本地文件", pipeline.displayName())
+	}
+	return pipeline, nil
+}
+
+// ResolveLocal 获取本地文件使用的处理流程，流程需要通过 import_local 导入文件而不是下载
+// 没有字幕文件时流程不能使用插件提交的字幕
+func ResolveLocal(config *types.AppConfig, name string, hasSubtitles bool) (*Pipeline, error) {
+	pipeline, err := Resolve(config, name)
+	if err != nil {
+		return nil, err
+	}
+	if !pipeline.Has(ImportLocal) || pipeline.Has(Download) {
+		return nil, fmt.Errorf("处理流程 %s 不能处理本地文件，需要使用 %s 步骤代替 %s", pipeline.displayName(), ImportLocal, Download)
+	}
+	if !hasSubtitles && pipeline.Has(GenerateSubtitles) {
+		return nil, fmt.Errorf("处理流程 %s 需要字幕文件，请同时提交 SRT 字幕或使用包含语音识别的流程", pipeline.displayName())
+	}
+	return pipeline, nil
+}
+
+// Names 返回配置中定义的所有流程名称
+func Names(config *types.AppConfig) []string {
+	if config.PipelineConfig == nil {
+		return nil
+	}
+	names := make([]string, 0, len(config.PipelineConfig.Pipelines))
+	for name := range config.PipelineConfig.Pipelines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// autoPipeline 根据当前配置自动选择步骤
+// 按字幕来源的优先级获取字幕，未启用语音识别时跳过语音识别
+func autoPipeline(config *types.AppConfig) *Pipeline {
+	asrEnabled := config.ASRConfig != nil && config.ASRConfig.Enabled
+
+	keys := []Key{Probe, Download, AcquireSubtitles, Cover, Chapters, Translate, Metadata, UploadVideo, UploadSubtitle}
+	pipeline := &Pipeline{Steps: make([]PipelineStep, 0, len(keys))}
+	for _, key := range keys {
+		step := PipelineStep{Step: byKey[key]}
+		if key == AcquireSubtitles {
+			step.Options = Options{"asr": asrEnabled}
+		}
+		pipeline.Steps = append(pipeline.Steps, step)
+	}
+	return pipeline
+}
+
+// validate 检查步骤所需的产物都有步骤产出，且每种产物只由一个步骤产出
+func (p *Pipeline) validate() error {
+	if len(p.Steps) == 0 {
+		return fmt.Errorf("没有任何步骤")
+	}
+
+	producers := make(map[manager.ArtifactKind]Key)
+	for _, step := range p.Steps {
+		for _, kind := range step.Produces {
+			if other, ok := producers[kind]; ok {
+				return fmt.Errorf("产物 %s 同时由 %s 和 %s 产出", kind, other, step.Key)
+			}
+			producers[kind] = step.Key
+		}
+	}
+	for _, step := range p.Steps {
+		for _, kind := range step.Needs {
+			if _, ok := producers[kind]; !ok {
+				return fmt.Errorf("步骤 %s 需要的产物 %s 没有步骤产出", step.Key, kind)
+			}
+		}
+	}
+
+	if p.Has(UploadSubtitle) && !p.Has(UploadVideo) {
+		return fmt.Errorf("步骤 %s 需要先执行 %s", UploadSubtitle, UploadVideo)
+	}
+	return nil
+}
+
+// displayName 流程名称，自动选择步骤的流程显示为 auto
+func (p *Pipeline) displayName() string {
+	if p.Name == "" {
+		return "auto"
+	}
+	return p.Name
+}
+
+// Has 流程是否包含指定步骤
+func (p *Pipeline) Has(key Key) bool {
+	_, ok := p.Step(key)
+	return ok
+}
+
+// Step 获取流程中的步骤
+func (p *Pipeline) Step(key Key) (PipelineStep, bool) {
+	for _, step := range p.Steps {
+		if step.Key == key {
+			return step, true
+		}
+	}
+	return PipelineStep{}, false
+}
+
+// Options 获取流程中为步骤设置的选项，步骤不在流程中时返回 nil
+func (p *Pipeline) Options(key Key) Options {
+	step, _ := p.Step(key)
+	return step.Options
+}
+
+// Specs 将流程中的步骤转换为初始化任务步骤记录所需的信息，按流程中的顺序排列
+func (p *Pipeline) Specs() []services.StepSpec {
+	specs := make([]services.StepSpec, 0, len(p.Steps))
+	for i, step := range p.Steps {
+		specs = append(specs, services.StepSpec{
+			Key:      string(step.Key),
+			Name:     step.Name,
+			Order:    i + 1,
+			CanRetry: step.CanRetry,
+		})
+	}
+	return specs
+}
@@ -0,0 +1,323 @@
+package steps
+
+import (
+	"github.com/difyz9/ytb2bili/internal/chain_task/handlers"
+	"github.com/difyz9/ytb2bili/internal/chain_task/manager"
+	"github.com/difyz9/ytb2bili/internal/core"
+	"github.com/difyz9/ytb2bili/internal/core/services"
+	"github.com/difyz9/ytb2bili/internal/core/types"
+	"github.com/difyz9/ytb2bili/pkg/store/model"
+
+	"gorm.io/gorm"
+)
+
+// Key 任务步骤的稳定标识，保存在 tb_task_steps.step_key 中，也用于接口和配置
+type Key string
+
+const (
+	Probe             Key = "probe"              // 获取视频信息并检查过滤规则
+	Download          Key = "download"           // 下载视频
+	ImportLocal       Key = "import_local"       // 导入本地视频文件
+	ExtractAudio      Key = "extract_audio"      // 分离音频
+	ASR               Key = "asr"                // 语音识别生成字幕
+	GenerateSubtitles Key = "generate_subtitles" // 使用插件提交的字幕
+	AcquireSubtitles  Key = "acquire_subtitles"  // 按优先级获取平台字幕、插件字幕或语音识别
+	Cover             Key = "cover"              // 下载封面
+	Chapters          Key = "chapters"           // 获取或生成视频章节
+	Translate         Key = "translate"          // 翻译字幕
+	Metadata          Key = "metadata"           // 生成标题和描述
+	UploadVideo       Key = "upload_video"       // 上传视频到 Bilibili
+	UploadSubtitle    Key = "upload_subtitle"    // 上传字幕到 Bilibili
+)
+
+// Stage 步骤所属的阶段
+type Stage string
+
+const (
+	StagePrepare Stage = "prepare" // 准备阶段，由任务链执行器执行
+	StageUpload  Stage = "upload"  // 上传阶段，由上传调度器按频率执行
+)
+
+// Deps 创建任务所需的依赖
+type Deps struct {
+	App               *core.AppServer
+	State             *manager.StateManager
+	DB                *gorm.DB
+	SavedVideoService *services.SavedVideoService
+	Options           Options // 流程中为该步骤设置的选项
+}
+
+// Step 任务步骤的定义
+type Step struct {
+	Key      Key
+	Name     string   // 中文显示名称
+	NameEn   string   // 英文显示名称
+	Aliases  []string // 历史上使用过的步骤名称，用于兼容旧数据和旧接口
+	Order    int      // 显示顺序
+	Stage    Stage
+	CanRetry bool
+	Needs    []manager.ArtifactKind // 执行前需要的产物
+	Produces []manager.ArtifactKind // 执行后产出的产物
+	After    []manager.ArtifactKind // 流程中有步骤产出时在其之后执行，没有时不影响执行
+	Records  []manager.ArtifactKind // 执行后记录但不参与调度的产物，多个步骤可以记录同一种产物
+
+	// RetryStatus 上传阶段的步骤重试时视频恢复到的状态，由上传调度器重新执行
+	RetryStatus model.VideoStatus
+
+	New func(d Deps) types.Task // 创建任务，任务名称即步骤标识
+}
+
+var registry = []*Step{
+	{
+		Key:      Probe,
+		Name:     "检查视频信息",
+		NameEn:   "Probe video",
+		Order:    0,
+		Stage:    StagePrepare,
+		CanRetry: true,
+		Produces: []manager.ArtifactKind{manager.ArtifactSourceInfo},
+		New: func(d Deps) types.Task {
+			return handlers.NewProbeVideo(string(Probe), d.App, d.State, d.App.CosClient, d.DB, d.SavedVideoService)
+		},
+	},
+	{
+		Key:      Download,
+		Name:     "下载视频",
+		NameEn:   "Download video",
+		Order:    1,
+		Stage:    StagePrepare,
+		CanRetry: true,
+		Produces: []manager.ArtifactKind{manager.ArtifactSourceVideo},
+		After:    []manager.ArtifactKind{manager.ArtifactSourceInfo},
+		New: func(d Deps) types.Task {
+			return handlers.NewDownloadVideo(string(Download), d.App, d.State, d.App.CosClient, d.SavedVideoService, d.Options.String("profile", ""))
+		},
+	},
+	{
+		Key:      ImportLocal,
+		Name:     "导入本地文件",
+		NameEn:   "Import local file",
+		Order:    1,
+		Stage:    StagePrepare,
+		CanRetry: true,
+		Produces: []manager.ArtifactKind{manager.ArtifactSourceVideo},
+		After:    []manager.ArtifactKind{manager.ArtifactSourceInfo},
+		New: func(d Deps) types.Task {
+			return handlers.NewImportLocal(string(ImportLocal), d.App, d.State, d.App.CosClient, d.SavedVideoService)
+		},
+	},
+	{
+		Key:      ExtractAudio,
+		Name:     "分离音频",
+		NameEn:   "Extract audio",
+		Order:    2,
+		Stage:    StagePrepare,
+		CanRetry: true,
+		Needs:    []manager.ArtifactKind{manager.ArtifactSourceVideo},
+		Produces: []manager.ArtifactKind{manager.ArtifactAudioWAV},
+		New: func(d Deps) types.Task {
+			return handlers.NewExtractAudio(string(ExtractAudio), d.App, d.State, d.App.CosClient)
+		},
+	},
+	{
+		Key:      ASR,
+		Name:     "语音转录",
+		NameEn:   "Transcribe audio",
+		Aliases:  []string{"B站必剪转录", "Whisper转录"},
+		Order:    3,
+		Stage:    StagePrepare,
+		CanRetry: true,
+		Needs:    []manager.ArtifactKind{manager.ArtifactAudioWAV},
+		Produces: []manager.ArtifactKind{manager.ArtifactOriginalSRT},
+		Records:  []manager.ArtifactKind{manager.ArtifactTranscript},
+		New: func(d Deps) types.Task {
+			return handlers.NewTranscribeAudio(string(ASR), d.App, d.State, d.App.CosClient, d.Options.String("language", ""))
+		},
+	},
+	{
+		Key:      GenerateSubtitles,
+		Name:     "生成字幕",
+		NameEn:   "Generate subtitles",
+		Order:    3,
+		Stage:    StagePrepare,
+		CanRetry: true,
+		Produces: []manager.ArtifactKind{manager.ArtifactOriginalSRT},
+		After:    []manager.ArtifactKind{manager.ArtifactSourceInfo},
+		New: func(d Deps) types.Task {
+			return handlers.NewGenerateSubtitles(string(GenerateSubtitles), d.App, d.State, d.App.CosClient, d.SavedVideoService)
+		},
+	},
+	{
+		Key:      AcquireSubtitles,
+		Name:     "获取字幕",
+		NameEn:   "Acquire subtitles",
+		Order:    3,
+		Stage:    StagePrepare,
+		CanRetry: true,
+		Produces: []manager.ArtifactKind{manager.ArtifactOriginalSRT},
+		// 语音识别需要下载的视频，流程中有下载步骤时在其之后执行；只获取平台字幕的流程可以不下载视频
+		// 流程中有分离音频的步骤时在其之后执行，没有时语音识别前自行分离音频并记录音频产物
+		After:   []manager.ArtifactKind{manager.ArtifactSourceInfo, manager.ArtifactSourceVideo, manager.ArtifactAudioWAV},
+		Records: []manager.ArtifactKind{manager.ArtifactTranscript, manager.ArtifactAudioWAV},
+		New: func(d Deps) types.Task {
+			// asr 选项为 false 时不使用语音识别生成字幕
+			return handlers.NewAcquireSubtitles(string(AcquireSubtitles), d.App, d.State, d.App.CosClient, d.SavedVideoService, d.Options.Bool("asr", true))
+		},
+	},
+	{
+		Key:      Cover,
+		Name:     "下载封面",
+		NameEn:   "Download cover",
+		Order:    4,
+		Stage:    StagePrepare,
+		CanRetry: true,
+		Produces: []manager.ArtifactKind{manager.ArtifactCover},
+		After:    []manager.ArtifactKind{manager.ArtifactSourceInfo},
+		New: func(d Deps) types.Task {
+			return handlers.NewDownloadImgHandler(string(Cover), d.App, d.State, d.App.CosClient, d.SavedVideoService)
+		},
+	},
+	{
+		Key:      Chapters,
+		Name:     "生成章节",
+		NameEn:   "Generate chapters",
+		Order:    4,
+		Stage:    StagePrepare,
+		CanRetry: true,
+		Produces: []manager.ArtifactKind{manager.ArtifactChapters},
+		// 下载时获取来源的章节，AI 生成章节需要原语言字幕
+		After: []manager.ArtifactKind{manager.ArtifactSourceInfo, manager.ArtifactSourceVideo, manager.ArtifactOriginalSRT},
+		New: func(d Deps) types.Task {
+			return handlers.NewGenerateChapters(string(Chapters), d.App, d.State, d.App.CosClient, d.SavedVideoService)
+		},
+	},
+	{
+		Key:      Translate,
+		Name:     "翻译字幕",
+		NameEn:   "Translate subtitles",
+		Order:    5,
+		Stage:    StagePrepare,
+		CanRetry: true,
+		Needs:    []manager.ArtifactKind{manager.ArtifactOriginalSRT},
+		Produces: []manager.ArtifactKind{manager.ArtifactTranslatedSRT, manager.ArtifactBilingualSRT, manager.ArtifactTranslatedASS},
+		// 翻译字幕后单独翻译章节标题
+		After: []manager.ArtifactKind{manager.ArtifactChapters},
+		New: func(d Deps) types.Task {
+			// 不在这里检查配置，让任务运行时动态检查最新配置
+			return handlers.NewTranslateSubtitle(string(Translate), d.App, d.State, d.App.CosClient, d.DB, "")
+		},
+	},
+	{
+		Key:      Metadata,
+		Name:     "生成视频元数据",
+		NameEn:   "Generate metadata",
+		Aliases:  []string{"生成元数据"},
+		Order:    6,
+		Stage:    StagePrepare,
+		CanRetry: true,
+		// 基于原语言字幕生成，无需等待翻译
+		Needs:    []manager.ArtifactKind{manager.ArtifactSourceVideo, manager.ArtifactOriginalSRT},
+		Produces: []manager.ArtifactKind{manager.ArtifactMetadata},
+		New: func(d Deps) types.Task {
+			return handlers.NewGenerateMetadata(string(Metadata), d.App, d.State, d.App.CosClient, "", d.DB, d.SavedVideoService)
+		},
+	},
+	{
+		Key:         UploadVideo,
+		Name:        "上传到Bilibili",
+		NameEn:      "Upload video to Bilibili",
+		Order:       7,
+		Stage:       StageUpload,
+		CanRetry:    true,
+		RetryStatus: model.VideoStatusReady,
+		Records:     []manager.ArtifactKind{manager.ArtifactBiliArchive},
+		New: func(d Deps) types.Task {
+			return handlers.NewUploadToBilibili(string(UploadVideo), d.App, d.State, d.App.CosClient, d.SavedVideoService)
+		},
+	},
+	{
+		Key:         UploadSubtitle,
+		Name:        "上传字幕到Bilibili",
+		NameEn:      "Upload subtitles to Bilibili",
+		Order:       8,
+		Stage:       StageUpload,
+		CanRetry:    true,
+		RetryStatus: model.VideoStatusVideoUploaded,
+		New: func(d Deps) types.Task {
+			return handlers.NewUploadSubtitleToBilibili(string(UploadSubtitle), d.App, d.State, d.App.CosClient, d.SavedVideoService)
+		},
+	},
+}
+
+var (
+	byKey  = make(map[Key]*Step, len(registry))
+	byName = make(map[string]*Step)
+)
+
+func init() {
+	for _, step := range registry {
+		byKey[step.Key] = step
+		byName[step.Name] = step
+		for _, alias := range step.Aliases {
+			byName[alias] = step
+		}
+	}
+}
+
+// Get 根据步骤标识获取步骤定义
+func Get(key Key) (*Step, bool) {
+	step, ok := byKey[key]
+	return step, ok
+}
+
+// Dependencies 执行前需要等待的产物，包括所需的产物和需要在其之后执行的产物
+func (s *Step) Dependencies() []manager.ArtifactKind {
+	if len(s.After) == 0 {
+		return s.Needs
+	}
+	deps := make([]manager.ArtifactKind, 0, len(s.Needs)+len(s.After))
+	return append(append(deps, s.Needs...), s.After...)
+}
+
+// Outputs 步骤执行后记录到步骤结果的产物，包括产出的产物和记录的产物
+func (s *Step) Outputs() []manager.ArtifactKind {
+	if len(s.Records) == 0 {
+		return s.Produces
+	}
+	outputs := make([]manager.ArtifactKind, 0, len(s.Produces)+len(s.Records))
+	return append(append(outputs, s.Produces...), s.Records...)
+}
+
+// Lookup 根据步骤标识或显示名称（含历史名称）查找步骤定义
+func Lookup(keyOrName string) (*Step, bool) {
+	if step, ok := byKey[Key(keyOrName)]; ok {
+		return step, true
+	}
+	step, ok := byName[keyOrName]
+	return step, ok
+}
+
+// All 返回所有步骤定义
+func All() []*Step {
+	return registry
+}
+
+// KeysByName 返回显示名称（含历史名称）到步骤标识的映射，用于补全旧数据的步骤标识
+func KeysByName() map[string]string {
+	keys := make(map[string]string, len(byName))
+	for name, step := range byName {
+		keys[name] = string(step.Key)
+	}
+	return keys
+}
+
+// KeysOf 返回指定阶段的所有步骤标识
+func KeysOf(stage Stage) []string {
+	var keys []string
+	for _, step := range registry {
+		if step.Stage == stage {
+			keys = append(keys, string(step.Key))
+		}
+	}
+	return keys
+}
@@ -0,0 +1,417 @@
+package chain_task
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/difyz9/ytb2bili/internal/core"
+	"github.com/difyz9/ytb2bili/internal/core/services"
+	"github.com/difyz9/ytb2bili/pkg/feed"
+	"github.com/difyz9/ytb2bili/pkg/store/model"
+	"github.com/difyz9/ytb2bili/pkg/utils"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+// SubscriptionScheduler 订阅调度器
+// 定时检查订阅的频道和播放列表，将尚未保存的新视频加入待处理列表，由任务调度器处理
+type SubscriptionScheduler struct {
+	App                 *core.AppServer
+	Task                *cron.Cron
+	SubscriptionService *services.SubscriptionService
+	SavedVideoService   *services.SavedVideoService
+	logger              *zap.SugaredLogger
+
+	// 上一轮检查未结束时跳过本轮
+	checking sync.Mutex
+
+	// 根上下文，服务关闭时结束正在执行的 yt-dlp
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+}
+
+// NewSubscriptionScheduler 创建订阅调度器实例
+func NewSubscriptionScheduler(
+	app *core.AppServer,
+	task *cron.Cron,
+	subscriptionService *services.SubscriptionService,
+	savedVideoService *services.SavedVideoService,
+) *SubscriptionScheduler {
+	ctx, cancel := context.WithCancelCause(context.Background())
+
+	return &SubscriptionScheduler{
+		App:                 app,
+		Task:                task,
+		SubscriptionService: subscriptionService,
+		SavedVideoService:   savedVideoService,
+		logger:              app.Logger,
+		ctx:                 ctx,
+		cancel:              cancel,
+	}
+}
+
+// SetUp 启动订阅调度器，每分钟检查一次是否有到期的订阅
+func (s *SubscriptionScheduler) SetUp() {
+	cfg := s.App.Config.SubscriptionConfig
+	if cfg == nil || !cfg.Enabled {
+		s.logger.Info("订阅检查未启用")
+		return
+	}
+
+	s.Task.AddFunc("0 * * * * *", s.checkDueSubscriptions)
+	s.logger.Info("✓ Subscription scheduler started, checking every minute")
+}
+
+// Shutdown 结束正在执行的订阅检查
+func (s *SubscriptionScheduler) Shutdown() {
+	s.cancel(ErrShuttingDown)
+}
+
+// checkDueSubscriptions 检查所有到期的订阅
+func (s *SubscriptionScheduler) checkDueSubscriptions() {
+	if !s.checking.TryLock() {
+		return
+	}
+	defer s.checking.Unlock()
+
+	now := time.Now()
+	subscriptions, err := s.SubscriptionService.GetDueSubscriptions(now, s.defaultInterval())
+	if err != nil {
+		s.logger.Errorf("❌ %v", err)
+		return
+	}
+
+	for i := range subscriptions {
+		if s.ctx.Err() != nil {
+			return
+		}
+		subscription := &subscriptions[i]
+
+		// 多个实例共享数据库时只有一个实例检查
+		claimed, err := s.SubscriptionService.ClaimCheck(subscription, now)
+		if err != nil {
+			s.logger.Errorf("❌ %v", err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		if _, err := s.check(s.ctx, subscription); err != nil {
+			s.logger.Errorf("❌ 检查订阅 %s 失败: %v", subscription.URL, err)
+		}
+	}
+}
+
+// CheckSubscription 立即检查指定的订阅，返回加入待处理列表的视频数量
+// 订阅正在被其他实例或请求检查时返回 services.ErrCheckInProgress
+func (s *SubscriptionScheduler) CheckSubscription(ctx context.Context, id uint) (int, error) {
+	subscription, err := s.SubscriptionService.GetSubscription(id)
+	if err != nil {
+		return 0, fmt.Errorf("获取订阅失败: %v", err)
+	}
+
+	// 请求断开或服务关闭时结束检查
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(s.ctx, cancel)
+	defer stop()
+
+	claimed, err := s.SubscriptionService.ClaimCheck(subscription, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	if !claimed {
+		return 0, services.ErrCheckInProgress
+	}
+	return s.check(ctx, subscription)
+}
+
+// check 获取订阅最新的视频，将尚未保存的视频加入待处理列表，并记录检查结果
+func (s *SubscriptionScheduler) check(ctx context.Context, subscription *model.Subscription) (int, error) {
+	var title string
+	var added int
+	var err error
+	switch subscription.Mode {
+	case model.SubscriptionModeFeed, model.SubscriptionModeWebSub:
+		title, added, err = s.pollFeed(ctx, subscription)
+		if subscription.Mode == model.SubscriptionModeWebSub {
+			// 订阅源读取失败时仍然尝试续订，推送不依赖轮询
+			if renewErr := s.renewWebSub(ctx, subscription); renewErr != nil && err == nil {
+				err = renewErr
+			}
+		}
+	default:
+		title, added, err = s.enqueueNewVideos(ctx, subscription)
+	}
+
+	if recordErr := s.SubscriptionService.RecordCheck(subscription.ID, title, err); recordErr != nil {
+		s.logger.Errorf("记录订阅检查结果失败: %v", recordErr)
+	}
+	if added > 0 {
+		s.logger.Infof("📥 订阅 %s 新增 %d 个待处理视频", subscription.URL, added)
+	}
+	return added, err
+}
+
+// enqueueNewVideos 使用 yt-dlp 列出最新的视频
+func (s *SubscriptionScheduler) enqueueNewVideos(ctx context.Context, subscription *model.Subscription) (string, int, error) {
+	s.logger.Debugf("检查订阅: %s", subscription.URL)
+	entries, err := s.listEntries(ctx, listURL(subscription), s.maxItems(subscription))
+	if err != nil {
+		return "", 0, err
+	}
+
+	title := ""
+	videos := make([]services.SubscriptionVideo, 0, len(entries))
+	for _, entry := range entries {
+		if title == "" {
+			title = entry.PlaylistTitle
+		}
+		if subscription.SourceID == "" {
+			s.saveSourceID(subscription, sourceIDFromEntry(subscription, entry))
+		}
+		// 尚未结束的直播不处理
+		if entry.LiveStatus == "is_live" || entry.LiveStatus == "is_upcoming" {
+			continue
+		}
+		videos = append(videos, services.SubscriptionVideo{
+			VideoID:    entry.ID,
+			URL:        entry.URL,
+			Title:      entry.Title,
+			PlaylistID: entry.PlaylistID,
+		})
+	}
+
+	added, err := s.SubscriptionService.EnqueueVideos(subscription, videos, s.pipeline(subscription))
+	return title, added, err
+}
+
+// pollFeed 读取 YouTube 的 Atom 订阅源，不需要 yt-dlp，但只包含最新的 15 个视频
+func (s *SubscriptionScheduler) pollFeed(ctx context.Context, subscription *model.Subscription) (string, int, error) {
+	topic, err := s.feedURL(ctx, subscription)
+	if err != nil {
+		return "", 0, err
+	}
+
+	s.logger.Debugf("读取订阅源: %s", topic)
+	f, err := feed.Fetch(ctx, s.httpClient(), topic)
+	if err != nil {
+		return "", 0, err
+	}
+
+	entries := f.Entries
+	if limit := s.maxItems(subscription); len(entries) > limit {
+		entries = entries[:limit]
+	}
+	added, err := s.SubscriptionService.EnqueueFeedEntries(subscription, entries, s.pipeline(subscription), time.Time{})
+	return f.Title, added, err
+}
+
+// renewWebSub 尚未订阅或即将到期时向 Hub 发送订阅请求
+// Hub 验证回调地址后，由 WebSubHandler 记录到期时间
+func (s *SubscriptionScheduler) renewWebSub(ctx context.Context, subscription *model.Subscription) error {
+	cfg := s.App.Config.SubscriptionConfig
+	margin := 2 * cfg.GetCheckInterval(subscription.CheckInterval)
+	if subscription.WebSubExpiresAt != nil && subscription.WebSubExpiresAt.After(time.Now().Add(margin)) {
+		return nil
+	}
+	if cfg == nil || cfg.PublicURL == "" {
+		return fmt.Errorf("未配置 subscription.public_url，无法使用 WebSub")
+	}
+
+	topic, err := s.feedURL(ctx, subscription)
+	if err != nil {
+		return err
+	}
+	if subscription.WebSubSecret == "" {
+		secret := feed.NewSecret()
+		if err := s.SubscriptionService.SetWebSubSecret(subscription.ID, secret); err != nil {
+			return fmt.Errorf("保存 WebSub 密钥失败: %v", err)
+		}
+		subscription.WebSubSecret = secret
+	}
+
+	err = feed.SendHubRequest(ctx, s.httpClient(), feed.HubRequest{
+		HubURL:       s.hubURL(),
+		Topic:        topic,
+		Callback:     services.WebSubCallbackURL(cfg.PublicURL, subscription.ID),
+		Secret:       subscription.WebSubSecret,
+		LeaseSeconds: cfg.LeaseSeconds,
+	})
+	if err != nil {
+		return err
+	}
+	s.logger.Infof("📡 已向 WebSub Hub 发送订阅请求: %s", topic)
+	return nil
+}
+
+// StopWebSub 向 Hub 取消订阅，删除订阅或不再使用 WebSub 时调用
+func (s *SubscriptionScheduler) StopWebSub(ctx context.Context, subscription *model.Subscription) error {
+	cfg := s.App.Config.SubscriptionConfig
+	if subscription.Mode != model.SubscriptionModeWebSub || subscription.SourceID == "" || cfg == nil || cfg.PublicURL == "" {
+		return nil
+	}
+
+	err := feed.SendHubRequest(ctx, s.httpClient(), feed.HubRequest{
+		HubURL:      s.hubURL(),
+		Topic:       services.SubscriptionFeedURL(subscription),
+		Callback:    services.WebSubCallbackURL(cfg.PublicURL, subscription.ID),
+		Unsubscribe: true,
+	})
+	if err != nil {
+		return err
+	}
+	return s.SubscriptionService.SetWebSubExpiresAt(subscription.ID, nil)
+}
+
+// feedURL 获取订阅的 Atom 订阅源地址，首次使用时获取频道ID或播放列表ID
+func (s *SubscriptionScheduler) feedURL(ctx context.Context, subscription *model.Subscription) (string, error) {
+	if subscription.SourceID == "" {
+		sourceID := sourceIDFromURL(subscription)
+		if sourceID == "" {
+			// 频道的自定义地址（/@handle、/c/name）需要通过 yt-dlp 获取频道ID
+			entries, err := s.listEntries(ctx, listURL(subscription), 1)
+			if err != nil {
+				return "", fmt.Errorf("获取频道ID失败: %v", err)
+			}
+			if len(entries) > 0 {
+				sourceID = sourceIDFromEntry(subscription, entries[0])
+			}
+		}
+		if sourceID == "" {
+			return "", fmt.Errorf("无法获取 %s 的频道ID或播放列表ID", subscription.URL)
+		}
+		s.saveSourceID(subscription, sourceID)
+	}
+	return services.SubscriptionFeedURL(subscription), nil
+}
+
+// saveSourceID 记录订阅的频道ID或播放列表ID
+func (s *SubscriptionScheduler) saveSourceID(subscription *model.Subscription, sourceID string) {
+	if sourceID == "" {
+		return
+	}
+	if err := s.SubscriptionService.SetSourceID(subscription.ID, sourceID); err != nil {
+		s.logger.Warnf("⚠️ 保存订阅 %s 的来源ID失败: %v", subscription.URL, err)
+		return
+	}
+	subscription.SourceID = sourceID
+}
+
+// listEntries 使用 yt-dlp 列出最新的 limit 个视频
+func (s *SubscriptionScheduler) listEntries(ctx context.Context, target string, limit int) ([]utils.PlaylistEntry, error) {
+	ytdlpPath, err := s.findYtDlp()
+	if err != nil {
+		return nil, err
+	}
+
+	var extraArgs []string
+	if proxy := s.App.Config.ProxyConfig; proxy != nil && proxy.UseProxy && proxy.ProxyHost != "" {
+		extraArgs = append(extraArgs, "--proxy", proxy.ProxyHost)
+	}
+	return utils.ListPlaylistEntries(ctx, ytdlpPath, target, limit, extraArgs...)
+}
+
+// httpClient 创建读取订阅源和请求 Hub 的 HTTP 客户端（支持代理）
+func (s *SubscriptionScheduler) httpClient() *http.Client {
+	client := &http.Client{Timeout: 30 * time.Second}
+	if proxy := s.App.Config.ProxyConfig; proxy != nil && proxy.UseProxy && proxy.ProxyHost != "" {
+		proxyURL, err := url.Parse(proxy.ProxyHost)
+		if err != nil {
+			s.logger.Warnf("⚠️ 代理URL解析失败: %v", err)
+			return client
+		}
+		client.Transport = &http.Transport{Proxy: http.ProxyURL(proxyURL)}
+	}
+	return client
+}
+
+// hubURL WebSub Hub 地址
+func (s *SubscriptionScheduler) hubURL() string {
+	if cfg := s.App.Config.SubscriptionConfig; cfg != nil && cfg.HubURL != "" {
+		return cfg.HubURL
+	}
+	return feed.DefaultHubURL
+}
+
+// findYtDlp 查找 yt-dlp 可执行文件
+func (s *SubscriptionScheduler) findYtDlp() (string, error) {
+	manager := utils.NewYtDlpManager(s.logger, s.App.Config.YtDlpPath)
+	if !manager.IsInstalled() {
+		return "", fmt.Errorf("未找到 yt-dlp，请确保已正确安装")
+	}
+	return manager.GetBinaryPath(), nil
+}
+
+// defaultInterval 订阅未设置检查间隔时使用的间隔
+func (s *SubscriptionScheduler) defaultInterval() time.Duration {
+	return s.App.Config.SubscriptionConfig.GetCheckInterval(0)
+}
+
+// maxItems 每次检查最新的视频数量
+func (s *SubscriptionScheduler) maxItems(subscription *model.Subscription) int {
+	return s.App.Config.SubscriptionConfig.GetMaxItems(subscription.MaxItems)
+}
+
+// pipeline 订阅的视频使用的处理流程
+func (s *SubscriptionScheduler) pipeline(subscription *model.Subscription) string {
+	return s.App.Config.SubscriptionConfig.GetPipeline(subscription.Pipeline)
+}
+
+// sourceIDFromURL 从订阅 URL 中获取播放列表ID或频道ID，自定义地址的频道返回空
+func sourceIDFromURL(subscription *model.Subscription) string {
+	u, err := url.Parse(subscription.URL)
+	if err != nil {
+		return ""
+	}
+	if subscription.Kind == model.SubscriptionKindPlaylist {
+		return u.Query().Get("list")
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) >= 2 && parts[0] == "channel" && strings.HasPrefix(parts[1], "UC") {
+		return parts[1]
+	}
+	return ""
+}
+
+// sourceIDFromEntry 从 yt-dlp 列出的视频中获取频道ID或播放列表ID
+func sourceIDFromEntry(subscription *model.Subscription, entry utils.PlaylistEntry) string {
+	if subscription.Kind == model.SubscriptionKindPlaylist {
+		return entry.PlaylistID
+	}
+	if entry.ChannelID != "" {
+		return entry.ChannelID
+	}
+	// 频道的“视频”标签页以频道ID作为播放列表ID
+	if strings.HasPrefix(entry.PlaylistID, "UC") {
+		return entry.PlaylistID
+	}
+	return ""
+}
+
+// listURL 获取用于列出视频的 URL
+// 频道主页会列出“视频”“Shorts”“直播”等标签页而不是视频，需要指定 videos 标签页
+func listURL(subscription *model.Subscription) string {
+	if subscription.Kind != model.SubscriptionKindChannel {
+		return subscription.URL
+	}
+	u, err := url.Parse(subscription.URL)
+	if err != nil || !strings.HasSuffix(u.Hostname(), "youtube.com") {
+		return subscription.URL
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	for _, tab := range []string{"/videos", "/shorts", "/streams"} {
+		if strings.HasSuffix(path, tab) {
+			return subscription.URL
+		}
+	}
+	u.Path = path + "/videos"
+	return u.String()
+}
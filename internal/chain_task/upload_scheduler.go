@@ -1,11 +1,13 @@
 package chain_task
 
 import (
-	"github.com/difyz9/ytb2bili/internal/chain_task/handlers"
+	"context"
+	"errors"
 	"github.com/difyz9/ytb2bili/internal/chain_task/manager"
+	"github.com/difyz9/ytb2bili/internal/chain_task/steps"
 	"github.com/difyz9/ytb2bili/internal/core"
 	"github.com/difyz9/ytb2bili/internal/core/services"
-	"github.com/difyz9/ytb2bili/internal/core/types"
+	"github.com/difyz9/ytb2bili/pkg/store/model"
 	"fmt"
 	"path/filepath"
 	"sync"
@@ -22,11 +24,16 @@ type UploadScheduler struct {
 	App               *core.AppServer
 	SavedVideoService *services.SavedVideoService
 	TaskStepService   *services.TaskStepService
+	ArtifactService   *services.ArtifactService
 	Db                *gorm.DB
 	Task              *cron.Cron
 	mutex             sync.Mutex
 	logger            *zap.SugaredLogger
 
+	// 根上下文，服务关闭时以 ErrShuttingDown 取消正在执行的上传
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+
 	// 上传队列跟踪
 	lastVideoUploadTime    time.Time // 最后一次视频上传时间
 	lastSubtitleUploadTime time.Time // 最后一次字幕上传时间
@@ -39,17 +46,40 @@ func NewUploadScheduler(
 	db *gorm.DB,
 	savedVideoService *services.SavedVideoService,
 	taskStepService *services.TaskStepService,
+	artifactService *services.ArtifactService,
 ) *UploadScheduler {
+	ctx, cancel := context.WithCancelCause(context.Background())
+
 	return &UploadScheduler{
 		App:               app,
 		Task:              task,
 		Db:                db,
 		SavedVideoService: savedVideoService,
 		TaskStepService:   taskStepService,
+		ArtifactService:   artifactService,
 		logger:            app.Logger,
+		ctx:               ctx,
+		cancel:            cancel,
+	}
+}
+
+// Shutdown 中断正在执行的上传任务
+func (s *UploadScheduler) Shutdown() {
+	s.cancel(ErrShuttingDown)
+}
+
+// updateStatus 更新视频状态并记录变更历史
+func (s *UploadScheduler) updateStatus(id uint, status model.VideoStatus, reason string) {
+	if err := s.SavedVideoService.Transition(id, status, model.StatusActorUploader, reason); err != nil {
+		s.logger.Errorf("更新视频状态失败: %v", err)
 	}
 }
 
+// shuttingDown 服务是否正在关闭
+func (s *UploadScheduler) shuttingDown() bool {
+	return errors.Is(context.Cause(s.ctx), ErrShuttingDown)
+}
+
 // SetUp 启动上传调度器
 func (s *UploadScheduler) SetUp() {
 	// 每5分钟检查一次是否需要上传
@@ -95,7 +125,7 @@ func (s *UploadScheduler) uploadNextVideo() error {
 
 	err := s.Db.Table("tb_saved_videos").
 		Select("id, video_id, title, created_at").
-		Where("status = ?", "200").
+		Where("status = ?", model.VideoStatusReady).
 		Where("deleted_at IS NULL").
 		Order("created_at ASC").
 		Limit(1).
@@ -113,23 +143,34 @@ func (s *UploadScheduler) uploadNextVideo() error {
 	video := videos[0]
 	s.logger.Infof("📤 开始上传视频: %s (VideoID: %s)", video.Title, video.VideoID)
 
-	// 更新状态为 '201' (上传视频中)
-	if err := s.SavedVideoService.UpdateStatus(video.ID, "201"); err != nil {
+	// 原子更新状态为 '201' (上传视频中)，多个实例共享数据库时只有一个实例上传
+	claimed, err := s.SavedVideoService.CompareAndSetStatus(video.ID, model.VideoStatusReady, model.VideoStatusUploadingVideo, model.StatusActorUploader, "开始上传视频")
+	if err != nil {
 		return fmt.Errorf("更新视频状态失败: %v", err)
 	}
+	if !claimed {
+		s.logger.Debugf("视频 %s 已被其他实例上传", video.VideoID)
+		return nil
+	}
 
 	// 执行上传任务
-	if err := s.executeUploadTask(video.VideoID, "上传到Bilibili"); err != nil {
+	if err := s.executeUploadTask(video.VideoID, steps.UploadVideo); err != nil {
+		// 服务关闭导致中断时恢复为 '200'，重启后重新上传
+		if s.shuttingDown() {
+			s.updateStatus(video.ID, model.VideoStatusReady, "服务关闭，等待重新上传")
+			return err
+		}
+		// 登录状态失效等需要人工处理的错误，更新状态为 '900'
+		if needsAttention(err) {
+			s.updateStatus(video.ID, model.VideoStatusNeedsAttention, fmt.Sprintf("上传视频失败: %v", err))
+			return fmt.Errorf("上传视频失败: %w", err)
+		}
 		// 上传失败，更新状态为 '299' (上传失败)
-		s.SavedVideoService.UpdateStatus(video.ID, "299")
+		s.updateStatus(video.ID, model.VideoStatusVideoUploadFailed, fmt.Sprintf("上传视频失败: %v", err))
 		return fmt.Errorf("上传视频失败: %v", err)
 	}
 
-	// 上传成功，更新状态为 '300' (视频已上传，待上传字幕)
-	if err := s.SavedVideoService.UpdateStatus(video.ID, "300"); err != nil {
-		return fmt.Errorf("更新视频状态失败: %v", err)
-	}
-
+	// 上传成功，状态已由 executeUploadTask 更新为 '300' (视频已上传，待上传字幕) 或 '400' (全部完成)
 	s.logger.Infof("✅ 视频上传成功: %s", video.VideoID)
 	return nil
 }
@@ -149,7 +190,7 @@ func (s *UploadScheduler) uploadNextSubtitle() error {
 
 	err := s.Db.Table("tb_saved_videos").
 		Select("id, video_id, title, updated_at, created_at").
-		Where("status = ? AND updated_at <= ?", "300", oneHourAgo).
+		Where("status = ? AND updated_at <= ?", model.VideoStatusVideoUploaded, oneHourAgo).
 		Where("deleted_at IS NULL").
 		Order("updated_at ASC").
 		Limit(1).
@@ -167,29 +208,44 @@ func (s *UploadScheduler) uploadNextSubtitle() error {
 	video := videos[0]
 	s.logger.Infof("📝 开始上传字幕: %s (VideoID: %s)", video.Title, video.VideoID)
 
-	// 更新状态为 '301' (上传字幕中)
-	if err := s.SavedVideoService.UpdateStatus(video.ID, "301"); err != nil {
+	// 原子更新状态为 '301' (上传字幕中)，多个实例共享数据库时只有一个实例上传
+	claimed, err := s.SavedVideoService.CompareAndSetStatus(video.ID, model.VideoStatusVideoUploaded, model.VideoStatusUploadingSubtitle, model.StatusActorUploader, "开始上传字幕")
+	if err != nil {
 		return fmt.Errorf("更新视频状态失败: %v", err)
 	}
+	if !claimed {
+		s.logger.Debugf("视频 %s 的字幕已被其他实例上传", video.VideoID)
+		return nil
+	}
 
 	// 执行上传字幕任务
-	if err := s.executeUploadTask(video.VideoID, "上传字幕到Bilibili"); err != nil {
+	if err := s.executeUploadTask(video.VideoID, steps.UploadSubtitle); err != nil {
+		if s.shuttingDown() {
+			s.updateStatus(video.ID, model.VideoStatusVideoUploaded, "服务关闭，等待重新上传")
+			return err
+		}
+		if needsAttention(err) {
+			s.updateStatus(video.ID, model.VideoStatusNeedsAttention, fmt.Sprintf("上传字幕失败: %v", err))
+			return fmt.Errorf("上传字幕失败: %w", err)
+		}
 		// 上传失败，更新状态为 '399' (字幕上传失败)
-		s.SavedVideoService.UpdateStatus(video.ID, "399")
+		s.updateStatus(video.ID, model.VideoStatusSubtitleUploadFailed, fmt.Sprintf("上传字幕失败: %v", err))
 		return fmt.Errorf("上传字幕失败: %v", err)
 	}
 
-	// 上传成功，更新状态为 '400' (全部完成)
-	if err := s.SavedVideoService.UpdateStatus(video.ID, "400"); err != nil {
-		return fmt.Errorf("更新视频状态失败: %v", err)
-	}
-
+	// 上传成功，状态已由 executeUploadTask 更新为 '400' (全部完成)
 	s.logger.Infof("✅ 字幕上传成功: %s", video.VideoID)
 	return nil
 }
 
 // executeUploadTask 执行上传任务
-func (s *UploadScheduler) executeUploadTask(videoID, taskName string) error {
+func (s *UploadScheduler) executeUploadTask(videoID string, stepKey steps.Key) error {
+	step, ok := steps.Get(stepKey)
+	if !ok || step.Stage != steps.StageUpload {
+		return fmt.Errorf("未知的任务类型: %s", stepKey)
+	}
+	taskName := string(step.Key)
+
 	// 获取视频信息
 	savedVideo, err := s.SavedVideoService.GetVideoByVideoID(videoID)
 	if err != nil {
@@ -202,72 +258,81 @@ func (s *UploadScheduler) executeUploadTask(videoID, taskName string) error {
 		return fmt.Errorf("获取文件上传目录失败: %v", err)
 	}
 
-	// 创建状态管理器
-	stateManager := manager.NewStateManager(savedVideo.ID, savedVideo.VideoID, currentDir, savedVideo.CreatedAt)
+	// 创建状态管理器（恢复封面、稿件ID等之前步骤的产物）
+	stateManager := manager.NewStateManager(savedVideo.ID, savedVideo.VideoID, currentDir, savedVideo.CreatedAt, s.ArtifactService)
+	stateManager.Events = s.App.Events
 
 	// 更新步骤状态为运行中
 	if err := s.TaskStepService.UpdateTaskStepStatus(videoID, taskName, "running"); err != nil {
 		s.logger.Errorf("更新任务步骤状态失败: %v", err)
 	}
 
-	// 创建任务链
-	chain := manager.NewTaskChain()
-	var task types.Task
-
-	// 根据任务名称创建对应的任务
-	switch taskName {
-	case "上传到Bilibili":
-		task = handlers.NewUploadToBilibili("上传到Bilibili", s.App, stateManager, s.App.CosClient, s.SavedVideoService)
-	case "上传字幕到Bilibili":
-		task = handlers.NewUploadSubtitleToBilibili("上传字幕到Bilibili", s.App, stateManager, s.App.CosClient, s.SavedVideoService)
-	default:
-		return fmt.Errorf("未知的任务类型: %s", taskName)
+	// 获取视频使用的处理流程，流程配置无效时按默认流程上传
+	pipeline, err := steps.Resolve(s.App.Config, savedVideo.Pipeline)
+	if err != nil {
+		s.logger.Warnf("获取处理流程失败，使用默认流程: %v", err)
+		pipeline, _ = steps.Resolve(s.App.Config, "")
 	}
 
-	// 添加任务到链
-	chain.AddTask(task)
+	// 创建任务链
+	chain := manager.NewTaskChain()
+	chain.AddTask(step.New(steps.Deps{App: s.App, State: stateManager, DB: s.Db, SavedVideoService: s.SavedVideoService, Options: pipeline.Options(stepKey)}))
 
 	s.logger.Infof("开始执行上传任务: %s (VideoID: %s)", taskName, videoID)
 
-	// 执行任务
-	result := chain.Run(false)
-
-	// 检查执行结果
-	success := true
-	var errorMsg string
-	if errorMsgInterface, exists := result["error"]; exists && errorMsgInterface != nil {
-		success = false
-		errorMsg = fmt.Sprintf("%v", errorMsgInterface)
+	// 执行任务，临时错误自动重试
+	result := runStep(s.ctx, newStepPolicy(s.App.Config.WorkerConfig, taskName), func(ctx context.Context) error {
+		return chain.Run(ctx, false)
+	}, func(attempt int, err error, delay time.Duration) {
+		s.logger.Warnf("任务 %s 第 %d 次执行失败，%v 后自动重试: %v", taskName, attempt, delay, err)
+		if err := recordStepRetry(s.TaskStepService, videoID, taskName, attempt, err, delay); err != nil {
+			s.logger.Errorf("更新任务步骤重试信息失败: %v", err)
+		}
+	})
+	status, runErr := result.Status, result.Err
+	if err := recordStepResult(s.TaskStepService, videoID, taskName, result); err != nil {
+		s.logger.Errorf("更新任务步骤重试信息失败: %v", err)
 	}
 
 	// 更新步骤状态
-	if success {
+	if status == model.TaskStepStatusCancelled {
+		if err := s.TaskStepService.UpdateTaskStepStatus(videoID, taskName, status, runErr.Error()); err != nil {
+			s.logger.Errorf("更新任务步骤状态失败: %v", err)
+		}
+		s.logger.Warnf("任务 %s 已中断: %v", taskName, runErr)
+		return runErr
+	}
+	if runErr == nil {
 		if err := s.TaskStepService.UpdateTaskStepStatus(videoID, taskName, "completed"); err != nil {
 			s.logger.Errorf("更新任务步骤状态失败: %v", err)
 		}
-		if err := s.TaskStepService.UpdateTaskStepResult(videoID, taskName, result); err != nil {
+		if err := s.TaskStepService.UpdateTaskStepResult(videoID, taskName, stateManager.Artifacts.Snapshot()); err != nil {
 			s.logger.Errorf("更新任务步骤结果失败: %v", err)
 		}
 		
-		// 如果是上传视频任务且成功，更新主状态为 "300" (已上传)
-		if taskName == "上传到Bilibili" {
-			if video, err := s.SavedVideoService.GetVideoByVideoID(videoID); err == nil {
-				if err := s.SavedVideoService.UpdateStatus(video.ID, "300"); err != nil {
-					s.logger.Errorf("更新视频主状态失败: %v", err)
-				} else {
-					s.logger.Infof("视频主状态已更新为 300 (已上传)")
-				}
+		// 上传视频成功后更新主状态为 "300" (已上传)，流程中没有上传字幕步骤时直接更新为 "400" (全部完成)
+		// 上传字幕成功后更新为 "400" (全部完成)
+		videoStatus, reason := model.VideoStatusCompleted, "字幕上传成功"
+		if stepKey == steps.UploadVideo {
+			videoStatus, reason = model.VideoStatusVideoUploaded, "视频上传成功，等待上传字幕"
+			if !pipeline.Has(steps.UploadSubtitle) {
+				videoStatus, reason = model.VideoStatusCompleted, "视频上传成功，流程中没有上传字幕步骤"
 			}
 		}
+		if err := s.SavedVideoService.Transition(savedVideo.ID, videoStatus, model.StatusActorUploader, reason); err != nil {
+			s.logger.Errorf("更新视频主状态失败: %v", err)
+		} else {
+			s.logger.Infof("视频主状态已更新为 %s", videoStatus)
+		}
 
 		s.logger.Infof("任务 %s 执行成功", taskName)
 		return nil
 	} else {
-		if err := s.TaskStepService.UpdateTaskStepStatus(videoID, taskName, "failed", errorMsg); err != nil {
+		if err := s.TaskStepService.UpdateTaskStepStatus(videoID, taskName, "failed", runErr.Error()); err != nil {
 			s.logger.Errorf("更新任务步骤状态失败: %v", err)
 		}
-		s.logger.Errorf("任务 %s 执行失败: %s", taskName, errorMsg)
-		return fmt.Errorf("任务执行失败: %s", errorMsg)
+		s.logger.Errorf("任务 %s 执行失败: %v", taskName, runErr)
+		return fmt.Errorf("任务执行失败: %w", runErr)
 	}
 }
 
@@ -275,16 +340,16 @@ func (s *UploadScheduler) executeUploadTask(videoID, taskName string) error {
 func (s *UploadScheduler) ExecuteManualUpload(videoID, taskType string) error {
 	s.logger.Infof("🎯 手动执行上传任务: VideoID=%s, TaskType=%s", videoID, taskType)
 	
-	var taskName string
+	var stepKey steps.Key
 	switch taskType {
 	case "video":
-		taskName = "上传到Bilibili"
+		stepKey = steps.UploadVideo
 	case "subtitle":
-		taskName = "上传字幕到Bilibili"
+		stepKey = steps.UploadSubtitle
 	default:
 		return fmt.Errorf("未知的任务类型: %s", taskType)
 	}
 	
-	return s.executeUploadTask(videoID, taskName)
+	return s.executeUploadTask(videoID, stepKey)
 }
 
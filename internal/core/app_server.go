@@ -3,6 +3,7 @@ package core
 import (
 	"github.com/difyz9/ytb2bili/internal/core/types"
 	"github.com/difyz9/ytb2bili/pkg/cos"
+	"github.com/difyz9/ytb2bili/pkg/events"
 	"context"
 	"fmt"
 	"io"
@@ -21,11 +22,12 @@ type AppServer struct {
 	Logger    *zap.SugaredLogger
 	DB        *gorm.DB
 	CosClient *cos.CosClient // COS客户端
+	Events    *events.Bus    // 任务进度事件总线
 
 }
 
 // NewServer 创建新的服务器实例
-func NewServer(config *types.AppConfig, logger *zap.SugaredLogger) *AppServer {
+func NewServer(config *types.AppConfig, logger *zap.SugaredLogger, bus *events.Bus) *AppServer {
 	// 设置Gin模式
 	gin.SetMode(gin.ReleaseMode)
 	gin.DefaultWriter = io.Discard
@@ -33,6 +35,7 @@ func NewServer(config *types.AppConfig, logger *zap.SugaredLogger) *AppServer {
 		Config: config,
 		Engine: gin.Default(),
 		Logger: logger,
+		Events: bus,
 	}
 }
 
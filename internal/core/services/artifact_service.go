@@ -0,0 +1,59 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/difyz9/ytb2bili/pkg/store/model"
+
+	"gorm.io/gorm"
+)
+
+// ArtifactService 视频产物服务
+type ArtifactService struct {
+	DB *gorm.DB
+}
+
+// NewArtifactService 创建视频产物服务实例
+func NewArtifactService(db *gorm.DB) *ArtifactService {
+	return &ArtifactService{
+		DB: db,
+	}
+}
+
+// SaveArtifact 保存视频产物，同一视频同一类型的产物只保留最新值
+func (s *ArtifactService) SaveArtifact(videoID, kind, value string) error {
+	var artifact model.VideoArtifact
+	err := s.DB.Unscoped().Where("video_id = ? AND kind = ?", videoID, kind).First(&artifact).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		artifact = model.VideoArtifact{
+			VideoID: videoID,
+			Kind:    kind,
+			Value:   value,
+		}
+		return s.DB.Create(&artifact).Error
+	}
+	if err != nil {
+		return fmt.Errorf("查询视频产物失败: %v", err)
+	}
+
+	return s.DB.Unscoped().Model(&artifact).Updates(map[string]interface{}{
+		"value":      value,
+		"deleted_at": nil,
+	}).Error
+}
+
+// GetArtifactsByVideoID 获取视频的所有产物
+func (s *ArtifactService) GetArtifactsByVideoID(videoID string) ([]model.VideoArtifact, error) {
+	var artifacts []model.VideoArtifact
+	err := s.DB.Where("video_id = ?", videoID).Find(&artifacts).Error
+	return artifacts, err
+}
+
+// DeleteArtifactsByVideoID 删除指定视频的所有产物（软删除）
+func (s *ArtifactService) DeleteArtifactsByVideoID(videoID string) error {
+	if err := s.DB.Where("video_id = ?", videoID).Delete(&model.VideoArtifact{}).Error; err != nil {
+		return fmt.Errorf("删除视频产物失败: %v", err)
+	}
+	return nil
+}
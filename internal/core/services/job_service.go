@@ -0,0 +1,241 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/difyz9/ytb2bili/pkg/store/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrLeaseLost 任务的租约已过期并被其他工作者领取
+var ErrLeaseLost = errors.New("任务租约已失效")
+
+// JobService 任务队列服务
+// 多个服务实例共享同一个数据库时，通过租约保证同一任务同时只由一个工作者执行
+type JobService struct {
+	DB *gorm.DB
+}
+
+// NewJobService 创建任务队列服务实例
+func NewJobService(db *gorm.DB) *JobService {
+	return &JobService{
+		DB: db,
+	}
+}
+
+// Enqueue 将视频的任务加入队列，返回是否加入成功
+// 同一视频已有排队或执行中的任务时不会重复加入
+func (s *JobService) Enqueue(videoID, kind, stepKey string) (bool, error) {
+	active := videoID
+	job := &model.Job{
+		VideoID:       videoID,
+		ActiveVideoID: &active,
+		Kind:          kind,
+		StepKey:       stepKey,
+		Status:        model.JobStatusQueued,
+	}
+	result := s.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(job)
+	if result.Error != nil {
+		return false, fmt.Errorf("加入任务队列失败: %v", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// EnqueuePendingVideos 将尚未加入队列的待处理视频（状态为 001，且记录了提交来源）加入队列
+// 返回加入的视频数量
+func (s *JobService) EnqueuePendingVideos(limit int) (int, error) {
+	var videoIDs []string
+	err := s.DB.Model(&model.SavedVideo{}).
+		Scopes(pendingVideos).
+		Where("NOT EXISTS (?)", s.DB.Model(&model.Job{}).
+			Select("1").
+			Where("tb_jobs.active_video_id = tb_saved_videos.video_id")).
+		Order("created_at ASC").
+		Limit(limit).
+		Pluck("video_id", &videoIDs).Error
+	if err != nil {
+		return 0, fmt.Errorf("查询待处理视频失败: %v", err)
+	}
+
+	enqueued := 0
+	for _, videoID := range videoIDs {
+		ok, err := s.Enqueue(videoID, model.JobKindChain, "")
+		if err != nil {
+			return enqueued, err
+		}
+		if ok {
+			enqueued++
+		}
+	}
+	return enqueued, nil
+}
+
+// Claim 领取最多 limit 个任务，领取的任务租约持续 lease
+// 租约已过期的执行中任务（工作者异常退出）也会被重新领取
+// 使用 SELECT ... FOR UPDATE SKIP LOCKED，多个实例同时领取时互不阻塞且不会领取到同一任务
+func (s *JobService) Claim(workerID string, limit int, lease time.Duration) ([]*model.Job, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	var jobs []*model.Job
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? OR (status = ? AND lease_expires_at < ?)", model.JobStatusQueued, model.JobStatusRunning, now).
+			Order("id ASC").
+			Limit(limit).
+			Find(&jobs).Error
+		if err != nil {
+			return err
+		}
+
+		expiresAt := now.Add(lease)
+		for _, job := range jobs {
+			job.Status = model.JobStatusRunning
+			job.LockedBy = workerID
+			job.LeaseExpiresAt = &expiresAt
+			job.HeartbeatAt = &now
+			job.Attempts++
+			err := tx.Model(job).Updates(map[string]interface{}{
+				"status":           job.Status,
+				"locked_by":        job.LockedBy,
+				"lease_expires_at": job.LeaseExpiresAt,
+				"heartbeat_at":     job.HeartbeatAt,
+				"attempts":         job.Attempts,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("领取任务失败: %v", err)
+	}
+	return jobs, nil
+}
+
+// Heartbeat 续约任务，已请求取消时返回请求取消的执行者，否则返回空字符串
+// 租约已被其他工作者领取时返回 ErrLeaseLost；领取次数作为令牌，同一实例重新领取后旧的执行者也会失去租约
+func (s *JobService) Heartbeat(job *model.Job, workerID string, lease time.Duration) (string, error) {
+	now := time.Now()
+	result := s.DB.Model(&model.Job{}).
+		Where("id = ? AND locked_by = ? AND attempts = ? AND status = ?", job.ID, workerID, job.Attempts, model.JobStatusRunning).
+		Updates(map[string]interface{}{
+			"lease_expires_at": now.Add(lease),
+			"heartbeat_at":     now,
+		})
+	if result.Error != nil {
+		return "", fmt.Errorf("任务续约失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return "", ErrLeaseLost
+	}
+
+	var current model.Job
+	if err := s.DB.Select("cancel_requested", "cancelled_by").First(&current, job.ID).Error; err != nil {
+		return "", fmt.Errorf("查询任务失败: %v", err)
+	}
+	if !current.CancelRequested {
+		return "", nil
+	}
+	if current.CancelledBy == "" {
+		return model.StatusActorScheduler, nil
+	}
+	return current.CancelledBy, nil
+}
+
+// Complete 结束任务，仅持有租约的工作者可以结束
+func (s *JobService) Complete(job *model.Job, workerID string) error {
+	return s.finish(job, workerID, model.JobStatusDone)
+}
+
+// Cancel 将任务标记为已取消，仅持有租约的工作者可以取消
+func (s *JobService) Cancel(job *model.Job, workerID string) error {
+	return s.finish(job, workerID, model.JobStatusCancelled)
+}
+
+func (s *JobService) finish(job *model.Job, workerID, status string) error {
+	result := s.DB.Model(&model.Job{}).
+		Where("id = ? AND locked_by = ? AND attempts = ? AND status = ?", job.ID, workerID, job.Attempts, model.JobStatusRunning).
+		Updates(map[string]interface{}{
+			"status":           status,
+			"active_video_id":  nil,
+			"lease_expires_at": nil,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("更新任务状态失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// Release 释放租约，任务重新排队（例如服务关闭时），可以立即被其他工作者领取
+func (s *JobService) Release(job *model.Job, workerID string) error {
+	result := s.DB.Model(&model.Job{}).
+		Where("id = ? AND locked_by = ? AND attempts = ? AND status = ?", job.ID, workerID, job.Attempts, model.JobStatusRunning).
+		Updates(map[string]interface{}{
+			"status":           model.JobStatusQueued,
+			"locked_by":        "",
+			"lease_expires_at": nil,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("释放任务失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// CancelQueued 取消视频尚未被领取的任务，返回是否取消了任务
+func (s *JobService) CancelQueued(videoID string) (bool, error) {
+	result := s.DB.Model(&model.Job{}).
+		Where("active_video_id = ? AND status = ?", videoID, model.JobStatusQueued).
+		Updates(map[string]interface{}{
+			"status":          model.JobStatusCancelled,
+			"active_video_id": nil,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("取消任务失败: %v", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RequestCancel 请求取消视频正在执行的任务，由持有租约的工作者在下次心跳时取消
+// actor 为请求取消的执行者，返回是否找到正在执行的任务
+func (s *JobService) RequestCancel(videoID, actor string) (bool, error) {
+	result := s.DB.Model(&model.Job{}).
+		Where("active_video_id = ? AND status = ?", videoID, model.JobStatusRunning).
+		Updates(map[string]interface{}{
+			"cancel_requested": true,
+			"cancelled_by":     actor,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("请求取消任务失败: %v", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// GetOrphanedVideoIDs 获取处于处理中但没有排队或执行中任务的视频
+// 通常是旧版本遗留或任务结束前服务异常退出，需要重新加入队列
+func (s *JobService) GetOrphanedVideoIDs() ([]string, error) {
+	var videoIDs []string
+	err := s.DB.Model(&model.SavedVideo{}).
+		Where("status = ?", model.VideoStatusProcessing).
+		Where("NOT EXISTS (?)", s.DB.Model(&model.Job{}).
+			Select("1").
+			Where("tb_jobs.active_video_id = tb_saved_videos.video_id")).
+		Pluck("video_id", &videoIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询遗留的处理中视频失败: %v", err)
+	}
+	return videoIDs, nil
+}
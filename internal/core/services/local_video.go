@@ -0,0 +1,75 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/difyz9/ytb2bili/pkg/source"
+	"github.com/difyz9/ytb2bili/pkg/store/model"
+	"github.com/difyz9/ytb2bili/pkg/subtitle"
+)
+
+// NewLocalVideo 根据本地视频文件生成待处理的视频记录（未保存），调用方设置处理流程后保存
+// 标题和描述取自与视频同名的 JSON 文件，同名的 SRT 文件作为原语言字幕，返回值表示是否带有字幕
+func NewLocalVideo(videoPath string) (*model.SavedVideo, bool, error) {
+	videoURL, err := source.LocalFileURL(videoPath)
+	if err != nil {
+		return nil, false, err
+	}
+	id, _, err := source.Parse(videoURL)
+	if err != nil {
+		return nil, false, err
+	}
+	sidecar, err := source.ReadSidecar(videoPath)
+	if err != nil {
+		return nil, false, err
+	}
+
+	subtitles, err := readLocalSubtitles(source.SidecarPath(videoPath, ".srt"), sidecar.Language)
+	if err != nil {
+		return nil, false, err
+	}
+	video := &model.SavedVideo{
+		VideoID:     id.Key(),
+		CanonicalID: id.String(),
+		URL:         videoURL,
+		Title:       sidecar.Title,
+		Description: sidecar.Description,
+		Status:      model.VideoStatusPending,
+		Origin:      model.OriginLocal,
+		SavedAt:     time.Now().Format(time.RFC3339),
+	}
+	// 没有字幕时留空，由语音识别生成
+	if len(subtitles) > 0 {
+		subtitlesJSON, err := json.Marshal(subtitles)
+		if err != nil {
+			return nil, false, err
+		}
+		video.Subtitles = string(subtitlesJSON)
+	}
+	return video, len(subtitles) > 0, nil
+}
+
+// readLocalSubtitles 读取 SRT 字幕并转换为插件提交的字幕格式，文件不存在时返回空列表
+func readLocalSubtitles(srtPath, language string) ([]model.SavedVideoSubtitle, error) {
+	subtitles := make([]model.SavedVideoSubtitle, 0)
+	sub, err := subtitle.ReadFile(srtPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return subtitles, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析字幕文件 %s 失败: %v", srtPath, err)
+	}
+	for _, cue := range sub.Cues {
+		subtitles = append(subtitles, model.SavedVideoSubtitle{
+			Text:     cue.Text,
+			Offset:   cue.Start.Seconds(),
+			Duration: cue.Duration().Seconds(),
+			Lang:     language,
+		})
+	}
+	return subtitles, nil
+}
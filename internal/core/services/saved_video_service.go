@@ -1,32 +1,130 @@
 package services
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/difyz9/ytb2bili/pkg/events"
 	"github.com/difyz9/ytb2bili/pkg/store/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrInvalidStatusTransition 不允许的视频状态变更
+var ErrInvalidStatusTransition = errors.New("不允许的视频状态变更")
+
 // SavedVideoService 保存视频服务
 type SavedVideoService struct {
-	DB *gorm.DB
+	DB     *gorm.DB
+	Events *events.Bus // 视频状态变化时发布事件
 }
 
 // NewSavedVideoService 创建保存视频服务实例
-func NewSavedVideoService(db *gorm.DB) *SavedVideoService {
+func NewSavedVideoService(db *gorm.DB, bus *events.Bus) *SavedVideoService {
 	return &SavedVideoService{
-		DB: db,
+		DB:     db,
+		Events: bus,
 	}
 }
 
-// GetPendingVideos 获取待处理的视频列表（状态为 001 且 subtitles 不为空）
+// pendingVideos 待处理视频的查询条件：状态为 001，且记录了提交来源
+// 没有提交来源的旧记录按原规则判断：有插件提交的字幕或来自订阅
+func pendingVideos(db *gorm.DB) *gorm.DB {
+	return db.Where("status = ?", model.VideoStatusPending).
+		Where("((origin IS NOT NULL AND origin != '') OR (subtitles IS NOT NULL AND subtitles != '') OR subscription_id IS NOT NULL)")
+}
+
+// GetPendingVideos 获取待处理的视频列表（状态为 001，且记录了提交来源）
 func (s *SavedVideoService) GetPendingVideos(limit int) ([]model.SavedVideo, error) {
 	var videos []model.SavedVideo
-	err := s.DB.Where("status = ? AND subtitles IS NOT NULL AND subtitles != ''", "001").
+	err := s.DB.Scopes(pendingVideos).
 		Order("created_at ASC").
 		Limit(limit).
 		Find(&videos).Error
 	return videos, err
 }
 
+// ClaimPendingVideo 原子地将待处理视频标记为处理中
+// 只有状态为 001，或租约过期后重新领取时仍为 002 才会更新成功，视频已被取消等情况返回 false
+func (s *SavedVideoService) ClaimPendingVideo(id uint, actor string) (bool, error) {
+	return s.transition(id, []model.VideoStatus{model.VideoStatusPending, model.VideoStatusProcessing}, model.VideoStatusProcessing, actor, "开始处理")
+}
+
+// CompareAndSetStatus 仅当视频状态为 from 时更新为 to，返回是否更新成功
+func (s *SavedVideoService) CompareAndSetStatus(id uint, from, to model.VideoStatus, actor, reason string) (bool, error) {
+	return s.transition(id, []model.VideoStatus{from}, to, actor, reason)
+}
+
+// Transition 将视频状态更新为 to 并记录变更历史
+// 状态变更不在允许的范围内时返回 ErrInvalidStatusTransition
+func (s *SavedVideoService) Transition(id uint, to model.VideoStatus, actor, reason string) error {
+	_, err := s.transition(id, nil, to, actor, reason)
+	return err
+}
+
+// transition 在事务中锁定视频记录，检查并更新状态、记录变更历史
+// from 不为空时仅当当前状态在 from 中才更新，否则返回 false
+func (s *SavedVideoService) transition(id uint, from []model.VideoStatus, to model.VideoStatus, actor, reason string) (bool, error) {
+	var video model.SavedVideo
+	updated := false
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "video_id", "status").
+			First(&video, id).Error
+		if err != nil {
+			return err
+		}
+		if len(from) > 0 && !containsStatus(from, video.Status) {
+			return nil
+		}
+		if !video.Status.CanTransitionTo(to) {
+			return fmt.Errorf("%w: %s → %s", ErrInvalidStatusTransition, video.Status, to)
+		}
+
+		if err := tx.Model(&model.SavedVideo{}).Where("id = ?", id).Update("status", to).Error; err != nil {
+			return err
+		}
+		history := &model.VideoStatusHistory{
+			VideoID:    video.VideoID,
+			FromStatus: video.Status,
+			ToStatus:   to,
+			Actor:      actor,
+			Reason:     reason,
+		}
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+		updated = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	if updated {
+		s.publishStatus(video.VideoID, to)
+	}
+	return updated, nil
+}
+
+func containsStatus(list []model.VideoStatus, status model.VideoStatus) bool {
+	for _, s := range list {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// GetStatusHistory 获取视频的状态变更历史，按时间顺序排列
+func (s *SavedVideoService) GetStatusHistory(videoID string) ([]model.VideoStatusHistory, error) {
+	var history []model.VideoStatusHistory
+	err := s.DB.Where("video_id = ?", videoID).
+		Order("id ASC").
+		Find(&history).Error
+	return history, err
+}
+
 // GetVideoByID 根据ID获取视频
 func (s *SavedVideoService) GetVideoByID(id uint) (*model.SavedVideo, error) {
 	var video model.SavedVideo
@@ -47,21 +145,130 @@ func (s *SavedVideoService) GetVideoByVideoID(videoID string) (*model.SavedVideo
 	return &video, nil
 }
 
-// UpdateStatus 更新视频状态
-func (s *SavedVideoService) UpdateStatus(id uint, status string) error {
-	return s.DB.Model(&model.SavedVideo{}).
-		Where("id = ?", id).
-		Update("status", status).Error
+// publishStatus 发布视频状态变化事件
+func (s *SavedVideoService) publishStatus(videoID string, status model.VideoStatus) {
+	s.Events.Publish(events.Event{
+		Type:    events.VideoStatus,
+		VideoID: videoID,
+		Status:  string(status),
+	})
+}
+
+// GetExistingVideoIDs 返回 videoIDs 中已保存过的视频ID（包括已删除的）
+func (s *SavedVideoService) GetExistingVideoIDs(videoIDs []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	if len(videoIDs) == 0 {
+		return existing, nil
+	}
+	var found []string
+	err := s.DB.Unscoped().Model(&model.SavedVideo{}).
+		Where("video_id IN ?", videoIDs).
+		Pluck("video_id", &found).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, videoID := range found {
+		existing[videoID] = true
+	}
+	return existing, nil
 }
 
-// UpdateVideo 更新视频信息
+// UpdateVideo 更新视频信息，状态不会被更新，需通过 Transition 变更
 func (s *SavedVideoService) UpdateVideo(video *model.SavedVideo) error {
-	return s.DB.Save(video).Error
+	return s.DB.Omit("status").Save(video).Error
 }
 
-// CreateVideo 创建新视频记录
-func (s *SavedVideoService) CreateVideo(video *model.SavedVideo) error {
-	return s.DB.Create(video).Error
+// CreateVideo 创建新视频记录并记录初始状态
+func (s *SavedVideoService) CreateVideo(video *model.SavedVideo, actor, reason string) error {
+	if video.Status == "" {
+		video.Status = model.VideoStatusPending
+	}
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(video).Error; err != nil {
+			return err
+		}
+		return tx.Create(&model.VideoStatusHistory{
+			VideoID:  video.VideoID,
+			ToStatus: video.Status,
+			Actor:    actor,
+			Reason:   reason,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+	s.publishStatus(video.VideoID, video.Status)
+	return nil
+}
+
+// CreateVideos 在一个事务中创建多个视频记录并记录初始状态，任意一个失败时全部回滚
+func (s *SavedVideoService) CreateVideos(videos []*model.SavedVideo, actor, reason string) error {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		for _, video := range videos {
+			if video.Status == "" {
+				video.Status = model.VideoStatusPending
+			}
+			if err := tx.Create(video).Error; err != nil {
+				return fmt.Errorf("保存视频 %s 失败: %w", video.VideoID, err)
+			}
+			err := tx.Create(&model.VideoStatusHistory{
+				VideoID:  video.VideoID,
+				ToStatus: video.Status,
+				Actor:    actor,
+				Reason:   reason,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, video := range videos {
+		s.publishStatus(video.VideoID, video.Status)
+	}
+	return nil
+}
+
+// ResubmitVideo 保存重新提交的视频并重置为待处理，记录状态变更历史
+// 已删除的记录会被恢复，不检查原来的状态；视频正在处理或上传时返回 ErrInvalidStatusTransition
+func (s *SavedVideoService) ResubmitVideo(video *model.SavedVideo, actor string) error {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var current model.SavedVideo
+		err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "status", "deleted_at").
+			First(&current, video.ID).Error
+		if err != nil {
+			return err
+		}
+
+		reason := "重新提交"
+		if current.DeletedAt.Valid {
+			reason = "恢复已删除的视频"
+		} else if !current.Status.CanTransitionTo(model.VideoStatusPending) {
+			return fmt.Errorf("%w: %s → %s", ErrInvalidStatusTransition, current.Status, model.VideoStatusPending)
+		}
+
+		video.Status = model.VideoStatusPending
+		video.DeletedAt = gorm.DeletedAt{}
+		if err := tx.Unscoped().Save(video).Error; err != nil {
+			return err
+		}
+		return tx.Create(&model.VideoStatusHistory{
+			VideoID:    video.VideoID,
+			FromStatus: current.Status,
+			ToStatus:   video.Status,
+			Actor:      actor,
+			Reason:     reason,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+	s.publishStatus(video.VideoID, video.Status)
+	return nil
 }
 
 // DeleteVideo 删除视频（软删除）
@@ -70,7 +277,7 @@ func (s *SavedVideoService) DeleteVideo(id uint) error {
 }
 
 // ListVideos 获取视频列表（支持分页和状态筛选）
-func (s *SavedVideoService) ListVideos(page, pageSize int, status string) ([]model.SavedVideo, int64, error) {
+func (s *SavedVideoService) ListVideos(page, pageSize int, status model.VideoStatus) ([]model.SavedVideo, int64, error) {
 	var videos []model.SavedVideo
 	var total int64
 
@@ -105,25 +312,102 @@ func (s *SavedVideoService) GetVideosByPlaylistID(playlistID string) ([]model.Sa
 	return videos, err
 }
 
-// UpdateVideoStatus 批量更新视频状态
-func (s *SavedVideoService) UpdateVideoStatus(ids []uint, status string) error {
-	return s.DB.Model(&model.SavedVideo{}).
-		Where("id IN ?", ids).
-		Update("status", status).Error
-}
-
 // GetVideosPaginated 获取分页视频列表（用于前端显示）
 func (s *SavedVideoService) GetVideosPaginated(offset, limit int) ([]model.SavedVideo, int, error) {
-	var videos []model.SavedVideo
-	var total int64
+	return s.QueryVideos(VideoListQuery{}, offset, limit)
+}
 
-	// 获取总数
-	if err := s.DB.Model(&model.SavedVideo{}).Count(&total).Error; err != nil {
+// VideoListQuery 视频列表的筛选和排序条件，为空的条件不筛选
+type VideoListQuery struct {
+	Status         model.VideoStatus
+	Keyword        string // 标题包含的文字
+	Uploader       string // 原视频作者，模糊匹配
+	ChannelID      string
+	Language       string // 原视频语言，en 可以匹配 en-US
+	Tag            string // 原视频标签，完全匹配
+	Category       string // 原视频分类，完全匹配
+	UploadedAfter  string // 发布日期不早于，格式 2006-01-02
+	UploadedBefore string // 发布日期不晚于，格式 2006-01-02
+	MinDuration    int    // 最短时长（秒）
+	MaxDuration    int    // 最长时长（秒）
+	MinViews       int64  // 最少播放量
+	Sort           string // 排序字段，见 VideoSortFields，为空时按创建时间
+	Asc            bool   // 是否升序，默认降序
+}
+
+// VideoSortFields 视频列表可以排序的字段及对应的列
+var VideoSortFields = map[string]string{
+	"created_at":  "created_at",
+	"upload_date": "upload_date",
+	"duration":    "duration",
+	"view_count":  "view_count",
+	"title":       "title",
+	"uploader":    "uploader",
+}
+
+// QueryVideos 按条件获取分页视频列表
+func (s *SavedVideoService) QueryVideos(q VideoListQuery, offset, limit int) ([]model.SavedVideo, int, error) {
+	column, ok := VideoSortFields[q.Sort]
+	if q.Sort == "" {
+		column, ok = "created_at", true
+	}
+	if !ok {
+		return nil, 0, fmt.Errorf("不支持的排序字段: %s", q.Sort)
+	}
+
+	query := s.DB.Model(&model.SavedVideo{})
+	if q.Status != "" {
+		query = query.Where("status = ?", q.Status)
+	}
+	if q.Keyword != "" {
+		query = query.Where("title LIKE ?", "%"+q.Keyword+"%")
+	}
+	if q.Uploader != "" {
+		query = query.Where("uploader LIKE ?", "%"+q.Uploader+"%")
+	}
+	if q.ChannelID != "" {
+		query = query.Where("channel_id = ?", q.ChannelID)
+	}
+	if q.Language != "" {
+		query = query.Where("(language = ? OR language LIKE ?)", q.Language, q.Language+"-%")
+	}
+	// 标签和分类保存为 JSON 数组，按带引号的 JSON 字符串匹配
+	if q.Tag != "" {
+		tag, _ := json.Marshal(q.Tag)
+		query = query.Where("source_tags LIKE ?", "%"+string(tag)+"%")
+	}
+	if q.Category != "" {
+		category, _ := json.Marshal(q.Category)
+		query = query.Where("categories LIKE ?", "%"+string(category)+"%")
+	}
+	if q.UploadedAfter != "" {
+		query = query.Where("upload_date <> '' AND upload_date >= ?", q.UploadedAfter)
+	}
+	if q.UploadedBefore != "" {
+		query = query.Where("upload_date <> '' AND upload_date <= ?", q.UploadedBefore)
+	}
+	if q.MinDuration > 0 {
+		query = query.Where("duration >= ?", q.MinDuration)
+	}
+	if q.MaxDuration > 0 {
+		query = query.Where("duration > 0 AND duration <= ?", q.MaxDuration)
+	}
+	if q.MinViews > 0 {
+		query = query.Where("view_count >= ?", q.MinViews)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	// 分页查询
-	err := s.DB.Order("created_at DESC").
+	order := column + " DESC"
+	if q.Asc {
+		order = column + " ASC"
+	}
+	var videos []model.SavedVideo
+	err := query.Order(order).
+		Order("id DESC").
 		Offset(offset).
 		Limit(limit).
 		Find(&videos).Error
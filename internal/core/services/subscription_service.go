@@ -0,0 +1,251 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/difyz9/ytb2bili/pkg/feed"
+	"github.com/difyz9/ytb2bili/pkg/source"
+	"github.com/difyz9/ytb2bili/pkg/store/model"
+
+	"gorm.io/gorm"
+)
+
+// ErrCheckInProgress 订阅正在被其他实例或请求检查
+var ErrCheckInProgress = errors.New("订阅正在检查中")
+
+// SubscriptionService 订阅服务
+type SubscriptionService struct {
+	DB                *gorm.DB
+	SavedVideoService *SavedVideoService
+}
+
+// NewSubscriptionService 创建订阅服务实例
+func NewSubscriptionService(db *gorm.DB, savedVideoService *SavedVideoService) *SubscriptionService {
+	return &SubscriptionService{
+		DB:                db,
+		SavedVideoService: savedVideoService,
+	}
+}
+
+// SubscriptionVideo 订阅中发现的视频
+type SubscriptionVideo struct {
+	VideoID    string // 平台内的视频ID，URL 为空时作为 YouTube 视频ID
+	URL        string // 为空时使用 YouTube 视频地址
+	Title      string
+	PlaylistID string
+}
+
+// ListSubscriptions 获取所有订阅
+func (s *SubscriptionService) ListSubscriptions() ([]model.Subscription, error) {
+	var subscriptions []model.Subscription
+	err := s.DB.Order("id ASC").Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// GetSubscription 根据ID获取订阅
+func (s *SubscriptionService) GetSubscription(id uint) (*model.Subscription, error) {
+	var subscription model.Subscription
+	if err := s.DB.First(&subscription, id).Error; err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// CreateSubscription 创建订阅
+func (s *SubscriptionService) CreateSubscription(subscription *model.Subscription) error {
+	return s.DB.Create(subscription).Error
+}
+
+// UpdateSubscription 更新订阅设置
+func (s *SubscriptionService) UpdateSubscription(subscription *model.Subscription) error {
+	return s.DB.Model(subscription).
+		Select("kind", "url", "title", "enabled", "pipeline", "max_items", "check_interval", "mode", "source_id", "filter_rules").
+		Updates(subscription).Error
+}
+
+// DeleteSubscription 删除订阅（软删除），已加入的视频不受影响
+func (s *SubscriptionService) DeleteSubscription(id uint) error {
+	return s.DB.Delete(&model.Subscription{}, id).Error
+}
+
+// GetDueSubscriptions 获取已启用且到了检查时间的订阅
+// defaultInterval 为订阅未设置检查间隔时使用的间隔
+func (s *SubscriptionService) GetDueSubscriptions(now time.Time, defaultInterval time.Duration) ([]model.Subscription, error) {
+	var subscriptions []model.Subscription
+	if err := s.DB.Where("enabled = ?", true).Order("id ASC").Find(&subscriptions).Error; err != nil {
+		return nil, fmt.Errorf("查询订阅失败: %v", err)
+	}
+
+	due := subscriptions[:0]
+	for _, subscription := range subscriptions {
+		interval := defaultInterval
+		if subscription.CheckInterval > 0 {
+			interval = time.Duration(subscription.CheckInterval) * time.Minute
+		}
+		if subscription.LastCheckedAt == nil || !subscription.LastCheckedAt.Add(interval).After(now) {
+			due = append(due, subscription)
+		}
+	}
+	return due, nil
+}
+
+// ClaimCheck 原子地将订阅的检查时间更新为 now，返回是否更新成功
+// 多个实例共享数据库时，只有一个实例会检查同一订阅
+func (s *SubscriptionService) ClaimCheck(subscription *model.Subscription, now time.Time) (bool, error) {
+	query := s.DB.Model(&model.Subscription{}).Where("id = ?", subscription.ID)
+	if subscription.LastCheckedAt == nil {
+		query = query.Where("last_checked_at IS NULL")
+	} else {
+		query = query.Where("last_checked_at = ?", *subscription.LastCheckedAt)
+	}
+	result := query.Update("last_checked_at", now)
+	if result.Error != nil {
+		return false, fmt.Errorf("更新订阅检查时间失败: %v", result.Error)
+	}
+	if result.RowsAffected == 1 {
+		subscription.LastCheckedAt = &now
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RecordCheck 记录检查结果：更新名称和错误信息
+func (s *SubscriptionService) RecordCheck(id uint, title string, checkErr error) error {
+	updates := map[string]interface{}{
+		"last_error": "",
+	}
+	if title != "" {
+		updates["title"] = title
+	}
+	if checkErr != nil {
+		updates["last_error"] = checkErr.Error()
+	}
+	return s.DB.Model(&model.Subscription{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// EnqueueVideos 将尚未保存的视频加入待处理列表，返回加入的数量
+// yt-dlp 检查、订阅源轮询和 WebSub 推送都通过这里保存视频
+func (s *SubscriptionService) EnqueueVideos(subscription *model.Subscription, videos []SubscriptionVideo, pipeline string) (int, error) {
+	// 统一转换为规范ID，视频ID与插件提交的视频一致
+	ids := make([]source.ID, len(videos))
+	videoIDs := make([]string, 0, len(videos))
+	for i, v := range videos {
+		if v.URL == "" {
+			videos[i].URL = fmt.Sprintf("https://www.youtube.com/watch?v=%s", v.VideoID)
+		}
+		id, _, err := source.Parse(videos[i].URL)
+		if err != nil {
+			continue
+		}
+		ids[i] = id
+		videoIDs = append(videoIDs, id.Key())
+	}
+
+	existing, err := s.SavedVideoService.GetExistingVideoIDs(videoIDs)
+	if err != nil {
+		return 0, fmt.Errorf("查询已保存的视频失败: %v", err)
+	}
+
+	added := 0
+	var lastErr error
+	for i, v := range videos {
+		// 无法识别的链接和已保存过的视频（包括已删除的）不处理
+		videoID := ids[i].Key()
+		if ids[i].VideoID == "" || existing[videoID] {
+			continue
+		}
+		existing[videoID] = true
+
+		subscriptionID := subscription.ID
+		video := &model.SavedVideo{
+			VideoID:        videoID,
+			CanonicalID:    ids[i].String(),
+			URL:            v.URL,
+			Title:          v.Title,
+			Status:         model.VideoStatusPending,
+			Pipeline:       pipeline,
+			Origin:         model.OriginSubscription,
+			SubscriptionID: &subscriptionID,
+			SavedAt:        time.Now().Format(time.RFC3339),
+		}
+		if subscription.Kind == model.SubscriptionKindPlaylist {
+			video.PlaylistID = v.PlaylistID
+		}
+
+		reason := fmt.Sprintf("订阅 %s 的新视频", subscription.URL)
+		if err := s.SavedVideoService.CreateVideo(video, model.StatusActorSubscription, reason); err != nil {
+			// 其他实例或插件同时保存了该视频
+			lastErr = fmt.Errorf("保存视频 %s 失败: %v", videoID, err)
+			continue
+		}
+		added++
+	}
+
+	if added > 0 {
+		if err := s.DB.Model(&model.Subscription{}).Where("id = ?", subscription.ID).
+			Update("video_count", gorm.Expr("video_count + ?", added)).Error; err != nil {
+			return added, fmt.Errorf("更新订阅视频数量失败: %v", err)
+		}
+	}
+	if added == 0 && lastErr != nil {
+		return 0, lastErr
+	}
+	return added, nil
+}
+
+// EnqueueFeedEntries 将订阅源中的视频加入待处理列表
+// 早于 since 发布的视频不处理，避免首次订阅或推送旧视频的更新时加入大量旧视频
+func (s *SubscriptionService) EnqueueFeedEntries(subscription *model.Subscription, entries []feed.Entry, pipeline string, since time.Time) (int, error) {
+	videos := make([]SubscriptionVideo, 0, len(entries))
+	for _, entry := range entries {
+		if entry.VideoID == "" {
+			continue
+		}
+		if !since.IsZero() && !entry.Published.IsZero() && entry.Published.Before(since) {
+			continue
+		}
+		video := SubscriptionVideo{
+			VideoID: entry.VideoID,
+			URL:     entry.Link,
+			Title:   entry.Title,
+		}
+		if subscription.Kind == model.SubscriptionKindPlaylist {
+			video.PlaylistID = subscription.SourceID
+		}
+		videos = append(videos, video)
+	}
+	return s.EnqueueVideos(subscription, videos, pipeline)
+}
+
+// SetSourceID 记录订阅的频道ID或播放列表ID
+func (s *SubscriptionService) SetSourceID(id uint, sourceID string) error {
+	return s.DB.Model(&model.Subscription{}).Where("id = ?", id).Update("source_id", sourceID).Error
+}
+
+// SetWebSubSecret 更新 WebSub 签名密钥，向 Hub 发送订阅请求前调用
+func (s *SubscriptionService) SetWebSubSecret(id uint, secret string) error {
+	return s.DB.Model(&model.Subscription{}).Where("id = ?", id).Update("websub_secret", secret).Error
+}
+
+// SetWebSubExpiresAt 记录 WebSub 订阅的到期时间，为 nil 表示未订阅
+func (s *SubscriptionService) SetWebSubExpiresAt(id uint, expiresAt *time.Time) error {
+	return s.DB.Model(&model.Subscription{}).Where("id = ?", id).Update("websub_expires_at", expiresAt).Error
+}
+
+// SubscriptionFeedURL 订阅的 Atom 订阅源地址，也是 WebSub 订阅的主题，尚未获取来源ID时为空
+func SubscriptionFeedURL(subscription *model.Subscription) string {
+	if subscription.SourceID == "" {
+		return ""
+	}
+	if subscription.Kind == model.SubscriptionKindPlaylist {
+		return feed.PlaylistFeedURL(subscription.SourceID)
+	}
+	return feed.ChannelFeedURL(subscription.SourceID)
+}
+
+// WebSubCallbackURL Hub 推送新视频的回调地址
+func WebSubCallbackURL(publicURL string, id uint) string {
+	return fmt.Sprintf("%s/api/v1/websub/%d", strings.TrimSuffix(publicURL, "/"), id)
+}
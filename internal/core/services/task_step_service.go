@@ -6,6 +6,7 @@ import (
 	"log"
 	"time"
 
+	"github.com/difyz9/ytb2bili/pkg/events"
 	"github.com/difyz9/ytb2bili/pkg/store/model"
 
 	"gorm.io/gorm"
@@ -13,50 +14,71 @@ import (
 
 // TaskStepService 任务步骤服务
 type TaskStepService struct {
-	DB *gorm.DB
+	DB     *gorm.DB
+	Events *events.Bus // 步骤状态变化时发布事件
 }
 
 // NewTaskStepService 创建任务步骤服务实例
-func NewTaskStepService(db *gorm.DB) *TaskStepService {
+func NewTaskStepService(db *gorm.DB, bus *events.Bus) *TaskStepService {
 	return &TaskStepService{
-		DB: db,
+		DB:     db,
+		Events: bus,
 	}
 }
 
-// InitTaskSteps 初始化视频的任务步骤
-func (s *TaskStepService) InitTaskSteps(videoID string) error {
-	// 定义标准任务步骤
-	steps := []struct {
-		Name     string
-		Order    int
-		CanRetry bool
-	}{
-		{"下载视频", 1, true},
-		{"生成字幕", 2, true},
-		{"翻译字幕", 3, true},
-		{"生成元数据", 4, true},
-		{"上传到Bilibili", 5, true},
-		// {"上传字幕到Bilibili", 6, true},
-	}
+// stepEventTypes 步骤状态对应的事件类型
+var stepEventTypes = map[string]events.Type{
+	model.TaskStepStatusPending:   events.StepPending,
+	model.TaskStepStatusRunning:   events.StepStarted,
+	model.TaskStepStatusCompleted: events.StepCompleted,
+	model.TaskStepStatusFailed:    events.StepFailed,
+	model.TaskStepStatusSkipped:   events.StepCancelled,
+	model.TaskStepStatusCancelled: events.StepCancelled,
+}
+
+// StepSpec 初始化任务步骤时使用的步骤信息
+type StepSpec struct {
+	Key      string
+	Name     string
+	Order    int
+	CanRetry bool
+}
 
-	// 检查是否已经初始化过
-	var count int64
-	if err := s.DB.Model(&model.TaskStep{}).Where("video_id = ?", videoID).Count(&count).Error; err != nil {
+// InitTaskSteps 初始化视频的任务步骤
+// 补充缺少的步骤记录，并删除不在当前流程中且尚未执行的步骤（例如切换了字幕来源）
+func (s *TaskStepService) InitTaskSteps(videoID string, specs []StepSpec) error {
+	var existing []model.TaskStep
+	if err := s.DB.Where("video_id = ?", videoID).Find(&existing).Error; err != nil {
 		return err
 	}
 
-	if count > 0 {
-		return nil // 已经初始化过，跳过
+	wanted := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		wanted[spec.Key] = true
+	}
+	present := make(map[string]bool, len(existing))
+	for _, step := range existing {
+		if !wanted[step.StepKey] && step.Status == model.TaskStepStatusPending {
+			if err := s.DB.Delete(&model.TaskStep{}, step.ID).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		present[step.StepKey] = true
 	}
 
-	// 创建任务步骤记录
-	for _, step := range steps {
+	// 创建缺少的任务步骤记录
+	for _, spec := range specs {
+		if present[spec.Key] {
+			continue
+		}
 		taskStep := &model.TaskStep{
 			VideoID:   videoID,
-			StepName:  step.Name,
-			StepOrder: step.Order,
+			StepKey:   spec.Key,
+			StepName:  spec.Name,
+			StepOrder: spec.Order,
 			Status:    model.TaskStepStatusPending,
-			CanRetry:  step.CanRetry,
+			CanRetry:  spec.CanRetry,
 		}
 
 		if err := s.DB.Create(taskStep).Error; err != nil {
@@ -67,6 +89,22 @@ func (s *TaskStepService) InitTaskSteps(videoID string) error {
 	return nil
 }
 
+// BackfillStepKeys 为旧版本创建的任务步骤补全步骤标识，names 为步骤名称到标识的映射
+func (s *TaskStepService) BackfillStepKeys(names map[string]string) error {
+	for name, key := range names {
+		result := s.DB.Model(&model.TaskStep{}).
+			Where("step_name = ? AND (step_key = '' OR step_key IS NULL)", name).
+			Update("step_key", key)
+		if result.Error != nil {
+			return fmt.Errorf("补全任务步骤标识失败: %v", result.Error)
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("Backfilled step_key %s for %d task steps named %s", key, result.RowsAffected, name)
+		}
+	}
+	return nil
+}
+
 // GetTaskStepsByVideoID 根据视频ID获取任务步骤列表
 func (s *TaskStepService) GetTaskStepsByVideoID(videoID string) ([]model.TaskStep, error) {
 	var steps []model.TaskStep
@@ -77,7 +115,7 @@ func (s *TaskStepService) GetTaskStepsByVideoID(videoID string) ([]model.TaskSte
 }
 
 // UpdateTaskStepStatus 更新任务步骤状态
-func (s *TaskStepService) UpdateTaskStepStatus(videoID, stepName, status string, errorMsg ...string) error {
+func (s *TaskStepService) UpdateTaskStepStatus(videoID, stepKey, status string, errorMsg ...string) error {
 	updates := map[string]interface{}{
 		"status": status,
 	}
@@ -86,12 +124,12 @@ func (s *TaskStepService) UpdateTaskStepStatus(videoID, stepName, status string,
 	now := time.Now()
 	if status == model.TaskStepStatusRunning {
 		updates["start_time"] = &now
-	} else if status == model.TaskStepStatusCompleted || status == model.TaskStepStatusFailed {
+	} else if status == model.TaskStepStatusCompleted || status == model.TaskStepStatusFailed || status == model.TaskStepStatusCancelled {
 		updates["end_time"] = &now
 
 		// 计算执行时长
 		var step model.TaskStep
-		if err := s.DB.Where("video_id = ? AND step_name = ?", videoID, stepName).First(&step).Error; err == nil {
+		if err := s.DB.Where("video_id = ? AND step_key = ?", videoID, stepKey).First(&step).Error; err == nil {
 			if step.StartTime != nil {
 				duration := now.Sub(*step.StartTime).Milliseconds()
 				updates["duration"] = duration
@@ -100,17 +138,33 @@ func (s *TaskStepService) UpdateTaskStepStatus(videoID, stepName, status string,
 	}
 
 	// 设置错误信息
+	message := ""
 	if len(errorMsg) > 0 && errorMsg[0] != "" {
-		updates["error_msg"] = errorMsg[0]
+		message = errorMsg[0]
+		updates["error_msg"] = message
 	}
 
-	return s.DB.Model(&model.TaskStep{}).
-		Where("video_id = ? AND step_name = ?", videoID, stepName).
+	err := s.DB.Model(&model.TaskStep{}).
+		Where("video_id = ? AND step_key = ?", videoID, stepKey).
 		Updates(updates).Error
+	if err != nil {
+		return err
+	}
+
+	if eventType, ok := stepEventTypes[status]; ok {
+		s.Events.Publish(events.Event{
+			Type:    eventType,
+			VideoID: videoID,
+			Step:    stepKey,
+			Status:  status,
+			Message: message,
+		})
+	}
+	return nil
 }
 
 // UpdateTaskStepResult 更新任务步骤执行结果
-func (s *TaskStepService) UpdateTaskStepResult(videoID, stepName string, resultData interface{}) error {
+func (s *TaskStepService) UpdateTaskStepResult(videoID, stepKey string, resultData interface{}) error {
 	var jsonData string
 	if resultData != nil {
 		if jsonBytes, err := json.Marshal(resultData); err == nil {
@@ -119,30 +173,43 @@ func (s *TaskStepService) UpdateTaskStepResult(videoID, stepName string, resultD
 	}
 
 	return s.DB.Model(&model.TaskStep{}).
-		Where("video_id = ? AND step_name = ?", videoID, stepName).
+		Where("video_id = ? AND step_key = ?", videoID, stepKey).
 		Update("result_data", jsonData).Error
 }
 
+// UpdateTaskStepAttempt 记录任务步骤的执行次数和最近一次错误
+func (s *TaskStepService) UpdateTaskStepAttempt(videoID, stepKey string, attempts int, errorClass, errorMsg string) error {
+	return s.DB.Model(&model.TaskStep{}).
+		Where("video_id = ? AND step_key = ?", videoID, stepKey).
+		Updates(map[string]interface{}{
+			"attempts":    attempts,
+			"error_class": errorClass,
+			"error_msg":   errorMsg,
+		}).Error
+}
+
 // ResetTaskStep 重置任务步骤（用于重新执行）
-func (s *TaskStepService) ResetTaskStep(videoID, stepName string) error {
+func (s *TaskStepService) ResetTaskStep(videoID, stepKey string) error {
 	updates := map[string]interface{}{
 		"status":      model.TaskStepStatusPending,
 		"start_time":  nil,
 		"end_time":    nil,
 		"duration":    0,
 		"error_msg":   "",
+		"error_class": "",
+		"attempts":    0,
 		"result_data": "",
 	}
 
 	return s.DB.Model(&model.TaskStep{}).
-		Where("video_id = ? AND step_name = ?", videoID, stepName).
+		Where("video_id = ? AND step_key = ?", videoID, stepKey).
 		Updates(updates).Error
 }
 
-// GetTaskStepByName 根据视频ID和步骤名称获取特定步骤
-func (s *TaskStepService) GetTaskStepByName(videoID, stepName string) (*model.TaskStep, error) {
+// GetTaskStepByKey 根据视频ID和步骤标识获取特定步骤
+func (s *TaskStepService) GetTaskStepByKey(videoID, stepKey string) (*model.TaskStep, error) {
 	var step model.TaskStep
-	err := s.DB.Where("video_id = ? AND step_name = ?", videoID, stepName).First(&step).Error
+	err := s.DB.Where("video_id = ? AND step_key = ?", videoID, stepKey).First(&step).Error
 	if err != nil {
 		return nil, err
 	}
@@ -187,47 +254,29 @@ func (s *TaskStepService) GetTaskProgress(videoID string) (map[string]interface{
 	return progress, nil
 }
 
-// ResetAllRunningTasks 重置所有运行中的任务
-func (s *TaskStepService) ResetAllRunningTasks() error {
-	// 开始事务
-	tx := s.DB.Begin()
-
-	// 重置所有状态为 Running 的任务步骤为 Pending
-	result := tx.Model(&model.TaskStep{}).
-		Where("status = ?", "Running").
-		Update("status", "Pending")
-
+// ResetOrphanedSteps 将没有对应任务在执行的"运行中"步骤重置为待执行
+// 只处理 stepKeys 中的步骤；视频有排队或执行中的任务时，步骤由持有租约的工作者负责
+func (s *TaskStepService) ResetOrphanedSteps(stepKeys []string) error {
+	result := s.DB.Model(&model.TaskStep{}).
+		Where("status = ?", model.TaskStepStatusRunning).
+		Where("step_key IN ?", stepKeys).
+		Where("NOT EXISTS (?)", s.DB.Model(&model.Job{}).
+			Select("1").
+			Where("tb_jobs.active_video_id = tb_task_steps.video_id")).
+		Update("status", model.TaskStepStatusPending)
 	if result.Error != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to reset running task steps: %v", result.Error)
-	}
-
-	taskStepsAffected := result.RowsAffected
-
-	// 重置相关视频的状态
-	// 将状态为 "002"(处理中) 的视频重置为 "001"(待处理)
-	videoResult := tx.Model(&model.SavedVideo{}).
-		Where("status = ?", "002").
-		Update("status", "001")
-
-	if videoResult.Error != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to reset running video status: %v", videoResult.Error)
+		return fmt.Errorf("failed to reset orphaned task steps: %v", result.Error)
 	}
 
-	videosAffected := videoResult.RowsAffected
-
-	// 提交事务
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("failed to commit transaction: %v", err)
+	if result.RowsAffected > 0 {
+		log.Printf("Reset %d orphaned running task steps to pending", result.RowsAffected)
 	}
-
-	log.Printf("Reset %d running task steps and %d videos (from processing to pending status)", taskStepsAffected, videosAffected)
 	return nil
 }
 
-// GetPendingSteps 获取所有状态为pending的任务步骤
-func (s *TaskStepService) GetPendingSteps() ([]*model.TaskStep, error) {
+// GetPendingSteps 获取指定步骤中所有状态为pending的任务步骤
+// 只返回已经执行过任务链的视频，尚未开始处理的视频由任务链统一执行
+func (s *TaskStepService) GetPendingSteps(stepKeys []string) ([]*model.TaskStep, error) {
 	var steps []*model.TaskStep
 
 	// 使用 JOIN 查询，只获取未删除视频的待处理步骤
@@ -235,6 +284,8 @@ func (s *TaskStepService) GetPendingSteps() ([]*model.TaskStep, error) {
 		Select("tb_task_steps.*").
 		Joins("INNER JOIN tb_saved_videos ON tb_task_steps.video_id = tb_saved_videos.video_id").
 		Where("tb_task_steps.status = ?", model.TaskStepStatusPending).
+		Where("tb_task_steps.step_key IN ?", stepKeys).
+		Where("tb_saved_videos.status NOT IN ?", []model.VideoStatus{model.VideoStatusPending, model.VideoStatusProcessing}).
 		Where("tb_task_steps.deleted_at IS NULL").
 		Where("tb_saved_videos.deleted_at IS NULL").
 		Order("tb_task_steps.created_at ASC").
@@ -247,6 +298,17 @@ func (s *TaskStepService) GetPendingSteps() ([]*model.TaskStep, error) {
 	return steps, nil
 }
 
+// CancelPendingSteps 将视频所有待执行的步骤标记为已取消
+func (s *TaskStepService) CancelPendingSteps(videoID string) error {
+	result := s.DB.Model(&model.TaskStep{}).
+		Where("video_id = ? AND status = ?", videoID, model.TaskStepStatusPending).
+		Update("status", model.TaskStepStatusCancelled)
+	if result.Error != nil {
+		return fmt.Errorf("取消待执行步骤失败: %v", result.Error)
+	}
+	return nil
+}
+
 // DeleteTaskStepsByVideoID 删除指定视频的所有任务步骤（软删除）
 func (s *TaskStepService) DeleteTaskStepsByVideoID(videoID string) error {
 	result := s.DB.Where("video_id = ?", videoID).Delete(&model.TaskStep{})
@@ -2,7 +2,7 @@ package services
 
 import (
 	"github.com/difyz9/ytb2bili/internal/core/models"
-	"github.com/difyz9/ytb2bili/pkg/utils"
+	"github.com/difyz9/ytb2bili/pkg/source"
 	"fmt"
 	"time"
 
@@ -28,7 +28,11 @@ func (s *TbVideoService) SaveUrl(data *SaveUrlRequest) (*models.TbVideo, error)
 	defer s.lock.Unlock()
 
 	// 从URL提取videoId，如果请求中没有提供的话
-	videoId := utils.ExtractVideoID(data.Url)
+	id, _, err := source.ParseRemote(data.Url)
+	if err != nil {
+		return nil, err
+	}
+	videoId := id.Key()
 
 	// 转换OperationType从string到int
 
@@ -4,6 +4,13 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/difyz9/ytb2bili/pkg/asr"
+	"github.com/difyz9/ytb2bili/pkg/download"
+	"github.com/difyz9/ytb2bili/pkg/filter"
+	"github.com/difyz9/ytb2bili/pkg/subtitle"
 
 	"github.com/BurntSushi/toml"
 )
@@ -25,14 +32,25 @@ type AppConfig struct {
 	PrimaryAIService         string                    `toml:"primary_ai_service"`          // 首选AI服务提供商
 	TenCosConfig             *TencentCosConfig         `toml:"TenCosConfig"`                // 腾讯云 COS 存储配置
 	OpenAICompatibleConfig   *OpenAICompatibleConfig   `toml:"OpenAICompatibleConfig"`      // OpenAI兼容API配置
-	BaiduTransConfig    *BaiduTransConfig    `toml:"BaiduTransConfig"`    // 百度翻译服务配置
-	DeepSeekTransConfig *DeepSeekTransConfig `toml:"DeepSeekTransConfig"` // DeepSeek翻译服务配置
-	GeminiConfig        *GeminiConfig        `toml:"GeminiConfig"`        // Gemini多模态服务配置
-	TranslatorConfig    *TranslatorConfig    `toml:"TranslatorConfig"`    // 翻译器总配置
-	ProxyConfig         *ProxyConfig         `toml:"ProxyConfig"`         // 代理配置
-	AnalyticsConfig     *AnalyticsConfig     `toml:"AnalyticsConfig"`     // 数据分析配置
-	BilibiliConfig      *BilibiliConfig      `toml:"BilibiliConfig"`      // Bilibili上传配置
-	WhisperConfig       *WhisperConfig       `toml:"WhisperConfig"`       // Whisper 语音识别配置
+	BaiduTransConfig     *BaiduTransConfig     `toml:"BaiduTransConfig"`     // 百度翻译服务配置
+	DeepSeekTransConfig  *DeepSeekTransConfig  `toml:"DeepSeekTransConfig"`  // DeepSeek翻译服务配置
+	GeminiConfig         *GeminiConfig         `toml:"GeminiConfig"`         // Gemini多模态服务配置
+	TranslatorConfig     *TranslatorConfig     `toml:"TranslatorConfig"`     // 翻译器总配置
+	ProxyConfig          *ProxyConfig          `toml:"ProxyConfig"`          // 代理配置
+	AnalyticsConfig      *AnalyticsConfig      `toml:"AnalyticsConfig"`      // 数据分析配置
+	BilibiliConfig       *BilibiliConfig       `toml:"BilibiliConfig"`       // Bilibili上传配置
+	WhisperConfig        *WhisperConfig        `toml:"WhisperConfig"`        // Whisper 语音识别配置
+	WorkerConfig         *WorkerConfig         `toml:"WorkerConfig"`         // 任务并发配置
+	PipelineConfig       *PipelineConfig       `toml:"PipelineConfig"`       // 处理流程配置
+	SubscriptionConfig   *SubscriptionConfig   `toml:"SubscriptionConfig"`   // 订阅配置
+	LocalImportConfig    *LocalImportConfig    `toml:"LocalImportConfig"`    // 本地文件导入配置
+	FilterConfig         *FilterConfig         `toml:"FilterConfig"`         // 全局视频过滤规则
+	SubtitleSourceConfig *SubtitleSourceConfig `toml:"SubtitleSourceConfig"` // 获取字幕的来源和优先级
+	DownloadConfig       *DownloadConfig       `toml:"DownloadConfig"`       // 视频下载的格式和大小限制
+	ChapterConfig        *ChapterConfig        `toml:"ChapterConfig"`        // 视频章节的来源和简介中的章节目录
+	ASRConfig            *ASRConfig            `toml:"ASRConfig"`            // 语音识别服务和顺序
+	SegmentConfig        *SegmentConfig        `toml:"SegmentConfig"`        // 字幕重新分段的行长、行数、显示时长和阅读速度
+	SubtitleOutputConfig *SubtitleOutputConfig `toml:"SubtitleOutputConfig"` // 双语字幕、ASS 样式和上传到B站的字幕
 }
 
 // BilibiliConfig Bilibili上传配置
@@ -42,8 +60,8 @@ type BilibiliConfig struct {
 	NoReprint           int    `toml:"no_reprint"`            // 0=允许转载, 1=禁止转载
 	UseOriginalTitle    bool   `toml:"use_original_title"`    // true=使用原视频标题, false=使用AI生成标题
 	UseOriginalDesc     bool   `toml:"use_original_desc"`     // true=使用原视频描述, false=使用AI生成描述
-	CustomTitleTemplate string `toml:"custom_title_template"` // 自定义标题模板，支持变量: {original_title}, {ai_title}
-	CustomDescTemplate  string `toml:"custom_desc_template"`  // 自定义描述模板，支持变量: {original_desc}, {ai_desc}
+	CustomTitleTemplate string `toml:"custom_title_template"` // 自定义标题模板，支持变量: {original_title}, {ai_title} 和原视频信息变量，如 {uploader}
+	CustomDescTemplate  string `toml:"custom_desc_template"`  // 自定义描述模板，支持变量: {original_desc}, {ai_desc} 和原视频信息变量，如 {uploader}, {upload_date}, {chapters}
 
 	// 新增配置项
 	Tid              int    `toml:"tid"`                // 分区ID（默认122，可自定义）
@@ -184,6 +202,7 @@ type AnalyticsConfig struct {
 }
 
 // WhisperConfig Whisper 语音识别配置
+// 已由 ASRConfig 代替，配置文件中只有 WhisperConfig 时转换为 ASRConfig
 type WhisperConfig struct {
 	Enabled   bool   `toml:"enabled"`    // 是否启用 Whisper
 	ModelPath string `toml:"model_path"` // Whisper 模型文件路径
@@ -191,6 +210,302 @@ type WhisperConfig struct {
 	Threads   int    `toml:"threads"`    // 使用的线程数
 }
 
+// WorkerConfig 任务并发配置
+type WorkerConfig struct {
+	Workers      int `toml:"workers"`       // 同时处理的视频数
+	MaxDownloads int `toml:"max_downloads"` // 同时进行的视频下载数
+	MaxFFmpeg    int `toml:"max_ffmpeg"`    // 同时运行的 ffmpeg 进程数
+	MaxASR       int `toml:"max_asr"`       // 同时进行的语音识别数
+	MaxLLM       int `toml:"max_llm"`       // 同时进行的大模型请求数（0 表示不限制）
+
+	StepTimeout  int            `toml:"step_timeout"`  // 单个步骤的默认超时时间（分钟），0 表示不限制
+	StepTimeouts map[string]int `toml:"step_timeouts"` // 按步骤标识（如 download、upload_video）单独设置的超时时间（分钟）
+
+	MaxRetries     int            `toml:"max_retries"`      // 临时错误的默认自动重试次数，0 表示不重试
+	StepMaxRetries map[string]int `toml:"step_max_retries"` // 按步骤标识单独设置的重试次数
+	RetryBaseDelay int            `toml:"retry_base_delay"` // 首次重试前的等待时间（秒），之后按指数增长
+	RetryMaxDelay  int            `toml:"retry_max_delay"`  // 重试等待时间的上限（秒）
+
+	InstanceID   string `toml:"instance_id"`   // 实例标识，多个实例共享数据库时用于区分任务的持有者，为空时使用主机名和进程号
+	LeaseTimeout int    `toml:"lease_timeout"` // 任务租约时长（秒），工作者异常退出后任务在租约过期后被重新领取
+}
+
+// GetStepTimeout 获取步骤的超时时间，0 表示不限制
+func (c *WorkerConfig) GetStepTimeout(stepKey string) time.Duration {
+	if c == nil {
+		return 0
+	}
+	minutes := c.StepTimeout
+	if m, ok := c.StepTimeouts[stepKey]; ok {
+		minutes = m
+	}
+	if minutes <= 0 {
+		return 0
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// GetMaxRetries 获取步骤遇到临时错误时的自动重试次数
+func (c *WorkerConfig) GetMaxRetries(stepKey string) int {
+	if c == nil {
+		return 0
+	}
+	retries := c.MaxRetries
+	if r, ok := c.StepMaxRetries[stepKey]; ok {
+		retries = r
+	}
+	if retries < 0 {
+		return 0
+	}
+	return retries
+}
+
+// PipelineConfig 处理流程配置
+// 视频提交时可以指定流程名称，未指定时使用 Default
+type PipelineConfig struct {
+	Default   string                         `toml:"default"`   // 默认流程名称，为空时按字幕配置自动选择步骤
+	Pipelines map[string]*PipelineDefinition `toml:"pipelines"` // 按名称定义的流程
+}
+
+// PipelineDefinition 流程定义
+// 步骤按列表顺序显示，执行顺序由步骤所需和产出的产物决定，互不依赖的步骤并发执行
+type PipelineDefinition struct {
+	Description string               `toml:"description"` // 流程说明
+	Steps       []PipelineStepConfig `toml:"steps"`       // 流程包含的步骤
+}
+
+// PipelineStepConfig 流程中的步骤
+type PipelineStepConfig struct {
+	Key     string                 `toml:"key"`     // 步骤标识，如 download、asr、translate
+	Options map[string]interface{} `toml:"options"` // 步骤选项，如 download 的 profile、asr 的 language、translate 的 group_size
+}
+
+// GetPipeline 获取指定名称的流程定义，name 为空时使用默认流程
+// 返回的名称为空表示按字幕配置自动选择步骤
+func (c *PipelineConfig) GetPipeline(name string) (string, *PipelineDefinition, bool) {
+	if c == nil {
+		return "", nil, name == ""
+	}
+	if name == "" {
+		name = c.Default
+	}
+	if name == "" {
+		return "", nil, true
+	}
+	pipeline, ok := c.Pipelines[name]
+	return name, pipeline, ok && pipeline != nil
+}
+
+// SubscriptionConfig 订阅配置
+// 订阅可以单独设置检查间隔、每次检查的视频数量和处理流程，未设置时使用这里的默认值
+type SubscriptionConfig struct {
+	Enabled       bool   `toml:"enabled"`        // 是否定时检查订阅
+	CheckInterval int    `toml:"check_interval"` // 默认检查间隔（分钟）
+	MaxItems      int    `toml:"max_items"`      // 每次检查最新的视频数量
+	Pipeline      string `toml:"pipeline"`       // 订阅的视频默认使用的处理流程，为空时使用默认流程
+
+	// WebSub 推送，YouTube 发布新视频时由 Hub 通知 {public_url}/api/v1/websub/{订阅ID}
+	PublicURL    string `toml:"public_url"`    // 外部可以访问的服务地址，如 https://example.com，为空时无法使用 WebSub
+	HubURL       string `toml:"hub_url"`       // WebSub Hub 地址
+	LeaseSeconds int    `toml:"lease_seconds"` // WebSub 订阅有效期（秒），到期前自动续订
+}
+
+// GetCheckInterval 获取订阅的检查间隔，interval 为订阅单独设置的间隔（分钟）
+func (c *SubscriptionConfig) GetCheckInterval(interval int) time.Duration {
+	if interval <= 0 && c != nil {
+		interval = c.CheckInterval
+	}
+	if interval <= 0 {
+		interval = 60
+	}
+	return time.Duration(interval) * time.Minute
+}
+
+// GetMaxItems 获取每次检查最新的视频数量，maxItems 为订阅单独设置的数量
+func (c *SubscriptionConfig) GetMaxItems(maxItems int) int {
+	if maxItems <= 0 && c != nil {
+		maxItems = c.MaxItems
+	}
+	if maxItems <= 0 {
+		maxItems = 10
+	}
+	return maxItems
+}
+
+// GetPipeline 获取订阅的视频使用的处理流程，pipeline 为订阅单独设置的流程
+func (c *SubscriptionConfig) GetPipeline(pipeline string) string {
+	if pipeline == "" && c != nil {
+		pipeline = c.Pipeline
+	}
+	return pipeline
+}
+
+// LocalImportConfig 本地文件导入配置
+// 上传的文件和监控目录中的文件不通过 yt-dlp 下载，标题和描述取自与视频同名的 JSON 文件
+type LocalImportConfig struct {
+	UploadDir        string `toml:"upload_dir"`        // 上传文件的保存目录，为空时使用 {data_path}/local
+	WatchDir         string `toml:"watch_dir"`         // 监控目录，新文件自动加入待处理列表，为空时不监控
+	ScanInterval     int    `toml:"scan_interval"`     // 监控目录扫描间隔（秒）
+	SettleSeconds    int    `toml:"settle_seconds"`    // 文件最后修改后经过多久才导入（秒），避免导入正在复制的文件
+	Pipeline         string `toml:"pipeline"`          // 没有字幕文件时使用的处理流程，需要包含 import_local 和语音识别
+	SubtitlePipeline string `toml:"subtitle_pipeline"` // 带有 SRT 字幕文件时使用的处理流程
+}
+
+// GetUploadDir 获取上传文件的保存目录
+func (c *LocalImportConfig) GetUploadDir(dataPath string) string {
+	if c != nil && c.UploadDir != "" {
+		return c.UploadDir
+	}
+	return filepath.Join(dataPath, "local")
+}
+
+// GetScanInterval 获取监控目录扫描间隔
+func (c *LocalImportConfig) GetScanInterval() time.Duration {
+	seconds := 60
+	if c != nil && c.ScanInterval > 0 {
+		seconds = c.ScanInterval
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// GetSettleTime 获取文件最后修改后等待导入的时间
+func (c *LocalImportConfig) GetSettleTime() time.Duration {
+	seconds := 30
+	if c != nil && c.SettleSeconds > 0 {
+		seconds = c.SettleSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// GetPipeline 获取本地文件使用的处理流程，hasSubtitles 表示是否带有字幕文件
+func (c *LocalImportConfig) GetPipeline(hasSubtitles bool) string {
+	if c == nil {
+		if hasSubtitles {
+			return "local-subtitles"
+		}
+		return "local"
+	}
+	if hasSubtitles && c.SubtitlePipeline != "" {
+		return c.SubtitlePipeline
+	}
+	return c.Pipeline
+}
+
+// FilterConfig 全局视频过滤规则，在下载前检查，订阅可以设置自己的规则覆盖其中的项
+type FilterConfig = filter.Rules
+
+// SubtitleSourceConfig 获取字幕的配置，acquire_subtitles 步骤按优先级依次尝试各个字幕来源
+type SubtitleSourceConfig struct {
+	Priority        []string `toml:"priority"`         // 字幕来源的优先级: manual_target、manual_source、auto、extension、asr
+	TargetLanguages []string `toml:"target_languages"` // 目标语言（翻译后的语言），按顺序匹配，如 zh-Hans、zh-CN、zh
+	SourceLanguages []string `toml:"source_languages"` // 原语言，按顺序匹配，为空时使用视频信息中的语言
+}
+
+// GetPriority 获取字幕来源的优先级，未配置时依次使用上传者字幕、自动字幕、插件字幕和语音识别
+func (c *SubtitleSourceConfig) GetPriority() []string {
+	if c == nil || len(c.Priority) == 0 {
+		return []string{"manual_target", "manual_source", "auto", "extension", "asr"}
+	}
+	return c.Priority
+}
+
+// GetTargetLanguages 获取目标语言，未配置时为简体中文
+func (c *SubtitleSourceConfig) GetTargetLanguages() []string {
+	if c == nil || len(c.TargetLanguages) == 0 {
+		return []string{"zh-Hans", "zh-CN", "zh"}
+	}
+	return c.TargetLanguages
+}
+
+// DownloadProfile 下载配置，包括最高分辨率、优先的视频编码、文件大小限制和容器格式
+type DownloadProfile = download.Profile
+
+// DownloadConfig 视频下载配置
+// download 步骤可以通过 profile 选项指定使用的配置，单个视频也可以指定配置并覆盖其中的项
+type DownloadConfig struct {
+	Default  string                      `toml:"default"`  // 默认使用的配置名称
+	Profiles map[string]*DownloadProfile `toml:"profiles"` // 按名称定义的下载配置
+}
+
+// GetProfile 获取指定名称的下载配置，name 为空时使用默认配置，都未设置时使用 yt-dlp 默认的最佳格式
+func (c *DownloadConfig) GetProfile(name string) (*DownloadProfile, error) {
+	if c == nil {
+		if name != "" {
+			return nil, fmt.Errorf("未定义的下载配置: %s", name)
+		}
+		return &DownloadProfile{}, nil
+	}
+	if name == "" {
+		name = c.Default
+	}
+	if name == "" {
+		return &DownloadProfile{}, nil
+	}
+	profile, ok := c.Profiles[name]
+	if !ok || profile == nil {
+		return nil, fmt.Errorf("未定义的下载配置: %s", name)
+	}
+	return profile, nil
+}
+
+// ChapterConfig 视频章节配置
+// chapters 步骤优先使用来源提供的章节，没有时从原视频描述中解析时间戳，仍然没有时可以根据字幕用 AI 生成
+type ChapterConfig struct {
+	ParseDescription bool `toml:"parse_description"`  // 从原视频描述中解析时间戳章节
+	AIGenerate       bool `toml:"ai_generate"`        // 没有章节时根据字幕时间轴用 AI 生成章节
+	AddToDescription bool `toml:"add_to_description"` // 上传时在B站简介中添加章节目录
+}
+
+// ASRConfig 语音识别配置
+// providers 中的服务按顺序尝试，前一个失败或没有识别出内容时使用下一个，字幕记录实际使用的服务
+type ASRConfig = asr.Config
+
+// SegmentConfig 字幕重新分段配置
+// 语音识别和自动字幕常常是一两个词的碎片或十几秒的大段文字，按行长、行数、显示时长和阅读速度重新合并和拆分
+type SegmentConfig = subtitle.SegmentConfig
+
+// 上传到B站的中文字幕
+const (
+	SubtitleUploadTranslated = "translated" // 只有译文
+	SubtitleUploadBilingual  = "bilingual"  // 译文在上、原文在下的双语字幕
+)
+
+// SubtitleOutputConfig 翻译后的字幕输出配置
+// 除了 zh.srt 外可以生成双语 SRT 和带样式的双语 ASS，并选择上传到B站的中文字幕
+type SubtitleOutputConfig struct {
+	Bilingual bool                            `toml:"bilingual"` // 生成双语 SRT（zh-bilingual.srt）
+	ASS       bool                            `toml:"ass"`       // 生成双语 ASS（zh.ass）
+	Preset    string                          `toml:"preset"`    // ASS 样式预设: default、yellow、boxed 或 presets 中定义的名称
+	Presets   map[string]subtitle.StylePreset `toml:"presets"`   // 自定义样式预设，与内置预设同名时覆盖内置预设
+	Upload    string                          `toml:"upload"`    // 上传到B站的中文字幕: translated（默认）、bilingual
+}
+
+// GetPreset 获取 ASS 样式预设，未配置时使用 default
+func (c *SubtitleOutputConfig) GetPreset() (subtitle.StylePreset, error) {
+	name := "default"
+	if c != nil && c.Preset != "" {
+		name = c.Preset
+	}
+	if c != nil {
+		if preset, ok := c.Presets[name]; ok {
+			return preset, nil
+		}
+	}
+	preset, ok := subtitle.Presets()[name]
+	if !ok {
+		return subtitle.StylePreset{}, fmt.Errorf("未定义的字幕样式预设: %s", name)
+	}
+	return preset, nil
+}
+
+// GetUpload 获取上传到B站的中文字幕，未配置时为译文
+func (c *SubtitleOutputConfig) GetUpload() string {
+	if c == nil || c.Upload == "" {
+		return SubtitleUploadTranslated
+	}
+	return c.Upload
+}
+
 // OpenAICompatibleConfig OpenAI兼容API配置
 type OpenAICompatibleConfig struct {
 	Enabled     bool    `toml:"enabled"`     // 是否启用
@@ -314,6 +629,169 @@ func NewDefaultConfig() *AppConfig {
 			MaxTokens:   4000,
 			Temperature: 0.7,
 		},
+
+		// 任务并发配置（默认值，可被 config.toml 覆盖）
+		WorkerConfig: &WorkerConfig{
+			Workers:      2, // 同时处理2个视频
+			MaxDownloads: 2,
+			MaxFFmpeg:    2,
+			MaxASR:       1,
+			MaxLLM:       4,
+			StepTimeout:  60, // 默认每个步骤最多执行1小时
+			StepTimeouts: map[string]int{
+				"download":     180,
+				"upload_video": 180,
+			},
+			MaxRetries: 3,
+			StepMaxRetries: map[string]int{
+				"upload_video": 1, // 上传失败可能已部分提交，只重试一次
+			},
+			RetryBaseDelay: 10,
+			RetryMaxDelay:  300,
+			LeaseTimeout:   60, // 每 20 秒续约一次
+		},
+
+		// 处理流程配置，default 为空时按字幕配置自动选择步骤
+		PipelineConfig: &PipelineConfig{
+			Default: "",
+			Pipelines: map[string]*PipelineDefinition{
+				"full": {
+					Description: "下载视频，优先使用平台字幕，没有时使用插件字幕或语音识别，翻译并上传视频和字幕",
+					Steps: []PipelineStepConfig{
+						{Key: "probe"}, {Key: "download"}, {Key: "acquire_subtitles"}, {Key: "cover"},
+						{Key: "chapters"}, {Key: "translate"}, {Key: "metadata"}, {Key: "upload_video"}, {Key: "upload_subtitle"},
+					},
+				},
+				"subtitle-only": {
+					Description: "只生成原语言和翻译字幕，不上传",
+					Steps: []PipelineStepConfig{
+						{Key: "probe"}, {Key: "download", Options: map[string]interface{}{"profile": "audio"}}, // 只需要音频
+						{Key: "extract_audio"}, {Key: "asr"}, {Key: "translate"},
+					},
+				},
+				"download-only": {
+					Description: "只下载视频和封面",
+					Steps: []PipelineStepConfig{
+						{Key: "probe"}, {Key: "download"}, {Key: "cover"},
+					},
+				},
+				"local": {
+					Description: "导入本地文件，语音识别、翻译并上传视频和字幕",
+					Steps: []PipelineStepConfig{
+						{Key: "import_local"}, {Key: "extract_audio"}, {Key: "asr"}, {Key: "cover"},
+						{Key: "chapters"}, {Key: "translate"}, {Key: "metadata"}, {Key: "upload_video"}, {Key: "upload_subtitle"},
+					},
+				},
+				"local-subtitles": {
+					Description: "导入本地文件和随附的 SRT 字幕，翻译并上传视频和字幕",
+					Steps: []PipelineStepConfig{
+						{Key: "import_local"}, {Key: "generate_subtitles"}, {Key: "cover"}, {Key: "chapters"}, {Key: "translate"},
+						{Key: "metadata"}, {Key: "upload_video"}, {Key: "upload_subtitle"},
+					},
+				},
+				"translate-and-upload": {
+					Description: "翻译插件提交的字幕并上传，不进行语音识别",
+					Steps: []PipelineStepConfig{
+						{Key: "probe"}, {Key: "download"}, {Key: "generate_subtitles"}, {Key: "cover"}, {Key: "chapters"}, {Key: "translate"},
+						{Key: "metadata"}, {Key: "upload_video"}, {Key: "upload_subtitle"},
+					},
+				},
+			},
+		},
+
+		// 订阅配置，定时检查订阅的频道和播放列表并处理新视频
+		SubscriptionConfig: &SubscriptionConfig{
+			Enabled:       true,
+			CheckInterval: 60, // 每小时检查一次
+			MaxItems:      10,
+			Pipeline:      "full", // 订阅的视频没有插件提交的字幕，没有平台字幕时需要语音识别
+			HubURL:        "https://pubsubhubbub.appspot.com/subscribe",
+			LeaseSeconds:  432000, // 5 天
+		},
+
+		// 本地文件导入配置，上传的文件和监控目录中的文件通过 import_local 步骤导入
+		LocalImportConfig: &LocalImportConfig{
+			WatchDir:         "", // 默认不监控目录
+			ScanInterval:     60,
+			SettleSeconds:    30,
+			Pipeline:         "local",
+			SubtitlePipeline: "local-subtitles",
+		},
+
+		// 全局过滤规则，默认不过滤任何视频
+		FilterConfig: &FilterConfig{},
+
+		// 获取字幕的配置，acquire_subtitles 步骤按优先级依次尝试
+		SubtitleSourceConfig: &SubtitleSourceConfig{
+			Priority:        []string{"manual_target", "manual_source", "auto", "extension", "asr"},
+			TargetLanguages: []string{"zh-Hans", "zh-CN", "zh"},
+			SourceLanguages: nil, // 使用视频信息中的语言
+		},
+
+		// 下载配置，download 步骤默认使用 default，流程可以通过 profile 选项指定其他配置
+		DownloadConfig: &DownloadConfig{
+			Default: "default",
+			Profiles: map[string]*DownloadProfile{
+				"default": {MaxHeight: 1080, Codecs: []string{"avc1", "hevc"}, Container: "mp4"}, // B站会重新编码，不需要更高的分辨率
+				"audio":   {AudioOnly: true},                                                      // 只生成字幕时不需要视频
+				"best":    {},                                                                     // yt-dlp 默认的最佳格式
+			},
+		},
+
+		// 章节配置，chapters 步骤获取章节，上传时在简介中添加章节目录
+		ChapterConfig: &ChapterConfig{
+			ParseDescription: true,
+			AIGenerate:       false, // 需要调用大模型，默认关闭
+			AddToDescription: true,
+		},
+
+		// 语音识别配置，按顺序尝试 providers 中的服务
+		ASRConfig: &ASRConfig{
+			Enabled:   false,
+			Providers: []string{asr.ProviderBcut},
+			Language:  "",
+			// 超过 10 分钟的音频分段识别
+			ChunkSeconds:     600,
+			ChunkOverlap:     2,
+			ChunkConcurrency: 3,
+			WhisperCpp: asr.WhisperCppConfig{
+				Binary:  "whisper-cli",
+				Threads: 4,
+			},
+			OpenAI: asr.OpenAIConfig{
+				BaseURL: "http://localhost:8000/v1", // faster-whisper-server 默认地址
+				Model:   "Systran/faster-whisper-small",
+				Timeout: 1800,
+			},
+		},
+
+		// 字幕重新分段配置，获取原语言字幕后和翻译后各分段一次
+		SegmentConfig: &SegmentConfig{
+			Enabled: false, // 默认关闭，避免升级后改变已有流程（包括插件提交的字幕）的时间轴
+			Source: subtitle.SegmentOptions{
+				MaxLineChars: 42,
+				MaxLines:     2,
+				MinDuration:  1,
+				MaxDuration:  7,
+				MaxCPS:       20,
+			},
+			// 中文字幕每行字数更少，阅读速度更慢
+			Translated: subtitle.SegmentOptions{
+				MaxLineChars: 16,
+				MaxLines:     2,
+				MinDuration:  1,
+				MaxDuration:  7,
+				MaxCPS:       9,
+			},
+		},
+
+		// 字幕输出配置，默认生成双语 SRT 和 ASS，上传译文
+		SubtitleOutputConfig: &SubtitleOutputConfig{
+			Bilingual: true,
+			ASS:       true,
+			Preset:    "default",
+			Upload:    SubtitleUploadTranslated,
+		},
 	}
 }
 
@@ -350,6 +828,17 @@ func LoadConfig(configFile string) (*AppConfig, error) {
 		AnalyticsConfig        *AnalyticsConfig        `toml:"AnalyticsConfig"`
 		BilibiliConfig         *BilibiliConfig         `toml:"BilibiliConfig"`
 		WhisperConfig          *WhisperConfig          `toml:"WhisperConfig"`
+		WorkerConfig           *WorkerConfig           `toml:"WorkerConfig"`
+		PipelineConfig         *PipelineConfig         `toml:"PipelineConfig"`
+		SubscriptionConfig     *SubscriptionConfig     `toml:"SubscriptionConfig"`
+		LocalImportConfig      *LocalImportConfig      `toml:"LocalImportConfig"`
+		FilterConfig           *FilterConfig           `toml:"FilterConfig"`
+		SubtitleSourceConfig   *SubtitleSourceConfig   `toml:"SubtitleSourceConfig"`
+		DownloadConfig         *DownloadConfig         `toml:"DownloadConfig"`
+		ChapterConfig          *ChapterConfig          `toml:"ChapterConfig"`
+		ASRConfig              *ASRConfig              `toml:"ASRConfig"`
+		SegmentConfig          *SegmentConfig          `toml:"SegmentConfig"`
+		SubtitleOutputConfig   *SubtitleOutputConfig   `toml:"SubtitleOutputConfig"`
 	}
 
 	// 解码TOML配置文件
@@ -398,7 +887,68 @@ func LoadConfig(configFile string) (*AppConfig, error) {
 	if fileConfig.WhisperConfig != nil {
 		config.WhisperConfig = fileConfig.WhisperConfig
 	}
-
+	if fileConfig.WorkerConfig != nil {
+		config.WorkerConfig = fileConfig.WorkerConfig
+	}
+	if fileConfig.PipelineConfig != nil {
+		// 配置文件中未定义的内置流程仍然可用，同名流程以配置文件为准
+		if fileConfig.PipelineConfig.Pipelines == nil {
+			fileConfig.PipelineConfig.Pipelines = make(map[string]*PipelineDefinition)
+		}
+		for name, pipeline := range config.PipelineConfig.Pipelines {
+			if _, ok := fileConfig.PipelineConfig.Pipelines[name]; !ok {
+				fileConfig.PipelineConfig.Pipelines[name] = pipeline
+			}
+		}
+		config.PipelineConfig = fileConfig.PipelineConfig
+	}
+	if fileConfig.SubscriptionConfig != nil {
+		config.SubscriptionConfig = fileConfig.SubscriptionConfig
+	}
+	if fileConfig.LocalImportConfig != nil {
+		config.LocalImportConfig = fileConfig.LocalImportConfig
+	}
+	if fileConfig.FilterConfig != nil {
+		config.FilterConfig = fileConfig.FilterConfig
+	}
+	if fileConfig.SubtitleSourceConfig != nil {
+		config.SubtitleSourceConfig = fileConfig.SubtitleSourceConfig
+	}
+	if fileConfig.DownloadConfig != nil {
+		// 配置文件中未定义的内置下载配置仍然可用，同名配置以配置文件为准
+		if fileConfig.DownloadConfig.Profiles == nil {
+			fileConfig.DownloadConfig.Profiles = make(map[string]*DownloadProfile)
+		}
+		for name, profile := range config.DownloadConfig.Profiles {
+			if _, ok := fileConfig.DownloadConfig.Profiles[name]; !ok {
+				fileConfig.DownloadConfig.Profiles[name] = profile
+			}
+		}
+		config.DownloadConfig = fileConfig.DownloadConfig
+	}
+	if fileConfig.ChapterConfig != nil {
+		config.ChapterConfig = fileConfig.ChapterConfig
+	}
+	if fileConfig.ASRConfig != nil {
+		config.ASRConfig = fileConfig.ASRConfig
+	} else if whisper := fileConfig.WhisperConfig; whisper != nil {
+		// 旧配置只有 WhisperConfig，启用时使用B站必剪，配置了模型时本地 whisper.cpp 作为备用
+		config.ASRConfig.Enabled = whisper.Enabled
+		config.ASRConfig.Language = whisper.Language
+		if whisper.ModelPath != "" {
+			config.ASRConfig.WhisperCpp.ModelPath = whisper.ModelPath
+			config.ASRConfig.Providers = append(config.ASRConfig.Providers, asr.ProviderWhisperCpp)
+		}
+		if whisper.Threads > 0 {
+			config.ASRConfig.WhisperCpp.Threads = whisper.Threads
+		}
+	}
+	if fileConfig.SegmentConfig != nil {
+		config.SegmentConfig = fileConfig.SegmentConfig
+	}
+	if fileConfig.SubtitleOutputConfig != nil {
+		config.SubtitleOutputConfig = fileConfig.SubtitleOutputConfig
+	}
 
 	return config, nil
 }
@@ -425,6 +975,17 @@ func SaveConfig(config *AppConfig) error {
 		AnalyticsConfig        *AnalyticsConfig        `toml:"AnalyticsConfig"`
 		BilibiliConfig         *BilibiliConfig         `toml:"BilibiliConfig"`
 		WhisperConfig          *WhisperConfig          `toml:"WhisperConfig"`
+		WorkerConfig           *WorkerConfig           `toml:"WorkerConfig"`
+		PipelineConfig         *PipelineConfig         `toml:"PipelineConfig"`
+		SubscriptionConfig     *SubscriptionConfig     `toml:"SubscriptionConfig"`
+		LocalImportConfig      *LocalImportConfig      `toml:"LocalImportConfig"`
+		FilterConfig           *FilterConfig           `toml:"FilterConfig"`
+		SubtitleSourceConfig   *SubtitleSourceConfig   `toml:"SubtitleSourceConfig"`
+		DownloadConfig         *DownloadConfig         `toml:"DownloadConfig"`
+		ChapterConfig          *ChapterConfig          `toml:"ChapterConfig"`
+		ASRConfig              *ASRConfig              `toml:"ASRConfig"`
+		SegmentConfig          *SegmentConfig          `toml:"SegmentConfig"`
+		SubtitleOutputConfig   *SubtitleOutputConfig   `toml:"SubtitleOutputConfig"`
 	}{
 		Listen:                 config.Listen,
 		Environment:            config.Environment,
@@ -444,6 +1005,17 @@ func SaveConfig(config *AppConfig) error {
 		AnalyticsConfig:        config.AnalyticsConfig,
 		BilibiliConfig:         config.BilibiliConfig,
 		WhisperConfig:          config.WhisperConfig,
+		WorkerConfig:           config.WorkerConfig,
+		PipelineConfig:         config.PipelineConfig,
+		SubscriptionConfig:     config.SubscriptionConfig,
+		LocalImportConfig:      config.LocalImportConfig,
+		FilterConfig:           config.FilterConfig,
+		SubtitleSourceConfig:   config.SubtitleSourceConfig,
+		DownloadConfig:         config.DownloadConfig,
+		ChapterConfig:          config.ChapterConfig,
+		ASRConfig:              config.ASRConfig,
+		SegmentConfig:          config.SegmentConfig,
+		SubtitleOutputConfig:   config.SubtitleOutputConfig,
 	}
 
 	buf := new(bytes.Buffer)
@@ -0,0 +1,139 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// ErrorClass 步骤错误的分类，决定失败后是否自动重试
+type ErrorClass string
+
+const (
+	ErrorTransient ErrorClass = "transient" // 网络异常、限流、超时等临时错误，自动重试
+	ErrorAuth      ErrorClass = "auth"      // cookies 或 API Key 失效，需要人工处理
+	ErrorQuota     ErrorClass = "quota"     // 账户额度或余额不足，需要人工处理
+	ErrorPermanent ErrorClass = "permanent" // 视频不可用、输入错误等，重试无效
+	ErrorFiltered  ErrorClass = "filtered"  // 视频不符合过滤规则，不再处理
+)
+
+// StepError 带分类的步骤错误
+type StepError struct {
+	Class ErrorClass
+	Err   error
+}
+
+func (e *StepError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// NewStepError 创建带分类的步骤错误，err 为 nil 时返回 nil
+func NewStepError(class ErrorClass, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &StepError{Class: class, Err: err}
+}
+
+// NeedsAttention 该类错误是否需要人工处理（认证失效或额度不足）
+func (c ErrorClass) NeedsAttention() bool {
+	return c == ErrorAuth || c == ErrorQuota
+}
+
+// ClassifyError 获取错误的分类
+// 带分类的错误直接返回其分类，其他错误根据错误信息判断，无法判断时视为永久错误
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ""
+	}
+
+	var stepErr *StepError
+	if errors.As(err, &stepErr) {
+		return stepErr.Class
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorTransient
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ErrorTransient
+	}
+
+	if class, ok := ClassifyMessage(err.Error()); ok {
+		return class
+	}
+	return ErrorPermanent
+}
+
+// 各类错误信息的匹配规则，按 permanent、auth、quota、transient 的顺序匹配
+// 靠前的分类只使用明确的短语，避免如"登录请求网络超时"的临时错误被归为登录失效
+// yt-dlp 的错误信息包含视频ID和URL，英文关键字只在单词边界上匹配，HTTP 状态码只在状态码的上下文中匹配
+var errorPatterns = []struct {
+	class    ErrorClass
+	patterns []*regexp.Regexp
+}{
+	{ErrorPermanent, keywordPatterns(
+		"video unavailable", "private video", "has been removed", "not available in your country",
+		"unsupported url", "no such file", "file too large", "invalid argument", "视频不可用",
+	)},
+	{ErrorAuth, append(keywordPatterns(
+		"unauthorized", "invalid_api_key", "api key", "cookies are no longer valid", "cookie expired",
+		"sign in to confirm", "login required", "未登录", "请重新登录", "登录状态已过期", "登录已过期",
+	), statusPattern("401"))},
+	{ErrorQuota, keywordPatterns(
+		"insufficient_quota", "quota", "insufficient balance", "余额不足", "额度",
+	)},
+	{ErrorTransient, append(keywordPatterns(
+		"too many requests", "rate limit", "timeout", "timed out", "deadline exceeded",
+		"connection reset", "connection refused", "broken pipe", "no such host", "eof",
+		"temporary failure", "internal server error", "bad gateway", "service unavailable", "gateway timeout",
+		"超时", "网络连接", "网络异常", "网络错误",
+	), statusPattern("429", "500", "502", "503", "504"))},
+}
+
+// keywordPatterns 生成关键字的匹配规则，关键字以字母或数字开头、结尾时需要在单词边界上
+// 例如 eof 不匹配 thereof，cookie 不匹配 cookies
+func keywordPatterns(keywords ...string) []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, 0, len(keywords))
+	for _, keyword := range keywords {
+		pattern := regexp.QuoteMeta(keyword)
+		if isWordByte(keyword[0]) {
+			pattern = `\b` + pattern
+		}
+		if isWordByte(keyword[len(keyword)-1]) {
+			pattern += `\b`
+		}
+		patterns = append(patterns, regexp.MustCompile(pattern))
+	}
+	return patterns
+}
+
+// statusPattern 生成 HTTP 状态码的匹配规则，如 "HTTP Error 429"、"HTTP 503"、"status code 401"
+func statusPattern(codes ...string) *regexp.Regexp {
+	return regexp.MustCompile(`\b(?:http(?: error)?|status(?: code)?)[\s:]*(?:` + strings.Join(codes, "|") + `)\b`)
+}
+
+// isWordByte 是否为 ASCII 字母、数字或下划线
+func isWordByte(b byte) bool {
+	return b == '_' || (b >= '0' && b <= '9') || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+// ClassifyMessage 根据错误信息（如 yt-dlp 输出、API 响应）判断错误分类
+func ClassifyMessage(message string) (ErrorClass, bool) {
+	message = strings.ToLower(message)
+	for _, group := range errorPatterns {
+		for _, pattern := range group.patterns {
+			if pattern.MatchString(message) {
+				return group.class, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,81 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestClassifyMessage(t *testing.T) {
+	tests := []struct {
+		message string
+		class   ErrorClass
+		ok      bool
+	}{
+		// 视频ID、URL、文件大小中的数字不是 HTTP 状态码
+		{"ERROR: [youtube] ab401Xyz: Video unavailable", ErrorPermanent, true},
+		{"ERROR: [youtube] x502y: Private video. Sign in if you've been granted access", ErrorPermanent, true},
+		{"downloading https://example.com/v/503429.mp4 (504 MiB)", "", false},
+		{"[download] 42.9% of 429.00MiB at 5.03MiB/s ETA 05:04", "", false},
+
+		// HTTP 状态码只在状态码的上下文中匹配
+		{"ERROR: unable to download video data: HTTP Error 429: Too Many Requests", ErrorTransient, true},
+		{"HTTP Error 503", ErrorTransient, true},
+		{"request failed: http 502", ErrorTransient, true},
+		{"unexpected status code: 504", ErrorTransient, true},
+		{"HTTP Error 401", ErrorAuth, true},
+		{"status code 401 returned", ErrorAuth, true},
+
+		// 关键字在单词边界上匹配
+		{"the file and the metadata thereof", "", false},
+		{"read tcp 10.0.0.1:443: unexpected EOF", ErrorTransient, true},
+		{"[info] using cookies file cookies.txt", "", false},
+		{"The provided YouTube account cookies are no longer valid", ErrorAuth, true},
+		{"Sign in to confirm you're not a bot", ErrorAuth, true},
+		{"Error code: 401 - invalid_api_key", ErrorAuth, true},
+		{"Incorrect API key provided", ErrorAuth, true},
+		{"未登录 Bilibili", ErrorAuth, true},
+		{"上传视频失败：登录状态已过期，请重新登录", ErrorAuth, true},
+		{"登录请求网络超时", ErrorTransient, true},
+		{"获取登录二维码失败: 网络连接异常", ErrorTransient, true},
+
+		{"You exceeded your current quota", ErrorQuota, true},
+		{"insufficient_quota", ErrorQuota, true},
+		{"账户余额不足", ErrorQuota, true},
+
+		{"dial tcp: lookup api.example.com: no such host", ErrorTransient, true},
+		{"connection reset by peer", ErrorTransient, true},
+		{"请求超时", ErrorTransient, true},
+		{"Unsupported URL: https://example.com", ErrorPermanent, true},
+		{"open /tmp/a.mp4: no such file or directory", ErrorPermanent, true},
+
+		{"something unexpected happened", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		class, ok := ClassifyMessage(tt.message)
+		if class != tt.class || ok != tt.ok {
+			t.Errorf("ClassifyMessage(%q) = %q, %v; want %q, %v", tt.message, class, ok, tt.class, tt.ok)
+		}
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err   error
+		class ErrorClass
+	}{
+		{nil, ""},
+		{NewStepError(ErrorQuota, errors.New("HTTP Error 503")), ErrorQuota},
+		{fmt.Errorf("步骤失败: %w", NewStepError(ErrorFiltered, errors.New("时长超过限制"))), ErrorFiltered},
+		{fmt.Errorf("wrap: %w", context.DeadlineExceeded), ErrorTransient},
+		{errors.New("HTTP Error 429: Too Many Requests"), ErrorTransient},
+		{errors.New("something unexpected happened"), ErrorPermanent},
+	}
+	for _, tt := range tests {
+		if class := ClassifyError(tt.err); class != tt.class {
+			t.Errorf("ClassifyError(%v) = %q, want %q", tt.err, class, tt.class)
+		}
+	}
+}
@@ -1,8 +1,12 @@
 package types
 
+import "context"
+
 // Task 接口定义了任务处理器的基本操作
+// 任务之间通过 StateManager 中的产物仓库传递数据，执行失败时返回错误
+// ctx 在任务超时、被用户取消或服务关闭时取消，任务应尽快结束外部进程和网络请求
 type Task interface {
-	Execute(context map[string]interface{}) bool
+	Execute(ctx context.Context) error
 	GetName() string
 	InsertTask() error
 	UpdateStatus(status, message string) error
@@ -2,6 +2,8 @@ package handler
 
 import (
 	"github.com/difyz9/ytb2bili/internal/core"
+	"github.com/difyz9/ytb2bili/internal/middleware"
+	"github.com/difyz9/ytb2bili/pkg/store/model"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
@@ -68,3 +70,11 @@ func (h *BaseHandler) GetString(c *gin.Context, key string, defaultValue string)
 	}
 	return value
 }
+
+// statusActor 记录视频状态变更时使用的执行者，已登录时包含用户名
+func statusActor(c *gin.Context) string {
+	if username := middleware.GetUsername(c); username != "" {
+		return model.StatusActorAPI + ":" + username
+	}
+	return model.StatusActorAPI
+}
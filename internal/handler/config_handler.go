@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"github.com/difyz9/ytb2bili/internal/chain_task/steps"
 	"github.com/difyz9/ytb2bili/internal/core"
 	"github.com/difyz9/ytb2bili/internal/core/types"
 	"net/http"
@@ -28,6 +29,7 @@ func (h *ConfigHandler) RegisterRoutes(server *core.AppServer) {
 		config.PUT("/deepseek", h.updateDeepSeekConfig)
 		config.GET("/proxy", h.getProxyConfig)
 		config.PUT("/proxy", h.updateProxyConfig)
+		config.GET("/pipelines", h.getPipelines)
 	}
 }
 
@@ -63,6 +65,14 @@ type ProxyConfigResponse struct {
 	ProxyHost string `json:"proxyHost"` // 代理地址
 }
 
+// PipelineResponse 处理流程响应
+type PipelineResponse struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Steps       []string `json:"steps"`           // 步骤标识，按流程中的顺序排列
+	Error       string   `json:"error,omitempty"` // 流程配置无效时的原因
+}
+
 // getDeepSeekConfig 获取DeepSeek配置
 func (h *ConfigHandler) getDeepSeekConfig(c *gin.Context) {
 	config := h.App.Config.DeepSeekTransConfig
@@ -284,3 +294,36 @@ func maskApiKey(apiKey string) string {
 	}
 	return "***"
 }
+
+// getPipelines 获取配置的处理流程
+func (h *ConfigHandler) getPipelines(c *gin.Context) {
+	pipelineConfig := h.App.Config.PipelineConfig
+	if pipelineConfig == nil {
+		pipelineConfig = &types.PipelineConfig{}
+	}
+
+	pipelines := make([]PipelineResponse, 0)
+	for _, name := range steps.Names(h.App.Config) {
+		definition := pipelineConfig.Pipelines[name]
+		item := PipelineResponse{Name: name, Steps: make([]string, 0)}
+		if definition != nil {
+			item.Description = definition.Description
+			for _, step := range definition.Steps {
+				item.Steps = append(item.Steps, step.Key)
+			}
+		}
+		if _, err := steps.Resolve(h.App.Config, name); err != nil {
+			item.Error = err.Error()
+		}
+		pipelines = append(pipelines, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data": gin.H{
+			"default":   pipelineConfig.Default,
+			"pipelines": pipelines,
+		},
+	})
+}
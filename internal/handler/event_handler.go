@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/difyz9/ytb2bili/internal/core"
+	"github.com/difyz9/ytb2bili/internal/core/services"
+	"github.com/difyz9/ytb2bili/pkg/events"
+	"github.com/difyz9/ytb2bili/pkg/store/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EventHandler 任务进度事件（Server-Sent Events）
+type EventHandler struct {
+	BaseHandler
+	SavedVideoService *services.SavedVideoService
+}
+
+// NewEventHandler 创建事件处理器
+func NewEventHandler(app *core.AppServer, savedVideoService *services.SavedVideoService) *EventHandler {
+	return &EventHandler{
+		BaseHandler:       BaseHandler{App: app},
+		SavedVideoService: savedVideoService,
+	}
+}
+
+// RegisterRoutes 注册事件相关路由
+func (h *EventHandler) RegisterRoutes(api *gin.RouterGroup) {
+	api.GET("/events", h.streamAllEvents)
+	api.GET("/videos/:id/events", h.streamVideoEvents)
+}
+
+// streamAllEvents 推送所有视频的事件
+func (h *EventHandler) streamAllEvents(c *gin.Context) {
+	h.stream(c, "")
+}
+
+// streamVideoEvents 推送指定视频的事件，id 可以是数字ID或 video_id
+func (h *EventHandler) streamVideoEvents(c *gin.Context) {
+	idStr := c.Param("id")
+
+	var savedVideo *model.SavedVideo
+	var err error
+	if id, parseErr := strconv.ParseUint(idStr, 10, 32); parseErr == nil {
+		savedVideo, err = h.SavedVideoService.GetVideoByID(uint(id))
+	} else {
+		savedVideo, err = h.SavedVideoService.GetVideoByVideoID(idStr)
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse(404, "视频不存在"))
+		return
+	}
+
+	h.stream(c, savedVideo.VideoID)
+}
+
+// stream 以 SSE 格式推送事件，直到客户端断开连接
+// 每条消息的 data 为事件的 JSON，id 为事件ID；客户端重连时通过 Last-Event-ID 补发断开期间的事件
+func (h *EventHandler) stream(c *gin.Context, videoID string) {
+	var lastID uint64
+	if value := c.GetHeader("Last-Event-ID"); value != "" {
+		lastID, _ = strconv.ParseUint(value, 10, 64)
+	}
+
+	sub := h.App.Events.Subscribe(videoID, lastID)
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 禁用 nginx 缓冲
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	// 定期发送注释行，避免代理因连接空闲而断开
+	keepAlive := time.NewTicker(15 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if err := writeEvent(c, event); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// writeEvent 写入一条 SSE 消息
+func writeEvent(c *gin.Context, event events.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil
+	}
+	if _, err := fmt.Fprintf(c.Writer, "id: %d\ndata: %s\n\n", event.ID, data); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
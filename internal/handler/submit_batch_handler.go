@@ -0,0 +1,369 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/difyz9/ytb2bili/internal/core/types"
+	"github.com/difyz9/ytb2bili/pkg/source"
+	"github.com/difyz9/ytb2bili/pkg/store/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBatchItems 一次批量提交的最大视频数量
+const maxBatchItems = 1000
+
+// BatchSubmitResult 批量提交中单个视频的处理结果
+const (
+	BatchResultCreated         = "created"          // 已加入待处理列表
+	BatchResultExisting        = "existing"         // 视频已保存过（包括已删除的），未做修改
+	BatchResultDuplicate       = "duplicate"        // 与本次提交中前面的视频重复
+	BatchResultInvalidURL      = "invalid_url"      // 无法识别的视频链接
+	BatchResultInvalidPipeline = "invalid_pipeline" // 处理流程不存在或需要插件字幕
+	BatchResultInvalidDownload = "invalid_download" // 下载配置不存在或设置无效
+)
+
+// BatchSubmitItem 批量提交中的单个视频
+type BatchSubmitItem struct {
+	URL      string                   `json:"url"`
+	Title    string                   `json:"title"`
+	Pipeline string                   `json:"pipeline"` // 为空时使用请求的默认流程
+	Upload   *model.UploadOverrides   `json:"upload"`   // 投稿设置，覆盖B站配置
+	Download *model.DownloadOverrides `json:"download"` // 下载设置，指定下载配置或覆盖其中的项
+}
+
+// BatchSubmitRequest 批量提交请求
+type BatchSubmitRequest struct {
+	Pipeline string            `json:"pipeline"` // 默认处理流程，为空时使用配置的默认流程
+	Items    []BatchSubmitItem `json:"items"`
+	URLs     []string          `json:"urls"` // 只有链接时可以使用的简写，与 items 合并
+}
+
+// BatchSubmitItemResult 单个视频的处理结果
+type BatchSubmitItemResult struct {
+	Index   int    `json:"index"` // 在提交列表中的位置，从 0 开始
+	URL     string `json:"url"`
+	VideoID string `json:"videoId,omitempty"`
+	ID      uint   `json:"id,omitempty"`
+	Result  string `json:"result"`
+	Message string `json:"message,omitempty"`
+}
+
+// submitBatch 批量提交视频，支持 JSON 请求体或上传 CSV/JSON 文件（multipart 的 file 字段）
+// 所有新视频在一个事务中保存，已保存过的视频不做修改
+func (h *SubtitleHandler) submitBatch(c *gin.Context) {
+	req, err := h.bindBatchRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	items := req.Items
+	for _, u := range req.URLs {
+		items = append(items, BatchSubmitItem{URL: u})
+	}
+	if len(items) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "没有要提交的视频",
+		})
+		return
+	}
+	if len(items) > maxBatchItems {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": fmt.Sprintf("一次最多提交 %d 个视频", maxBatchItems),
+		})
+		return
+	}
+
+	results := make([]BatchSubmitItemResult, len(items))
+	videos := make([]*model.SavedVideo, 0, len(items))
+	indexes := make([]int, 0, len(items))
+	seen := make(map[string]int)
+	pipelineErrors := make(map[string]error)
+	savedAt := time.Now().Format(time.RFC3339)
+
+	for i, item := range items {
+		videoURL := strings.TrimSpace(item.URL)
+		results[i] = BatchSubmitItemResult{Index: i, URL: videoURL}
+
+		id, _, err := source.ParseRemote(videoURL)
+		if err != nil {
+			results[i].Result = BatchResultInvalidURL
+			results[i].Message = err.Error()
+			continue
+		}
+		videoID := id.Key()
+		results[i].VideoID = videoID
+
+		if first, ok := seen[videoID]; ok {
+			results[i].Result = BatchResultDuplicate
+			results[i].Message = fmt.Sprintf("与第 %d 个视频重复", first)
+			continue
+		}
+		seen[videoID] = i
+
+		pipeline := item.Pipeline
+		if pipeline == "" {
+			pipeline = req.Pipeline
+		}
+		pipelineErr, checked := pipelineErrors[pipeline]
+		if !checked {
+			pipelineErr = validatePipelineWithoutSubtitles(h.App.Config, pipeline)
+			pipelineErrors[pipeline] = pipelineErr
+		}
+		if pipelineErr != nil {
+			results[i].Result = BatchResultInvalidPipeline
+			results[i].Message = pipelineErr.Error()
+			continue
+		}
+
+		if err := validateDownloadOverrides(h.App.Config, item.Download); err != nil {
+			results[i].Result = BatchResultInvalidDownload
+			results[i].Message = err.Error()
+			continue
+		}
+
+		video := &model.SavedVideo{
+			VideoID:     videoID,
+			CanonicalID: id.String(),
+			URL:         videoURL,
+			Title:       item.Title,
+			Status:      model.VideoStatusPending,
+			Pipeline:    pipeline,
+			Origin:      model.OriginBatch,
+			SavedAt:     savedAt,
+		}
+		video.SetUploadOverrides(item.Upload)
+		video.SetDownloadOverrides(item.Download)
+		videos = append(videos, video)
+		indexes = append(indexes, i)
+	}
+
+	// 过滤已保存过的视频
+	videoIDs := make([]string, 0, len(videos))
+	for _, video := range videos {
+		videoIDs = append(videoIDs, video.VideoID)
+	}
+	existing, err := h.SavedVideoService.GetExistingVideoIDs(videoIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Database error: " + err.Error(),
+		})
+		return
+	}
+
+	newVideos := videos[:0]
+	newIndexes := indexes[:0]
+	for j, video := range videos {
+		i := indexes[j]
+		if existing[video.VideoID] {
+			results[i].Result = BatchResultExisting
+			continue
+		}
+		newVideos = append(newVideos, video)
+		newIndexes = append(newIndexes, i)
+	}
+
+	if len(newVideos) > 0 {
+		if err := h.SavedVideoService.CreateVideos(newVideos, statusActor(c), "批量提交"); err != nil {
+			// 其他请求同时保存了其中的视频，整批回滚，重新提交即可
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"message": "Failed to save videos: " + err.Error(),
+			})
+			return
+		}
+	}
+	for j, video := range newVideos {
+		i := newIndexes[j]
+		results[i].Result = BatchResultCreated
+		results[i].ID = video.ID
+	}
+
+	summary := make(map[string]int)
+	for _, result := range results {
+		summary[result.Result]++
+	}
+	h.App.Logger.Infof("📥 批量提交 %d 个视频: 新增 %d, 已存在 %d, 重复 %d, 无效 %d",
+		len(items), summary[BatchResultCreated], summary[BatchResultExisting], summary[BatchResultDuplicate],
+		summary[BatchResultInvalidURL]+summary[BatchResultInvalidPipeline]+summary[BatchResultInvalidDownload])
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("新增 %d 个视频", summary[BatchResultCreated]),
+		"data": gin.H{
+			"summary": summary,
+			"results": results,
+		},
+	})
+}
+
+// bindBatchRequest 解析 JSON 请求体或上传的 CSV/JSON 文件
+func (h *SubtitleHandler) bindBatchRequest(c *gin.Context) (*BatchSubmitRequest, error) {
+	var req BatchSubmitRequest
+	if !strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			return nil, fmt.Errorf("Invalid request parameters: %v", err)
+		}
+		return &req, nil
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return nil, fmt.Errorf("缺少上传的文件（file 字段）")
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("读取上传的文件失败: %v", err)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, 10<<20))
+	if err != nil {
+		return nil, fmt.Errorf("读取上传的文件失败: %v", err)
+	}
+
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // Excel 导出的 CSV 带 BOM
+	trimmed := bytes.TrimSpace(data)
+	isJSON := strings.EqualFold(filepath.Ext(fileHeader.Filename), ".json") ||
+		bytes.HasPrefix(trimmed, []byte("[")) || bytes.HasPrefix(trimmed, []byte("{"))
+	if isJSON {
+		// 支持 {"items": [...]} 或直接为数组
+		if bytes.HasPrefix(trimmed, []byte("[")) {
+			err = json.Unmarshal(trimmed, &req.Items)
+		} else {
+			err = json.Unmarshal(trimmed, &req)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析 JSON 文件失败: %v", err)
+		}
+	} else {
+		if req.Items, err = parseBatchCSV(data); err != nil {
+			return nil, err
+		}
+	}
+
+	if pipeline := c.PostForm("pipeline"); pipeline != "" {
+		req.Pipeline = pipeline
+	}
+	return &req, nil
+}
+
+// parseBatchCSV 解析批量提交的 CSV 文件
+// 第一行包含 url 列时作为表头，可用的列：url、title、pipeline、upload_title、upload_description、
+// tags、tid、copyright、source、dynamic、download_profile、max_height、max_filesize_mb；
+// 没有表头时依次为 url、title、pipeline
+func parseBatchCSV(data []byte) ([]BatchSubmitItem, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("解析 CSV 文件失败: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := []string{"url", "title", "pipeline"}
+	for _, cell := range records[0] {
+		if strings.EqualFold(strings.TrimSpace(cell), "url") {
+			columns = make([]string, len(records[0]))
+			for i, name := range records[0] {
+				columns[i] = strings.ToLower(strings.TrimSpace(name))
+			}
+			records = records[1:]
+			break
+		}
+	}
+
+	items := make([]BatchSubmitItem, 0, len(records))
+	for row, record := range records {
+		var item BatchSubmitItem
+		var upload model.UploadOverrides
+		var download model.DownloadOverrides
+		for i, value := range record {
+			if i >= len(columns) {
+				break
+			}
+			value = strings.TrimSpace(value)
+			if value == "" {
+				continue
+			}
+			switch columns[i] {
+			case "url":
+				item.URL = value
+			case "title":
+				item.Title = value
+			case "pipeline":
+				item.Pipeline = value
+			case "upload_title":
+				upload.Title = value
+			case "upload_description":
+				upload.Description = value
+			case "tags":
+				upload.Tags = value
+			case "source":
+				upload.Source = value
+			case "dynamic":
+				upload.Dynamic = value
+			case "download_profile":
+				download.ProfileName = value
+			case "tid", "copyright", "max_height", "max_filesize_mb":
+				n, err := strconv.Atoi(value)
+				if err != nil {
+					return nil, fmt.Errorf("CSV 第 %d 行 %s 列不是数字: %s", row+1, columns[i], value)
+				}
+				switch columns[i] {
+				case "tid":
+					upload.Tid = n
+				case "copyright":
+					upload.Copyright = n
+				case "max_height":
+					download.MaxHeight = n
+				default:
+					download.MaxFileSize = n
+				}
+			}
+		}
+		// 跳过空行
+		if item.URL == "" && item.Title == "" {
+			continue
+		}
+		if !upload.IsEmpty() {
+			item.Upload = &upload
+		}
+		if !download.IsEmpty() {
+			item.Download = &download
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// validateDownloadOverrides 检查视频的下载设置，指定的下载配置必须存在
+func validateDownloadOverrides(config *types.AppConfig, overrides *model.DownloadOverrides) error {
+	if overrides == nil {
+		return nil
+	}
+	if overrides.ProfileName != "" {
+		if _, err := config.DownloadConfig.GetProfile(overrides.ProfileName); err != nil {
+			return err
+		}
+	}
+	return overrides.Profile.Validate()
+}
@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/difyz9/ytb2bili/internal/chain_task/steps"
+	"github.com/difyz9/ytb2bili/internal/core/services"
+	"github.com/difyz9/ytb2bili/pkg/source"
+	"github.com/difyz9/ytb2bili/pkg/store/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// submitLocal 上传本地视频文件（multipart），保存后加入待处理列表
+// 表单字段：file 视频文件（必填）、subtitle SRT 字幕、sidecar 包含标题和描述的 JSON 文件、
+// title 和 description（覆盖 JSON 文件中的值）、language 字幕语言、pipeline 处理流程
+func (h *SubtitleHandler) submitLocal(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "缺少上传的视频文件（file 字段）",
+		})
+		return
+	}
+	if !source.IsMediaFile(fileHeader.Filename) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "不支持的文件类型: " + filepath.Ext(fileHeader.Filename),
+		})
+		return
+	}
+
+	sidecar, err := localSidecar(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	subtitleHeader, _ := c.FormFile("subtitle")
+	if subtitleHeader != nil && !strings.EqualFold(filepath.Ext(subtitleHeader.Filename), ".srt") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "字幕文件只支持 SRT 格式",
+		})
+		return
+	}
+
+	// 每次上传保存在单独的目录中，同名文件不会覆盖
+	dir, err := localUploadDir(h.App.Config.LocalImportConfig.GetUploadDir(h.App.Config.DataPath))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "创建上传目录失败: " + err.Error(),
+		})
+		return
+	}
+	videoPath := filepath.Join(dir, localFileName(fileHeader.Filename))
+
+	video, err := h.saveLocalUpload(c, fileHeader, subtitleHeader, sidecar, videoPath)
+	if err != nil {
+		os.RemoveAll(dir)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.SavedVideoService.CreateVideo(video, statusActor(c), "上传本地文件"); err != nil {
+		os.RemoveAll(dir)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to save video: " + err.Error(),
+		})
+		return
+	}
+	h.App.Logger.Infof("📥 已上传本地文件: %s（流程 %s）", videoPath, video.Pipeline)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Video uploaded successfully",
+		"data": gin.H{
+			"id":       video.ID,
+			"videoId":  video.VideoID,
+			"title":    video.Title,
+			"pipeline": video.Pipeline,
+		},
+	})
+}
+
+// saveLocalUpload 保存上传的视频、字幕和 JSON 文件，生成待保存的视频记录
+func (h *SubtitleHandler) saveLocalUpload(c *gin.Context, fileHeader, subtitleHeader *multipart.FileHeader, sidecar *source.Sidecar, videoPath string) (*model.SavedVideo, error) {
+	if err := c.SaveUploadedFile(fileHeader, videoPath); err != nil {
+		return nil, fmt.Errorf("保存视频文件失败: %v", err)
+	}
+	if subtitleHeader != nil {
+		if err := c.SaveUploadedFile(subtitleHeader, source.SidecarPath(videoPath, ".srt")); err != nil {
+			return nil, fmt.Errorf("保存字幕文件失败: %v", err)
+		}
+	}
+	if sidecar.Title == "" {
+		sidecar.Title = strings.TrimSuffix(fileHeader.Filename, filepath.Ext(fileHeader.Filename))
+	}
+	data, err := json.MarshalIndent(sidecar, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(source.SidecarPath(videoPath, ".json"), data, 0644); err != nil {
+		return nil, fmt.Errorf("保存视频信息失败: %v", err)
+	}
+
+	video, hasSubtitles, err := services.NewLocalVideo(videoPath)
+	if err != nil {
+		return nil, err
+	}
+	pipeline := c.PostForm("pipeline")
+	if pipeline == "" {
+		pipeline = h.App.Config.LocalImportConfig.GetPipeline(hasSubtitles)
+	}
+	if _, err := steps.ResolveLocal(h.App.Config, pipeline, hasSubtitles); err != nil {
+		return nil, err
+	}
+	video.Pipeline = pipeline
+	return video, nil
+}
+
+// localSidecar 读取上传的 JSON 文件，表单中的标题、描述和字幕语言覆盖文件中的值
+func localSidecar(c *gin.Context) (*source.Sidecar, error) {
+	var sidecar source.Sidecar
+	if header, err := c.FormFile("sidecar"); err == nil {
+		file, err := header.Open()
+		if err != nil {
+			return nil, fmt.Errorf("读取视频信息文件失败: %v", err)
+		}
+		defer file.Close()
+		data, err := io.ReadAll(io.LimitReader(file, 1<<20))
+		if err != nil {
+			return nil, fmt.Errorf("读取视频信息文件失败: %v", err)
+		}
+		if err := json.Unmarshal(data, &sidecar); err != nil {
+			return nil, fmt.Errorf("解析视频信息文件失败: %v", err)
+		}
+	}
+	if title := strings.TrimSpace(c.PostForm("title")); title != "" {
+		sidecar.Title = title
+	}
+	if description := c.PostForm("description"); description != "" {
+		sidecar.Description = description
+	}
+	if language := c.PostForm("language"); language != "" {
+		sidecar.Language = language
+	}
+	return &sidecar, nil
+}
+
+// localUploadDir 创建本次上传使用的目录，如 {upload_dir}/20240102-150405-1a2b3c4d
+func localUploadDir(root string) (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	dir := filepath.Join(root, time.Now().Format("20060102-150405")+"-"+hex.EncodeToString(suffix))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// localFileName 上传文件保存使用的文件名，去掉路径和文件名中不能使用的字符
+func localFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`<>:"/\|?*`, r) || r < 0x20 {
+			return '_'
+		}
+		return r
+	}, name)
+	if strings.TrimSuffix(name, filepath.Ext(name)) == "" {
+		name = "video" + strings.ToLower(filepath.Ext(name))
+	}
+	return name
+}
@@ -0,0 +1,424 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/difyz9/ytb2bili/internal/chain_task/steps"
+	"github.com/difyz9/ytb2bili/internal/core"
+	"github.com/difyz9/ytb2bili/internal/core/services"
+	"github.com/difyz9/ytb2bili/internal/core/types"
+	"github.com/difyz9/ytb2bili/pkg/filter"
+	"github.com/difyz9/ytb2bili/pkg/store/model"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SubscriptionHandler 频道和播放列表订阅
+type SubscriptionHandler struct {
+	BaseHandler
+	SubscriptionService *services.SubscriptionService
+	Checker             SubscriptionChecker
+}
+
+// SubscriptionChecker 订阅调度器
+type SubscriptionChecker interface {
+	CheckSubscription(ctx context.Context, id uint) (int, error)
+	StopWebSub(ctx context.Context, subscription *model.Subscription) error
+}
+
+// NewSubscriptionHandler 创建订阅处理器
+func NewSubscriptionHandler(app *core.AppServer, subscriptionService *services.SubscriptionService) *SubscriptionHandler {
+	return &SubscriptionHandler{
+		BaseHandler:         BaseHandler{App: app},
+		SubscriptionService: subscriptionService,
+	}
+}
+
+// SetChecker 设置订阅调度器，用于立即检查订阅
+func (h *SubscriptionHandler) SetChecker(checker SubscriptionChecker) {
+	h.Checker = checker
+}
+
+// RegisterRoutes 注册订阅相关路由
+func (h *SubscriptionHandler) RegisterRoutes(api *gin.RouterGroup) {
+	subscription := api.Group("/subscriptions")
+	{
+		subscription.GET("", h.listSubscriptions)
+		subscription.POST("", h.createSubscription)
+		subscription.GET("/:id", h.getSubscription)
+		subscription.PUT("/:id", h.updateSubscription)
+		subscription.DELETE("/:id", h.deleteSubscription)
+		subscription.POST("/:id/check", h.checkSubscription)
+	}
+}
+
+// SubscriptionRequest 创建或更新订阅的请求，更新时未提供的字段保持不变
+type SubscriptionRequest struct {
+	URL           *string       `json:"url,omitempty"`            // 频道或播放列表的 URL
+	Kind          *string       `json:"kind,omitempty"`           // channel 或 playlist，为空时根据 URL 判断
+	Title         *string       `json:"title,omitempty"`          // 名称，为空时检查时自动获取
+	Enabled       *bool         `json:"enabled,omitempty"`        // 是否启用，创建时默认启用
+	Pipeline      *string       `json:"pipeline,omitempty"`       // 新视频使用的处理流程
+	MaxItems      *int          `json:"max_items,omitempty"`      // 每次检查最新的视频数量
+	CheckInterval *int          `json:"check_interval,omitempty"` // 检查间隔（分钟）
+	Mode          *string       `json:"mode,omitempty"`           // 检查方式: ytdlp、feed、websub
+	FilterRules   *filter.Rules `json:"filter_rules,omitempty"`   // 过滤规则，覆盖全局规则中的同一项，设置为 {} 时清除
+}
+
+// listSubscriptions 获取所有订阅
+func (h *SubscriptionHandler) listSubscriptions(c *gin.Context) {
+	subscriptions, err := h.SubscriptionService.ListSubscriptions()
+	if err != nil {
+		h.App.Logger.Errorf("获取订阅列表失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "获取订阅列表失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    subscriptions,
+	})
+}
+
+// getSubscription 获取订阅详情
+func (h *SubscriptionHandler) getSubscription(c *gin.Context) {
+	subscription, ok := h.findSubscription(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    subscription,
+	})
+}
+
+// createSubscription 创建订阅
+func (h *SubscriptionHandler) createSubscription(c *gin.Context) {
+	var req SubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+	if req.URL == nil || strings.TrimSpace(*req.URL) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "url 不能为空",
+		})
+		return
+	}
+
+	subscription := &model.Subscription{Enabled: true}
+	if err := h.applyRequest(subscription, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.SubscriptionService.CreateSubscription(subscription); err != nil {
+		h.App.Logger.Errorf("创建订阅失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "创建订阅失败，URL 可能已被订阅",
+		})
+		return
+	}
+
+	h.App.Logger.Infof("➕ 新增订阅: %s (%s)", subscription.URL, subscription.Kind)
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "订阅已创建",
+		"data":    subscription,
+	})
+}
+
+// updateSubscription 更新订阅设置
+func (h *SubscriptionHandler) updateSubscription(c *gin.Context) {
+	subscription, ok := h.findSubscription(c)
+	if !ok {
+		return
+	}
+	previous := *subscription
+
+	var req SubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+	if err := h.applyRequest(subscription, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.SubscriptionService.UpdateSubscription(subscription); err != nil {
+		h.App.Logger.Errorf("更新订阅失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "更新订阅失败",
+		})
+		return
+	}
+
+	// 不再使用 WebSub 或订阅地址改变时取消原来的 WebSub 订阅，下次检查时重新订阅
+	if previous.Mode == model.SubscriptionModeWebSub &&
+		(subscription.Mode != model.SubscriptionModeWebSub || !subscription.Enabled || subscription.SourceID != previous.SourceID) {
+		h.stopWebSub(c.Request.Context(), &previous)
+		subscription.WebSubExpiresAt = nil
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "订阅已更新",
+		"data":    subscription,
+	})
+}
+
+// deleteSubscription 删除订阅，已加入的视频不受影响
+func (h *SubscriptionHandler) deleteSubscription(c *gin.Context) {
+	subscription, ok := h.findSubscription(c)
+	if !ok {
+		return
+	}
+
+	if err := h.SubscriptionService.DeleteSubscription(subscription.ID); err != nil {
+		h.App.Logger.Errorf("删除订阅失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "删除订阅失败",
+		})
+		return
+	}
+	h.stopWebSub(c.Request.Context(), subscription)
+
+	h.App.Logger.Infof("🗑️ 删除订阅: %s", subscription.URL)
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "订阅已删除",
+		"data": gin.H{
+			"id": subscription.ID,
+		},
+	})
+}
+
+// checkSubscription 立即检查订阅，将新视频加入待处理列表
+func (h *SubscriptionHandler) checkSubscription(c *gin.Context) {
+	subscription, ok := h.findSubscription(c)
+	if !ok {
+		return
+	}
+
+	if h.Checker == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "订阅调度器未初始化",
+		})
+		return
+	}
+
+	added, err := h.Checker.CheckSubscription(c.Request.Context(), subscription.ID)
+	if errors.Is(err, services.ErrCheckInProgress) {
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    409,
+			"message": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"code":    502,
+			"message": fmt.Sprintf("检查订阅失败: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": fmt.Sprintf("新增 %d 个待处理视频", added),
+		"data": gin.H{
+			"id":    subscription.ID,
+			"added": added,
+		},
+	})
+}
+
+// stopWebSub 取消 WebSub 订阅，失败时 Hub 在订阅到期后自动停止推送
+func (h *SubscriptionHandler) stopWebSub(ctx context.Context, subscription *model.Subscription) {
+	if h.Checker == nil {
+		return
+	}
+	if err := h.Checker.StopWebSub(ctx, subscription); err != nil {
+		h.App.Logger.Warnf("⚠️ 取消 WebSub 订阅 %s 失败: %v", subscription.URL, err)
+	}
+}
+
+// findSubscription 根据路径参数获取订阅，不存在时返回 404
+func (h *SubscriptionHandler) findSubscription(c *gin.Context) (*model.Subscription, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的订阅ID",
+		})
+		return nil, false
+	}
+
+	subscription, err := h.SubscriptionService.GetSubscription(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"code":    404,
+				"message": "订阅不存在",
+			})
+		} else {
+			h.App.Logger.Errorf("获取订阅失败: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "获取订阅失败",
+			})
+		}
+		return nil, false
+	}
+	return subscription, true
+}
+
+// applyRequest 将请求中的字段应用到订阅并校验
+func (h *SubscriptionHandler) applyRequest(subscription *model.Subscription, req *SubscriptionRequest) error {
+	if req.URL != nil {
+		u := strings.TrimSpace(*req.URL)
+		if u != subscription.URL {
+			subscription.SourceID = ""
+		}
+		subscription.URL = u
+		subscription.Kind = ""
+	}
+	if req.Kind != nil {
+		subscription.Kind = *req.Kind
+	}
+	if req.Title != nil {
+		subscription.Title = *req.Title
+	}
+	if req.Enabled != nil {
+		subscription.Enabled = *req.Enabled
+	}
+	if req.Pipeline != nil {
+		subscription.Pipeline = *req.Pipeline
+	}
+	if req.MaxItems != nil {
+		subscription.MaxItems = *req.MaxItems
+	}
+	if req.CheckInterval != nil {
+		subscription.CheckInterval = *req.CheckInterval
+	}
+	if req.Mode != nil {
+		subscription.Mode = *req.Mode
+	}
+	if req.FilterRules != nil {
+		if err := req.FilterRules.Validate(); err != nil {
+			return fmt.Errorf("过滤规则无效: %v", err)
+		}
+		subscription.SetFilterRules(req.FilterRules)
+	}
+
+	u, err := url.Parse(subscription.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("无效的 URL: %s", subscription.URL)
+	}
+	if subscription.Kind == "" {
+		subscription.Kind = model.SubscriptionKindChannel
+		if u.Query().Get("list") != "" {
+			subscription.Kind = model.SubscriptionKindPlaylist
+		}
+	}
+	if subscription.Kind != model.SubscriptionKindChannel && subscription.Kind != model.SubscriptionKindPlaylist {
+		return fmt.Errorf("未知的订阅类型: %s", subscription.Kind)
+	}
+	if subscription.MaxItems < 0 || subscription.CheckInterval < 0 {
+		return fmt.Errorf("max_items 和 check_interval 不能为负数")
+	}
+	if err := h.validateMode(subscription, u); err != nil {
+		return err
+	}
+
+	return h.validatePipeline(subscription.Pipeline, subscription.FilterRules != "")
+}
+
+// validateMode 检查订阅的检查方式，订阅源和 WebSub 只支持 YouTube
+func (h *SubscriptionHandler) validateMode(subscription *model.Subscription, u *url.URL) error {
+	switch subscription.Mode {
+	case "", model.SubscriptionModeYtDlp:
+		return nil
+	case model.SubscriptionModeFeed, model.SubscriptionModeWebSub:
+		if !strings.HasSuffix(u.Hostname(), "youtube.com") {
+			return fmt.Errorf("检查方式 %s 只支持 YouTube 的频道和播放列表", subscription.Mode)
+		}
+		if subscription.Mode == model.SubscriptionModeWebSub {
+			if cfg := h.App.Config.SubscriptionConfig; cfg == nil || cfg.PublicURL == "" {
+				return fmt.Errorf("使用 WebSub 需要在订阅配置中设置 public_url")
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("未知的检查方式: %s", subscription.Mode)
+}
+
+// validatePipeline 检查订阅使用的处理流程
+func (h *SubscriptionHandler) validatePipeline(name string, hasFilterRules bool) error {
+	if name == "" && h.App.Config.SubscriptionConfig != nil {
+		name = h.App.Config.SubscriptionConfig.Pipeline
+	}
+	if err := validatePipelineWithoutSubtitles(h.App.Config, name); err != nil {
+		return err
+	}
+	// 过滤规则由 probe 步骤检查，流程中没有该步骤时规则不会生效
+	if hasFilterRules {
+		pipeline, _ := steps.Resolve(h.App.Config, name)
+		if !pipeline.Has(steps.Probe) {
+			return fmt.Errorf("处理流程 %s 不包含 %s 步骤，过滤规则不会生效", pipelineDisplayName(pipeline), steps.Probe)
+		}
+	}
+	return nil
+}
+
+// validatePipelineWithoutSubtitles 检查没有插件字幕的视频（订阅、批量导入）使用的处理流程
+// 这些视频没有插件提交的字幕，流程需要通过语音识别生成字幕
+func validatePipelineWithoutSubtitles(config *types.AppConfig, name string) error {
+	pipeline, err := steps.ResolveRemote(config, name)
+	if err != nil {
+		return err
+	}
+	if pipeline.Has(steps.GenerateSubtitles) {
+		return fmt.Errorf("处理流程 %s 使用插件提交的字幕，该视频没有插件字幕，请使用包含语音识别的流程", pipelineDisplayName(pipeline))
+	}
+	return nil
+}
+
+// pipelineDisplayName 流程名称，自动选择步骤的流程显示为 auto
+func pipelineDisplayName(pipeline *steps.Pipeline) string {
+	if pipeline.Name == "" {
+		return "auto"
+	}
+	return pipeline.Name
+}
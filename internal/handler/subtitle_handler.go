@@ -1,11 +1,14 @@
 package handler
 
 import (
+	"github.com/difyz9/ytb2bili/internal/chain_task/steps"
 	"github.com/difyz9/ytb2bili/internal/core"
+	"github.com/difyz9/ytb2bili/internal/core/services"
 	"github.com/difyz9/ytb2bili/pkg/auth"
 	"github.com/difyz9/ytb2bili/pkg/store/model"
-	"github.com/difyz9/ytb2bili/pkg/utils"
+	"github.com/difyz9/ytb2bili/pkg/source"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -21,12 +24,14 @@ import (
 
 type SubtitleHandler struct {
 	BaseHandler
+	SavedVideoService *services.SavedVideoService
 }
 
-func NewSubtitleHandler(app *core.AppServer) *SubtitleHandler {
+func NewSubtitleHandler(app *core.AppServer, savedVideoService *services.SavedVideoService) *SubtitleHandler {
 
 	return &SubtitleHandler{
-		BaseHandler: BaseHandler{App: app},
+		BaseHandler:       BaseHandler{App: app},
+		SavedVideoService: savedVideoService,
 	}
 }
 
@@ -36,6 +41,7 @@ type SaveVideoRequest struct {
 	Title         string                     `json:"title"`
 	Description   string                     `json:"description"`
 	OperationType string                     `json:"operationType"`
+	Pipeline      string                     `json:"pipeline"` // 处理流程名称，为空时使用与 operationType 同名的流程或默认流程
 	Subtitles     []model.SavedVideoSubtitle `json:"subtitles"`
 	PlaylistID    string                     `json:"playlistId"`
 	Timestamp     string                     `json:"timestamp"`
@@ -88,15 +94,26 @@ func (h *SubtitleHandler) saveVideoSubtitles(c *gin.Context) {
 		}
 	}
 
+	// 确定视频使用的处理流程
+	pipeline, err := h.selectPipeline(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
 	// 从 URL 中提取 videoId
-	videoID := utils.ExtractVideoID(req.URL)
-	if videoID == "" {
+	id, _, err := source.ParseRemote(req.URL)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"message": "Invalid video URL: cannot extract video ID",
+			"message": "Invalid video URL: " + err.Error(),
 		})
 		return
 	}
+	videoID := id.Key()
 	fmt.Println("Extracted videoId:", videoID)
 
 	// 将字幕数组转换为JSON字符串
@@ -132,18 +149,27 @@ func (h *SubtitleHandler) saveVideoSubtitles(c *gin.Context) {
 		// 找到了记录（可能是已删除的），更新字段
 		isExisting = true
 		existingVideo.URL = req.URL
+		existingVideo.CanonicalID = id.String()
 		existingVideo.Title = req.Title
 		existingVideo.Description = req.Description
 		existingVideo.OperationType = req.OperationType
+		existingVideo.Pipeline = pipeline
+		existingVideo.Origin = model.OriginExtension
 		existingVideo.Subtitles = subtitlesJSONStr
 		existingVideo.PlaylistID = req.PlaylistID
 		existingVideo.Timestamp = req.Timestamp
 		existingVideo.SavedAt = req.SavedAt
-		existingVideo.Status = "001" // 重置状态为待处理
-		existingVideo.DeletedAt = gorm.DeletedAt{} // 恢复记录（清除删除标记）
-
-		// 更新到数据库（使用 Unscoped 以便更新已删除的记录）
-		if err := h.App.DB.Unscoped().Save(&existingVideo).Error; err != nil {
+		restored := existingVideo.DeletedAt.Valid
+
+		// 重置状态为待处理并恢复已删除的记录，视频正在处理或上传时拒绝
+		if err := h.SavedVideoService.ResubmitVideo(&existingVideo, statusActor(c)); err != nil {
+			if errors.Is(err, services.ErrInvalidStatusTransition) {
+				c.JSON(http.StatusConflict, gin.H{
+					"success": false,
+					"message": "Video is being processed: " + err.Error(),
+				})
+				return
+			}
 			fmt.Printf("更新视频失败，字幕数据长度: %d\n", len(subtitlesJSONStr))
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"success": false,
@@ -153,18 +179,21 @@ func (h *SubtitleHandler) saveVideoSubtitles(c *gin.Context) {
 		}
 		savedVideo = &existingVideo
 		
-		if existingVideo.DeletedAt.Valid {
+		if restored {
 			fmt.Printf("✅ 恢复已删除的视频: %s\n", videoID)
 		}
 	} else if err == gorm.ErrRecordNotFound {
 		// 记录不存在，创建新记录
 		savedVideo = &model.SavedVideo{
 			VideoID:       videoID,
+			CanonicalID:   id.String(),
 			URL:           req.URL,
 			Title:         req.Title,
-			Status:        "001",
+			Status:        model.VideoStatusPending,
 			Description:   req.Description,
 			OperationType: req.OperationType,
+			Pipeline:      pipeline,
+			Origin:        model.OriginExtension,
 			Subtitles:     subtitlesJSONStr,
 			PlaylistID:    req.PlaylistID,
 			Timestamp:     req.Timestamp,
@@ -172,7 +201,7 @@ func (h *SubtitleHandler) saveVideoSubtitles(c *gin.Context) {
 		}
 
 		// 保存到数据库
-		if err := h.App.DB.Create(savedVideo).Error; err != nil {
+		if err := h.SavedVideoService.CreateVideo(savedVideo, statusActor(c), "插件提交"); err != nil {
 			fmt.Printf("创建视频失败，字幕数据长度: %d\n", len(subtitlesJSONStr))
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"success": false,
@@ -204,16 +233,38 @@ func (h *SubtitleHandler) saveVideoSubtitles(c *gin.Context) {
 			"id":            savedVideo.ID,
 			"title":         savedVideo.Title,
 			"operationType": savedVideo.OperationType,
+			"pipeline":      savedVideo.Pipeline,
 			"subtitleCount": subtitleCount,
 			"isExisting":    isExisting,
 		},
 	})
 }
 
+// selectPipeline 确定视频使用的处理流程
+// 优先使用请求指定的流程，其次是与操作类型同名的流程，都没有时返回空字符串表示默认流程
+func (h *SubtitleHandler) selectPipeline(req SaveVideoRequest) (string, error) {
+	name := req.Pipeline
+	if name == "" {
+		if req.OperationType == "" {
+			return "", nil
+		}
+		if _, _, ok := h.App.Config.PipelineConfig.GetPipeline(req.OperationType); !ok {
+			return "", nil
+		}
+		name = req.OperationType
+	}
+	if _, err := steps.ResolveRemote(h.App.Config, name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
 // RegisterRoutes 注册上传相关路由（无认证）
 func (h *SubtitleHandler) RegisterRoutes(server *core.AppServer) {
 	api := server.Engine.Group("/api/v1")
 	api.POST("/submit", h.saveVideoSubtitles)
+	api.POST("/submit/batch", h.submitBatch)
+	api.POST("/submit/local", h.submitLocal)
 }
 
 // RegisterRoutesWithAuth 注册上传相关路由（带认证和解密）
@@ -226,6 +277,8 @@ func (h *SubtitleHandler) RegisterRoutesWithAuth(server *core.AppServer, authMid
 
 	// 为 /submit 路由添加认证中间件和解密中间件
 	api.POST("/submit", authMiddleware.Handler(), decryptMiddleware, h.saveVideoSubtitles)
+	api.POST("/submit/batch", authMiddleware.Handler(), h.submitBatch)
+	api.POST("/submit/local", authMiddleware.Handler(), h.submitLocal)
 }
 
 // saveCookiesToFile 保存 cookies 到文件（Netscape 格式）
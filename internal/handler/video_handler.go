@@ -2,15 +2,19 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/difyz9/ytb2bili/internal/chain_task/steps"
 	"github.com/difyz9/ytb2bili/internal/core"
 	"github.com/difyz9/ytb2bili/internal/core/services"
+	"github.com/difyz9/ytb2bili/pkg/filter"
 	"github.com/difyz9/ytb2bili/pkg/store/model"
 
 	"github.com/gin-gonic/gin"
@@ -20,17 +24,22 @@ type VideoHandler struct {
 	BaseHandler
 	SavedVideoService *services.SavedVideoService
 	TaskStepService   *services.TaskStepService
+	ArtifactService   *services.ArtifactService
 	UploadScheduler   interface {
 		ExecuteManualUpload(videoID, taskType string) error
 	}
+	TaskCanceller interface {
+		CancelVideo(videoID, actor string) error
+	}
 	AnalyticsHandler *AnalyticsHandler
 }
 
-func NewVideoHandler(app *core.AppServer, savedVideoService *services.SavedVideoService, taskStepService *services.TaskStepService) *VideoHandler {
+func NewVideoHandler(app *core.AppServer, savedVideoService *services.SavedVideoService, taskStepService *services.TaskStepService, artifactService *services.ArtifactService) *VideoHandler {
 	return &VideoHandler{
 		BaseHandler:       BaseHandler{App: app},
 		SavedVideoService: savedVideoService,
 		TaskStepService:   taskStepService,
+		ArtifactService:   artifactService,
 		UploadScheduler:   nil, // Will be set later via SetUploadScheduler
 	}
 }
@@ -42,6 +51,13 @@ func (h *VideoHandler) SetUploadScheduler(scheduler interface {
 	h.UploadScheduler = scheduler
 }
 
+// SetTaskCanceller 设置任务取消器（避免循环依赖）
+func (h *VideoHandler) SetTaskCanceller(canceller interface {
+	CancelVideo(videoID, actor string) error
+}) {
+	h.TaskCanceller = canceller
+}
+
 // RegisterRoutes 注册视频相关路由
 func (h *VideoHandler) RegisterRoutes(api *gin.RouterGroup) {
 	video := api.Group("/videos")
@@ -49,8 +65,10 @@ func (h *VideoHandler) RegisterRoutes(api *gin.RouterGroup) {
 		video.GET("", h.getVideoList)
 		video.GET("/:id", h.getVideoDetail)
 		video.DELETE("/:id", h.deleteVideo)
-		video.POST("/:id/steps/:stepName/retry", h.retryTaskStep)
+		video.POST("/:id/cancel", h.cancelVideo)
+		video.POST("/:id/steps/:step/retry", h.retryTaskStep)
 		video.GET("/:id/files", h.getVideoFiles)
+		video.GET("/:id/history", h.getVideoStatusHistory)
 		video.POST("/:id/upload/video", h.manualUploadVideo)
 		video.POST("/:id/upload/subtitle", h.manualUploadSubtitle)
 	}
@@ -75,9 +93,26 @@ type VideoListData struct {
 type VideoInfo struct {
 	ID             uint                   `json:"id"`
 	VideoID        string                 `json:"video_id"`
+	CanonicalID    string                 `json:"canonical_id"` // 带平台前缀的规范ID，如 youtube:dQw4w9WgXcQ
 	Title          string                 `json:"title"`
 	URL            string                 `json:"url"`
-	Status         string                 `json:"status"`
+	Status         model.VideoStatus      `json:"status"`
+	Pipeline       string                 `json:"pipeline"`                  // 处理流程名称，为空表示默认流程
+	SubtitleSource string                 `json:"subtitle_source,omitempty"` // 原语言字幕的来源，如 manual_source、auto、asr
+	FormatID       string                 `json:"format_id,omitempty"`       // 下载时选择的 yt-dlp 格式ID
+	Resolution     string                 `json:"resolution,omitempty"`      // 下载的视频分辨率
+	Codec          string                 `json:"codec,omitempty"`           // 下载的视频编码
+	Uploader       string                 `json:"uploader,omitempty"`        // 原视频作者
+	ChannelID      string                 `json:"channel_id,omitempty"`      // 原视频的频道ID
+	UploadDate     string                 `json:"upload_date,omitempty"`     // 原视频发布日期，格式 2006-01-02
+	Duration       int                    `json:"duration,omitempty"`        // 时长（秒）
+	ViewCount      int64                  `json:"view_count,omitempty"`      // 播放量
+	Language       string                 `json:"language,omitempty"`        // 原视频语言
+	ThumbnailURL   string                 `json:"thumbnail_url,omitempty"`   // 原视频封面地址
+	SourceTags     []string               `json:"source_tags,omitempty"`     // 原视频标签
+	Categories     []string               `json:"categories,omitempty"`      // 原视频分类
+	Chapters       []model.Chapter        `json:"chapters,omitempty"`        // 原视频章节
+	ChapterSource  string                 `json:"chapter_source,omitempty"`  // 章节的来源
 	GeneratedTitle string                 `json:"generated_title"`
 	GeneratedDesc  string                 `json:"generated_desc"`
 	GeneratedTags  string                 `json:"generated_tags"`
@@ -93,14 +128,17 @@ type VideoInfo struct {
 
 // TaskStepInfo 任务步骤信息
 type TaskStepInfo struct {
-	StepName  string `json:"step_name"`
-	StepOrder int    `json:"step_order"`
-	Status    string `json:"status"`
-	StartTime string `json:"start_time"`
-	EndTime   string `json:"end_time"`
-	Duration  int64  `json:"duration"`
-	ErrorMsg  string `json:"error_msg"`
-	CanRetry  bool   `json:"can_retry"`
+	StepKey    string `json:"step_key"`
+	StepName   string `json:"step_name"`
+	StepOrder  int    `json:"step_order"`
+	Status     string `json:"status"`
+	StartTime  string `json:"start_time"`
+	EndTime    string `json:"end_time"`
+	Duration   int64  `json:"duration"`
+	ErrorMsg   string `json:"error_msg"`
+	ErrorClass string `json:"error_class,omitempty"`
+	Attempts   int    `json:"attempts"`
+	CanRetry   bool   `json:"can_retry"`
 }
 
 // getVideoList 获取视频列表
@@ -122,8 +160,18 @@ func (h *VideoHandler) getVideoList(c *gin.Context) {
 	// 计算偏移量
 	offset := (page - 1) * limit
 
+	// 解析筛选和排序参数
+	query, err := parseVideoListQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, VideoListResponse{
+			Code:    400,
+			Message: err.Error(),
+		})
+		return
+	}
+
 	// 获取视频列表
-	savedVideos, total, err := h.SavedVideoService.GetVideosPaginated(offset, limit)
+	savedVideos, total, err := h.SavedVideoService.QueryVideos(query, offset, limit)
 	if err != nil {
 		h.App.Logger.Errorf("获取视频列表失败: %v", err)
 		c.JSON(http.StatusInternalServerError, VideoListResponse{
@@ -139,9 +187,17 @@ func (h *VideoHandler) getVideoList(c *gin.Context) {
 		videos = append(videos, VideoInfo{
 			ID:             sv.ID,
 			VideoID:        sv.VideoID,
+			CanonicalID:    sv.CanonicalID,
 			Title:          sv.Title,
 			URL:            sv.URL,
 			Status:         sv.Status,
+			Pipeline:       sv.Pipeline,
+			SubtitleSource: sv.SubtitleSource,
+			Uploader:       sv.Uploader,
+			UploadDate:     sv.UploadDate,
+			Duration:       sv.Duration,
+			ViewCount:      sv.ViewCount,
+			Language:       sv.Language,
 			GeneratedTitle: sv.GeneratedTitle,
 			GeneratedDesc:  sv.GeneratedDesc,
 			GeneratedTags:  sv.GeneratedTags,
@@ -164,6 +220,59 @@ func (h *VideoHandler) getVideoList(c *gin.Context) {
 	})
 }
 
+// parseVideoListQuery 解析视频列表的筛选和排序参数
+// 支持 status、keyword、uploader、channel_id、language、tag、category、uploaded_after、uploaded_before、
+// min_duration、max_duration、min_views，排序参数 sort 和 order（asc 或 desc，默认 desc）
+func parseVideoListQuery(c *gin.Context) (services.VideoListQuery, error) {
+	query := services.VideoListQuery{
+		Status:         model.VideoStatus(c.Query("status")),
+		Keyword:        strings.TrimSpace(c.Query("keyword")),
+		Uploader:       strings.TrimSpace(c.Query("uploader")),
+		ChannelID:      strings.TrimSpace(c.Query("channel_id")),
+		Language:       strings.TrimSpace(c.Query("language")),
+		Tag:            strings.TrimSpace(c.Query("tag")),
+		Category:       strings.TrimSpace(c.Query("category")),
+		UploadedAfter:  c.Query("uploaded_after"),
+		UploadedBefore: c.Query("uploaded_before"),
+		Sort:           c.Query("sort"),
+	}
+	for _, date := range []string{query.UploadedAfter, query.UploadedBefore} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(filter.DateLayout, date); err != nil {
+			return query, fmt.Errorf("日期 %s 格式错误，应为 %s", date, filter.DateLayout)
+		}
+	}
+	for name, target := range map[string]*int{"min_duration": &query.MinDuration, "max_duration": &query.MaxDuration} {
+		if value := c.Query(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return query, fmt.Errorf("%s 必须是非负整数", name)
+			}
+			*target = n
+		}
+	}
+	if value := c.Query("min_views"); value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 0 {
+			return query, fmt.Errorf("min_views 必须是非负整数")
+		}
+		query.MinViews = n
+	}
+	if _, ok := services.VideoSortFields[query.Sort]; query.Sort != "" && !ok {
+		return query, fmt.Errorf("不支持的排序字段: %s", query.Sort)
+	}
+	switch order := strings.ToLower(c.Query("order")); order {
+	case "", "desc":
+	case "asc":
+		query.Asc = true
+	default:
+		return query, fmt.Errorf("order 只能是 asc 或 desc")
+	}
+	return query, nil
+}
+
 // getVideoDetail 获取视频详情
 func (h *VideoHandler) getVideoDetail(c *gin.Context) {
 	idStr := c.Param("id")
@@ -199,12 +308,15 @@ func (h *VideoHandler) getVideoDetail(c *gin.Context) {
 	var taskStepInfos []TaskStepInfo
 	for _, step := range taskSteps {
 		stepInfo := TaskStepInfo{
-			StepName:  step.StepName,
-			StepOrder: step.StepOrder,
-			Status:    step.Status,
-			Duration:  step.Duration,
-			ErrorMsg:  step.ErrorMsg,
-			CanRetry:  step.CanRetry,
+			StepKey:    step.StepKey,
+			StepName:   step.StepName,
+			StepOrder:  step.StepOrder,
+			Status:     step.Status,
+			Duration:   step.Duration,
+			ErrorMsg:   step.ErrorMsg,
+			ErrorClass: step.ErrorClass,
+			Attempts:   step.Attempts,
+			CanRetry:   step.CanRetry,
 		}
 
 		if step.StartTime != nil {
@@ -232,9 +344,26 @@ func (h *VideoHandler) getVideoDetail(c *gin.Context) {
 	videoInfo := VideoInfo{
 		ID:             savedVideo.ID,
 		VideoID:        savedVideo.VideoID,
+		CanonicalID:    savedVideo.CanonicalID,
 		Title:          savedVideo.Title,
 		URL:            savedVideo.URL,
 		Status:         savedVideo.Status,
+		Pipeline:       savedVideo.Pipeline,
+		SubtitleSource: savedVideo.SubtitleSource,
+		FormatID:       savedVideo.FormatID,
+		Resolution:     savedVideo.Resolution,
+		Codec:          savedVideo.Codec,
+		Uploader:       savedVideo.Uploader,
+		ChannelID:      savedVideo.ChannelID,
+		UploadDate:     savedVideo.UploadDate,
+		Duration:       savedVideo.Duration,
+		ViewCount:      savedVideo.ViewCount,
+		Language:       savedVideo.Language,
+		ThumbnailURL:   savedVideo.ThumbnailURL,
+		SourceTags:     savedVideo.GetSourceTags(),
+		Categories:     savedVideo.GetCategories(),
+		Chapters:       savedVideo.GetChapters(),
+		ChapterSource:  savedVideo.ChapterSource,
 		GeneratedTitle: savedVideo.GeneratedTitle,
 		GeneratedDesc:  savedVideo.GeneratedDesc,
 		GeneratedTags:  savedVideo.GeneratedTags,
@@ -255,10 +384,19 @@ func (h *VideoHandler) getVideoDetail(c *gin.Context) {
 	})
 }
 
-// retryTaskStep 重新执行任务步骤
+// retryTaskStep 重新执行任务步骤，步骤可以使用标识（如 translate）或显示名称
 func (h *VideoHandler) retryTaskStep(c *gin.Context) {
 	idStr := c.Param("id")
-	stepName := c.Param("stepName")
+
+	step, ok := steps.Lookup(c.Param("step"))
+	if !ok {
+		c.JSON(http.StatusNotFound, VideoListResponse{
+			Code:    404,
+			Message: "任务步骤不存在",
+		})
+		return
+	}
+	stepKey := string(step.Key)
 
 	// 尝试解析为数字ID，如果失败则当作video_id处理
 	var savedVideo *model.SavedVideo
@@ -280,7 +418,7 @@ func (h *VideoHandler) retryTaskStep(c *gin.Context) {
 	}
 
 	// 检查步骤是否存在且可重试
-	taskStep, err := h.TaskStepService.GetTaskStepByName(savedVideo.VideoID, stepName)
+	taskStep, err := h.TaskStepService.GetTaskStepByKey(savedVideo.VideoID, stepKey)
 	if err != nil {
 		c.JSON(http.StatusNotFound, VideoListResponse{
 			Code:    404,
@@ -298,10 +436,19 @@ func (h *VideoHandler) retryTaskStep(c *gin.Context) {
 	}
 
 	// 重新执行任务步骤
-	h.App.Logger.Infof("🔄 用户请求重试任务步骤: %s - %s", savedVideo.VideoID, stepName)
+	h.App.Logger.Infof("🔄 用户请求重试任务步骤: %s - %s", savedVideo.VideoID, stepKey)
+
+	// 上传阶段的步骤交给上传调度器，视频恢复到等待上传的状态
+	if step.Stage == steps.StageUpload {
+		reason := fmt.Sprintf("重试任务步骤 %s", stepKey)
+		if err := h.SavedVideoService.Transition(savedVideo.ID, step.RetryStatus, statusActor(c), reason); err != nil {
+			h.sendStatusError(c, err)
+			return
+		}
+	}
 
 	// 重置任务步骤状态为待执行
-	err = h.TaskStepService.UpdateTaskStepStatus(savedVideo.VideoID, stepName, "pending")
+	err = h.TaskStepService.UpdateTaskStepStatus(savedVideo.VideoID, stepKey, "pending")
 	if err != nil {
 		h.App.Logger.Errorf("更新任务步骤状态失败: %v", err)
 		c.JSON(http.StatusInternalServerError, VideoListResponse{
@@ -311,20 +458,72 @@ func (h *VideoHandler) retryTaskStep(c *gin.Context) {
 		return
 	}
 
-	h.App.Logger.Infof("✅ 任务步骤 %s 已重置为待执行状态，等待调度器处理", stepName)
+	h.App.Logger.Infof("✅ 任务步骤 %s 已重置为待执行状态，等待调度器处理", stepKey)
 
 	c.JSON(http.StatusOK, VideoListResponse{
 		Code:    200,
-		Message: fmt.Sprintf("任务步骤 %s 已加入重新执行队列", stepName),
+		Message: fmt.Sprintf("任务步骤 %s 已加入重新执行队列", step.Name),
 		Data: gin.H{
 			"video_id":  savedVideo.VideoID,
-			"step_name": stepName,
+			"step_key":  stepKey,
+			"step_name": step.Name,
 			"status":    "pending",
 			"message":   "任务已重置，将在下次调度时重新执行",
 		},
 	})
 }
 
+// cancelVideo 取消视频的处理任务
+func (h *VideoHandler) cancelVideo(c *gin.Context) {
+	idStr := c.Param("id")
+
+	// 尝试解析为数字ID，如果失败则当作video_id处理
+	var savedVideo *model.SavedVideo
+	var err error
+
+	if id, parseErr := strconv.ParseUint(idStr, 10, 32); parseErr == nil {
+		savedVideo, err = h.SavedVideoService.GetByID(uint(id))
+	} else {
+		savedVideo, err = h.SavedVideoService.GetVideoByVideoID(idStr)
+	}
+
+	if err != nil {
+		h.App.Logger.Errorf("获取视频详情失败: %v", err)
+		c.JSON(http.StatusNotFound, VideoListResponse{
+			Code:    404,
+			Message: "视频不存在",
+		})
+		return
+	}
+
+	if h.TaskCanceller == nil {
+		c.JSON(http.StatusInternalServerError, VideoListResponse{
+			Code:    500,
+			Message: "任务调度器未初始化",
+		})
+		return
+	}
+
+	h.App.Logger.Infof("🛑 用户请求取消视频任务: %s", savedVideo.VideoID)
+
+	if err := h.TaskCanceller.CancelVideo(savedVideo.VideoID, statusActor(c)); err != nil {
+		c.JSON(http.StatusBadRequest, VideoListResponse{
+			Code:    400,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, VideoListResponse{
+		Code:    200,
+		Message: "任务已取消",
+		Data: gin.H{
+			"video_id": savedVideo.VideoID,
+			"status":   model.VideoStatusCancelled,
+		},
+	})
+}
+
 // deleteVideo 删除视频及其相关数据
 func (h *VideoHandler) deleteVideo(c *gin.Context) {
 	idStr := c.Param("id")
@@ -360,6 +559,11 @@ func (h *VideoHandler) deleteVideo(c *gin.Context) {
 		return
 	}
 
+	// 删除记录的步骤产物
+	if err := h.ArtifactService.DeleteArtifactsByVideoID(savedVideo.VideoID); err != nil {
+		h.App.Logger.Errorf("删除视频产物失败: %v", err)
+	}
+
 	// 2. 删除视频文件（可选）
 	videoDir := h.getVideoDirectory(savedVideo.VideoID)
 	if _, err := os.Stat(videoDir); err == nil {
@@ -431,6 +635,66 @@ func (h *VideoHandler) getVideoFiles(c *gin.Context) {
 	})
 }
 
+// getVideoStatusHistory 获取视频的状态变更历史
+func (h *VideoHandler) getVideoStatusHistory(c *gin.Context) {
+	idStr := c.Param("id")
+
+	// 尝试解析为数字ID，如果失败则当作video_id处理
+	var savedVideo *model.SavedVideo
+	var err error
+
+	if id, parseErr := strconv.ParseUint(idStr, 10, 32); parseErr == nil {
+		savedVideo, err = h.SavedVideoService.GetByID(uint(id))
+	} else {
+		savedVideo, err = h.SavedVideoService.GetVideoByVideoID(idStr)
+	}
+
+	if err != nil {
+		h.App.Logger.Errorf("获取视频详情失败: %v", err)
+		c.JSON(http.StatusNotFound, VideoListResponse{
+			Code:    404,
+			Message: "视频不存在",
+		})
+		return
+	}
+
+	history, err := h.SavedVideoService.GetStatusHistory(savedVideo.VideoID)
+	if err != nil {
+		h.App.Logger.Errorf("获取视频状态历史失败: %v", err)
+		c.JSON(http.StatusInternalServerError, VideoListResponse{
+			Code:    500,
+			Message: "获取视频状态历史失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, VideoListResponse{
+		Code:    200,
+		Message: "success",
+		Data: gin.H{
+			"video_id": savedVideo.VideoID,
+			"status":   savedVideo.Status,
+			"history":  history,
+		},
+	})
+}
+
+// sendStatusError 返回更新视频状态失败的响应，当前状态不允许变更时返回 409
+func (h *VideoHandler) sendStatusError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrInvalidStatusTransition) {
+		c.JSON(http.StatusConflict, VideoListResponse{
+			Code:    409,
+			Message: err.Error(),
+		})
+		return
+	}
+	h.App.Logger.Errorf("更新视频状态失败: %v", err)
+	c.JSON(http.StatusInternalServerError, VideoListResponse{
+		Code:    500,
+		Message: "更新视频状态失败",
+	})
+}
+
 // getVideoMetaData 获取视频元数据
 func (h *VideoHandler) getVideoMetaData(videoID string) map[string]interface{} {
 	videoDir := h.getVideoDirectory(videoID)
@@ -564,7 +828,7 @@ func (h *VideoHandler) manualUploadVideo(c *gin.Context) {
 	}
 
 	// 检查视频状态是否允许上传
-	if savedVideo.Status != "200" && savedVideo.Status != "299" {
+	if savedVideo.Status != model.VideoStatusReady && savedVideo.Status != model.VideoStatusVideoUploadFailed {
 		c.JSON(http.StatusBadRequest, VideoListResponse{
 			Code:    400,
 			Message: fmt.Sprintf("当前状态 %s 不允许上传视频，只有状态为 200(准备就绪) 或 299(上传失败) 的视频才能上传", savedVideo.Status),
@@ -584,12 +848,9 @@ func (h *VideoHandler) manualUploadVideo(c *gin.Context) {
 	h.App.Logger.Infof("🚀 用户手动触发视频上传: %s (%s)", savedVideo.VideoID, savedVideo.Title)
 
 	// 更新状态为上传中
-	if err := h.SavedVideoService.UpdateStatus(savedVideo.ID, "201"); err != nil {
-		h.App.Logger.Errorf("更新视频状态失败: %v", err)
-		c.JSON(http.StatusInternalServerError, VideoListResponse{
-			Code:    500,
-			Message: "更新视频状态失败",
-		})
+	actor := statusActor(c)
+	if err := h.SavedVideoService.Transition(savedVideo.ID, model.VideoStatusUploadingVideo, actor, "手动上传视频"); err != nil {
+		h.sendStatusError(c, err)
 		return
 	}
 
@@ -598,11 +859,13 @@ func (h *VideoHandler) manualUploadVideo(c *gin.Context) {
 		if err := h.UploadScheduler.ExecuteManualUpload(savedVideo.VideoID, "video"); err != nil {
 			h.App.Logger.Errorf("手动上传视频失败: %v", err)
 			// 上传失败，更新状态为 299
-			h.SavedVideoService.UpdateStatus(savedVideo.ID, "299")
+			reason := fmt.Sprintf("手动上传视频失败: %v", err)
+			if err := h.SavedVideoService.Transition(savedVideo.ID, model.VideoStatusVideoUploadFailed, actor, reason); err != nil {
+				h.App.Logger.Errorf("更新视频状态失败: %v", err)
+			}
 		} else {
+			// 上传成功，状态已由上传调度器更新为 300 或 400
 			h.App.Logger.Infof("✅ 手动上传视频成功: %s", savedVideo.VideoID)
-			// 上传成功，更新状态为 300
-			h.SavedVideoService.UpdateStatus(savedVideo.ID, "300")
 		}
 	}()
 
@@ -611,7 +874,7 @@ func (h *VideoHandler) manualUploadVideo(c *gin.Context) {
 		Message: "视频上传任务已启动",
 		Data: gin.H{
 			"video_id": savedVideo.VideoID,
-			"status":   "201",
+			"status":   model.VideoStatusUploadingVideo,
 			"message":  "视频正在后台上传中，请稍后刷新查看结果",
 		},
 	})
@@ -641,7 +904,7 @@ func (h *VideoHandler) manualUploadSubtitle(c *gin.Context) {
 	}
 
 	// 检查视频状态是否允许上传字幕
-	if savedVideo.Status != "300" && savedVideo.Status != "399" {
+	if savedVideo.Status != model.VideoStatusVideoUploaded && savedVideo.Status != model.VideoStatusSubtitleUploadFailed {
 		c.JSON(http.StatusBadRequest, VideoListResponse{
 			Code:    400,
 			Message: fmt.Sprintf("当前状态 %s 不允许上传字幕，只有状态为 300(视频已上传) 或 399(字幕上传失败) 的视频才能上传字幕", savedVideo.Status),
@@ -670,12 +933,9 @@ func (h *VideoHandler) manualUploadSubtitle(c *gin.Context) {
 	h.App.Logger.Infof("🚀 用户手动触发字幕上传: %s (%s)", savedVideo.VideoID, savedVideo.Title)
 
 	// 更新状态为上传字幕中
-	if err := h.SavedVideoService.UpdateStatus(savedVideo.ID, "301"); err != nil {
-		h.App.Logger.Errorf("更新视频状态失败: %v", err)
-		c.JSON(http.StatusInternalServerError, VideoListResponse{
-			Code:    500,
-			Message: "更新视频状态失败",
-		})
+	actor := statusActor(c)
+	if err := h.SavedVideoService.Transition(savedVideo.ID, model.VideoStatusUploadingSubtitle, actor, "手动上传字幕"); err != nil {
+		h.sendStatusError(c, err)
 		return
 	}
 
@@ -684,11 +944,13 @@ func (h *VideoHandler) manualUploadSubtitle(c *gin.Context) {
 		if err := h.UploadScheduler.ExecuteManualUpload(savedVideo.VideoID, "subtitle"); err != nil {
 			h.App.Logger.Errorf("手动上传字幕失败: %v", err)
 			// 上传失败，更新状态为 399
-			h.SavedVideoService.UpdateStatus(savedVideo.ID, "399")
+			reason := fmt.Sprintf("手动上传字幕失败: %v", err)
+			if err := h.SavedVideoService.Transition(savedVideo.ID, model.VideoStatusSubtitleUploadFailed, actor, reason); err != nil {
+				h.App.Logger.Errorf("更新视频状态失败: %v", err)
+			}
 		} else {
+			// 上传成功，状态已由上传调度器更新为 400
 			h.App.Logger.Infof("✅ 手动上传字幕成功: %s", savedVideo.VideoID)
-			// 上传成功，更新状态为 400
-			h.SavedVideoService.UpdateStatus(savedVideo.ID, "400")
 		}
 	}()
 
@@ -697,7 +959,7 @@ func (h *VideoHandler) manualUploadSubtitle(c *gin.Context) {
 		Message: "字幕上传任务已启动",
 		Data: gin.H{
 			"video_id": savedVideo.VideoID,
-			"status":   "301",
+			"status":   model.VideoStatusUploadingSubtitle,
 			"message":  "字幕正在后台上传中，请稍后刷新查看结果",
 		},
 	})
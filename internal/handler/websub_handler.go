@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/difyz9/ytb2bili/internal/core"
+	"github.com/difyz9/ytb2bili/internal/core/services"
+	"github.com/difyz9/ytb2bili/pkg/feed"
+	"github.com/difyz9/ytb2bili/pkg/store/model"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// WebSubHandler 接收 WebSub Hub 的验证请求和新视频推送
+// 回调地址为 /api/v1/websub/{订阅ID}，由 Hub 访问，不需要登录
+type WebSubHandler struct {
+	BaseHandler
+	SubscriptionService *services.SubscriptionService
+}
+
+// NewWebSubHandler 创建 WebSub 处理器
+func NewWebSubHandler(app *core.AppServer, subscriptionService *services.SubscriptionService) *WebSubHandler {
+	return &WebSubHandler{
+		BaseHandler:         BaseHandler{App: app},
+		SubscriptionService: subscriptionService,
+	}
+}
+
+// RegisterRoutes 注册 WebSub 回调路由
+func (h *WebSubHandler) RegisterRoutes(api *gin.RouterGroup) {
+	api.GET("/websub/:id", h.verifyIntent)
+	api.POST("/websub/:id", h.receiveNotification)
+}
+
+// verifyIntent 响应 Hub 的订阅验证请求，确认后原样返回 hub.challenge
+func (h *WebSubHandler) verifyIntent(c *gin.Context) {
+	mode := c.Query("hub.mode")
+	topic := c.Query("hub.topic")
+	challenge := c.Query("hub.challenge")
+
+	subscription, err := h.findSubscription(c)
+	if err != nil {
+		// 订阅已删除时确认取消订阅，其他请求一律拒绝
+		if errors.Is(err, gorm.ErrRecordNotFound) && mode == "unsubscribe" && challenge != "" {
+			c.String(http.StatusOK, challenge)
+			return
+		}
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	switch mode {
+	case "subscribe":
+		if challenge == "" || subscription.Mode != model.SubscriptionModeWebSub || !subscription.Enabled ||
+			topic != services.SubscriptionFeedURL(subscription) {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		leaseSeconds, _ := strconv.Atoi(c.Query("hub.lease_seconds"))
+		if leaseSeconds <= 0 && h.App.Config.SubscriptionConfig != nil {
+			leaseSeconds = h.App.Config.SubscriptionConfig.LeaseSeconds
+		}
+		expiresAt := time.Now().Add(time.Duration(leaseSeconds) * time.Second)
+		if err := h.SubscriptionService.SetWebSubExpiresAt(subscription.ID, &expiresAt); err != nil {
+			h.App.Logger.Errorf("记录 WebSub 到期时间失败: %v", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		h.App.Logger.Infof("📡 WebSub 订阅已生效: %s，到期时间 %s", subscription.URL, expiresAt.Format(time.RFC3339))
+		c.String(http.StatusOK, challenge)
+
+	case "unsubscribe":
+		// 仍在使用的主题不允许取消，避免他人伪造取消请求
+		if challenge == "" || (subscription.Mode == model.SubscriptionModeWebSub && subscription.Enabled &&
+			topic == services.SubscriptionFeedURL(subscription)) {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.String(http.StatusOK, challenge)
+
+	case "denied":
+		reason := c.Query("hub.reason")
+		h.App.Logger.Warnf("⚠️ WebSub Hub 拒绝了订阅 %s: %s", subscription.URL, reason)
+		if err := h.SubscriptionService.SetWebSubExpiresAt(subscription.ID, nil); err != nil {
+			h.App.Logger.Errorf("清除 WebSub 到期时间失败: %v", err)
+		}
+		c.Status(http.StatusOK)
+
+	default:
+		c.Status(http.StatusBadRequest)
+	}
+}
+
+// receiveNotification 接收 Hub 推送的新视频
+// 签名无效的内容按照 WebSub 规范仍返回 2xx，但不处理，避免 Hub 重试
+func (h *WebSubHandler) receiveNotification(c *gin.Context) {
+	subscription, err := h.findSubscription(c)
+	if err != nil {
+		c.Status(http.StatusGone)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	if subscription.WebSubSecret == "" || !feed.VerifySignature(subscription.WebSubSecret, body, c.GetHeader("X-Hub-Signature")) {
+		h.App.Logger.Warnf("⚠️ 忽略签名无效的 WebSub 推送: 订阅 %d", subscription.ID)
+		c.Status(http.StatusAccepted)
+		return
+	}
+	if subscription.Mode != model.SubscriptionModeWebSub || !subscription.Enabled {
+		c.Status(http.StatusAccepted)
+		return
+	}
+
+	f, err := feed.Parse(body)
+	if err != nil {
+		h.App.Logger.Warnf("⚠️ 解析 WebSub 推送失败: %v", err)
+		c.Status(http.StatusAccepted)
+		return
+	}
+
+	// 旧视频更新标题或描述时也会推送，只处理订阅创建后发布的视频
+	pipeline := h.App.Config.SubscriptionConfig.GetPipeline(subscription.Pipeline)
+	added, err := h.SubscriptionService.EnqueueFeedEntries(subscription, f.Entries, pipeline, subscription.CreatedAt)
+	if err != nil {
+		h.App.Logger.Errorf("❌ 保存 WebSub 推送的视频失败: %v", err)
+	}
+	if added > 0 {
+		h.App.Logger.Infof("📥 WebSub 推送: 订阅 %s 新增 %d 个待处理视频", subscription.URL, added)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// findSubscription 根据路径参数获取订阅
+func (h *WebSubHandler) findSubscription(c *gin.Context) (*model.Subscription, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return h.SubscriptionService.GetSubscription(uint(id))
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/difyz9/ytb2bili/pkg/analytics"
 	"github.com/difyz9/ytb2bili/pkg/auth"
 	"github.com/difyz9/ytb2bili/pkg/cos"
+	"github.com/difyz9/ytb2bili/pkg/events"
 	"github.com/difyz9/ytb2bili/pkg/logger"
 	biliAccountService "github.com/difyz9/ytb2bili/pkg/services"
 	"github.com/difyz9/ytb2bili/pkg/store"
@@ -74,6 +75,7 @@ func main() {
 		fx.Provide(store.NewDatabase),
 
 		// 核心模块
+		fx.Provide(events.NewBus),
 		fx.Provide(core.NewServer),
 		fx.Provide(cos.NewCosClient),
 
@@ -120,6 +122,9 @@ func main() {
 		fx.Provide(services.NewVideoService),
 		fx.Provide(services.NewSavedVideoService),
 		fx.Provide(services.NewTaskStepService),
+		fx.Provide(services.NewArtifactService),
+		fx.Provide(services.NewJobService),
+		fx.Provide(services.NewSubscriptionService),
 		fx.Provide(biliAccountService.NewBilibiliAccountService),
 
 		// 注册cron
@@ -157,6 +162,32 @@ func main() {
 			s.SetUp()
 		}),
 
+		// 添加订阅调度器
+		fx.Provide(chain_task.NewSubscriptionScheduler),
+		fx.Invoke(func(s *chain_task.SubscriptionScheduler) {
+			// 定时检查订阅的频道和播放列表，新视频加入待处理列表
+			s.SetUp()
+		}),
+
+		// 添加监控目录调度器
+		fx.Provide(chain_task.NewLocalImportScheduler),
+		fx.Invoke(func(s *chain_task.LocalImportScheduler) {
+			// 定时扫描监控目录，新的视频文件加入待处理列表
+			s.SetUp()
+		}),
+
+		// 关闭时中断正在执行的任务，被中断的任务在下次启动时继续
+		fx.Invoke(func(lifecycle fx.Lifecycle, h *chain_task.ChainTaskHandler, s *chain_task.UploadScheduler, subscriptionScheduler *chain_task.SubscriptionScheduler, localImportScheduler *chain_task.LocalImportScheduler) {
+			lifecycle.Append(fx.Hook{
+				OnStop: func(ctx context.Context) error {
+					subscriptionScheduler.Shutdown()
+					localImportScheduler.Shutdown()
+					s.Shutdown()
+					return h.Shutdown(ctx)
+				},
+			})
+		}),
+
 		// 初始化应用服务器
 		fx.Invoke(func(server *core.AppServer, db *gorm.DB) {
 			server.Init(db)
@@ -230,15 +261,36 @@ func main() {
 			h *handler.VideoHandler,
 			server *core.AppServer,
 			uploadScheduler *chain_task.UploadScheduler,
+			chainTaskHandler *chain_task.ChainTaskHandler,
 			analyticsHandler *handler.AnalyticsHandler,
 			logger *zap.SugaredLogger,
 		) {
 			h.AnalyticsHandler = analyticsHandler
 			h.SetUploadScheduler(uploadScheduler)
+			h.SetTaskCanceller(chainTaskHandler)
 			h.RegisterRoutes(server.Engine.Group("/api/v1"))
 			logger.Info("✓ Video routes registered")
 		}),
 
+		fx.Provide(handler.NewSubscriptionHandler),
+		fx.Invoke(func(h *handler.SubscriptionHandler, server *core.AppServer, scheduler *chain_task.SubscriptionScheduler, logger *zap.SugaredLogger) {
+			h.SetChecker(scheduler)
+			h.RegisterRoutes(server.Engine.Group("/api/v1"))
+			logger.Info("✓ Subscription routes registered")
+		}),
+
+		fx.Provide(handler.NewWebSubHandler),
+		fx.Invoke(func(h *handler.WebSubHandler, server *core.AppServer, logger *zap.SugaredLogger) {
+			h.RegisterRoutes(server.Engine.Group("/api/v1"))
+			logger.Info("✓ WebSub routes registered")
+		}),
+
+		fx.Provide(handler.NewEventHandler),
+		fx.Invoke(func(h *handler.EventHandler, server *core.AppServer, logger *zap.SugaredLogger) {
+			h.RegisterRoutes(server.Engine.Group("/api/v1"))
+			logger.Info("✓ Event routes registered")
+		}),
+
 		// 健康检查和静态文件服务
 		fx.Invoke(func(server *core.AppServer, logger *zap.SugaredLogger) {
 			// 健康检查
@@ -310,7 +362,8 @@ func main() {
 	log.Println("🛑 Shutting down gracefully...")
 
 	// 关闭应用程序
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// 预留时间结束子进程并写回任务状态
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 	if err := app.Stop(ctx); err != nil {
 		log.Fatal(err)
@@ -0,0 +1,83 @@
+// Package asr 语音识别服务，将音频转换为带时间的文本片段
+package asr
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/difyz9/ytb2bili/pkg/subtitle"
+)
+
+// 语音识别服务名称，用于配置和记录
+const (
+	ProviderBcut       = "bcut"        // B站必剪
+	ProviderWhisperCpp = "whisper_cpp" // 本地 whisper.cpp 命令行程序
+	ProviderOpenAI     = "openai"      // OpenAI 兼容的 /v1/audio/transcriptions 接口
+)
+
+// Word 带时间的单词，服务提供逐词时间时才有
+type Word struct {
+	Start float64 `json:"start"` // 开始时间（秒）
+	End   float64 `json:"end"`   // 结束时间（秒）
+	Text  string  `json:"text"`
+}
+
+// Segment 带时间的文本片段
+type Segment struct {
+	Start float64 `json:"start"` // 开始时间（秒）
+	End   float64 `json:"end"`   // 结束时间（秒）
+	Text  string  `json:"text"`
+	Words []Word  `json:"words,omitempty"`
+}
+
+// Result 语音识别结果
+type Result struct {
+	Provider string    // 生成结果的服务名称
+	Language string    // 服务识别出的语言，未知时为空
+	Segments []Segment // 按时间排序的文本片段
+	Skipped  []string  // 按顺序尝试时失败而跳过的服务及原因
+}
+
+// Options 语音识别选项
+type Options struct {
+	Language string                                     // 识别语言，为空或 auto 时自动检测
+	Progress func(current, total int64, message string) // 报告进度，可以为空
+}
+
+// Provider 语音识别服务
+type Provider interface {
+	// Name 服务名称
+	Name() string
+	// Transcribe 识别音频文件，音频为 16kHz 单声道 WAV
+	Transcribe(ctx context.Context, audioPath string, opts Options) (*Result, error)
+}
+
+// Cues 转换为字幕条目，保留逐词时间，去掉空白的片段
+func (r *Result) Cues() []subtitle.Cue {
+	cues := make([]subtitle.Cue, 0, len(r.Segments))
+	for _, segment := range r.Segments {
+		text := strings.TrimSpace(segment.Text)
+		if text == "" {
+			continue
+		}
+		cue := subtitle.Cue{Start: subtitle.Seconds(segment.Start), End: subtitle.Seconds(segment.End), Text: text}
+		for _, word := range segment.Words {
+			cue.Words = append(cue.Words, subtitle.Word{Start: subtitle.Seconds(word.Start), End: subtitle.Seconds(word.End), Text: word.Text})
+		}
+		cues = append(cues, cue)
+	}
+	return cues
+}
+
+// IsAutoLanguage 是否自动检测语言
+func IsAutoLanguage(language string) bool {
+	return language == "" || strings.EqualFold(language, "auto")
+}
+
+// report 报告进度，没有设置进度回调时忽略
+func (o Options) report(current, total int64, format string, args ...interface{}) {
+	if o.Progress != nil {
+		o.Progress(current, total, fmt.Sprintf(format, args...))
+	}
+}
@@ -0,0 +1,270 @@
+package asr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	bcutBaseURL      = "https://member.bilibili.com/x/bcut/rubick-interface"
+	bcutReqUpload    = bcutBaseURL + "/resource/create"
+	bcutCommitUpload = bcutBaseURL + "/resource/create/complete"
+	bcutCreateTask   = bcutBaseURL + "/task"
+	bcutQueryResult  = bcutBaseURL + "/task/result"
+)
+
+// bcutMinQueries 查询转录结果的最少次数，较长的音频按时长增加查询次数
+const bcutMinQueries = 60
+
+// bcutWAVBytesPerSecond 16kHz 单声道 16 位 WAV 每秒的字节数，用于估计音频时长
+const bcutWAVBytesPerSecond = 32000
+
+// bcutQueryInterval 查询转录结果的间隔
+const bcutQueryInterval = 3 * time.Second
+
+// Bcut B站必剪语音识别，不需要账号，识别语言由服务自动检测
+type Bcut struct {
+	client *http.Client
+}
+
+// NewBcut 创建B站必剪语音识别服务
+func NewBcut() *Bcut {
+	return &Bcut{client: &http.Client{Timeout: 60 * time.Second}}
+}
+
+func (b *Bcut) Name() string {
+	return ProviderBcut
+}
+
+// bcutUpload 申请上传的结果
+type bcutUpload struct {
+	UploadID   string   `json:"upload_id"`
+	InBossKey  string   `json:"in_boss_key"`
+	PerSize    int      `json:"per_size"`
+	UploadURLs []string `json:"upload_urls"`
+}
+
+// bcutTaskResult 查询转录任务的结果
+type bcutTaskResult struct {
+	Status    int         `json:"status"` // 0、1 处理中，2 成功，3 失败
+	Result    string      `json:"result"`
+	ErrorCode interface{} `json:"error_code"`
+}
+
+// bcutTranscript 转录结果，时间单位为毫秒
+type bcutTranscript struct {
+	Language   string `json:"language"`
+	Utterances []struct {
+		Transcript string `json:"transcript"`
+		StartTime  int64  `json:"start_time"`
+		EndTime    int64  `json:"end_time"`
+		Words      []struct {
+			Label     string `json:"label"`
+			StartTime int64  `json:"start_time"`
+			EndTime   int64  `json:"end_time"`
+		} `json:"words"`
+	} `json:"utterances"`
+}
+
+func (b *Bcut) Transcribe(ctx context.Context, audioPath string, opts Options) (*Result, error) {
+	fileData, err := os.ReadFile(audioPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取音频文件失败: %v", err)
+	}
+
+	// 1. 申请上传
+	var upload bcutUpload
+	err = b.request(ctx, http.MethodPost, bcutReqUpload, map[string]interface{}{
+		"type":        2,
+		"name":        "audio.wav",
+		"size":        len(fileData),
+		"resource_id": 0,
+		"model_id":    7,
+	}, &upload)
+	if err != nil {
+		return nil, fmt.Errorf("申请上传失败: %v", err)
+	}
+	if upload.PerSize <= 0 || len(upload.UploadURLs) == 0 {
+		return nil, fmt.Errorf("申请上传失败: 没有返回上传地址")
+	}
+
+	// 2. 上传音频分片
+	etags, err := b.uploadParts(ctx, &upload, fileData, opts)
+	if err != nil {
+		return nil, fmt.Errorf("上传音频失败: %v", err)
+	}
+
+	// 3. 提交上传
+	parts := make([]map[string]interface{}, len(etags))
+	for i, etag := range etags {
+		parts[i] = map[string]interface{}{"part_number": i + 1, "etag": etag}
+	}
+	err = b.request(ctx, http.MethodPost, bcutCommitUpload, map[string]interface{}{
+		"in_boss_key": upload.InBossKey,
+		"upload_id":   upload.UploadID,
+		"model_id":    7,
+		"parts":       parts,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("提交上传失败: %v", err)
+	}
+
+	// 4. 创建转录任务
+	var task struct {
+		TaskID string `json:"task_id"`
+	}
+	err = b.request(ctx, http.MethodPost, bcutCreateTask, map[string]interface{}{
+		"resource": map[string]interface{}{
+			"in_boss_key": upload.InBossKey,
+			"upload_id":   upload.UploadID,
+			"model_id":    7,
+		},
+		"model_id": "8",
+	}, &task)
+	if err != nil {
+		return nil, fmt.Errorf("创建任务失败: %v", err)
+	}
+
+	// 5. 轮询查询结果，最多等待音频时长的一半
+	maxQueries := max(bcutMinQueries, len(fileData)/bcutWAVBytesPerSecond/2/int(bcutQueryInterval/time.Second))
+	transcript, err := b.waitResult(ctx, task.TaskID, maxQueries, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Provider: ProviderBcut, Language: transcript.Language}
+	for _, utterance := range transcript.Utterances {
+		segment := Segment{
+			Start: float64(utterance.StartTime) / 1000,
+			End:   float64(utterance.EndTime) / 1000,
+			Text:  strings.TrimSpace(utterance.Transcript),
+		}
+		for _, word := range utterance.Words {
+			segment.Words = append(segment.Words, Word{
+				Start: float64(word.StartTime) / 1000,
+				End:   float64(word.EndTime) / 1000,
+				Text:  word.Label,
+			})
+		}
+		result.Segments = append(result.Segments, segment)
+	}
+	return result, nil
+}
+
+// uploadParts 上传音频分片，返回各分片的 ETag
+func (b *Bcut) uploadParts(ctx context.Context, upload *bcutUpload, fileData []byte, opts Options) ([]string, error) {
+	clips := len(upload.UploadURLs)
+	etags := make([]string, 0, clips)
+	for i := 0; i < clips; i++ {
+		start := i * upload.PerSize
+		end := min(start+upload.PerSize, len(fileData))
+		if start > end {
+			start = end
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, upload.UploadURLs[i], bytes.NewReader(fileData[start:end]))
+		if err != nil {
+			return nil, fmt.Errorf("创建上传请求失败: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/octet-stream")
+
+		resp, err := b.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("上传分片 %d 失败: %v", i, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("上传分片 %d 失败，状态码: %d", i, resp.StatusCode)
+		}
+		etags = append(etags, strings.Trim(resp.Header.Get("ETag"), "\""))
+		opts.report(int64(i+1), int64(clips), "上传音频到 B站必剪，分片 %d/%d", i+1, clips)
+	}
+	return etags, nil
+}
+
+// waitResult 轮询转录结果
+func (b *Bcut) waitResult(ctx context.Context, taskID string, maxQueries int, opts Options) (*bcutTranscript, error) {
+	url := fmt.Sprintf("%s?model_id=7&task_id=%s", bcutQueryResult, taskID)
+	for i := 0; i < maxQueries; i++ {
+		var result bcutTaskResult
+		if err := b.request(ctx, http.MethodGet, url, nil, &result); err != nil {
+			return nil, fmt.Errorf("查询结果失败: %v", err)
+		}
+
+		switch result.Status {
+		case 2: // 成功
+			var transcript bcutTranscript
+			if err := json.Unmarshal([]byte(result.Result), &transcript); err != nil {
+				return nil, fmt.Errorf("解析结果JSON失败: %v", err)
+			}
+			return &transcript, nil
+		case 3: // 失败
+			return nil, fmt.Errorf("转录任务失败，错误代码: %v", result.ErrorCode)
+		case 0, 1: // 处理中
+			opts.report(int64(i+1), int64(maxQueries), "等待 B站必剪 转录结果，第 %d 次查询 (状态 %d)", i+1, result.Status)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(bcutQueryInterval):
+			}
+		default:
+			return nil, fmt.Errorf("未知状态: %d", result.Status)
+		}
+	}
+	return nil, fmt.Errorf("查询超时，已重试 %d 次", maxQueries)
+}
+
+// request 发起 JSON 请求，接口返回成功时将 data 解码到 out
+func (b *Bcut) request(ctx context.Context, method, url string, payload, out interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("序列化请求数据失败: %v", err)
+		}
+		body = bytes.NewReader(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	var result struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return fmt.Errorf("解析响应JSON失败: %v", err)
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("API错误 (code: %d): %s", result.Code, result.Message)
+	}
+	if out != nil {
+		if err := json.Unmarshal(result.Data, out); err != nil {
+			return fmt.Errorf("解析响应数据失败: %v", err)
+		}
+	}
+	return nil
+}
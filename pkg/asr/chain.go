@@ -0,0 +1,66 @@
+package asr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Chain 按顺序尝试多个语音识别服务，前一个失败或没有识别出内容时使用下一个
+type Chain struct {
+	providers []Provider
+}
+
+// NewChain 创建按顺序尝试的语音识别服务
+func NewChain(providers ...Provider) *Chain {
+	return &Chain{providers: providers}
+}
+
+// Name 所有服务的名称，按尝试的顺序以逗号分隔
+func (c *Chain) Name() string {
+	names := make([]string, 0, len(c.providers))
+	for _, provider := range c.providers {
+		names = append(names, provider.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+// Transcribe 依次尝试各个服务，返回第一个识别出内容的结果
+// 所有服务都没有识别出内容时返回最后一个空结果，都失败时返回所有错误
+func (c *Chain) Transcribe(ctx context.Context, audioPath string, opts Options) (*Result, error) {
+	if len(c.providers) == 0 {
+		return nil, fmt.Errorf("没有配置语音识别服务")
+	}
+
+	var skipped []string
+	var errs []error
+	var empty *Result
+	for _, provider := range c.providers {
+		result, err := provider.Transcribe(ctx, audioPath, opts)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s: %v", provider.Name(), err))
+			errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+			continue
+		}
+		if result.Provider == "" {
+			result.Provider = provider.Name()
+		}
+		if len(result.Cues()) == 0 {
+			skipped = append(skipped, fmt.Sprintf("%s: 没有识别出内容", provider.Name()))
+			empty = result
+			continue
+		}
+		result.Skipped = skipped
+		return result, nil
+	}
+
+	if empty != nil {
+		empty.Skipped = skipped
+		return empty, nil
+	}
+	return nil, fmt.Errorf("所有语音识别服务都失败: %w", errors.Join(errs...))
+}
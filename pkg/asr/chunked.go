@@ -0,0 +1,261 @@
+package asr
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/difyz9/ytb2bili/pkg/utils"
+)
+
+const (
+	silenceNoiseDB      = -35.0 // 静音的音量阈值
+	silenceMinDuration  = 0.4   // 最短的静音时长（秒）
+	cutSearchRatio      = 0.15  // 在目标切分点前后分段长度的这一比例内寻找静音
+	lastChunkExtraRatio = 0.25  // 剩余部分不超过分段长度的这一比例时合并到最后一段
+)
+
+// Chunked 将长音频按静音切分为多段，并发识别后按时间拼接
+// 相邻分段有一小段重叠，避免切分点附近的内容丢失，拼接时去掉重叠部分重复识别的内容
+type Chunked struct {
+	provider    Provider
+	seconds     float64
+	overlap     float64
+	concurrency int
+}
+
+// NewChunked 创建分段识别的服务，seconds 为每段的目标长度，overlap 为相邻分段重叠的时长（秒）
+func NewChunked(provider Provider, seconds int, overlap float64, concurrency int) *Chunked {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	return &Chunked{
+		provider:    provider,
+		seconds:     float64(seconds),
+		overlap:     max(overlap, 0),
+		concurrency: concurrency,
+	}
+}
+
+func (c *Chunked) Name() string {
+	return c.provider.Name()
+}
+
+// chunk 一个分段，[Start, End) 为分段负责的时间范围，音频前后各多截取 overlap
+type chunk struct {
+	Index      int
+	Start, End float64
+	AudioStart float64
+	AudioEnd   float64
+}
+
+func (c *Chunked) Transcribe(ctx context.Context, audioPath string, opts Options) (*Result, error) {
+	// 不超过一段的音频直接识别，不需要检测静音（silencedetect 需要解码整个文件）
+	duration, err := utils.AudioDuration(ctx, audioPath)
+	if err != nil {
+		return nil, err
+	}
+	if singleChunk(duration, c.seconds) {
+		return c.provider.Transcribe(ctx, audioPath, opts)
+	}
+
+	silences, duration, err := utils.DetectSilences(ctx, audioPath, silenceNoiseDB, silenceMinDuration)
+	if err != nil {
+		return nil, err
+	}
+	chunks := planChunks(duration, silences, c.seconds, c.overlap)
+	if len(chunks) <= 1 {
+		return c.provider.Transcribe(ctx, audioPath, opts)
+	}
+
+	dir, err := os.MkdirTemp(filepath.Dir(audioPath), "asr-chunks-")
+	if err != nil {
+		return nil, fmt.Errorf("创建分段目录失败: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	total := int64(len(chunks))
+	opts.report(0, total, "音频时长 %s，分为 %d 段识别", utils.FormatClock(duration), len(chunks))
+
+	results := make([]*Result, len(chunks))
+	var (
+		mu       sync.Mutex
+		done     int64
+		firstErr error
+	)
+	sem := make(chan struct{}, c.concurrency)
+	var wg sync.WaitGroup
+	for _, ch := range chunks {
+		wg.Add(1)
+		go func(ch chunk) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			result, err := c.transcribeChunk(ctx, audioPath, dir, ch, len(chunks), opts)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("第 %d/%d 段识别失败: %w", ch.Index+1, len(chunks), err)
+					cancel()
+				}
+				return
+			}
+			results[ch.Index] = result
+			done++
+			opts.report(done, total, "第 %d/%d 段识别完成 (%s - %s)", ch.Index+1, len(chunks), utils.FormatClock(ch.Start), utils.FormatClock(ch.End))
+		}(ch)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return stitch(chunks, results), nil
+}
+
+// transcribeChunk 截取并识别一个分段，结果中的时间相对于分段音频的开始
+func (c *Chunked) transcribeChunk(ctx context.Context, audioPath, dir string, ch chunk, count int, opts Options) (*Result, error) {
+	chunkPath := filepath.Join(dir, fmt.Sprintf("chunk_%03d%s", ch.Index, filepath.Ext(audioPath)))
+	if err := utils.CutAudio(ctx, audioPath, chunkPath, ch.AudioStart, ch.AudioEnd-ch.AudioStart); err != nil {
+		return nil, err
+	}
+	defer os.Remove(chunkPath)
+
+	chunkOpts := Options{Language: opts.Language}
+	if opts.Progress != nil {
+		chunkOpts.Progress = func(current, total int64, message string) {
+			opts.Progress(current, total, fmt.Sprintf("第 %d/%d 段: %s", ch.Index+1, count, message))
+		}
+	}
+	return c.provider.Transcribe(ctx, chunkPath, chunkOpts)
+}
+
+// planChunks 按目标长度切分音频，切分点优先选择目标位置附近最长的静音的中点，附近没有静音时在目标位置切分
+func planChunks(duration float64, silences []utils.Silence, seconds, overlap float64) []chunk {
+	if singleChunk(duration, seconds) {
+		return []chunk{{Start: 0, End: duration, AudioStart: 0, AudioEnd: duration}}
+	}
+
+	cuts := []float64{0}
+	pos := 0.0
+	window := seconds * cutSearchRatio
+	for duration-pos > seconds*(1+lastChunkExtraRatio) {
+		target := pos + seconds
+		cut, longest := target, 0.0
+		for _, silence := range silences {
+			mid := (silence.Start + silence.End) / 2
+			length := silence.End - silence.Start
+			if math.Abs(mid-target) <= window && length > longest {
+				cut, longest = mid, length
+			}
+		}
+		cuts = append(cuts, cut)
+		pos = cut
+	}
+	cuts = append(cuts, duration)
+
+	chunks := make([]chunk, 0, len(cuts)-1)
+	for i := 0; i+1 < len(cuts); i++ {
+		chunks = append(chunks, chunk{
+			Index:      i,
+			Start:      cuts[i],
+			End:        cuts[i+1],
+			AudioStart: max(cuts[i]-overlap, 0),
+			AudioEnd:   min(cuts[i+1]+overlap, duration),
+		})
+	}
+	return chunks
+}
+
+// singleChunk 音频是否不需要分段：未设置分段长度，或时长不超过一段加上可以合并到最后一段的长度
+func singleChunk(duration, seconds float64) bool {
+	return seconds <= 0 || duration <= seconds*(1+lastChunkExtraRatio)
+}
+
+// stitch 加上各分段的时间偏移后拼接结果
+// 每个片段只保留在中点所在的分段中识别的结果，去掉重叠部分被相邻分段重复识别的内容
+func stitch(chunks []chunk, results []*Result) *Result {
+	stitched := &Result{}
+	var providers []string
+	for i, ch := range chunks {
+		result := results[i]
+		if result == nil {
+			continue
+		}
+		if stitched.Language == "" {
+			stitched.Language = result.Language
+		}
+		if !slices.Contains(providers, result.Provider) {
+			providers = append(providers, result.Provider)
+		}
+		for _, skipped := range result.Skipped {
+			stitched.Skipped = append(stitched.Skipped, fmt.Sprintf("第 %d 段 %s", i+1, skipped))
+		}
+
+		last := i == len(chunks)-1
+		for _, segment := range result.Segments {
+			segment = shiftSegment(segment, ch.AudioStart)
+			mid := (segment.Start + segment.End) / 2
+			if mid < ch.Start || (mid >= ch.End && !last) {
+				continue
+			}
+			if n := len(stitched.Segments); n > 0 && duplicateSegment(stitched.Segments[n-1], segment) {
+				continue
+			}
+			stitched.Segments = append(stitched.Segments, segment)
+		}
+	}
+	stitched.Provider = strings.Join(providers, ",")
+	return stitched
+}
+
+// shiftSegment 将片段和其中单词的时间加上偏移
+func shiftSegment(segment Segment, offset float64) Segment {
+	segment.Start += offset
+	segment.End += offset
+	if len(segment.Words) > 0 {
+		words := make([]Word, len(segment.Words))
+		for i, word := range segment.Words {
+			word.Start += offset
+			word.End += offset
+			words[i] = word
+		}
+		segment.Words = words
+	}
+	return segment
+}
+
+// duplicateSegment 相邻分段在切分点附近识别出的同一句话：时间重叠且文本相同
+func duplicateSegment(prev, next Segment) bool {
+	return next.Start < prev.End && normalizeText(prev.Text) == normalizeText(next.Text)
+}
+
+// normalizeText 去掉空白和标点并转为小写，用于比较识别结果
+func normalizeText(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		if r == ' ' || r == '\t' || r == '\n' || strings.ContainsRune(".,!?;:'\"，。！？；：、", r) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
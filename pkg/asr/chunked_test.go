@@ -0,0 +1,138 @@
+package asr
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/difyz9/ytb2bili/pkg/utils"
+)
+
+func TestPlanChunks(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration float64
+		silences []utils.Silence
+		seconds  float64
+		overlap  float64
+		want     []chunk
+	}{
+		{
+			name:     "不超过一段加上可合并的长度时不分段",
+			duration: 75, seconds: 60, overlap: 2,
+			want: []chunk{{Start: 0, End: 75, AudioStart: 0, AudioEnd: 75}},
+		},
+		{
+			name:     "未设置分段长度时不分段",
+			duration: 1000, seconds: 0, overlap: 2,
+			want: []chunk{{Start: 0, End: 1000, AudioStart: 0, AudioEnd: 1000}},
+		},
+		{
+			name:     "附近没有静音时在目标位置切分，剩余部分合并到最后一段",
+			duration: 250, seconds: 60, overlap: 2,
+			want: []chunk{
+				{Index: 0, Start: 0, End: 60, AudioStart: 0, AudioEnd: 62},
+				{Index: 1, Start: 60, End: 120, AudioStart: 58, AudioEnd: 122},
+				{Index: 2, Start: 120, End: 180, AudioStart: 118, AudioEnd: 182},
+				{Index: 3, Start: 180, End: 250, AudioStart: 178, AudioEnd: 250},
+			},
+		},
+		{
+			name:     "在目标位置附近最长的静音中点切分，范围外的静音被忽略",
+			duration: 100, seconds: 60, overlap: 2,
+			silences: []utils.Silence{{Start: 40, End: 50}, {Start: 55, End: 56}, {Start: 62, End: 64}},
+			want: []chunk{
+				{Index: 0, Start: 0, End: 63, AudioStart: 0, AudioEnd: 65},
+				{Index: 1, Start: 63, End: 100, AudioStart: 61, AudioEnd: 100},
+			},
+		},
+		{
+			name:     "没有重叠",
+			duration: 130, seconds: 60, overlap: 0,
+			want: []chunk{
+				{Index: 0, Start: 0, End: 60, AudioStart: 0, AudioEnd: 60},
+				{Index: 1, Start: 60, End: 130, AudioStart: 60, AudioEnd: 130},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := planChunks(tt.duration, tt.silences, tt.seconds, tt.overlap); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("planChunks() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStitch(t *testing.T) {
+	chunks := []chunk{
+		{Index: 0, Start: 0, End: 63, AudioStart: 0, AudioEnd: 65},
+		{Index: 1, Start: 63, End: 100, AudioStart: 61, AudioEnd: 100},
+	}
+	results := []*Result{
+		{
+			Provider: "bcut",
+			Language: "en",
+			Segments: []Segment{
+				{Start: 0, End: 5, Text: "first"},
+				{Start: 60, End: 64, Text: "Across the cut.", Words: []Word{{Start: 60, End: 62, Text: "Across"}}},
+				{Start: 64, End: 65, Text: "partial"}, // 中点在下一段，由下一段识别
+			},
+		},
+		{
+			Provider: "whisper",
+			Language: "en",
+			Skipped:  []string{"bcut: timeout"},
+			Segments: []Segment{
+				{Start: 0, End: 1.5, Text: "cut"},          // 中点在上一段，由上一段识别
+				{Start: 1, End: 4, Text: "across the cut"}, // 与上一段的结果重复
+				{Start: 3, End: 4, Text: "partial words", Words: []Word{{Start: 3, End: 4, Text: "partial"}}},
+				{Start: 38, End: 39.5, Text: "last"}, // 中点超过结束时间，最后一段仍然保留
+			},
+		},
+	}
+
+	want := &Result{
+		Provider: "bcut,whisper",
+		Language: "en",
+		Skipped:  []string{"第 2 段 bcut: timeout"},
+		Segments: []Segment{
+			{Start: 0, End: 5, Text: "first"},
+			{Start: 60, End: 64, Text: "Across the cut.", Words: []Word{{Start: 60, End: 62, Text: "Across"}}},
+			{Start: 64, End: 65, Text: "partial words", Words: []Word{{Start: 64, End: 65, Text: "partial"}}},
+			{Start: 99, End: 100.5, Text: "last"},
+		},
+	}
+	if got := stitch(chunks, results); !reflect.DeepEqual(got, want) {
+		t.Errorf("stitch() = %+v, want %+v", got, want)
+	}
+}
+
+func TestStitchMissingResult(t *testing.T) {
+	chunks := []chunk{
+		{Index: 0, Start: 0, End: 60, AudioStart: 0, AudioEnd: 62},
+		{Index: 1, Start: 60, End: 120, AudioStart: 58, AudioEnd: 120},
+	}
+	results := []*Result{nil, {Provider: "bcut", Segments: []Segment{{Start: 10, End: 12, Text: "hello"}}}}
+
+	want := &Result{Provider: "bcut", Segments: []Segment{{Start: 68, End: 70, Text: "hello"}}}
+	if got := stitch(chunks, results); !reflect.DeepEqual(got, want) {
+		t.Errorf("stitch() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDuplicateSegment(t *testing.T) {
+	tests := []struct {
+		prev, next Segment
+		want       bool
+	}{
+		{Segment{Start: 60, End: 64, Text: "Hello, world!"}, Segment{Start: 62, End: 65, Text: "hello world"}, true},
+		{Segment{Start: 60, End: 64, Text: "你好，世界。"}, Segment{Start: 63, End: 65, Text: "你好世界"}, true},
+		{Segment{Start: 60, End: 64, Text: "hello"}, Segment{Start: 64, End: 65, Text: "hello"}, false}, // 时间不重叠
+		{Segment{Start: 60, End: 64, Text: "hello"}, Segment{Start: 62, End: 65, Text: "hello there"}, false},
+	}
+	for _, tt := range tests {
+		if got := duplicateSegment(tt.prev, tt.next); got != tt.want {
+			t.Errorf("duplicateSegment(%q, %q) = %v, want %v", tt.prev.Text, tt.next.Text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package asr
+
+import "fmt"
+
+// Config 语音识别配置
+type Config struct {
+	Enabled   bool     `toml:"enabled"`   // 自动选择处理流程时是否通过语音识别生成字幕
+	Providers []string `toml:"providers"` // 使用的语音识别服务，按顺序尝试: bcut、whisper_cpp、openai
+	Language  string   `toml:"language"`  // 识别语言 (en, zh, auto等)，为空或 auto 时自动检测
+
+	// 长音频按静音切分为多段识别，避免一次上传整个音频和等待超时
+	ChunkSeconds     int     `toml:"chunk_seconds"`     // 每段的目标长度（秒），0 表示不分段
+	ChunkOverlap     float64 `toml:"chunk_overlap"`     // 相邻分段重叠的时长（秒）
+	ChunkConcurrency int     `toml:"chunk_concurrency"` // 同时识别的分段数，使用本地 whisper.cpp 时每段都会占用配置的线程数
+
+	WhisperCpp WhisperCppConfig `toml:"WhisperCpp"` // 本地 whisper.cpp 配置
+	OpenAI     OpenAIConfig     `toml:"OpenAI"`     // OpenAI 兼容接口配置
+}
+
+// Validate 检查配置的服务名称和必填项
+func (c *Config) Validate() error {
+	if len(c.Providers) == 0 {
+		return fmt.Errorf("没有配置语音识别服务")
+	}
+	if c.ChunkSeconds < 0 || c.ChunkOverlap < 0 || c.ChunkConcurrency < 0 {
+		return fmt.Errorf("分段长度、重叠时长和并发数不能为负数")
+	}
+	if c.ChunkSeconds > 0 && c.ChunkOverlap*2 >= float64(c.ChunkSeconds) {
+		return fmt.Errorf("分段重叠时长 %g 秒过长，需要小于分段长度的一半", c.ChunkOverlap)
+	}
+	for _, name := range c.Providers {
+		switch name {
+		case ProviderBcut:
+		case ProviderWhisperCpp:
+			if c.WhisperCpp.ModelPath == "" {
+				return fmt.Errorf("使用 whisper_cpp 需要配置 model_path")
+			}
+		case ProviderOpenAI:
+			if c.OpenAI.BaseURL == "" {
+				return fmt.Errorf("使用 openai 需要配置 base_url")
+			}
+		default:
+			return fmt.Errorf("不支持的语音识别服务: %s，可选 bcut、whisper_cpp、openai", name)
+		}
+	}
+	return nil
+}
+
+// New 按配置的顺序创建语音识别服务，配置了分段长度时每段分别按顺序尝试
+func New(c *Config) (Provider, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	providers := make([]Provider, 0, len(c.Providers))
+	for _, name := range c.Providers {
+		switch name {
+		case ProviderBcut:
+			providers = append(providers, NewBcut())
+		case ProviderWhisperCpp:
+			providers = append(providers, NewWhisperCpp(c.WhisperCpp))
+		case ProviderOpenAI:
+			providers = append(providers, NewOpenAI(c.OpenAI))
+		}
+	}
+	chain := NewChain(providers...)
+	if c.ChunkSeconds > 0 {
+		return NewChunked(chain, c.ChunkSeconds, c.ChunkOverlap, c.ChunkConcurrency), nil
+	}
+	return chain, nil
+}
@@ -0,0 +1,181 @@
+package asr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// OpenAIConfig OpenAI 兼容的语音识别接口配置，也可以使用本地的 faster-whisper-server 等服务
+type OpenAIConfig struct {
+	BaseURL string `toml:"base_url"` // 接口地址，如 http://localhost:8000/v1，请求 {base_url}/audio/transcriptions
+	APIKey  string `toml:"api_key"`  // API密钥，本地服务可以为空
+	Model   string `toml:"model"`    // 使用的模型，如 whisper-1、Systran/faster-whisper-large-v3
+	Timeout int    `toml:"timeout"`  // 请求超时时间（秒），0 时为 1800
+}
+
+// OpenAI 通过 OpenAI 兼容的 /v1/audio/transcriptions 接口进行语音识别
+type OpenAI struct {
+	config OpenAIConfig
+	client *http.Client
+}
+
+// NewOpenAI 创建 OpenAI 兼容的语音识别服务
+func NewOpenAI(config OpenAIConfig) *OpenAI {
+	if config.Model == "" {
+		config.Model = "whisper-1"
+	}
+	timeout := time.Duration(config.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Minute
+	}
+	return &OpenAI{config: config, client: &http.Client{Timeout: timeout}}
+}
+
+func (o *OpenAI) Name() string {
+	return ProviderOpenAI
+}
+
+// openAITranscription verbose_json 格式的识别结果，时间单位为秒
+type openAITranscription struct {
+	Language string  `json:"language"`
+	Text     string  `json:"text"`
+	Duration float64 `json:"duration"`
+	Segments []struct {
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+		Text  string  `json:"text"`
+	} `json:"segments"`
+	Words []struct {
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+		Word  string  `json:"word"`
+	} `json:"words"`
+}
+
+func (o *OpenAI) Transcribe(ctx context.Context, audioPath string, opts Options) (*Result, error) {
+	if o.config.BaseURL == "" {
+		return nil, fmt.Errorf("未配置语音识别接口地址")
+	}
+
+	file, err := os.Open(audioPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取音频文件失败: %v", err)
+	}
+	defer file.Close()
+
+	// 边读取文件边上传，不需要把整个音频读入内存
+	body, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+	go func() {
+		writer.CloseWithError(o.writeForm(form, file, filepath.Base(audioPath), opts.Language))
+	}()
+
+	url := strings.TrimRight(o.config.BaseURL, "/") + "/audio/transcriptions"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	if err != nil {
+		body.Close()
+		return nil, fmt.Errorf("创建请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if o.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.config.APIKey)
+	}
+
+	opts.report(0, 0, "上传音频到语音识别接口 %s", o.config.BaseURL)
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求语音识别接口失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("语音识别接口返回错误 (HTTP %d): %s", resp.StatusCode, truncate(string(respBody), 300))
+	}
+
+	var transcription openAITranscription
+	if err := json.Unmarshal(respBody, &transcription); err != nil {
+		return nil, fmt.Errorf("解析识别结果失败: %v", err)
+	}
+
+	result := &Result{Provider: ProviderOpenAI, Language: languageCode(transcription.Language)}
+	for _, item := range transcription.Segments {
+		result.Segments = append(result.Segments, Segment{Start: item.Start, End: item.End, Text: strings.TrimSpace(item.Text)})
+	}
+	if len(result.Segments) == 0 && strings.TrimSpace(transcription.Text) != "" {
+		// 接口不返回分段时整段作为一条
+		result.Segments = []Segment{{Start: 0, End: transcription.Duration, Text: strings.TrimSpace(transcription.Text)}}
+	}
+
+	// 逐词时间单独返回，按时间分配到所在的分段
+	i := 0
+	for _, word := range transcription.Words {
+		for i < len(result.Segments)-1 && word.Start >= result.Segments[i].End {
+			i++
+		}
+		if i < len(result.Segments) {
+			result.Segments[i].Words = append(result.Segments[i].Words, Word{Start: word.Start, End: word.End, Text: strings.TrimSpace(word.Word)})
+		}
+	}
+	return result, nil
+}
+
+// writeForm 写入请求的表单，请求逐段和逐词的时间
+func (o *OpenAI) writeForm(form *multipart.Writer, file io.Reader, fileName, language string) error {
+	part, err := form.CreateFormFile("file", fileName)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return err
+	}
+	fields := [][2]string{
+		{"model", o.config.Model},
+		{"response_format", "verbose_json"},
+		{"timestamp_granularities[]", "segment"},
+		{"timestamp_granularities[]", "word"},
+	}
+	if !IsAutoLanguage(language) {
+		fields = append(fields, [2]string{"language", language})
+	}
+	for _, field := range fields {
+		if err := form.WriteField(field[0], field[1]); err != nil {
+			return err
+		}
+	}
+	return form.Close()
+}
+
+// languageNames Whisper 返回的常见语言名称对应的语言代码
+var languageNames = map[string]string{
+	"english": "en", "chinese": "zh", "japanese": "ja", "korean": "ko", "spanish": "es",
+	"french": "fr", "german": "de", "russian": "ru", "portuguese": "pt", "italian": "it",
+}
+
+// languageCode 将 Whisper 返回的语言名称转换为语言代码，已是代码或未知的名称原样返回
+func languageCode(language string) string {
+	if code, ok := languageNames[strings.ToLower(language)]; ok {
+		return code
+	}
+	return language
+}
+
+// truncate 截断过长的文本，用于错误信息
+func truncate(s string, maxLen int) string {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
+		return s
+	}
+	return string(runes[:maxLen]) + "..."
+}
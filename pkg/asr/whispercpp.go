@@ -0,0 +1,147 @@
+package asr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/difyz9/ytb2bili/pkg/utils"
+)
+
+// WhisperCppConfig 本地 whisper.cpp 命令行程序的配置
+type WhisperCppConfig struct {
+	Binary    string   `toml:"binary"`     // 命令行程序路径，为空时为 whisper-cli
+	ModelPath string   `toml:"model_path"` // 模型文件路径，如 models/ggml-large-v3.bin
+	Threads   int      `toml:"threads"`    // 使用的线程数，0 时为 4
+	Args      []string `toml:"args"`       // 额外的命令行参数，如 ["--max-len", "60"]
+}
+
+// WhisperCpp 通过子进程运行本地的 whisper.cpp 进行语音识别
+type WhisperCpp struct {
+	config WhisperCppConfig
+}
+
+// NewWhisperCpp 创建 whisper.cpp 语音识别服务
+func NewWhisperCpp(config WhisperCppConfig) *WhisperCpp {
+	if config.Binary == "" {
+		config.Binary = "whisper-cli"
+	}
+	if config.Threads <= 0 {
+		config.Threads = 4
+	}
+	return &WhisperCpp{config: config}
+}
+
+func (w *WhisperCpp) Name() string {
+	return ProviderWhisperCpp
+}
+
+// whisperCppOutput whisper.cpp 的完整 JSON 输出（-ojf），时间单位为毫秒
+type whisperCppOutput struct {
+	Result struct {
+		Language string `json:"language"`
+	} `json:"result"`
+	Transcription []struct {
+		Text    string `json:"text"`
+		Offsets struct {
+			From int64 `json:"from"`
+			To   int64 `json:"to"`
+		} `json:"offsets"`
+		Tokens []struct {
+			Text    string `json:"text"`
+			Offsets struct {
+				From int64 `json:"from"`
+				To   int64 `json:"to"`
+			} `json:"offsets"`
+		} `json:"tokens"`
+	} `json:"transcription"`
+}
+
+func (w *WhisperCpp) Transcribe(ctx context.Context, audioPath string, opts Options) (*Result, error) {
+	if w.config.ModelPath == "" {
+		return nil, fmt.Errorf("未配置 whisper.cpp 模型路径")
+	}
+	if _, err := os.Stat(w.config.ModelPath); err != nil {
+		return nil, fmt.Errorf("whisper.cpp 模型文件不存在: %s", w.config.ModelPath)
+	}
+
+	outDir, err := os.MkdirTemp("", "whisper-cpp-")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %v", err)
+	}
+	defer os.RemoveAll(outDir)
+	outPrefix := filepath.Join(outDir, "transcript")
+
+	language := opts.Language
+	if IsAutoLanguage(language) {
+		language = "auto"
+	}
+	args := []string{
+		"-m", w.config.ModelPath,
+		"-f", audioPath,
+		"-l", language,
+		"-t", strconv.Itoa(w.config.Threads),
+		"-ojf", // 完整 JSON 输出，包含逐词时间
+		"-of", outPrefix,
+		"-np", // 不输出识别的文本，只保留错误信息
+	}
+	args = append(args, w.config.Args...)
+
+	opts.report(0, 0, "使用 whisper.cpp 识别音频")
+	var stderr bytes.Buffer
+	cmd := utils.CommandContext(ctx, w.config.Binary, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("运行 whisper.cpp 失败: %v: %s", err, lastLines(stderr.String(), 5))
+	}
+
+	data, err := os.ReadFile(outPrefix + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("读取 whisper.cpp 输出失败: %v", err)
+	}
+	var output whisperCppOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, fmt.Errorf("解析 whisper.cpp 输出失败: %v", err)
+	}
+
+	result := &Result{Provider: ProviderWhisperCpp, Language: output.Result.Language}
+	for _, item := range output.Transcription {
+		segment := Segment{
+			Start: float64(item.Offsets.From) / 1000,
+			End:   float64(item.Offsets.To) / 1000,
+			Text:  strings.TrimSpace(item.Text),
+		}
+		// 以空格开头的 token 是新单词，[_BEG_]、[_TT_150] 等特殊 token 不是文本
+		for _, token := range item.Tokens {
+			if strings.HasPrefix(token.Text, "[_") || token.Text == "" {
+				continue
+			}
+			start, end := float64(token.Offsets.From)/1000, float64(token.Offsets.To)/1000
+			if n := len(segment.Words); n > 0 && !strings.HasPrefix(token.Text, " ") {
+				segment.Words[n-1].Text += token.Text
+				segment.Words[n-1].End = end
+				continue
+			}
+			segment.Words = append(segment.Words, Word{Start: start, End: end, Text: strings.TrimSpace(token.Text)})
+		}
+		result.Segments = append(result.Segments, segment)
+	}
+	return result, nil
+}
+
+// lastLines 取输出的最后几行，用于错误信息
+func lastLines(output string, n int) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
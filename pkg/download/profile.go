@@ -0,0 +1,148 @@
+// Package download 视频下载配置，生成 yt-dlp 的格式选择参数
+package download
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Profile 下载配置，零值表示使用 yt-dlp 默认的最佳格式并合并为 mp4
+type Profile struct {
+	MaxHeight   int      `toml:"max_height" json:"max_height,omitempty"`           // 最高分辨率（视频高度），如 1080，0 表示不限制
+	Codecs      []string `toml:"codecs" json:"codecs,omitempty"`                   // 优先使用的视频编码，按顺序尝试: avc1(h264)、hevc(h265)、vp9、av1
+	MaxFileSize int      `toml:"max_filesize_mb" json:"max_filesize_mb,omitempty"` // 最大文件大小（MB），0 表示不限制
+	AudioOnly   bool     `toml:"audio_only" json:"audio_only,omitempty"`           // 只下载音频，用于只生成字幕的流程
+	Container   string   `toml:"container" json:"container,omitempty"`             // 合并后的容器格式: mp4、mkv、webm，为空时为 mp4
+}
+
+// codecPatterns 视频编码名称对应的 yt-dlp vcodec 正则
+var codecPatterns = map[string]string{
+	"avc1": "^(avc|h264)",
+	"avc":  "^(avc|h264)",
+	"h264": "^(avc|h264)",
+	"hevc": "^(hev|hvc|h265)",
+	"h265": "^(hev|hvc|h265)",
+	"vp9":  "^vp0?9",
+	"av1":  "^av0?1",
+	"av01": "^av0?1",
+}
+
+var containers = map[string]bool{"mp4": true, "mkv": true, "webm": true}
+
+// audioShare 分别下载视频和音频时为音频保留的文件大小比例（百分比）
+// yt-dlp 的大小过滤和 --max-filesize 只作用于单个文件，视频和音频各自不超过分到的大小，合并后才不会超过限制
+const audioShare = 15
+
+// IsEmpty 是否没有任何设置
+func (p *Profile) IsEmpty() bool {
+	return p == nil || (p.MaxHeight == 0 && len(p.Codecs) == 0 && p.MaxFileSize == 0 && !p.AudioOnly && p.Container == "")
+}
+
+// Validate 检查配置的取值
+func (p *Profile) Validate() error {
+	if p == nil {
+		return nil
+	}
+	if p.MaxHeight < 0 || p.MaxFileSize < 0 {
+		return fmt.Errorf("分辨率和文件大小不能为负数")
+	}
+	for _, codec := range p.Codecs {
+		if _, ok := codecPatterns[strings.ToLower(codec)]; !ok {
+			return fmt.Errorf("不支持的视频编码: %s，可选 avc1、hevc、vp9、av1", codec)
+		}
+	}
+	if p.Container != "" && !containers[strings.ToLower(p.Container)] {
+		return fmt.Errorf("不支持的容器格式: %s，可选 mp4、mkv、webm", p.Container)
+	}
+	return nil
+}
+
+// Merge 返回合并后的配置，override 中设置了的项覆盖 p 中的同一项
+func (p *Profile) Merge(override *Profile) *Profile {
+	merged := Profile{}
+	if p != nil {
+		merged = *p
+	}
+	if override == nil {
+		return &merged
+	}
+	if override.MaxHeight != 0 {
+		merged.MaxHeight = override.MaxHeight
+	}
+	if override.Codecs != nil {
+		merged.Codecs = override.Codecs
+	}
+	if override.MaxFileSize != 0 {
+		merged.MaxFileSize = override.MaxFileSize
+	}
+	if override.AudioOnly {
+		merged.AudioOnly = true
+	}
+	if override.Container != "" {
+		merged.Container = override.Container
+	}
+	return &merged
+}
+
+// Args 生成 yt-dlp 的格式选择参数
+// 按编码的顺序依次尝试不超过最高分辨率和文件大小的视频，都没有时使用不超过限制的任意格式，最后使用默认的最佳格式
+// 限制文件大小时视频和音频分别下载的格式按 audioShare 分配大小，合并后的文件不超过限制
+func (p *Profile) Args() []string {
+	if p == nil {
+		p = &Profile{}
+	}
+
+	total := p.MaxFileSize * 1000 // KB
+	var args []string
+	if p.AudioOnly {
+		selector := "ba[ext=m4a]/ba/b"
+		if filter := sizeFilter(total); filter != "" {
+			selector = "ba[ext=m4a]" + filter + "/ba" + filter + "/" + selector
+		}
+		args = []string{"-f", selector}
+	} else {
+		audioSize := total * audioShare / 100
+		videoFilter, audioFilter, fileFilter := sizeFilter(total-audioSize), sizeFilter(audioSize), sizeFilter(total)
+		if p.MaxHeight > 0 {
+			height := fmt.Sprintf("[height<=%d]", p.MaxHeight)
+			videoFilter, fileFilter = height+videoFilter, height+fileFilter
+		}
+		// mp4 容器优先使用 m4a 音频，合并时不需要转换
+		audio := []string{"ba" + audioFilter}
+		if p.container() == "mp4" {
+			audio = []string{"ba[ext=m4a]" + audioFilter, "ba" + audioFilter}
+		}
+
+		var selectors []string
+		for _, codec := range p.Codecs {
+			for _, a := range audio {
+				selectors = append(selectors, fmt.Sprintf("bv*%s[vcodec~='%s']+%s", videoFilter, codecPatterns[strings.ToLower(codec)], a))
+			}
+		}
+		if fileFilter != "" {
+			selectors = append(selectors, "bv*"+videoFilter+"+ba"+audioFilter, "b"+fileFilter)
+		}
+		selectors = append(selectors, "bv*+ba", "b")
+		args = []string{"-f", strings.Join(selectors, "/"), "--merge-output-format", p.container()}
+	}
+
+	if p.MaxFileSize > 0 {
+		args = append(args, "--max-filesize", fmt.Sprintf("%dM", p.MaxFileSize))
+	}
+	return args
+}
+
+// sizeFilter 不超过 kb 的文件大小过滤条件，大小未知的格式不排除，kb 不大于 0 时不限制
+func sizeFilter(kb int) string {
+	if kb <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("[filesize<?%dK]", kb)
+}
+
+func (p *Profile) container() string {
+	if p.Container == "" {
+		return "mp4"
+	}
+	return strings.ToLower(p.Container)
+}
@@ -0,0 +1,164 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Type 事件类型
+type Type string
+
+const (
+	StepPending   Type = "step.pending"   // 步骤等待执行（手动重试或服务关闭后恢复）
+	StepStarted   Type = "step.started"   // 步骤开始执行
+	StepCompleted Type = "step.completed" // 步骤执行成功
+	StepFailed    Type = "step.failed"    // 步骤执行失败
+	StepRetrying  Type = "step.retrying"  // 步骤遇到临时错误，等待自动重试
+	StepCancelled Type = "step.cancelled" // 步骤被取消或跳过
+	StepProgress  Type = "step.progress"  // 步骤执行进度（下载百分比、转录状态、翻译分组等）
+	VideoStatus   Type = "video.status"   // 视频状态变化
+)
+
+// Progress 进度信息
+type Progress struct {
+	Current int64   `json:"current"`
+	Total   int64   `json:"total"`
+	Percent float64 `json:"percent"`
+}
+
+// NewProgress 根据当前值和总数创建进度，总数未知时百分比为 0
+func NewProgress(current, total int64) *Progress {
+	progress := &Progress{Current: current, Total: total}
+	if total > 0 {
+		progress.Percent = float64(current) * 100 / float64(total)
+	}
+	return progress
+}
+
+// Event 事件
+type Event struct {
+	ID       uint64                 `json:"id"`
+	Type     Type                   `json:"type"`
+	VideoID  string                 `json:"video_id"`
+	Step     string                 `json:"step,omitempty"`    // 步骤标识
+	Status   string                 `json:"status,omitempty"`  // 步骤或视频的新状态
+	Message  string                 `json:"message,omitempty"` // 说明或错误信息
+	Progress *Progress              `json:"progress,omitempty"`
+	Data     map[string]interface{} `json:"data,omitempty"`
+	Time     time.Time              `json:"time"`
+}
+
+// Subscription 事件订阅
+type Subscription struct {
+	C <-chan Event
+
+	bus     *Bus
+	ch      chan Event
+	videoID string
+}
+
+// Close 取消订阅
+func (s *Subscription) Close() {
+	s.bus.unsubscribe(s)
+}
+
+// Bus 进程内的事件总线
+// 发布不会阻塞：订阅者处理不及时时丢弃该订阅者的事件；最近的事件保留在内存中，用于断线重连后补发
+// 多个实例共享数据库时，每个实例只能看到自己执行的任务的事件
+type Bus struct {
+	mu          sync.RWMutex
+	nextID      uint64
+	history     []Event // 环形缓冲区
+	historySize int
+	subscribers map[*Subscription]struct{}
+}
+
+// NewBus 创建事件总线
+func NewBus() *Bus {
+	return &Bus{
+		historySize: 1000,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish 发布事件，bus 为空时忽略
+func (b *Bus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	event.ID = b.nextID
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	if len(b.history) < b.historySize {
+		b.history = append(b.history, event)
+	} else {
+		b.history[int((event.ID-1)%uint64(b.historySize))] = event
+	}
+
+	for sub := range b.subscribers {
+		if sub.videoID != "" && sub.videoID != event.VideoID {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe 订阅事件，videoID 为空时订阅所有视频的事件
+// afterID 大于 0 时先补发保留的该 ID 之后的事件（用于 SSE 的 Last-Event-ID）
+func (b *Bus) Subscribe(videoID string, afterID uint64) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []Event
+	if afterID > 0 {
+		replay = b.since(afterID, videoID)
+	}
+
+	ch := make(chan Event, 64+len(replay))
+	for _, event := range replay {
+		ch <- event
+	}
+
+	sub := &Subscription{C: ch, bus: b, ch: ch, videoID: videoID}
+	b.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// since 返回保留的 ID 大于 afterID 的事件，按发布顺序排列，调用方需持有锁
+func (b *Bus) since(afterID uint64, videoID string) []Event {
+	var events []Event
+	start := uint64(0)
+	if b.nextID > uint64(len(b.history)) {
+		start = b.nextID - uint64(len(b.history))
+	}
+	if afterID > start {
+		start = afterID
+	}
+	for id := start + 1; id <= b.nextID; id++ {
+		event := b.history[int((id-1)%uint64(b.historySize))]
+		if videoID == "" || event.VideoID == videoID {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func (b *Bus) unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.ch)
+	}
+}
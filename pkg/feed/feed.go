@@ -0,0 +1,181 @@
+package feed
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/difyz9/ytb2bili/pkg/source"
+)
+
+// Feed 解析后的订阅源
+type Feed struct {
+	Title   string
+	Entries []Entry
+}
+
+// Entry 订阅源中的视频
+type Entry struct {
+	VideoID   string // 视频ID，YouTube 为 yt:videoId，其他订阅源从链接中提取，无法识别时为空
+	ChannelID string // YouTube 频道ID
+	Title     string
+	Link      string
+	Published time.Time
+}
+
+// ChannelFeedURL YouTube 频道的 Atom 订阅源地址
+func ChannelFeedURL(channelID string) string {
+	return "https://www.youtube.com/feeds/videos.xml?channel_id=" + url.QueryEscape(channelID)
+}
+
+// PlaylistFeedURL YouTube 播放列表的 Atom 订阅源地址
+func PlaylistFeedURL(playlistID string) string {
+	return "https://www.youtube.com/feeds/videos.xml?playlist_id=" + url.QueryEscape(playlistID)
+}
+
+// atomFeed Atom 格式（YouTube 订阅源和 WebSub 推送的内容）
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	Title   string      `xml:"title"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	VideoID   string     `xml:"http://www.youtube.com/xml/schemas/2015 videoId"`
+	ChannelID string     `xml:"http://www.youtube.com/xml/schemas/2015 channelId"`
+	Title     string     `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	Published string     `xml:"published"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+// rssFeed RSS 2.0 格式
+type rssFeed struct {
+	XMLName xml.Name `xml:"rss"`
+	Channel struct {
+		Title string    `xml:"title"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type rssItem struct {
+	Title   string `xml:"title"`
+	Link    string `xml:"link"`
+	GUID    string `xml:"guid"`
+	PubDate string `xml:"pubDate"`
+}
+
+// Parse 解析 Atom 或 RSS 订阅源
+// WebSub 推送的删除通知（at:deleted-entry）不包含 entry，解析结果为空
+func Parse(data []byte) (*Feed, error) {
+	var root struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("解析订阅源失败: %v", err)
+	}
+
+	switch root.XMLName.Local {
+	case "feed":
+		var atom atomFeed
+		if err := xml.Unmarshal(data, &atom); err != nil {
+			return nil, fmt.Errorf("解析 Atom 订阅源失败: %v", err)
+		}
+		feed := &Feed{Title: strings.TrimSpace(atom.Title)}
+		for _, item := range atom.Entries {
+			entry := Entry{
+				VideoID:   strings.TrimSpace(item.VideoID),
+				ChannelID: strings.TrimSpace(item.ChannelID),
+				Title:     strings.TrimSpace(item.Title),
+				Link:      alternateLink(item.Links),
+				Published: parseTime(item.Published),
+			}
+			if entry.VideoID == "" {
+				entry.VideoID = videoIDFromLink(entry.Link)
+			}
+			feed.Entries = append(feed.Entries, entry)
+		}
+		return feed, nil
+
+	case "rss":
+		var rss rssFeed
+		if err := xml.Unmarshal(data, &rss); err != nil {
+			return nil, fmt.Errorf("解析 RSS 订阅源失败: %v", err)
+		}
+		feed := &Feed{Title: strings.TrimSpace(rss.Channel.Title)}
+		for _, item := range rss.Channel.Items {
+			link := strings.TrimSpace(item.Link)
+			if link == "" {
+				link = strings.TrimSpace(item.GUID)
+			}
+			feed.Entries = append(feed.Entries, Entry{
+				VideoID:   videoIDFromLink(link),
+				Title:     strings.TrimSpace(item.Title),
+				Link:      link,
+				Published: parseTime(item.PubDate),
+			})
+		}
+		return feed, nil
+	}
+	return nil, fmt.Errorf("不支持的订阅源格式: %s", root.XMLName.Local)
+}
+
+// Fetch 下载并解析订阅源
+func Fetch(ctx context.Context, client *http.Client, feedURL string) (*Feed, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("获取订阅源失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("获取订阅源失败: HTTP %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return nil, fmt.Errorf("读取订阅源失败: %v", err)
+	}
+	return Parse(data)
+}
+
+func alternateLink(links []atomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+// videoIDFromLink 从 YouTube 或 Bilibili 的视频链接中提取视频ID
+// 其他链接可能是文章等非视频内容，不处理
+func videoIDFromLink(link string) string {
+	id, _, err := source.Parse(link)
+	if err != nil || (id.Platform != source.PlatformYouTube && id.Platform != source.PlatformBilibili) {
+		return ""
+	}
+	return id.VideoID
+}
+
+func parseTime(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{time.RFC3339, time.RFC1123Z, time.RFC1123} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
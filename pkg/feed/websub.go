@@ -0,0 +1,106 @@
+package feed
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultHubURL YouTube 使用的 WebSub Hub
+const DefaultHubURL = "https://pubsubhubbub.appspot.com/subscribe"
+
+// HubRequest 向 Hub 发送的订阅或取消订阅请求
+type HubRequest struct {
+	HubURL       string
+	Topic        string // 订阅源地址
+	Callback     string // 接收推送的地址，Hub 会先向该地址发送验证请求
+	Secret       string // 用于验证推送内容签名的密钥
+	LeaseSeconds int    // 订阅有效期（秒），到期前需要重新订阅
+	Unsubscribe  bool
+}
+
+// SendHubRequest 向 Hub 发送订阅或取消订阅请求
+// Hub 接受请求后异步向回调地址发送验证请求，验证通过后订阅才生效
+func SendHubRequest(ctx context.Context, client *http.Client, r HubRequest) error {
+	mode := "subscribe"
+	if r.Unsubscribe {
+		mode = "unsubscribe"
+	}
+	form := url.Values{
+		"hub.mode":     {mode},
+		"hub.topic":    {r.Topic},
+		"hub.callback": {r.Callback},
+		"hub.verify":   {"async"},
+	}
+	if r.Secret != "" {
+		form.Set("hub.secret", r.Secret)
+	}
+	if r.LeaseSeconds > 0 {
+		form.Set("hub.lease_seconds", strconv.Itoa(r.LeaseSeconds))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.HubURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求 WebSub Hub 失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("WebSub Hub 拒绝了%s请求: HTTP %d %s", mode, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// VerifySignature 验证推送内容的签名
+// header 为 X-Hub-Signature 请求头，格式为 算法=十六进制摘要，支持 sha1、sha256、sha512
+func VerifySignature(secret string, body []byte, header string) bool {
+	algorithm, signature, ok := strings.Cut(header, "=")
+	if !ok {
+		return false
+	}
+
+	var newHash func() hash.Hash
+	switch strings.ToLower(algorithm) {
+	case "sha1":
+		newHash = sha1.New
+	case "sha256":
+		newHash = sha256.New
+	case "sha512":
+		newHash = sha512.New
+	default:
+		return false
+	}
+
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// NewSecret 生成随机的签名密钥
+func NewSecret() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
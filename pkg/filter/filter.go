@@ -0,0 +1,239 @@
+// Package filter 视频过滤规则
+// 在获取视频信息后、下载前检查，不符合规则的视频不再处理
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DateLayout 发布日期的格式
+const DateLayout = "2006-01-02"
+
+// Rules 过滤规则，零值表示不限制
+// 视频缺少某项信息（如语言、发布日期未知）时该项规则不生效
+type Rules struct {
+	MinDuration     int      `toml:"min_duration" json:"min_duration,omitempty"`         // 最短时长（秒），可以排除 Shorts
+	MaxDuration     int      `toml:"max_duration" json:"max_duration,omitempty"`         // 最长时长（秒），可以排除直播录像
+	IncludeKeywords []string `toml:"include_keywords" json:"include_keywords,omitempty"` // 标题需要包含其中一个关键字（不区分大小写）
+	ExcludeKeywords []string `toml:"exclude_keywords" json:"exclude_keywords,omitempty"` // 标题包含其中任意一个关键字时排除
+	AllowUploaders  []string `toml:"allow_uploaders" json:"allow_uploaders,omitempty"`   // 只处理这些上传者的视频，可以使用名称、ID 或频道ID
+	DenyUploaders   []string `toml:"deny_uploaders" json:"deny_uploaders,omitempty"`     // 排除这些上传者的视频
+	Languages       []string `toml:"languages" json:"languages,omitempty"`               // 只处理这些语言的视频，如 en、ja，en 可以匹配 en-US
+	PublishedAfter  string   `toml:"published_after" json:"published_after,omitempty"`   // 只处理该日期及之后发布的视频，格式 2006-01-02
+	MaxAgeDays      int      `toml:"max_age_days" json:"max_age_days,omitempty"`         // 只处理最近多少天内发布的视频
+	SkipLive        bool     `toml:"skip_live" json:"skip_live,omitempty"`               // 排除直播、直播录像和尚未开始的首播
+	MinViews        int64    `toml:"min_views" json:"min_views,omitempty"`               // 最少播放量
+	Categories      []string `toml:"categories" json:"categories,omitempty"`             // 只处理这些分类的视频，如 Education、Science & Technology
+	ExcludeTags     []string `toml:"exclude_tags" json:"exclude_tags,omitempty"`         // 视频标签包含其中任意一个时排除（不区分大小写）
+}
+
+// Video 检查规则使用的视频信息
+type Video struct {
+	Title      string
+	Duration   int // 时长（秒），0 表示未知
+	Uploader   string
+	UploaderID string
+	ChannelID  string
+	Language   string
+	UploadDate time.Time // 零值表示未知
+	LiveStatus string    // yt-dlp 的 live_status: not_live、is_live、is_upcoming、was_live、post_live
+	ViewCount  int64     // 播放量，0 表示未知
+	Tags       []string
+	Categories []string
+}
+
+// IsEmpty 是否没有任何规则
+func (r *Rules) IsEmpty() bool {
+	return r == nil || (r.MinDuration == 0 && r.MaxDuration == 0 &&
+		len(r.IncludeKeywords) == 0 && len(r.ExcludeKeywords) == 0 &&
+		len(r.AllowUploaders) == 0 && len(r.DenyUploaders) == 0 &&
+		len(r.Languages) == 0 && r.PublishedAfter == "" && r.MaxAgeDays == 0 && !r.SkipLive &&
+		r.MinViews == 0 && len(r.Categories) == 0 && len(r.ExcludeTags) == 0)
+}
+
+// Validate 检查规则的取值
+func (r *Rules) Validate() error {
+	if r == nil {
+		return nil
+	}
+	if r.MinDuration < 0 || r.MaxDuration < 0 || r.MaxAgeDays < 0 || r.MinViews < 0 {
+		return fmt.Errorf("时长、天数和播放量不能为负数")
+	}
+	if r.MaxDuration > 0 && r.MinDuration > r.MaxDuration {
+		return fmt.Errorf("最短时长 %d 秒大于最长时长 %d 秒", r.MinDuration, r.MaxDuration)
+	}
+	if r.PublishedAfter != "" {
+		if _, err := time.Parse(DateLayout, r.PublishedAfter); err != nil {
+			return fmt.Errorf("发布日期 %s 格式错误，应为 %s", r.PublishedAfter, DateLayout)
+		}
+	}
+	return nil
+}
+
+// Merge 返回合并后的规则，override 中设置了的项覆盖 r 中的同一项
+// 订阅的规则覆盖全局规则，列表整体覆盖而不是追加
+func (r *Rules) Merge(override *Rules) *Rules {
+	merged := Rules{}
+	if r != nil {
+		merged = *r
+	}
+	if override == nil {
+		return &merged
+	}
+	if override.MinDuration != 0 {
+		merged.MinDuration = override.MinDuration
+	}
+	if override.MaxDuration != 0 {
+		merged.MaxDuration = override.MaxDuration
+	}
+	if override.IncludeKeywords != nil {
+		merged.IncludeKeywords = override.IncludeKeywords
+	}
+	if override.ExcludeKeywords != nil {
+		merged.ExcludeKeywords = override.ExcludeKeywords
+	}
+	if override.AllowUploaders != nil {
+		merged.AllowUploaders = override.AllowUploaders
+	}
+	if override.DenyUploaders != nil {
+		merged.DenyUploaders = override.DenyUploaders
+	}
+	if override.Languages != nil {
+		merged.Languages = override.Languages
+	}
+	if override.PublishedAfter != "" {
+		merged.PublishedAfter = override.PublishedAfter
+	}
+	if override.MaxAgeDays != 0 {
+		merged.MaxAgeDays = override.MaxAgeDays
+	}
+	if override.SkipLive {
+		merged.SkipLive = true
+	}
+	if override.MinViews != 0 {
+		merged.MinViews = override.MinViews
+	}
+	if override.Categories != nil {
+		merged.Categories = override.Categories
+	}
+	if override.ExcludeTags != nil {
+		merged.ExcludeTags = override.ExcludeTags
+	}
+	return &merged
+}
+
+// Check 检查视频是否符合规则，不符合时返回原因
+func (r *Rules) Check(video Video, now time.Time) (string, bool) {
+	if r == nil {
+		return "", true
+	}
+
+	if video.Duration > 0 {
+		if r.MinDuration > 0 && video.Duration < r.MinDuration {
+			return fmt.Sprintf("时长 %s 短于 %s", formatDuration(video.Duration), formatDuration(r.MinDuration)), false
+		}
+		if r.MaxDuration > 0 && video.Duration > r.MaxDuration {
+			return fmt.Sprintf("时长 %s 超过 %s", formatDuration(video.Duration), formatDuration(r.MaxDuration)), false
+		}
+	}
+
+	title := strings.ToLower(video.Title)
+	if len(r.IncludeKeywords) > 0 && !containsAny(title, r.IncludeKeywords) {
+		return fmt.Sprintf("标题不包含关键字 %s", strings.Join(r.IncludeKeywords, "、")), false
+	}
+	for _, keyword := range r.ExcludeKeywords {
+		if keyword != "" && strings.Contains(title, strings.ToLower(keyword)) {
+			return fmt.Sprintf("标题包含排除的关键字 %s", keyword), false
+		}
+	}
+
+	uploaders := []string{video.Uploader, video.UploaderID, video.ChannelID}
+	if len(r.AllowUploaders) > 0 && !matchAny(uploaders, r.AllowUploaders) {
+		return fmt.Sprintf("上传者 %s 不在允许列表中", video.Uploader), false
+	}
+	if matchAny(uploaders, r.DenyUploaders) {
+		return fmt.Sprintf("上传者 %s 在排除列表中", video.Uploader), false
+	}
+
+	if len(r.Languages) > 0 && video.Language != "" && !matchLanguage(video.Language, r.Languages) {
+		return fmt.Sprintf("视频语言 %s 不在 %s 中", video.Language, strings.Join(r.Languages, "、")), false
+	}
+
+	if !video.UploadDate.IsZero() {
+		if after, err := time.Parse(DateLayout, r.PublishedAfter); err == nil && video.UploadDate.Before(after) {
+			return fmt.Sprintf("发布日期 %s 早于 %s", video.UploadDate.Format(DateLayout), r.PublishedAfter), false
+		}
+		if r.MaxAgeDays > 0 && now.Sub(video.UploadDate) > time.Duration(r.MaxAgeDays)*24*time.Hour {
+			return fmt.Sprintf("发布日期 %s 超过 %d 天", video.UploadDate.Format(DateLayout), r.MaxAgeDays), false
+		}
+	}
+
+	if r.MinViews > 0 && video.ViewCount > 0 && video.ViewCount < r.MinViews {
+		return fmt.Sprintf("播放量 %d 少于 %d", video.ViewCount, r.MinViews), false
+	}
+	if len(r.Categories) > 0 && len(video.Categories) > 0 && !matchAny(video.Categories, r.Categories) {
+		return fmt.Sprintf("分类 %s 不在 %s 中", strings.Join(video.Categories, "、"), strings.Join(r.Categories, "、")), false
+	}
+	for _, tag := range video.Tags {
+		if matchAny([]string{tag}, r.ExcludeTags) {
+			return fmt.Sprintf("标签包含排除的标签 %s", tag), false
+		}
+	}
+
+	if r.SkipLive {
+		switch video.LiveStatus {
+		case "is_live":
+			return "视频正在直播", false
+		case "is_upcoming":
+			return "直播或首播尚未开始", false
+		case "was_live", "post_live":
+			return "视频是直播录像", false
+		}
+	}
+	return "", true
+}
+
+// containsAny text 是否包含任意一个关键字，text 已转为小写
+func containsAny(text string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if keyword != "" && strings.Contains(text, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAny values 中是否有与 list 中某项相同的值（不区分大小写，忽略 @ 前缀）
+func matchAny(values, list []string) bool {
+	for _, value := range values {
+		value = strings.TrimPrefix(strings.TrimSpace(value), "@")
+		if value == "" {
+			continue
+		}
+		for _, item := range list {
+			if strings.EqualFold(value, strings.TrimPrefix(strings.TrimSpace(item), "@")) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchLanguage 语言是否在列表中，en 可以匹配 en-US、en_GB
+func matchLanguage(language string, languages []string) bool {
+	language = strings.ToLower(strings.ReplaceAll(language, "_", "-"))
+	for _, item := range languages {
+		item = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(item), "_", "-"))
+		if item != "" && (language == item || strings.HasPrefix(language, item+"-")) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatDuration 将秒数格式化为 1h02m03s 形式
+func formatDuration(seconds int) string {
+	return (time.Duration(seconds) * time.Second).String()
+}
@@ -0,0 +1,183 @@
+package filter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse(DateLayout, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestCheck(t *testing.T) {
+	now := date("2026-06-01")
+	tests := []struct {
+		name   string
+		rules  *Rules
+		video  Video
+		ok     bool
+		reason string // 不符合时原因中包含的内容
+	}{
+		{"没有规则", nil, Video{Title: "任意视频", Duration: 10}, true, ""},
+		{"空规则", &Rules{}, Video{Title: "任意视频", Duration: 10}, true, ""},
+
+		// 时长，边界值符合规则
+		{"短于最短时长", &Rules{MinDuration: 60}, Video{Duration: 59}, false, "短于 1m0s"},
+		{"等于最短时长", &Rules{MinDuration: 60}, Video{Duration: 60}, true, ""},
+		{"超过最长时长", &Rules{MaxDuration: 3600}, Video{Duration: 3601}, false, "超过 1h0m0s"},
+		{"等于最长时长", &Rules{MaxDuration: 3600}, Video{Duration: 3600}, true, ""},
+		{"时长在范围内", &Rules{MinDuration: 60, MaxDuration: 3600}, Video{Duration: 600}, true, ""},
+		{"时长未知", &Rules{MinDuration: 60, MaxDuration: 3600}, Video{}, true, ""},
+
+		// 发布日期
+		{"早于发布日期", &Rules{PublishedAfter: "2026-01-01"}, Video{UploadDate: date("2025-12-31")}, false, "早于 2026-01-01"},
+		{"等于发布日期", &Rules{PublishedAfter: "2026-01-01"}, Video{UploadDate: date("2026-01-01")}, true, ""},
+		{"超过最大天数", &Rules{MaxAgeDays: 30}, Video{UploadDate: date("2026-05-01")}, false, "超过 30 天"},
+		{"正好最大天数", &Rules{MaxAgeDays: 30}, Video{UploadDate: date("2026-05-02")}, true, ""},
+		{"发布日期未知", &Rules{PublishedAfter: "2026-01-01", MaxAgeDays: 30}, Video{}, true, ""},
+		{"发布日期格式错误时不生效", &Rules{PublishedAfter: "2026/01/01"}, Video{UploadDate: date("2020-01-01")}, true, ""},
+
+		// 关键字不区分大小写
+		{"包含关键字", &Rules{IncludeKeywords: []string{"golang", "Rust"}}, Video{Title: "Learn RUST in 10 minutes"}, true, ""},
+		{"不包含关键字", &Rules{IncludeKeywords: []string{"golang", "Rust"}}, Video{Title: "Python tips"}, false, "不包含关键字 golang、Rust"},
+		{"包含排除的关键字", &Rules{ExcludeKeywords: []string{"#shorts"}}, Video{Title: "Funny cat #Shorts"}, false, "排除的关键字 #shorts"},
+		{"排除优先于包含", &Rules{IncludeKeywords: []string{"cat"}, ExcludeKeywords: []string{"live"}}, Video{Title: "Cat live stream"}, false, "排除的关键字 live"},
+		{"忽略空关键字", &Rules{ExcludeKeywords: []string{""}}, Video{Title: "任意视频"}, true, ""},
+
+		// 上传者、语言和直播
+		{"允许的上传者ID", &Rules{AllowUploaders: []string{"@GoogleDevelopers"}}, Video{Uploader: "Google for Developers", UploaderID: "@googledevelopers"}, true, ""},
+		{"排除的上传者", &Rules{DenyUploaders: []string{"UC123"}}, Video{Uploader: "someone", ChannelID: "UC123"}, false, "排除列表"},
+		{"语言前缀匹配", &Rules{Languages: []string{"en"}}, Video{Language: "en_US"}, true, ""},
+		{"语言不匹配", &Rules{Languages: []string{"en"}}, Video{Language: "ja"}, false, "视频语言 ja"},
+		{"跳过直播录像", &Rules{SkipLive: true}, Video{LiveStatus: "was_live"}, false, "直播录像"},
+	}
+	for _, tt := range tests {
+		reason, ok := tt.rules.Check(tt.video, now)
+		if ok != tt.ok {
+			t.Errorf("%s: Check() = %q, %v; want %v", tt.name, reason, ok, tt.ok)
+			continue
+		}
+		if !strings.Contains(reason, tt.reason) {
+			t.Errorf("%s: Check() 原因为 %q，应包含 %q", tt.name, reason, tt.reason)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	global := &Rules{
+		MinDuration:     60,
+		MaxDuration:     3600,
+		IncludeKeywords: []string{"golang"},
+		ExcludeKeywords: []string{"#shorts"},
+		PublishedAfter:  "2026-01-01",
+		MaxAgeDays:      30,
+	}
+	tests := []struct {
+		name     string
+		global   *Rules
+		override *Rules
+		want     *Rules
+	}{
+		{"没有订阅规则", global, nil, global},
+		{"订阅规则为空", global, &Rules{}, global},
+		{"没有全局规则", nil, &Rules{MinDuration: 120}, &Rules{MinDuration: 120}},
+		{
+			"覆盖时长和日期",
+			global,
+			&Rules{MinDuration: 300, PublishedAfter: "2026-03-01"},
+			&Rules{
+				MinDuration:     300,
+				MaxDuration:     3600,
+				IncludeKeywords: []string{"golang"},
+				ExcludeKeywords: []string{"#shorts"},
+				PublishedAfter:  "2026-03-01",
+				MaxAgeDays:      30,
+			},
+		},
+		{
+			// 列表整体覆盖而不是追加，空列表表示取消全局的关键字
+			"覆盖关键字列表",
+			global,
+			&Rules{IncludeKeywords: []string{"rust", "zig"}, ExcludeKeywords: []string{}},
+			&Rules{
+				MinDuration:     60,
+				MaxDuration:     3600,
+				IncludeKeywords: []string{"rust", "zig"},
+				ExcludeKeywords: []string{},
+				PublishedAfter:  "2026-01-01",
+				MaxAgeDays:      30,
+			},
+		},
+		{
+			"开启跳过直播",
+			&Rules{MinViews: 100},
+			&Rules{SkipLive: true, MinViews: 1000},
+			&Rules{SkipLive: true, MinViews: 1000},
+		},
+	}
+	for _, tt := range tests {
+		got := tt.global.Merge(tt.override)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Merge() = %+v; want %+v", tt.name, got, tt.want)
+		}
+	}
+
+	// 合并结果是副本，不修改全局规则
+	merged := global.Merge(&Rules{MinDuration: 1})
+	merged.MaxDuration = 1
+	if global.MinDuration != 60 || global.MaxDuration != 3600 {
+		t.Errorf("Merge() 修改了全局规则: %+v", global)
+	}
+}
+
+func TestMergeCheck(t *testing.T) {
+	now := date("2026-06-01")
+	global := &Rules{MinDuration: 60, ExcludeKeywords: []string{"#shorts"}}
+	video := Video{Title: "Go tips #shorts", Duration: 45, UploadDate: date("2026-05-20")}
+
+	if _, ok := global.Check(video, now); ok {
+		t.Fatalf("全局规则应排除视频")
+	}
+	// 订阅允许短视频，只排除较早发布的视频
+	override := &Rules{MinDuration: 30, ExcludeKeywords: []string{}, MaxAgeDays: 14}
+	if reason, ok := global.Merge(override).Check(video, now); !ok {
+		t.Errorf("合并后的规则应保留视频，实际排除原因: %s", reason)
+	}
+	video.UploadDate = date("2026-05-01")
+	if _, ok := global.Merge(override).Check(video, now); ok {
+		t.Errorf("合并后的规则应排除 31 天前发布的视频")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules *Rules
+		ok    bool
+	}{
+		{"没有规则", nil, true},
+		{"空规则", &Rules{}, true},
+		{"时长范围", &Rules{MinDuration: 60, MaxDuration: 3600}, true},
+		{"只有最短时长", &Rules{MinDuration: 60}, true},
+		{"最短等于最长", &Rules{MinDuration: 60, MaxDuration: 60}, true},
+		{"最短大于最长", &Rules{MinDuration: 120, MaxDuration: 60}, false},
+		{"负数时长", &Rules{MinDuration: -1}, false},
+		{"负数天数", &Rules{MaxAgeDays: -1}, false},
+		{"负数播放量", &Rules{MinViews: -1}, false},
+		{"发布日期", &Rules{PublishedAfter: "2026-01-01"}, true},
+		{"发布日期格式错误", &Rules{PublishedAfter: "2026/01/01"}, false},
+		{"发布日期不存在", &Rules{PublishedAfter: "2026-02-30"}, false},
+	}
+	for _, tt := range tests {
+		err := tt.rules.Validate()
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: Validate() = %v; want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
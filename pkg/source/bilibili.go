@@ -0,0 +1,73 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/difyz9/ytb2bili/pkg/utils"
+)
+
+// PlatformBilibili Bilibili
+const PlatformBilibili = "bilibili"
+
+var bilibiliAID = regexp.MustCompile(`^av[0-9]+$`)
+
+// bilibiliSource Bilibili 视频
+// 多P视频的视频ID带有分P后缀，如 BV1xx411c7mD_p2，与原来保存的视频ID相同
+type bilibiliSource struct{}
+
+func (bilibiliSource) Platform() string { return PlatformBilibili }
+
+func (bilibiliSource) VideoID(u *url.URL) (string, error) {
+	videoID := utils.ExtractBvidFromURL(u.Path)
+	if videoID == "" {
+		for _, segment := range strings.Split(u.Path, "/") {
+			if bilibiliAID.MatchString(segment) {
+				videoID = segment
+				break
+			}
+		}
+	}
+	if videoID == "" {
+		return "", fmt.Errorf("%w: 无法从 Bilibili 链接中获取 BV 号，短链接请先在浏览器中打开获取完整链接: %s", ErrUnknownURL, u)
+	}
+	if page := u.Query().Get("p"); page != "" && page != "1" {
+		videoID += "_p" + page
+	}
+	return videoID, nil
+}
+
+func (bilibiliSource) VideoURL(videoID string) string {
+	id, page, ok := strings.Cut(videoID, "_p")
+	if !ok {
+		return "https://www.bilibili.com/video/" + videoID
+	}
+	return fmt.Sprintf("https://www.bilibili.com/video/%s?p=%s", id, page)
+}
+
+func (bilibiliSource) FetchMetadata(ctx context.Context, opts Options, videoURL string) (*Metadata, error) {
+	info, err := dumpJSON(ctx, opts, videoURL)
+	if err != nil {
+		return nil, err
+	}
+	return info.metadata(), nil
+}
+
+func (bilibiliSource) FetchThumbnail(ctx context.Context, opts Options, videoURL, dir string) (string, error) {
+	info, err := dumpJSON(ctx, opts, videoURL)
+	if err != nil {
+		return "", err
+	}
+	return downloadThumbnail(ctx, opts, info.Thumbnail, dir)
+}
+
+func (bilibiliSource) ListSubtitles(ctx context.Context, opts Options, videoURL string) ([]SubtitleTrack, error) {
+	info, err := dumpJSON(ctx, opts, videoURL)
+	if err != nil {
+		return nil, err
+	}
+	return info.subtitleTracks(), nil
+}
@@ -0,0 +1,77 @@
+package source
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// chapterLeading 时间在前的章节行，如 "00:00 Intro"、"(1:02:03) - Q&A"
+var chapterLeading = regexp.MustCompile(`^[\(\[]?((?:\d{1,2}:)?\d{1,2}:\d{2})[\)\]]?\s*[-–—:|·]?\s*(.+)$`)
+
+// chapterTrailing 时间在后的章节行，如 "Intro - 00:00"
+var chapterTrailing = regexp.MustCompile(`^(.+?)\s*[-–—:|·]?\s*[\(\[]?((?:\d{1,2}:)?\d{1,2}:\d{2})[\)\]]?$`)
+
+// minChapters 描述中至少需要的章节数，与 YouTube 的规则相同
+const minChapters = 3
+
+// ParseDescriptionChapters 从视频描述中解析时间戳章节
+// 与 YouTube 的规则相同：第一个章节从 0:00 开始，至少 3 个章节且时间递增，不符合时返回 nil
+// duration 为视频时长（秒），最后一个章节在视频结束时结束，未知时为 0
+func ParseDescriptionChapters(description string, duration int) []Chapter {
+	var chapters []Chapter
+	for _, line := range strings.Split(description, "\n") {
+		line = strings.TrimSpace(line)
+		var timestamp, title string
+		if match := chapterLeading.FindStringSubmatch(line); match != nil {
+			timestamp, title = match[1], match[2]
+		} else if match := chapterTrailing.FindStringSubmatch(line); match != nil {
+			timestamp, title = match[2], match[1]
+		} else {
+			continue
+		}
+
+		start, ok := parseTimestamp(timestamp)
+		title = strings.TrimSpace(title)
+		if !ok || title == "" {
+			continue
+		}
+		if n := len(chapters); n > 0 && start <= chapters[n-1].StartTime {
+			// 时间不递增，已有完整的章节列表时忽略之后的时间戳，否则重新开始
+			if n >= minChapters {
+				break
+			}
+			chapters = nil
+		}
+		if len(chapters) == 0 && start != 0 {
+			// 章节列表从 0:00 开始，之前提到的时间不是章节
+			continue
+		}
+		chapters = append(chapters, Chapter{Title: title, StartTime: start})
+	}
+
+	if len(chapters) < minChapters {
+		return nil
+	}
+	for i := range chapters {
+		if i+1 < len(chapters) {
+			chapters[i].EndTime = chapters[i+1].StartTime
+		} else {
+			chapters[i].EndTime = max(chapters[i].StartTime, float64(duration))
+		}
+	}
+	return chapters
+}
+
+// parseTimestamp 解析 mm:ss 或 h:mm:ss 格式的时间
+func parseTimestamp(timestamp string) (float64, bool) {
+	seconds := 0
+	for _, part := range strings.Split(timestamp, ":") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, false
+		}
+		seconds = seconds*60 + n
+	}
+	return float64(seconds), true
+}
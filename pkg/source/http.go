@@ -0,0 +1,62 @@
+package source
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// PlatformHTTP 直接指向视频文件的链接
+const PlatformHTTP = "http"
+
+// mediaExtensions 直链文件支持的扩展名
+var mediaExtensions = map[string]bool{
+	".mp4": true, ".mkv": true, ".webm": true, ".mov": true, ".avi": true, ".flv": true, ".m4v": true, ".ts": true,
+	".mp3": true, ".m4a": true, ".wav": true, ".flac": true, ".ogg": true,
+}
+
+// httpFileSource 视频文件直链，由 yt-dlp 的 generic 提取器下载
+type httpFileSource struct{}
+
+func (httpFileSource) Platform() string { return PlatformHTTP }
+
+// Match 链接路径以视频或音频文件扩展名结尾
+func (httpFileSource) Match(u *url.URL) bool {
+	return mediaExtensions[strings.ToLower(path.Ext(u.Path))]
+}
+
+func (httpFileSource) VideoID(u *url.URL) (string, error) {
+	return urlDigest(u), nil
+}
+
+func (httpFileSource) VideoURL(string) string { return "" }
+
+// FetchMetadata 以文件名作为标题，同时检查链接是否可以访问
+func (httpFileSource) FetchMetadata(ctx context.Context, opts Options, videoURL string) (*Metadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, videoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := opts.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	title := path.Base(resp.Request.URL.Path)
+	if unescaped, err := url.PathUnescape(title); err == nil {
+		title = unescaped
+	}
+	return &Metadata{Title: strings.TrimSuffix(title, path.Ext(title))}, nil
+}
+
+func (httpFileSource) FetchThumbnail(context.Context, Options, string, string) (string, error) {
+	return "", ErrNotSupported
+}
+
+// ListSubtitles 直链文件没有平台字幕
+func (httpFileSource) ListSubtitles(context.Context, Options, string) ([]SubtitleTrack, error) {
+	return nil, nil
+}
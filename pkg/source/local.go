@@ -0,0 +1,157 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/difyz9/ytb2bili/pkg/utils"
+)
+
+// PlatformLocal 服务器上的本地文件，如上传的文件和监控目录中的文件
+const PlatformLocal = "local"
+
+// localSource 本地视频文件，链接为 file:// 格式
+// 标题和描述取自与视频同名的 JSON 文件，封面和字幕取自同名的图片和 SRT 文件
+type localSource struct{}
+
+func (localSource) Platform() string { return PlatformLocal }
+
+// VideoID 同一路径的文件识别为同一视频
+func (localSource) VideoID(u *url.URL) (string, error) {
+	if u.Path == "" {
+		return "", fmt.Errorf("%w: 缺少文件路径: %s", ErrUnknownURL, u)
+	}
+	return urlDigest(u), nil
+}
+
+func (localSource) VideoURL(string) string { return "" }
+
+// FetchMetadata 读取同名的 JSON 文件，没有时以文件名作为标题
+func (localSource) FetchMetadata(_ context.Context, _ Options, videoURL string) (*Metadata, error) {
+	videoPath, err := LocalFilePath(videoURL)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(videoPath); err != nil {
+		return nil, err
+	}
+
+	sidecar, err := ReadSidecar(videoPath)
+	if err != nil {
+		return nil, err
+	}
+	return &Metadata{
+		Title:       sidecar.Title,
+		Description: sidecar.Description,
+		Language:    sidecar.Language,
+	}, nil
+}
+
+// FetchThumbnail 复制同名的图片文件作为封面
+func (localSource) FetchThumbnail(_ context.Context, _ Options, videoURL, dir string) (string, error) {
+	videoPath, err := LocalFilePath(videoURL)
+	if err != nil {
+		return "", err
+	}
+	for _, ext := range []string{".jpg", ".jpeg", ".png", ".webp"} {
+		imagePath := SidecarPath(videoPath, ext)
+		if _, err := os.Stat(imagePath); err != nil {
+			continue
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", err
+		}
+		coverPath := filepath.Join(dir, "cover"+ext)
+		if err := utils.CopyFile(imagePath, coverPath); err != nil {
+			return "", fmt.Errorf("复制封面失败: %v", err)
+		}
+		return coverPath, nil
+	}
+	return "", fmt.Errorf("%w: 没有与视频同名的封面图片", ErrNotSupported)
+}
+
+// ListSubtitles 同名的 SRT 文件作为上传者提供的字幕，语言取自 JSON 文件
+func (localSource) ListSubtitles(_ context.Context, _ Options, videoURL string) ([]SubtitleTrack, error) {
+	videoPath, err := LocalFilePath(videoURL)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(SidecarPath(videoPath, ".srt")); err != nil {
+		return nil, nil
+	}
+	sidecar, err := ReadSidecar(videoPath)
+	if err != nil {
+		return nil, err
+	}
+	return []SubtitleTrack{{Language: sidecar.Language, Formats: []string{"srt"}}}, nil
+}
+
+// Sidecar 与视频同名的 JSON 文件，如 video.mp4 对应 video.json
+type Sidecar struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Language    string `json:"language"` // 同名 SRT 字幕的语言，可以为空
+}
+
+// SidecarPath 与视频同名、扩展名为 ext 的文件路径
+func SidecarPath(videoPath, ext string) string {
+	return strings.TrimSuffix(videoPath, filepath.Ext(videoPath)) + ext
+}
+
+// ReadSidecar 读取视频的 JSON 文件，没有文件或未设置标题时以文件名作为标题
+func ReadSidecar(videoPath string) (*Sidecar, error) {
+	var sidecar Sidecar
+	data, err := os.ReadFile(SidecarPath(videoPath, ".json"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &sidecar); err != nil {
+			return nil, fmt.Errorf("解析 %s 失败: %v", SidecarPath(videoPath, ".json"), err)
+		}
+	}
+	if strings.TrimSpace(sidecar.Title) == "" {
+		sidecar.Title = strings.TrimSuffix(filepath.Base(videoPath), filepath.Ext(videoPath))
+	}
+	return &sidecar, nil
+}
+
+// IsMediaFile 文件是否为支持的视频或音频文件
+func IsMediaFile(name string) bool {
+	return mediaExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+var windowsDrivePath = regexp.MustCompile(`^/[A-Za-z]:/`)
+
+// LocalFileURL 根据本地文件路径生成 file:// 链接
+func LocalFileURL(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	slashPath := filepath.ToSlash(absPath)
+	if !strings.HasPrefix(slashPath, "/") {
+		slashPath = "/" + slashPath // Windows 盘符路径
+	}
+	return (&url.URL{Scheme: "file", Path: slashPath}).String(), nil
+}
+
+// LocalFilePath 从 file:// 链接中获取本地文件路径
+func LocalFilePath(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "file" || u.Path == "" {
+		return "", fmt.Errorf("%w: 不是本地文件链接: %s", ErrUnknownURL, rawURL)
+	}
+	path := u.Path
+	if windowsDrivePath.MatchString(path) {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path), nil
+}
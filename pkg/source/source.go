@@ -0,0 +1,255 @@
+// Package source 视频来源适配器
+// 每个平台实现 Source 接口，负责从链接中提取视频ID、构建视频链接、获取元数据、封面和平台自带的字幕，
+// 按域名注册，无法按域名匹配的链接依次交给直链文件和 yt-dlp 通用适配器处理，本地文件使用 file:// 链接
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotSupported 来源不支持该操作，例如直链文件没有封面和字幕
+var ErrNotSupported = errors.New("来源不支持该操作")
+
+// ErrUnknownURL 无法识别的视频链接
+var ErrUnknownURL = errors.New("无法识别的视频链接")
+
+// Source 视频来源
+type Source interface {
+	// Platform 平台名称，作为规范ID的命名空间，如 youtube
+	Platform() string
+	// VideoID 从链接中提取平台内的视频ID
+	VideoID(u *url.URL) (string, error)
+	// VideoURL 根据平台内的视频ID构建视频链接，无法构建时返回空字符串
+	VideoURL(videoID string) string
+	// FetchMetadata 获取视频的标题、简介等信息
+	FetchMetadata(ctx context.Context, opts Options, videoURL string) (*Metadata, error)
+	// FetchThumbnail 下载视频封面到 dir，返回文件路径
+	FetchThumbnail(ctx context.Context, opts Options, videoURL, dir string) (string, error)
+	// ListSubtitles 列出平台自带的字幕（包括自动生成的字幕）
+	ListSubtitles(ctx context.Context, opts Options, videoURL string) ([]SubtitleTrack, error)
+}
+
+// Matcher 未按域名注册的来源通过 Match 判断是否能处理链接
+type Matcher interface {
+	Match(u *url.URL) bool
+}
+
+// Options 访问来源时使用的工具和网络设置
+type Options struct {
+	YtDlpPath          string       // yt-dlp 可执行文件路径
+	Proxy              string       // 代理地址，为空时不使用代理
+	CookiesFile        string       // Netscape 格式的 cookies 文件
+	CookiesFromBrowser string       // 没有 cookies 文件时从浏览器读取 cookies，如 chrome
+	HTTPClient         *http.Client // 为空时使用 http.DefaultClient
+}
+
+func (o Options) httpClient() *http.Client {
+	if o.HTTPClient != nil {
+		return o.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// Metadata 视频元数据
+type Metadata struct {
+	Title       string
+	Description string
+	Uploader    string
+	UploaderID  string
+	ChannelID   string
+	Duration    int       // 时长（秒）
+	Thumbnail   string    // 封面地址
+	Language    string    // 视频语言，如 en，未知时为空
+	UploadDate  time.Time // 发布日期，未知时为零值
+	LiveStatus  string    // 直播状态: not_live、is_live、is_upcoming、was_live、post_live，未知时为空
+	ViewCount   int64     // 播放量，未知时为 0
+	Tags        []string  // 上传者设置的标签
+	Categories  []string  // 平台的分类，如 Education
+	Chapters    []Chapter // 上传者设置的章节
+	Raw         []byte    // 来源返回的完整信息，如 yt-dlp 的 info JSON，没有时为空
+}
+
+// Chapter 视频章节
+type Chapter struct {
+	Title     string  `json:"title"`
+	StartTime float64 `json:"start_time"` // 开始时间（秒）
+	EndTime   float64 `json:"end_time"`   // 结束时间（秒）
+}
+
+// SubtitleTrack 平台自带的字幕
+type SubtitleTrack struct {
+	Language  string   `json:"language"`  // 语言代码，如 en、zh-Hans
+	Name      string   `json:"name"`      // 语言名称
+	Automatic bool     `json:"automatic"` // 是否为平台自动生成的字幕
+	Formats   []string `json:"formats"`   // 可用的格式，如 vtt、srv3、json3
+}
+
+// ID 规范的视频ID，由平台和平台内的视频ID组成，如 youtube:dQw4w9WgXcQ
+type ID struct {
+	Platform string
+	VideoID  string
+}
+
+// String 返回规范ID的字符串形式
+func (id ID) String() string {
+	return id.Platform + ":" + id.VideoID
+}
+
+var unsafeKeyChars = regexp.MustCompile(`[^0-9A-Za-z_-]`)
+
+// Key 用作视频记录 video_id 和目录名的ID
+// YouTube 和 Bilibili 沿用平台内的视频ID，与已保存的视频兼容，其他平台加上平台前缀避免冲突
+func (id ID) Key() string {
+	switch id.Platform {
+	case PlatformYouTube, PlatformBilibili:
+		return id.VideoID
+	}
+	return id.Platform + "_" + unsafeKeyChars.ReplaceAllString(id.VideoID, "_")
+}
+
+// ParseID 解析规范ID的字符串形式
+func ParseID(s string) (ID, error) {
+	platform, videoID, ok := strings.Cut(s, ":")
+	if !ok || platform == "" || videoID == "" {
+		return ID{}, fmt.Errorf("无效的视频ID: %s", s)
+	}
+	return ID{Platform: platform, VideoID: videoID}, nil
+}
+
+type registry struct {
+	mu         sync.RWMutex
+	byHost     map[string]Source
+	byPlatform map[string]Source
+	byScheme   map[string]Source
+	fallbacks  []Source
+}
+
+var sources = &registry{
+	byHost:     make(map[string]Source),
+	byPlatform: make(map[string]Source),
+	byScheme:   make(map[string]Source),
+}
+
+func init() {
+	Register(youtubeSource{}, "youtube.com", "youtu.be", "youtube-nocookie.com")
+	Register(bilibiliSource{}, "bilibili.com", "b23.tv")
+	RegisterScheme(localSource{}, "file")
+	// 后备来源按顺序匹配：先直链文件，其他链接交给 yt-dlp
+	Register(httpFileSource{})
+	Register(ytdlpSource{})
+}
+
+// Register 注册来源，hosts 为来源处理的域名（包括子域名）
+// 没有 hosts 时作为后备来源，按注册顺序通过 Matcher 判断是否处理
+func Register(src Source, hosts ...string) {
+	sources.mu.Lock()
+	defer sources.mu.Unlock()
+
+	sources.byPlatform[src.Platform()] = src
+	if len(hosts) == 0 {
+		sources.fallbacks = append(sources.fallbacks, src)
+		return
+	}
+	for _, host := range hosts {
+		sources.byHost[strings.ToLower(host)] = src
+	}
+}
+
+// RegisterScheme 注册处理非 http 链接的来源，如处理 file:// 链接的本地文件
+func RegisterScheme(src Source, schemes ...string) {
+	sources.mu.Lock()
+	defer sources.mu.Unlock()
+
+	sources.byPlatform[src.Platform()] = src
+	for _, scheme := range schemes {
+		sources.byScheme[strings.ToLower(scheme)] = src
+	}
+}
+
+// Get 根据平台名称获取来源
+func Get(platform string) (Source, bool) {
+	sources.mu.RLock()
+	defer sources.mu.RUnlock()
+	src, ok := sources.byPlatform[platform]
+	return src, ok
+}
+
+// ForURL 获取处理链接的来源，非 http 链接按协议匹配，http 链接依次按域名、父域名和后备来源匹配
+func ForURL(rawURL string) (Source, *url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnknownURL, rawURL)
+	}
+
+	sources.mu.RLock()
+	defer sources.mu.RUnlock()
+
+	if src, ok := sources.byScheme[strings.ToLower(u.Scheme)]; ok {
+		return src, u, nil
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnknownURL, rawURL)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for host != "" {
+		if src, ok := sources.byHost[host]; ok {
+			return src, u, nil
+		}
+		_, parent, ok := strings.Cut(host, ".")
+		if !ok {
+			break
+		}
+		host = parent
+	}
+	for _, src := range sources.fallbacks {
+		if m, ok := src.(Matcher); !ok || m.Match(u) {
+			return src, u, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("%w: %s", ErrUnknownURL, rawURL)
+}
+
+// Parse 从链接中提取规范ID
+func Parse(rawURL string) (ID, Source, error) {
+	src, u, err := ForURL(rawURL)
+	if err != nil {
+		return ID{}, nil, err
+	}
+	videoID, err := src.VideoID(u)
+	if err != nil {
+		return ID{}, nil, err
+	}
+	return ID{Platform: src.Platform(), VideoID: videoID}, src, nil
+}
+
+// ParseRemote 从网络视频链接中提取规范ID，不接受本地文件链接
+// 用户提交的链接使用此函数，避免通过 file:// 链接读取服务器上的任意文件
+func ParseRemote(rawURL string) (ID, Source, error) {
+	id, src, err := Parse(rawURL)
+	if err != nil {
+		return ID{}, nil, err
+	}
+	if id.Platform == PlatformLocal {
+		return ID{}, nil, fmt.Errorf("%w: 本地文件请通过上传接口提交: %s", ErrUnknownURL, rawURL)
+	}
+	return id, src, nil
+}
+
+// VideoURL 根据规范ID构建视频链接，来源无法构建时返回 fallback（通常为保存的原始链接）
+func VideoURL(id ID, fallback string) string {
+	if src, ok := Get(id.Platform); ok {
+		if videoURL := src.VideoURL(id.VideoID); videoURL != "" {
+			return videoURL
+		}
+	}
+	return fallback
+}
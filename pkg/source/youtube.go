@@ -0,0 +1,100 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/difyz9/ytb2bili/pkg/utils"
+)
+
+// PlatformYouTube YouTube
+const PlatformYouTube = "youtube"
+
+var youtubeVideoID = regexp.MustCompile(`^[0-9A-Za-z_-]{11}$`)
+
+// youtubeSource YouTube 视频
+type youtubeSource struct{}
+
+func (youtubeSource) Platform() string { return PlatformYouTube }
+
+// VideoID 支持 watch?v=、youtu.be/、/shorts/、/live/、/embed/ 和 /v/ 格式的链接
+func (youtubeSource) VideoID(u *url.URL) (string, error) {
+	if v := u.Query().Get("v"); youtubeVideoID.MatchString(v) {
+		return v, nil
+	}
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	candidate := ""
+	if strings.HasSuffix(strings.ToLower(u.Hostname()), "youtu.be") {
+		candidate = segments[0]
+	} else if len(segments) >= 2 {
+		switch segments[0] {
+		case "shorts", "live", "embed", "v", "e":
+			candidate = segments[1]
+		}
+	}
+	if youtubeVideoID.MatchString(candidate) {
+		return candidate, nil
+	}
+	return "", fmt.Errorf("%w: 不是 YouTube 视频链接: %s", ErrUnknownURL, u)
+}
+
+func (youtubeSource) VideoURL(videoID string) string {
+	return "https://www.youtube.com/watch?v=" + videoID
+}
+
+func (youtubeSource) FetchMetadata(ctx context.Context, opts Options, videoURL string) (*Metadata, error) {
+	info, err := dumpJSON(ctx, opts, videoURL)
+	if err != nil {
+		return nil, err
+	}
+	return info.metadata(), nil
+}
+
+// FetchThumbnail 直接从 YouTube 图片服务器下载封面，不需要 yt-dlp，优先最高质量
+func (s youtubeSource) FetchThumbnail(ctx context.Context, opts Options, videoURL, dir string) (string, error) {
+	u, err := url.Parse(videoURL)
+	if err != nil {
+		return "", err
+	}
+	videoID, err := s.VideoID(u)
+	if err != nil {
+		return "", err
+	}
+
+	options := utils.DownloadOptions{
+		SavePath:         dir,
+		FilenameTemplate: "{quality}",
+		Timeout:          10 * time.Second,
+		MaxRetries:       3,
+		QualityFallback:  true,
+		CreateDirs:       true,
+		Overwrite:        false,
+	}
+	qualities := []utils.ImageQuality{utils.QualityMax, utils.QualityStandard}
+	results := utils.DownloadYouTubeThumbnail(videoID, qualities, options, "").(map[string]utils.DownloadResult)
+
+	for _, quality := range qualities {
+		if result, ok := results[string(quality)]; ok && result.Success {
+			return result.FilePath, nil
+		}
+	}
+	for _, result := range results {
+		if !result.Success {
+			return "", fmt.Errorf("下载封面失败: %s", result.ErrorMessage)
+		}
+	}
+	return "", fmt.Errorf("下载封面失败")
+}
+
+func (youtubeSource) ListSubtitles(ctx context.Context, opts Options, videoURL string) ([]SubtitleTrack, error) {
+	info, err := dumpJSON(ctx, opts, videoURL)
+	if err != nil {
+		return nil, err
+	}
+	return info.subtitleTracks(), nil
+}
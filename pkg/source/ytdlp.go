@@ -0,0 +1,231 @@
+package source
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/difyz9/ytb2bili/pkg/utils"
+)
+
+// PlatformYtDlp yt-dlp 支持的其他网站
+const PlatformYtDlp = "ytdlp"
+
+// ytdlpSource yt-dlp 通用适配器，处理没有专门适配器的网站
+// 视频ID为规范化链接的摘要，同一链接多次提交时能识别为同一视频
+type ytdlpSource struct{}
+
+func (ytdlpSource) Platform() string { return PlatformYtDlp }
+
+func (ytdlpSource) VideoID(u *url.URL) (string, error) {
+	return urlDigest(u), nil
+}
+
+func (ytdlpSource) VideoURL(string) string { return "" }
+
+func (ytdlpSource) FetchMetadata(ctx context.Context, opts Options, videoURL string) (*Metadata, error) {
+	info, err := dumpJSON(ctx, opts, videoURL)
+	if err != nil {
+		return nil, err
+	}
+	return info.metadata(), nil
+}
+
+func (ytdlpSource) FetchThumbnail(ctx context.Context, opts Options, videoURL, dir string) (string, error) {
+	info, err := dumpJSON(ctx, opts, videoURL)
+	if err != nil {
+		return "", err
+	}
+	return downloadThumbnail(ctx, opts, info.Thumbnail, dir)
+}
+
+func (ytdlpSource) ListSubtitles(ctx context.Context, opts Options, videoURL string) ([]SubtitleTrack, error) {
+	info, err := dumpJSON(ctx, opts, videoURL)
+	if err != nil {
+		return nil, err
+	}
+	return info.subtitleTracks(), nil
+}
+
+// ytdlpInfo yt-dlp --dump-json 输出中使用的字段
+type ytdlpInfo struct {
+	ID                string                      `json:"id"`
+	Title             string                      `json:"title"`
+	Description       string                      `json:"description"`
+	Uploader          string                      `json:"uploader"`
+	UploaderID        string                      `json:"uploader_id"`
+	ChannelID         string                      `json:"channel_id"`
+	Duration          float64                     `json:"duration"`
+	Thumbnail         string                      `json:"thumbnail"`
+	Language          string                      `json:"language"`
+	UploadDate        string                      `json:"upload_date"` // 格式 20060102
+	LiveStatus        string                      `json:"live_status"`
+	ViewCount         int64                       `json:"view_count"`
+	Tags              []string                    `json:"tags"`
+	Categories        []string                    `json:"categories"`
+	Chapters          []Chapter                   `json:"chapters"`
+	Subtitles         map[string][]ytdlpSubFormat `json:"subtitles"`
+	AutomaticCaptions map[string][]ytdlpSubFormat `json:"automatic_captions"`
+
+	raw []byte // yt-dlp 输出的完整 JSON
+}
+
+type ytdlpSubFormat struct {
+	Ext  string `json:"ext"`
+	Name string `json:"name"`
+}
+
+func (info *ytdlpInfo) metadata() *Metadata {
+	metadata := &Metadata{
+		Title:       info.Title,
+		Description: info.Description,
+		Uploader:    info.Uploader,
+		UploaderID:  info.UploaderID,
+		ChannelID:   info.ChannelID,
+		Duration:    int(info.Duration),
+		Thumbnail:   info.Thumbnail,
+		Language:    info.Language,
+		LiveStatus:  info.LiveStatus,
+		ViewCount:   info.ViewCount,
+		Tags:        info.Tags,
+		Categories:  info.Categories,
+		Chapters:    info.Chapters,
+		Raw:         info.raw,
+	}
+	if date, err := time.Parse("20060102", info.UploadDate); err == nil {
+		metadata.UploadDate = date
+	}
+	return metadata
+}
+
+// subtitleTracks 字幕列表，上传者提供的字幕在前，按语言排序
+func (info *ytdlpInfo) subtitleTracks() []SubtitleTrack {
+	var tracks []SubtitleTrack
+	add := func(subs map[string][]ytdlpSubFormat, automatic bool) {
+		languages := make([]string, 0, len(subs))
+		for lang := range subs {
+			// live_chat 是直播聊天记录，不是字幕
+			if lang != "live_chat" {
+				languages = append(languages, lang)
+			}
+		}
+		sort.Strings(languages)
+		for _, lang := range languages {
+			track := SubtitleTrack{Language: lang, Automatic: automatic}
+			for _, format := range subs[lang] {
+				if track.Name == "" {
+					track.Name = format.Name
+				}
+				track.Formats = append(track.Formats, format.Ext)
+			}
+			tracks = append(tracks, track)
+		}
+	}
+	add(info.Subtitles, false)
+	add(info.AutomaticCaptions, true)
+	return tracks
+}
+
+// dumpJSON 使用 yt-dlp 获取视频信息，不下载视频
+func dumpJSON(ctx context.Context, opts Options, videoURL string) (*ytdlpInfo, error) {
+	if opts.YtDlpPath == "" {
+		return nil, fmt.Errorf("未找到 yt-dlp，请确保已正确安装")
+	}
+
+	args := []string{"--dump-json", "--no-download", "--no-playlist"}
+	if opts.CookiesFile != "" {
+		args = append(args, "--cookies", opts.CookiesFile)
+	} else if opts.CookiesFromBrowser != "" {
+		args = append(args, "--cookies-from-browser", opts.CookiesFromBrowser)
+	}
+	if opts.Proxy != "" {
+		args = append(args, "--proxy", opts.Proxy)
+	}
+	args = append(args, "--", videoURL)
+
+	cmd := utils.CommandContext(ctx, opts.YtDlpPath, args...)
+	output, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("获取视频信息失败: %v, %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("获取视频信息失败: %v", err)
+	}
+
+	var info ytdlpInfo
+	if err := json.Unmarshal(output, &info); err != nil {
+		return nil, fmt.Errorf("解析视频信息失败: %v", err)
+	}
+	info.raw = output
+	return &info, nil
+}
+
+// downloadThumbnail 下载封面图片到 dir，文件名为 cover 加原扩展名
+func downloadThumbnail(ctx context.Context, opts Options, thumbnailURL, dir string) (string, error) {
+	if thumbnailURL == "" {
+		return "", fmt.Errorf("%w: 视频没有封面", ErrNotSupported)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, thumbnailURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := opts.httpClient().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("下载封面失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("下载封面失败: HTTP %d", resp.StatusCode)
+	}
+
+	ext := ".jpg"
+	if u, err := url.Parse(thumbnailURL); err == nil {
+		switch e := strings.ToLower(path.Ext(u.Path)); e {
+		case ".png", ".webp", ".jpeg":
+			ext = e
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	filePath := filepath.Join(dir, "cover"+ext)
+	file, err := os.Create(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if _, err := io.Copy(file, io.LimitReader(resp.Body, 20<<20)); err != nil {
+		return "", fmt.Errorf("保存封面失败: %v", err)
+	}
+	return filePath, nil
+}
+
+// urlDigest 规范化链接后计算摘要：忽略协议、www 前缀、末尾斜杠、片段和查询参数的顺序
+func urlDigest(u *url.URL) string {
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	query := u.Query()
+	normalized := host + strings.TrimSuffix(u.EscapedPath(), "/")
+	if len(query) > 0 {
+		normalized += "?" + query.Encode() // Encode 按键排序
+	}
+	sum := sha1.Sum([]byte(normalized))
+	return hex.EncodeToString(sum[:8])
+}
@@ -2,17 +2,49 @@ package store
 
 import (
 	"github.com/difyz9/ytb2bili/internal/core/models"
+	"github.com/difyz9/ytb2bili/pkg/source"
 	"github.com/difyz9/ytb2bili/pkg/store/model"
 	"gorm.io/gorm"
 )
 
 // MigrateDatabase 自动迁移数据库表
 func MigrateDatabase(db *gorm.DB) error {
-	return db.AutoMigrate(
+	err := db.AutoMigrate(
 		&model.User{},
 		&model.SavedVideo{},
 		&model.TaskStep{},
+		&model.VideoArtifact{},
+		&model.Job{},
+		&model.VideoStatusHistory{},
+		&model.Subscription{},
 		&model.AccountBinding{},
 		&models.TBUser{}, // 管理员用户表
 	)
+	if err != nil {
+		return err
+	}
+	return backfillCanonicalIDs(db)
+}
+
+// backfillCanonicalIDs 为添加规范ID之前保存的视频补充规范ID，无法识别链接的视频保持为空
+func backfillCanonicalIDs(db *gorm.DB) error {
+	var videos []model.SavedVideo
+	return db.Unscoped().
+		Select("id", "url").
+		Where("canonical_id IS NULL OR canonical_id = ''").
+		FindInBatches(&videos, 500, func(tx *gorm.DB, batch int) error {
+			for _, video := range videos {
+				id, _, err := source.Parse(video.URL)
+				if err != nil {
+					continue
+				}
+				err = db.Unscoped().Model(&model.SavedVideo{}).
+					Where("id = ?", video.ID).
+					UpdateColumn("canonical_id", id.String()).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
 }
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"encoding/json"
+
+	"github.com/difyz9/ytb2bili/pkg/download"
+)
+
+// DownloadOverrides 单个视频的下载设置，可以指定下载配置并覆盖其中的项，为空的字段不覆盖
+type DownloadOverrides struct {
+	ProfileName      string `json:"profile,omitempty"` // 下载配置名称，为空时使用流程或全局的配置
+	download.Profile        // 覆盖下载配置中的项
+}
+
+// IsEmpty 是否没有任何覆盖的设置
+func (o DownloadOverrides) IsEmpty() bool {
+	return o.ProfileName == "" && o.Profile.IsEmpty()
+}
+
+// SetDownloadOverrides 保存视频的下载设置，为空时清除
+func (v *SavedVideo) SetDownloadOverrides(overrides *DownloadOverrides) {
+	if overrides == nil || overrides.IsEmpty() {
+		v.DownloadOverrides = ""
+		return
+	}
+	data, _ := json.Marshal(overrides) // 只包含字符串、整数和布尔值，不会失败
+	v.DownloadOverrides = string(data)
+}
+
+// GetDownloadOverrides 获取视频的下载设置，未设置时返回空设置
+func (v *SavedVideo) GetDownloadOverrides() (DownloadOverrides, error) {
+	var overrides DownloadOverrides
+	if v.DownloadOverrides == "" {
+		return overrides, nil
+	}
+	err := json.Unmarshal([]byte(v.DownloadOverrides), &overrides)
+	return overrides, err
+}
@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// Job 任务队列中的任务
+// 工作者领取任务后持有租约并定期续约，工作者异常退出时租约过期，任务由其他工作者重新领取
+type Job struct {
+	BaseModel
+	VideoID         string     `gorm:"type:varchar(100);not null;index" json:"video_id"`   // 关联的视频ID
+	ActiveVideoID   *string    `gorm:"type:varchar(100);uniqueIndex" json:"-"`             // 排队或执行中时等于 VideoID，结束后清空，保证同一视频同时只有一个任务
+	Kind            string     `gorm:"type:varchar(20);not null" json:"kind"`              // 任务类型: chain, step
+	StepKey         string     `gorm:"type:varchar(50)" json:"step_key"`                   // 重试的步骤标识，仅 step 类型
+	Status          string     `gorm:"type:varchar(20);not null;index" json:"status"`      // 任务状态: queued, running, done, cancelled
+	LockedBy        string     `gorm:"type:varchar(100)" json:"locked_by"`                 // 持有租约的工作者
+	LeaseExpiresAt  *time.Time `gorm:"index" json:"lease_expires_at"`                      // 租约到期时间
+	HeartbeatAt     *time.Time `json:"heartbeat_at"`                                       // 最近一次心跳时间
+	Attempts        int        `gorm:"type:int;default:0" json:"attempts"`                 // 被领取的次数
+	CancelRequested bool       `gorm:"type:boolean;default:false" json:"cancel_requested"` // 是否已请求取消，由持有租约的工作者在心跳时处理
+	CancelledBy     string     `gorm:"type:varchar(100)" json:"cancelled_by"`              // 请求取消的执行者，记录到视频状态历史
+}
+
+// TableName 指定表名
+func (Job) TableName() string {
+	return "tb_jobs"
+}
+
+// JobKind 任务类型常量
+const (
+	JobKindChain = "chain" // 执行视频的处理流程
+	JobKindStep  = "step"  // 重试单个步骤
+)
+
+// JobStatus 任务状态常量
+const (
+	JobStatusQueued    = "queued"    // 等待领取
+	JobStatusRunning   = "running"   // 执行中
+	JobStatusDone      = "done"      // 已结束
+	JobStatusCancelled = "cancelled" // 已取消
+)
@@ -76,9 +76,10 @@ type SavedVideoSubtitle struct {
 type SavedVideo struct {
 	BaseModel
 	VideoID          string `gorm:"type:varchar(100);uniqueIndex;not null" json:"video_id"`    // 视频ID（唯一）
+	CanonicalID      string `gorm:"type:varchar(150);index" json:"canonical_id"`               // 带平台前缀的规范ID，如 youtube:dQw4w9WgXcQ
 	URL              string `gorm:"type:varchar(500);not null;index" json:"url"`               // 视频URL
 	Title            string `gorm:"type:varchar(500)" json:"title"`                            // 视频标题
-	Status           string `gorm:"type:varchar(20)" json:"status"`                            // 视频状态
+	Status           VideoStatus `gorm:"type:varchar(20)" json:"status"`                       // 视频状态
 	Description      string `gorm:"type:text" json:"description"`                              // 视频描述
 	GeneratedTitle   string `gorm:"type:varchar(500)" json:"generated_title"`                  // AI生成的标题
 	GeneratedDesc    string `gorm:"type:text" json:"generated_desc"`                           // AI生成的描述
@@ -86,8 +87,29 @@ type SavedVideo struct {
 	BiliBVID         string `gorm:"type:varchar(50)" json:"bili_bvid"`                         // Bilibili BVID
 	BiliAID          int64  `gorm:"type:bigint" json:"bili_aid"`                               // Bilibili AID
 	OperationType    string `gorm:"type:varchar(50)" json:"operation_type"`                    // 操作类型 (download/upload等)
+	Pipeline         string `gorm:"type:varchar(50)" json:"pipeline"`                          // 处理流程名称，为空时使用默认流程
+	Origin           string `gorm:"type:varchar(20);index" json:"origin"`                      // 视频的提交来源，见 Origin 常量
 	Subtitles        string `gorm:"type:longtext" json:"subtitles"`                           // 字幕JSON字符串
 	PlaylistID       string `gorm:"type:varchar(100);index" json:"playlist_id"`                // 播放列表ID
+	SubscriptionID   *uint  `gorm:"index" json:"subscription_id"`                              // 来源订阅ID，插件提交的视频为空
+	UploadOverrides  string `gorm:"type:text" json:"upload_overrides"`                         // 投稿设置JSON字符串，覆盖B站配置，见 UploadOverrides
+	SubtitleSource   string `gorm:"type:varchar(20)" json:"subtitle_source"`                   // 原语言字幕的来源，见 SubtitleSource 常量
+	SubtitleLanguage string `gorm:"type:varchar(20)" json:"subtitle_language"`                 // 原语言字幕的语言
+	DownloadOverrides string `gorm:"type:text" json:"download_overrides"`                     // 下载设置JSON字符串，覆盖下载配置，见 DownloadOverrides
+	FormatID         string `gorm:"type:varchar(100)" json:"format_id"`                        // 下载时选择的 yt-dlp 格式ID，如 137+140
+	Resolution       string `gorm:"type:varchar(20)" json:"resolution"`                        // 下载的视频分辨率，如 1920x1080，只下载音频时为 audio only
+	Codec            string `gorm:"type:varchar(100)" json:"codec"`                            // 下载的视频编码，只下载音频时为音频编码
+	Uploader         string `gorm:"type:varchar(200);index" json:"uploader"`                   // 原视频作者
+	ChannelID        string `gorm:"type:varchar(100);index" json:"channel_id"`                 // 原视频的频道ID
+	UploadDate       string `gorm:"type:varchar(10);index" json:"upload_date"`                 // 原视频发布日期，格式 2006-01-02
+	Duration         int    `gorm:"index" json:"duration"`                                     // 时长（秒）
+	ViewCount        int64  `gorm:"index" json:"view_count"`                                   // 获取信息时的播放量
+	SourceTags       string `gorm:"type:text" json:"source_tags"`                              // 原视频标签JSON数组，见 GetSourceTags
+	Categories       string `gorm:"type:varchar(500)" json:"categories"`                       // 原视频分类JSON数组，见 GetCategories
+	Chapters         string `gorm:"type:text" json:"chapters"`                                 // 原视频章节JSON字符串，见 GetChapters
+	ChapterSource    string `gorm:"type:varchar(20)" json:"chapter_source"`                    // 章节的来源，见 ChapterSource 常量
+	Language         string `gorm:"type:varchar(20);index" json:"language"`                    // 原视频语言
+	ThumbnailURL     string `gorm:"type:varchar(1000)" json:"thumbnail_url"`                   // 原视频封面地址
 	Timestamp        string `gorm:"type:varchar(50)" json:"timestamp"`                         // 时间戳
 	SavedAt          string `gorm:"type:varchar(50)" json:"saved_at"`                          // 保存时间
 }
@@ -96,3 +118,20 @@ type SavedVideo struct {
 func (SavedVideo) TableName() string {
 	return "tb_saved_videos"
 }
+
+// 视频的提交来源
+const (
+	OriginExtension    = "extension"    // 浏览器插件提交
+	OriginBatch        = "batch"        // 批量提交
+	OriginLocal        = "local"        // 上传或监控目录导入的本地视频
+	OriginSubscription = "subscription" // 订阅检查发现的新视频
+)
+
+// 原语言字幕的来源
+const (
+	SubtitleSourceManualTarget = "manual_target" // 上传者提供的目标语言字幕，不需要翻译
+	SubtitleSourceManualSource = "manual_source" // 上传者提供的原语言字幕
+	SubtitleSourceAuto         = "auto"          // 平台自动生成的字幕
+	SubtitleSourceExtension    = "extension"     // 插件提交的字幕
+	SubtitleSourceASR          = "asr"           // 语音识别
+)
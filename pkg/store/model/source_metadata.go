@@ -0,0 +1,78 @@
+package model
+
+import "encoding/json"
+
+// Chapter 原视频的章节
+type Chapter struct {
+	Title           string  `json:"title"`
+	TranslatedTitle string  `json:"translated_title,omitempty"` // 翻译后的标题，由翻译字幕的步骤在字幕之后翻译
+	StartTime       float64 `json:"start_time"`                 // 开始时间（秒）
+	EndTime         float64 `json:"end_time"`                   // 结束时间（秒）
+}
+
+// DisplayTitle 显示使用的标题，有翻译时使用翻译后的标题
+func (c Chapter) DisplayTitle() string {
+	if c.TranslatedTitle != "" {
+		return c.TranslatedTitle
+	}
+	return c.Title
+}
+
+// 章节的来源
+const (
+	ChapterSourcePlatform    = "platform"    // 视频来源提供的章节
+	ChapterSourceDescription = "description" // 从原视频描述中解析的时间戳
+	ChapterSourceAI          = "ai"          // 根据字幕用 AI 生成
+)
+
+// SetSourceTags 保存原视频的标签
+func (v *SavedVideo) SetSourceTags(tags []string) {
+	v.SourceTags = marshalList(tags)
+}
+
+// GetSourceTags 获取原视频的标签
+func (v *SavedVideo) GetSourceTags() []string {
+	var tags []string
+	unmarshalList(v.SourceTags, &tags)
+	return tags
+}
+
+// SetCategories 保存原视频的分类
+func (v *SavedVideo) SetCategories(categories []string) {
+	v.Categories = marshalList(categories)
+}
+
+// GetCategories 获取原视频的分类
+func (v *SavedVideo) GetCategories() []string {
+	var categories []string
+	unmarshalList(v.Categories, &categories)
+	return categories
+}
+
+// SetChapters 保存原视频的章节
+func (v *SavedVideo) SetChapters(chapters []Chapter) {
+	v.Chapters = marshalList(chapters)
+}
+
+// GetChapters 获取原视频的章节
+func (v *SavedVideo) GetChapters() []Chapter {
+	var chapters []Chapter
+	unmarshalList(v.Chapters, &chapters)
+	return chapters
+}
+
+// marshalList 将列表保存为 JSON 数组，空列表保存为空字符串
+func marshalList[T any](list []T) string {
+	if len(list) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(list) // 只包含字符串和数字，不会失败
+	return string(data)
+}
+
+// unmarshalList 解析 JSON 数组，内容无效时忽略
+func unmarshalList[T any](data string, list *[]T) {
+	if data != "" {
+		_ = json.Unmarshal([]byte(data), list)
+	}
+}
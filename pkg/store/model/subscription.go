@@ -0,0 +1,70 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/difyz9/ytb2bili/pkg/filter"
+)
+
+// Subscription 订阅的频道或播放列表
+// 定时检查最新的视频，尚未保存的视频自动加入待处理列表
+type Subscription struct {
+	BaseModel
+	Kind          string     `gorm:"type:varchar(20);not null" json:"kind"`             // 订阅类型: channel, playlist
+	URL           string     `gorm:"type:varchar(500);not null;uniqueIndex" json:"url"` // 频道或播放列表的 URL
+	Title         string     `gorm:"type:varchar(500)" json:"title"`                    // 频道或播放列表名称，检查时自动更新
+	Enabled       bool       `gorm:"type:boolean;not null" json:"enabled"`              // 是否启用
+	Pipeline      string     `gorm:"type:varchar(50)" json:"pipeline"`                  // 新视频使用的处理流程，为空时使用订阅配置中的流程
+	MaxItems      int        `gorm:"type:int;default:0" json:"max_items"`               // 每次检查最新的视频数量，0 表示使用订阅配置
+	CheckInterval int        `gorm:"type:int;default:0" json:"check_interval"`          // 检查间隔（分钟），0 表示使用订阅配置
+	Mode          string     `gorm:"type:varchar(20)" json:"mode"`                      // 检查方式: ytdlp、feed、websub，为空时为 ytdlp
+	SourceID      string     `gorm:"type:varchar(100)" json:"source_id"`                // 频道ID（UC 开头）或播放列表ID，用于订阅源地址，检查时自动获取
+	LastCheckedAt *time.Time `gorm:"index" json:"last_checked_at"`                      // 最近一次检查时间
+	LastError     string     `gorm:"type:text" json:"last_error"`                       // 最近一次检查的错误，成功时清空
+	VideoCount    int        `gorm:"type:int;default:0" json:"video_count"`             // 已加入待处理列表的视频数量
+	FilterRules   string     `gorm:"type:text" json:"filter_rules"`                     // 过滤规则JSON字符串，覆盖全局过滤规则中的同一项，见 filter.Rules
+
+	WebSubSecret    string     `gorm:"column:websub_secret;type:varchar(100)" json:"-"`   // 验证推送内容签名的密钥
+	WebSubExpiresAt *time.Time `gorm:"column:websub_expires_at" json:"websub_expires_at"` // WebSub 订阅到期时间，Hub 验证通过后更新
+}
+
+// TableName 指定表名
+func (Subscription) TableName() string {
+	return "tb_subscriptions"
+}
+
+// SubscriptionKind 订阅类型常量
+const (
+	SubscriptionKindChannel  = "channel"  // 频道
+	SubscriptionKindPlaylist = "playlist" // 播放列表
+)
+
+// SubscriptionMode 订阅检查方式常量
+const (
+	SubscriptionModeYtDlp  = "ytdlp"  // 定时使用 yt-dlp 列出最新的视频
+	SubscriptionModeFeed   = "feed"   // 定时读取 Atom 订阅源
+	SubscriptionModeWebSub = "websub" // 由 Hub 推送新视频，同时定时读取订阅源作为补充
+)
+
+// SetFilterRules 保存订阅的过滤规则，为空时清除
+func (s *Subscription) SetFilterRules(rules *filter.Rules) {
+	if rules.IsEmpty() {
+		s.FilterRules = ""
+		return
+	}
+	data, _ := json.Marshal(rules) // 只包含字符串、整数和布尔值，不会失败
+	s.FilterRules = string(data)
+}
+
+// GetFilterRules 获取订阅的过滤规则，未设置时返回 nil
+func (s *Subscription) GetFilterRules() (*filter.Rules, error) {
+	if s.FilterRules == "" {
+		return nil, nil
+	}
+	var rules filter.Rules
+	if err := json.Unmarshal([]byte(s.FilterRules), &rules); err != nil {
+		return nil, err
+	}
+	return &rules, nil
+}
@@ -8,13 +8,16 @@ import (
 type TaskStep struct {
 	BaseModel
 	VideoID     string    `gorm:"type:varchar(100);not null;index" json:"video_id"`       // 关联的视频ID
-	StepName    string    `gorm:"type:varchar(100);not null" json:"step_name"`            // 步骤名称
+	StepKey     string    `gorm:"type:varchar(50);index" json:"step_key"`                 // 步骤标识，如 download、asr、upload_video
+	StepName    string    `gorm:"type:varchar(100);not null" json:"step_name"`            // 步骤显示名称
 	StepOrder   int       `gorm:"type:int;not null" json:"step_order"`                    // 步骤顺序
-	Status      string    `gorm:"type:varchar(20);not null" json:"status"`                // 步骤状态: pending, running, completed, failed, skipped
+	Status      string    `gorm:"type:varchar(20);not null" json:"status"`                // 步骤状态: pending, running, completed, failed, skipped, cancelled
 	StartTime   *time.Time `gorm:"type:datetime" json:"start_time"`                       // 开始时间
 	EndTime     *time.Time `gorm:"type:datetime" json:"end_time"`                         // 结束时间
 	Duration    int64     `gorm:"type:bigint" json:"duration"`                            // 执行时长（毫秒）
 	ErrorMsg    string    `gorm:"type:text" json:"error_msg"`                             // 错误信息
+	ErrorClass  string    `gorm:"type:varchar(20)" json:"error_class"`                    // 错误分类: transient, auth, quota, permanent
+	Attempts    int       `gorm:"type:int;default:0" json:"attempts"`                     // 执行次数（含自动重试）
 	ResultData  string    `gorm:"type:longtext" json:"result_data"`                       // 步骤执行结果数据（JSON）
 	CanRetry    bool      `gorm:"type:boolean;default:true" json:"can_retry"`             // 是否可以重试
 }
@@ -31,4 +34,5 @@ const (
 	TaskStepStatusCompleted = "completed" // 已完成
 	TaskStepStatusFailed    = "failed"    // 失败
 	TaskStepStatusSkipped   = "skipped"   // 跳过
+	TaskStepStatusCancelled = "cancelled" // 已取消
 )
\ No newline at end of file
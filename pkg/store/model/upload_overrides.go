@@ -0,0 +1,39 @@
+package model
+
+import "encoding/json"
+
+// UploadOverrides 单个视频的投稿设置，覆盖 B站配置和自动生成的内容，为空的字段不覆盖
+type UploadOverrides struct {
+	Title       string `json:"title,omitempty"`       // 投稿标题
+	Description string `json:"description,omitempty"` // 投稿简介，原视频链接仍会追加在末尾
+	Tags        string `json:"tags,omitempty"`        // 标签（逗号分隔）
+	Tid         int    `json:"tid,omitempty"`         // 分区ID
+	Copyright   int    `json:"copyright,omitempty"`   // 1=自制 2=转载
+	Source      string `json:"source,omitempty"`      // 转载来源
+	Dynamic     string `json:"dynamic,omitempty"`     // 动态内容
+}
+
+// IsEmpty 是否没有任何覆盖的设置
+func (o UploadOverrides) IsEmpty() bool {
+	return o == UploadOverrides{}
+}
+
+// SetUploadOverrides 保存视频的投稿设置，为空时清除
+func (v *SavedVideo) SetUploadOverrides(overrides *UploadOverrides) {
+	if overrides == nil || overrides.IsEmpty() {
+		v.UploadOverrides = ""
+		return
+	}
+	data, _ := json.Marshal(overrides) // 只包含字符串和整数，不会失败
+	v.UploadOverrides = string(data)
+}
+
+// GetUploadOverrides 获取视频的投稿设置，未设置时返回空设置
+func (v *SavedVideo) GetUploadOverrides() (UploadOverrides, error) {
+	var overrides UploadOverrides
+	if v.UploadOverrides == "" {
+		return overrides, nil
+	}
+	err := json.Unmarshal([]byte(v.UploadOverrides), &overrides)
+	return overrides, err
+}
@@ -0,0 +1,14 @@
+package model
+
+// VideoArtifact 视频处理过程中产出的产物（文件路径、生成的元数据、B站稿件ID等）
+type VideoArtifact struct {
+	BaseModel
+	VideoID string `gorm:"type:varchar(100);not null;uniqueIndex:idx_video_artifact_kind" json:"video_id"` // 关联的视频ID
+	Kind    string `gorm:"type:varchar(50);not null;uniqueIndex:idx_video_artifact_kind" json:"kind"`      // 产物类型
+	Value   string `gorm:"type:text" json:"value"`                                                         // 产物值（JSON）
+}
+
+// TableName 指定表名
+func (VideoArtifact) TableName() string {
+	return "tb_video_artifacts"
+}
@@ -0,0 +1,130 @@
+package model
+
+// VideoStatus 视频状态
+type VideoStatus string
+
+// VideoStatus 视频状态常量
+const (
+	VideoStatusPending              VideoStatus = "001" // 待处理
+	VideoStatusProcessing           VideoStatus = "002" // 处理中
+	VideoStatusReady                VideoStatus = "200" // 准备就绪，等待上传视频
+	VideoStatusUploadingVideo       VideoStatus = "201" // 上传视频中
+	VideoStatusVideoUploadFailed    VideoStatus = "299" // 视频上传失败
+	VideoStatusVideoUploaded        VideoStatus = "300" // 视频已上传，等待上传字幕
+	VideoStatusUploadingSubtitle    VideoStatus = "301" // 上传字幕中
+	VideoStatusSubtitleUploadFailed VideoStatus = "399" // 字幕上传失败
+	VideoStatusCompleted            VideoStatus = "400" // 全部完成
+	VideoStatusNeedsAttention       VideoStatus = "900" // 需要人工处理（cookies/API Key 失效或额度不足）
+	VideoStatusFiltered             VideoStatus = "997" // 不符合过滤规则，不再处理
+	VideoStatusCancelled            VideoStatus = "998" // 已取消
+	VideoStatusFailed               VideoStatus = "999" // 处理失败
+)
+
+// StatusActor 视频状态变更的执行者
+const (
+	StatusActorScheduler    = "scheduler"        // 任务调度器
+	StatusActorUploader     = "upload_scheduler" // 上传调度器
+	StatusActorSubscription = "subscription"     // 订阅调度器
+	StatusActorWatchFolder  = "watch_folder"     // 监控目录导入
+	StatusActorAPI          = "api"              // API 请求，已登录时记录为 api:<用户名>
+)
+
+// videoStatusTransitions 允许的状态变更，键为当前状态，空字符串表示新建的视频
+var videoStatusTransitions = map[VideoStatus][]VideoStatus{
+	"": {VideoStatusPending},
+	VideoStatusPending: {
+		VideoStatusPending,    // 重新提交
+		VideoStatusProcessing, // 开始处理
+		VideoStatusCancelled,  // 处理前取消
+	},
+	VideoStatusProcessing: {
+		VideoStatusProcessing,     // 租约过期后由其他工作者重新领取
+		VideoStatusPending,        // 服务关闭，等待重新处理
+		VideoStatusReady,          // 处理完成，等待上传
+		VideoStatusCompleted,      // 流程中没有上传步骤
+		VideoStatusNeedsAttention, // 认证失效或额度不足
+		VideoStatusFiltered,       // 不符合过滤规则
+		VideoStatusCancelled,      // 处理中取消
+		VideoStatusFailed,         // 处理失败
+	},
+	VideoStatusReady: {
+		VideoStatusUploadingVideo,
+		VideoStatusPending,
+	},
+	VideoStatusUploadingVideo: {
+		VideoStatusReady,             // 服务关闭，等待重新上传
+		VideoStatusVideoUploaded,     // 上传成功，等待上传字幕
+		VideoStatusCompleted,         // 流程中没有上传字幕步骤
+		VideoStatusVideoUploadFailed, // 上传失败
+		VideoStatusNeedsAttention,    // 登录状态失效
+	},
+	VideoStatusVideoUploadFailed: {
+		VideoStatusUploadingVideo, // 手动上传
+		VideoStatusReady,          // 重试上传步骤
+		VideoStatusPending,
+	},
+	VideoStatusVideoUploaded: {
+		VideoStatusUploadingSubtitle,
+		VideoStatusPending,
+	},
+	VideoStatusUploadingSubtitle: {
+		VideoStatusVideoUploaded,        // 服务关闭，等待重新上传
+		VideoStatusCompleted,            // 上传成功
+		VideoStatusSubtitleUploadFailed, // 上传失败
+		VideoStatusNeedsAttention,       // 登录状态失效
+	},
+	VideoStatusSubtitleUploadFailed: {
+		VideoStatusUploadingSubtitle, // 手动上传
+		VideoStatusVideoUploaded,     // 重试上传步骤
+		VideoStatusPending,
+	},
+	VideoStatusCompleted: {
+		VideoStatusVideoUploaded, // 重新上传字幕
+		VideoStatusPending,
+	},
+	VideoStatusNeedsAttention: {
+		VideoStatusNeedsAttention,
+		VideoStatusReady,         // 处理后重试上传视频，或重试准备步骤成功后等待上传
+		VideoStatusVideoUploaded, // 处理后重试上传字幕
+		VideoStatusCompleted,     // 重试准备步骤成功，流程中没有上传步骤
+		VideoStatusPending,       // 重试准备步骤成功后重新处理剩余的步骤
+	},
+	VideoStatusFiltered: {
+		VideoStatusPending, // 重新提交
+	},
+	VideoStatusCancelled: {
+		VideoStatusPending,
+	},
+	VideoStatusFailed: {
+		VideoStatusNeedsAttention, // 重试步骤时认证失效或额度不足
+		VideoStatusFiltered,       // 重试步骤时不符合过滤规则
+		VideoStatusReady,          // 重试准备步骤成功后等待上传
+		VideoStatusCompleted,      // 重试准备步骤成功，流程中没有上传步骤
+		VideoStatusPending,        // 重试准备步骤成功后重新处理剩余的步骤
+	},
+}
+
+// CanTransitionTo 是否允许从当前状态变更为 to
+func (s VideoStatus) CanTransitionTo(to VideoStatus) bool {
+	for _, allowed := range videoStatusTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// VideoStatusHistory 视频状态变更历史
+type VideoStatusHistory struct {
+	BaseModel
+	VideoID    string      `gorm:"type:varchar(100);not null;index" json:"video_id"` // 视频ID
+	FromStatus VideoStatus `gorm:"type:varchar(20)" json:"from_status"`              // 变更前的状态，新建的视频为空
+	ToStatus   VideoStatus `gorm:"type:varchar(20);not null" json:"to_status"`       // 变更后的状态
+	Actor      string      `gorm:"type:varchar(100)" json:"actor"`                   // 执行者: scheduler, upload_scheduler, api:<用户名>
+	Reason     string      `gorm:"type:text" json:"reason"`                          // 变更原因
+}
+
+// TableName 指定表名
+func (VideoStatusHistory) TableName() string {
+	return "tb_video_status_history"
+}
@@ -0,0 +1,425 @@
+package subtitle
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Style ASS 样式，颜色为 &HAABBGGRR 格式（AA 为透明度，00 不透明）
+type Style struct {
+	Name            string  `toml:"name" json:"name"`
+	FontName        string  `toml:"font_name" json:"font_name"`
+	FontSize        float64 `toml:"font_size" json:"font_size"`
+	PrimaryColour   string  `toml:"primary_colour" json:"primary_colour"`     // 文字颜色
+	SecondaryColour string  `toml:"secondary_colour" json:"secondary_colour"` // 卡拉OK 未唱部分的颜色
+	OutlineColour   string  `toml:"outline_colour" json:"outline_colour"`     // 描边颜色
+	BackColour      string  `toml:"back_colour" json:"back_colour"`           // 阴影或背景框颜色
+	Bold            bool    `toml:"bold" json:"bold"`
+	Italic          bool    `toml:"italic" json:"italic"`
+	Underline       bool    `toml:"underline" json:"underline"`
+	StrikeOut       bool    `toml:"strike_out" json:"strike_out"`
+	ScaleX          float64 `toml:"scale_x" json:"scale_x"` // 横向缩放百分比，0 时为 100
+	ScaleY          float64 `toml:"scale_y" json:"scale_y"` // 纵向缩放百分比，0 时为 100
+	Spacing         float64 `toml:"spacing" json:"spacing"`
+	Angle           float64 `toml:"angle" json:"angle"`
+	BorderStyle     int     `toml:"border_style" json:"border_style"` // 1 描边加阴影，3 不透明背景框，0 时为 1
+	Outline         float64 `toml:"outline" json:"outline"`           // 描边宽度
+	Shadow          float64 `toml:"shadow" json:"shadow"`             // 阴影距离
+	Alignment       int     `toml:"alignment" json:"alignment"`       // 按小键盘排列的位置，2 为底部居中，0 时为 2
+	MarginL         int     `toml:"margin_l" json:"margin_l"`
+	MarginR         int     `toml:"margin_r" json:"margin_r"`
+	MarginV         int     `toml:"margin_v" json:"margin_v"`
+	Encoding        int     `toml:"encoding" json:"encoding"`
+}
+
+// DefaultStyle 默认样式：白色文字、黑色描边，底部居中，适用于 1920x1080 的分辨率
+func DefaultStyle() Style {
+	return Style{
+		Name:            "Default",
+		FontName:        "Arial",
+		FontSize:        60,
+		PrimaryColour:   "&H00FFFFFF",
+		SecondaryColour: "&H000000FF",
+		OutlineColour:   "&H00000000",
+		BackColour:      "&H80000000",
+		ScaleX:          100,
+		ScaleY:          100,
+		BorderStyle:     1,
+		Outline:         3,
+		Shadow:          1,
+		Alignment:       2,
+		MarginL:         40,
+		MarginR:         40,
+		MarginV:         50,
+		Encoding:        1,
+	}
+}
+
+// 样式和事件字段的默认顺序，文件中没有 Format 行时使用
+var (
+	assStyleFormat = []string{"Name", "Fontname", "Fontsize", "PrimaryColour", "SecondaryColour", "OutlineColour", "BackColour",
+		"Bold", "Italic", "Underline", "StrikeOut", "ScaleX", "ScaleY", "Spacing", "Angle", "BorderStyle", "Outline", "Shadow",
+		"Alignment", "MarginL", "MarginR", "MarginV", "Encoding"}
+	ssaStyleFormat = []string{"Name", "Fontname", "Fontsize", "PrimaryColour", "SecondaryColour", "TertiaryColour", "BackColour",
+		"Bold", "Italic", "BorderStyle", "Outline", "Shadow", "Alignment", "MarginL", "MarginR", "MarginV", "AlphaLevel", "Encoding"}
+	assEventFormat = []string{"Layer", "Start", "End", "Style", "Name", "MarginL", "MarginR", "MarginV", "Effect", "Text"}
+	ssaEventFormat = []string{"Marked", "Start", "End", "Style", "Name", "MarginL", "MarginR", "MarginV", "Effect", "Text"}
+)
+
+// assTimePattern ASS 时间，如 0:01:02.34（百分之一秒）
+var assTimePattern = regexp.MustCompile(`^\s*(\d+):(\d{1,2}):(\d{1,2})[.:](\d{1,3})\s*$`)
+
+// ParseASS 解析 ASS 或 SSA 字幕，按 Format 行确定字段顺序，跳过 Comment 等非对话行
+// 文本中的样式覆盖标签会被去掉，\N 转换为换行，转义的反斜杠和花括号被还原
+func ParseASS(data []byte) (*Subtitle, error) {
+	sub := &Subtitle{}
+	section := ""
+	valid := false
+	var styleFormat, eventFormat []string
+	for _, line := range strings.Split(normalizeNewlines(string(data)), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "!:") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.ToLower(line)
+			switch section {
+			case "[script info]", "[events]":
+				valid = true
+			case "[v4 styles]":
+				styleFormat = ssaStyleFormat
+			case "[v4+ styles]":
+				styleFormat = assStyleFormat
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimLeft(value, " \t")
+
+		switch section {
+		case "[script info]":
+			switch strings.ToLower(key) {
+			case "playresx":
+				sub.PlayResX, _ = strconv.Atoi(value)
+			case "playresy":
+				sub.PlayResY, _ = strconv.Atoi(value)
+			}
+		case "[v4 styles]", "[v4+ styles]":
+			switch key {
+			case "Format":
+				styleFormat = splitFormat(value)
+			case "Style":
+				if fields := assFields(value, styleFormat); fields != nil {
+					sub.Styles = append(sub.Styles, parseASSStyle(fields, section == "[v4 styles]"))
+				}
+			}
+		case "[events]":
+			switch key {
+			case "Format":
+				eventFormat = splitFormat(value)
+			case "Dialogue":
+				if eventFormat == nil {
+					eventFormat = assEventFormat
+				}
+				if cue, ok := parseASSDialogue(assFields(value, eventFormat)); ok {
+					sub.Cues = append(sub.Cues, cue)
+				}
+			}
+		}
+	}
+	if !valid {
+		return nil, fmt.Errorf("不是有效的 ASS 字幕")
+	}
+	sort.SliceStable(sub.Cues, func(i, j int) bool { return sub.Cues[i].Start < sub.Cues[j].Start })
+	return sub, nil
+}
+
+// splitFormat 解析 Format 行的字段名
+func splitFormat(value string) []string {
+	fields := strings.Split(value, ",")
+	for i, field := range fields {
+		fields[i] = strings.TrimSpace(field)
+	}
+	return fields
+}
+
+// assFields 按字段名拆分样式或对话行，最后一个字段（对话的 Text）可以包含逗号，字段名转为小写
+func assFields(value string, format []string) map[string]string {
+	if len(format) == 0 {
+		return nil
+	}
+	values := strings.SplitN(value, ",", len(format))
+	if len(values) < len(format) {
+		return nil
+	}
+	fields := make(map[string]string, len(format))
+	for i, name := range format {
+		if i == len(format)-1 {
+			fields[strings.ToLower(name)] = values[i]
+		} else {
+			fields[strings.ToLower(name)] = strings.TrimSpace(values[i])
+		}
+	}
+	return fields
+}
+
+// parseASSDialogue 解析对话行，时间无效或没有文本时返回 false
+func parseASSDialogue(fields map[string]string) (Cue, bool) {
+	start := assTimePattern.FindStringSubmatch(fields["start"])
+	end := assTimePattern.FindStringSubmatch(fields["end"])
+	if start == nil || end == nil {
+		return Cue{}, false
+	}
+	text := unescapeASS(fields["text"])
+	cue := Cue{
+		Start:   clock(start[1], start[2], start[3], start[4]),
+		End:     clock(end[1], end[2], end[3], end[4]),
+		Text:    cleanText(text),
+		Style:   strings.TrimPrefix(fields["style"], "*"),
+		Speaker: fields["name"],
+	}
+	return cue, cue.Text != ""
+}
+
+// parseASSStyle 解析样式行，SSA 的对齐方式转换为 ASS 的小键盘位置
+func parseASSStyle(fields map[string]string, ssa bool) Style {
+	number := func(name string) float64 {
+		v, _ := strconv.ParseFloat(fields[name], 64)
+		return v
+	}
+	integer := func(name string) int {
+		v, _ := strconv.Atoi(fields[name])
+		return v
+	}
+	flag := func(name string) bool {
+		return fields[name] != "" && fields[name] != "0"
+	}
+	style := Style{
+		Name:            fields["name"],
+		FontName:        fields["fontname"],
+		FontSize:        number("fontsize"),
+		PrimaryColour:   fields["primarycolour"],
+		SecondaryColour: fields["secondarycolour"],
+		OutlineColour:   fields["outlinecolour"],
+		BackColour:      fields["backcolour"],
+		Bold:            flag("bold"),
+		Italic:          flag("italic"),
+		Underline:       flag("underline"),
+		StrikeOut:       flag("strikeout"),
+		ScaleX:          number("scalex"),
+		ScaleY:          number("scaley"),
+		Spacing:         number("spacing"),
+		Angle:           number("angle"),
+		BorderStyle:     integer("borderstyle"),
+		Outline:         number("outline"),
+		Shadow:          number("shadow"),
+		Alignment:       integer("alignment"),
+		MarginL:         integer("marginl"),
+		MarginR:         integer("marginr"),
+		MarginV:         integer("marginv"),
+		Encoding:        integer("encoding"),
+	}
+	if ssa {
+		style.OutlineColour = fields["tertiarycolour"]
+		style.Alignment = ssaToASSAlignment(style.Alignment)
+	}
+	return style
+}
+
+// ssaToASSAlignment SSA 的对齐方式（1-3 底部，5-7 顶部，9-11 中间）转换为小键盘位置
+func ssaToASSAlignment(alignment int) int {
+	switch {
+	case alignment >= 9 && alignment <= 11:
+		return alignment - 5
+	case alignment >= 5 && alignment <= 7:
+		return alignment + 2
+	default:
+		return alignment
+	}
+}
+
+// assToSSAAlignment 小键盘位置转换为 SSA 的对齐方式
+func assToSSAAlignment(alignment int) int {
+	switch {
+	case alignment >= 4 && alignment <= 6:
+		return alignment + 5
+	case alignment >= 7 && alignment <= 9:
+		return alignment - 2
+	default:
+		return alignment
+	}
+}
+
+// WriteASS 写入 ASS 字幕，没有样式时使用 DefaultStyle，字幕条目没有指定样式时使用第一个样式
+func WriteASS(w io.Writer, sub *Subtitle) error {
+	return writeASS(w, sub, false)
+}
+
+// WriteSSA 写入 SSA v4 字幕
+func WriteSSA(w io.Writer, sub *Subtitle) error {
+	return writeASS(w, sub, true)
+}
+
+func writeASS(w io.Writer, sub *Subtitle, ssa bool) error {
+	styles := sub.Styles
+	if len(styles) == 0 {
+		styles = []Style{DefaultStyle()}
+	}
+	resX, resY := sub.PlayResX, sub.PlayResY
+	if resX <= 0 || resY <= 0 {
+		resX, resY = 1920, 1080
+	}
+
+	var b strings.Builder
+	scriptType, stylesSection, styleFormat, eventFormat := "v4.00+", "[V4+ Styles]", assStyleFormat, assEventFormat
+	if ssa {
+		scriptType, stylesSection, styleFormat, eventFormat = "v4.00", "[V4 Styles]", ssaStyleFormat, ssaEventFormat
+	}
+	fmt.Fprintf(&b, "[Script Info]\n; Script generated by ytb2bili\nScriptType: %s\nPlayResX: %d\nPlayResY: %d\nWrapStyle: 0\nScaledBorderAndShadow: yes\n\n", scriptType, resX, resY)
+
+	fmt.Fprintf(&b, "%s\nFormat: %s\n", stylesSection, strings.Join(styleFormat, ", "))
+	defaultStyle := ""
+	for i, style := range styles {
+		fields := formatASSStyle(style, ssa)
+		if i == 0 {
+			defaultStyle = fields[0]
+		}
+		fmt.Fprintf(&b, "Style: %s\n", strings.Join(fields, ","))
+	}
+
+	fmt.Fprintf(&b, "\n[Events]\nFormat: %s\n", strings.Join(eventFormat, ", "))
+	first := "0"
+	if ssa {
+		first = "Marked=0"
+	}
+	for _, cue := range sub.Cues {
+		lines := textLines(cue.Text)
+		if len(lines) == 0 {
+			continue
+		}
+		style := assName(cue.Style)
+		if style == "" {
+			style = defaultStyle
+		}
+		for i, line := range lines {
+			lines[i] = escapeASS(line)
+		}
+		fmt.Fprintf(&b, "Dialogue: %s,%s,%s,%s,%s,0,0,0,,%s\n", first, formatASSTime(cue.Start), formatASSTime(cue.End),
+			style, assName(cue.Speaker), strings.Join(lines, `\N`))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatASSStyle 按 Format 行的顺序生成样式字段，缺省的数值使用 ASS 的默认值
+func formatASSStyle(style Style, ssa bool) []string {
+	number := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	flag := func(v bool) string {
+		if v {
+			return "-1"
+		}
+		return "0"
+	}
+	or := func(v, def float64) float64 {
+		if v == 0 {
+			return def
+		}
+		return v
+	}
+	borderStyle, alignment := style.BorderStyle, style.Alignment
+	if borderStyle == 0 {
+		borderStyle = 1
+	}
+	if alignment == 0 {
+		alignment = 2
+	}
+	name, fontName := assName(style.Name), assName(style.FontName)
+	if name == "" {
+		name = "Default"
+	}
+	if fontName == "" {
+		fontName = "Arial"
+	}
+	colour := func(v, def string) string {
+		if v = assName(v); v == "" {
+			return def
+		}
+		return v
+	}
+	primary := colour(style.PrimaryColour, "&H00FFFFFF")
+	secondary := colour(style.SecondaryColour, "&H000000FF")
+	outline := colour(style.OutlineColour, "&H00000000")
+	back := colour(style.BackColour, "&H00000000")
+
+	if ssa {
+		return []string{name, fontName, number(or(style.FontSize, 20)), primary, secondary, outline, back,
+			flag(style.Bold), flag(style.Italic), strconv.Itoa(borderStyle), number(style.Outline), number(style.Shadow),
+			strconv.Itoa(assToSSAAlignment(alignment)), strconv.Itoa(style.MarginL), strconv.Itoa(style.MarginR),
+			strconv.Itoa(style.MarginV), "0", strconv.Itoa(style.Encoding)}
+	}
+	return []string{name, fontName, number(or(style.FontSize, 20)), primary, secondary, outline, back,
+		flag(style.Bold), flag(style.Italic), flag(style.Underline), flag(style.StrikeOut),
+		number(or(style.ScaleX, 100)), number(or(style.ScaleY, 100)), number(style.Spacing), number(style.Angle),
+		strconv.Itoa(borderStyle), number(style.Outline), number(style.Shadow), strconv.Itoa(alignment),
+		strconv.Itoa(style.MarginL), strconv.Itoa(style.MarginR), strconv.Itoa(style.MarginV), strconv.Itoa(style.Encoding)}
+}
+
+// formatASSTime 格式化为 ASS 时间 H:MM:SS.cc，负数按 0 处理
+// ASS 的时间精度为百分之一秒，毫秒四舍五入
+func formatASSTime(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	cs := (d.Milliseconds() + 5) / 10
+	return fmt.Sprintf("%d:%02d:%02d.%02d", cs/360000, cs/6000%60, cs/100%60, cs%100)
+}
+
+// escapeASS 转义文本中的反斜杠和花括号，避免被当作换行等控制符或样式覆盖标签
+func escapeASS(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "{", `\{`, "}", `\}`).Replace(text)
+}
+
+// unescapeASS 去掉文本中的样式覆盖标签，\N 和 \n 转换为换行，\h 转换为空格，还原转义的反斜杠和花括号
+// 没有闭合的 { 和无法识别的反斜杠序列保留原样
+func unescapeASS(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == '{':
+			if end := strings.IndexByte(text[i+1:], '}'); end >= 0 {
+				i += end + 1
+				continue
+			}
+			b.WriteByte(c)
+		case c == '\\' && i+1 < len(text):
+			switch next := text[i+1]; next {
+			case 'N', 'n':
+				b.WriteByte('\n')
+			case 'h':
+				b.WriteByte(' ')
+			case '\\', '{', '}':
+				b.WriteByte(next)
+			default:
+				b.WriteByte(c)
+				continue
+			}
+			i++
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// assName 样式名、说话人等字段不能包含逗号和换行
+func assName(value string) string {
+	return strings.TrimSpace(strings.NewReplacer(",", " ", "\n", " ", "\r", " ").Replace(value))
+}
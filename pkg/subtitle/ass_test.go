@@ -0,0 +1,69 @@
+package subtitle
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestASSEscape(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"花括号", "a {b} c"},
+		{"反斜杠", `C:\path\N\h`},
+		{"没有闭合的花括号", ">>>-{1\\\n0-}"},
+		{"只有右花括号", "a } b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteASS(&buf, &Subtitle{Cues: []Cue{{Start: 0, End: time.Second, Text: tt.text}}}); err != nil {
+				t.Fatalf("WriteASS: %v", err)
+			}
+			sub, err := ParseASS(buf.Bytes())
+			if err != nil {
+				t.Fatalf("ParseASS: %v", err)
+			}
+			if len(sub.Cues) != 1 || sub.Cues[0].Text != tt.text {
+				t.Errorf("got %+v, want %q", sub.Cues, tt.text)
+			}
+		})
+	}
+}
+
+func TestParseASSText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{`{\i1}hello{\i0}\Nworld`, "hello\nworld"},
+		{`a\hb`, "a b"},
+		{`a \{b\} \\ c`, `a {b} \ c`},
+		{`open { brace`, "open { brace"},
+		{`unknown \q escape`, `unknown \q escape`},
+	}
+	for _, tt := range tests {
+		if got := unescapeASS(tt.text); got != tt.want {
+			t.Errorf("unescapeASS(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func FuzzParseASS(f *testing.F) {
+	f.Add([]byte("[Script Info]\nScriptType: v4.00+\n\n[V4+ Styles]\nFormat: Name, Fontname, Fontsize\nStyle: Default,Arial,48\n\n[Events]\nFormat: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\nDialogue: 0,0:00:01.00,0:00:02.50,Default,Bob,0,0,0,,{\\i1}hello{\\i0}\\Nworld, again\n"))
+	f.Add([]byte("\ufeff[Events]\r\nDialogue: Marked=0,0:00:01.00,0:00:02.00,*Default,,0,0,0,,a \\{b\\} c\r\n"))
+	f.Add([]byte("[V4+ Styles]\nFormat:\nStyle:\n[Events]\nDialogue:,0:0:0.0,0:0:0.0,,,,,,,0"))
+	for _, text := range []string{"a {b} c", ">>>-{1\\\n0-}"} {
+		var buf bytes.Buffer
+		if err := WriteASS(&buf, &Subtitle{Cues: []Cue{{Start: 0, End: time.Second, Text: text}}}); err != nil {
+			f.Fatal(err)
+		}
+		f.Add(buf.Bytes())
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkRoundTrip(t, data, FormatASS)
+		checkRoundTrip(t, data, FormatSSA)
+	})
+}
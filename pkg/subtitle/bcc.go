@@ -0,0 +1,75 @@
+package subtitle
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+// bccFile B站字幕 JSON 格式，时间单位为秒
+type bccFile struct {
+	FontSize        float64   `json:"font_size"`
+	FontColor       string    `json:"font_color"`
+	BackgroundAlpha float64   `json:"background_alpha"`
+	BackgroundColor string    `json:"background_color"`
+	Stroke          string    `json:"Stroke"`
+	Body            []bccItem `json:"body"`
+}
+
+type bccItem struct {
+	From     float64 `json:"from"`
+	To       float64 `json:"to"`
+	Location int     `json:"location"` // 显示位置，2 为底部居中
+	Content  string  `json:"content"`
+}
+
+// ParseBCC 解析 B站字幕 JSON
+func ParseBCC(data []byte) (*Subtitle, error) {
+	var file bccFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("不是有效的 BCC 字幕: %v", err)
+	}
+
+	sub := &Subtitle{}
+	for _, item := range file.Body {
+		text := cleanText(item.Content)
+		if text == "" {
+			continue
+		}
+		sub.Cues = append(sub.Cues, Cue{Start: Seconds(item.From), End: Seconds(item.To), Text: text})
+	}
+	return sub, nil
+}
+
+// WriteBCC 写入 B站字幕 JSON，使用网页播放器的默认字幕样式
+func WriteBCC(w io.Writer, sub *Subtitle) error {
+	file := bccFile{
+		FontSize:        0.4,
+		FontColor:       "#FFFFFF",
+		BackgroundAlpha: 0.5,
+		BackgroundColor: "#9C27B0",
+		Stroke:          "none",
+		Body:            []bccItem{},
+	}
+	for _, cue := range sub.Cues {
+		text := cleanText(cue.Text)
+		if text == "" {
+			continue
+		}
+		file.Body = append(file.Body, bccItem{
+			From:     bccSeconds(cue.Start.Milliseconds()),
+			To:       bccSeconds(cue.End.Milliseconds()),
+			Location: 2,
+			Content:  text,
+		})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(file)
+}
+
+// bccSeconds 将毫秒转换为秒，负数按 0 处理
+func bccSeconds(ms int64) float64 {
+	return math.Max(float64(ms), 0) / 1000
+}
@@ -0,0 +1,11 @@
+package subtitle
+
+import "testing"
+
+func FuzzParseBCC(f *testing.F) {
+	f.Add([]byte(`{"font_size":0.4,"body":[{"from":1.5,"to":2.25,"location":2,"content":"hello\nworld"},{"from":360000.001,"to":360001,"content":"<a> & b"}]}`))
+	f.Add([]byte(`{"body":[{"from":-1,"to":0.0004,"content":"  "}]}`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkRoundTrip(t, data, FormatBCC)
+	})
+}
@@ -0,0 +1,98 @@
+package subtitle
+
+import "sort"
+
+// 双语 ASS 字幕中译文和原文的样式名称
+const (
+	StyleTranslated = "Translated"
+	StyleOriginal   = "Original"
+)
+
+// StylePreset 双语字幕的样式预设，译文和原文各一个 ASS 样式，样式名称会被替换为 Translated 和 Original
+type StylePreset struct {
+	Translated Style `toml:"Translated" json:"translated"` // 译文，显示在上方
+	Original   Style `toml:"Original" json:"original"`     // 原文，显示在译文下方
+}
+
+// Presets 内置的样式预设：default 白字黑边，yellow 黄色译文，boxed 半透明背景框
+func Presets() map[string]StylePreset {
+	translated := DefaultStyle()
+	translated.FontName = "Microsoft YaHei"
+	translated.FontSize = 64
+	translated.MarginV = 30
+
+	original := DefaultStyle()
+	original.FontSize = 44
+	original.PrimaryColour = "&H00DCDCDC"
+	original.Outline = 2
+	original.MarginV = 30
+
+	yellow := translated
+	yellow.PrimaryColour = "&H0000FFFF"
+
+	// 背景框样式中 OutlineColour 为背景框的颜色，Outline 为文字到边框的距离
+	boxedTranslated, boxedOriginal := translated, original
+	for _, style := range []*Style{&boxedTranslated, &boxedOriginal} {
+		style.BorderStyle = 3
+		style.OutlineColour = "&H80000000"
+		style.Outline = 8
+		style.Shadow = 0
+	}
+
+	return map[string]StylePreset{
+		"default": {Translated: translated, Original: original},
+		"yellow":  {Translated: yellow, Original: original},
+		"boxed":   {Translated: boxedTranslated, Original: boxedOriginal},
+	}
+}
+
+// Bilingual 合并译文和原文为双语字幕，每条字幕译文在上、原文在下，使用译文的时间轴
+// 原文条目归入中点时间所在的译文条目，两者时间轴相同时逐条对应
+func Bilingual(translated, original []Cue) []Cue {
+	groups := pairCues(translated, original)
+	result := make([]Cue, len(translated))
+	for i, cue := range translated {
+		cue.Text = cleanText(cue.Text)
+		for _, text := range groups[i] {
+			cue.Text += "\n" + text
+		}
+		cue.Words = nil
+		result[i] = cue
+	}
+	return result
+}
+
+// BilingualASS 生成双语 ASS 字幕，译文和原文分别使用预设中的样式
+// 原文事件写在译文之前，底部对齐的字幕重叠时播放器把后面的事件排在上方，译文显示在原文上方
+func BilingualASS(translated, original []Cue, preset StylePreset) *Subtitle {
+	preset.Translated.Name = StyleTranslated
+	preset.Original.Name = StyleOriginal
+	sub := &Subtitle{Styles: []Style{preset.Translated, preset.Original}}
+
+	groups := pairCues(translated, original)
+	for i, cue := range translated {
+		for _, text := range groups[i] {
+			sub.Cues = append(sub.Cues, Cue{Start: cue.Start, End: cue.End, Text: text, Style: StyleOriginal, Speaker: cue.Speaker})
+		}
+		sub.Cues = append(sub.Cues, Cue{Start: cue.Start, End: cue.End, Text: cue.Text, Style: StyleTranslated, Speaker: cue.Speaker})
+	}
+	return sub
+}
+
+// pairCues 返回每条译文对应的原文文本，原文条目按中点时间归入译文条目，不在任何译文条目时间内的原文被丢弃
+// 时长为 0 的译文条目只接收中点与其时间相同的原文
+func pairCues(translated, original []Cue) [][]string {
+	groups := make([][]string, len(translated))
+	for _, cue := range original {
+		text := cleanText(cue.Text)
+		if text == "" {
+			continue
+		}
+		mid := cue.Start + cue.Duration()/2
+		i := sort.Search(len(translated), func(i int) bool { return translated[i].Start > mid }) - 1
+		if i >= 0 && (mid < translated[i].End || mid == translated[i].Start) {
+			groups[i] = append(groups[i], text)
+		}
+	}
+	return groups
+}
@@ -0,0 +1,105 @@
+package subtitle
+
+import (
+	"reflect"
+	"testing"
+)
+
+// cue 测试用的字幕条目，时间单位为秒
+func cue(start, end float64, text string) Cue {
+	return Cue{Start: Seconds(start), End: Seconds(end), Text: text}
+}
+
+func TestPairCues(t *testing.T) {
+	tests := []struct {
+		name       string
+		translated []Cue
+		original   []Cue
+		want       [][]string
+	}{
+		{
+			name:       "时间轴相同逐条对应",
+			translated: []Cue{cue(0, 2, "一"), cue(2, 4, "二")},
+			original:   []Cue{cue(0, 2, "one"), cue(2, 4, "two")},
+			want:       [][]string{{"one"}, {"two"}},
+		},
+		{
+			name:       "原文按中点归入译文，跨越两条译文的原文归入中点所在的一条",
+			translated: []Cue{cue(0, 2, "一"), cue(2, 4, "二")},
+			original:   []Cue{cue(0, 1, "a"), cue(1, 2.5, "b"), cue(2.5, 4, "c")},
+			want:       [][]string{{"a", "b"}, {"c"}},
+		},
+		{
+			name:       "译文相互重叠时归入最后开始的一条",
+			translated: []Cue{cue(0, 3, "一"), cue(2, 4, "二")},
+			original:   []Cue{cue(0, 2, "a"), cue(2, 3, "b")},
+			want:       [][]string{{"a"}, {"b"}},
+		},
+		{
+			name:       "缺少原文的译文为空",
+			translated: []Cue{cue(0, 2, "一"), cue(2, 4, "二"), cue(4, 6, "三")},
+			original:   []Cue{cue(0, 2, "one"), cue(4, 6, "three")},
+			want:       [][]string{{"one"}, nil, {"three"}},
+		},
+		{
+			name:       "不在任何译文时间内的原文被丢弃",
+			translated: []Cue{cue(1, 2, "一"), cue(3, 4, "二")},
+			original:   []Cue{cue(0, 0.5, "before"), cue(1, 2, "one"), cue(2.2, 2.8, "gap"), cue(3, 4, "two"), cue(5, 6, "after")},
+			want:       [][]string{{"one"}, {"two"}},
+		},
+		{
+			name:       "时长为 0 的译文只接收时间相同的原文",
+			translated: []Cue{cue(0, 2, "一"), cue(2, 2, "零"), cue(3, 4, "三")},
+			original:   []Cue{cue(2, 2, "zero"), cue(2.5, 2.9, "gap"), cue(3, 4, "three")},
+			want:       [][]string{nil, {"zero"}, {"three"}},
+		},
+		{
+			name:       "空白的原文被忽略",
+			translated: []Cue{cue(0, 2, "一")},
+			original:   []Cue{cue(0, 1, " \n "), cue(1, 2, " one \n\n two ")},
+			want:       [][]string{{"one\ntwo"}},
+		},
+		{
+			name:       "没有原文",
+			translated: []Cue{cue(0, 2, "一")},
+			want:       [][]string{nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pairCues(tt.translated, tt.original); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pairCues() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBilingual(t *testing.T) {
+	translated := []Cue{cue(0, 2, " 一\n\n"), cue(2, 4, "二"), cue(4, 6, "三")}
+	translated[0].Words = []Word{{Text: "一"}}
+	original := []Cue{cue(0, 1, "one,"), cue(1, 2, "uno"), cue(4, 6, "three")}
+
+	want := []Cue{cue(0, 2, "一\none,\nuno"), cue(2, 4, "二"), cue(4, 6, "三\nthree")}
+	if got := Bilingual(translated, original); !reflect.DeepEqual(got, want) {
+		t.Errorf("Bilingual() = %+v, want %+v", got, want)
+	}
+}
+
+func TestBilingualASS(t *testing.T) {
+	translated := []Cue{{Start: 0, End: Seconds(2), Text: "一", Speaker: "Bob"}, cue(2, 4, "二")}
+	original := []Cue{cue(0, 2, "one"), cue(5, 6, "extra")}
+
+	sub := BilingualASS(translated, original, Presets()["default"])
+	if len(sub.Styles) != 2 || sub.Styles[0].Name != StyleTranslated || sub.Styles[1].Name != StyleOriginal {
+		t.Fatalf("styles = %+v", sub.Styles)
+	}
+	// 原文事件在译文之前，多出的原文被丢弃
+	want := []Cue{
+		{Start: 0, End: Seconds(2), Text: "one", Style: StyleOriginal, Speaker: "Bob"},
+		{Start: 0, End: Seconds(2), Text: "一", Style: StyleTranslated, Speaker: "Bob"},
+		{Start: Seconds(2), End: Seconds(4), Text: "二", Style: StyleTranslated},
+	}
+	if !reflect.DeepEqual(sub.Cues, want) {
+		t.Errorf("cues = %+v, want %+v", sub.Cues, want)
+	}
+}
@@ -0,0 +1,76 @@
+package subtitle
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// json3File YouTube json3 字幕格式，时间单位为毫秒
+type json3File struct {
+	WireMagic string       `json:"wireMagic,omitempty"`
+	Events    []json3Event `json:"events"`
+}
+
+type json3Event struct {
+	StartMs    int64      `json:"tStartMs"`
+	DurationMs int64      `json:"dDurationMs"`
+	Segs       []json3Seg `json:"segs,omitempty"`
+}
+
+type json3Seg struct {
+	UTF8 string `json:"utf8"`
+}
+
+// ParseJSON3 解析 YouTube json3 字幕
+// 自动字幕的条目时间会相互重叠，每条字幕在下一条开始时结束
+func ParseJSON3(data []byte) (*Subtitle, error) {
+	var file json3File
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("不是有效的 json3 字幕: %v", err)
+	}
+
+	sub := &Subtitle{}
+	for _, event := range file.Events {
+		var b strings.Builder
+		for _, seg := range event.Segs {
+			b.WriteString(seg.UTF8)
+		}
+		text := cleanText(b.String())
+		if text == "" {
+			continue
+		}
+		start := time.Duration(event.StartMs) * time.Millisecond
+		if n := len(sub.Cues); n > 0 && sub.Cues[n-1].End > start {
+			sub.Cues[n-1].End = start
+		}
+		sub.Cues = append(sub.Cues, Cue{
+			Start: start,
+			End:   time.Duration(event.StartMs+event.DurationMs) * time.Millisecond,
+			Text:  text,
+		})
+	}
+	return sub, nil
+}
+
+// WriteJSON3 写入 YouTube json3 字幕，每条字幕为一个事件
+func WriteJSON3(w io.Writer, sub *Subtitle) error {
+	file := json3File{WireMagic: "pb3", Events: []json3Event{}}
+	for _, cue := range sub.Cues {
+		text := cleanText(cue.Text)
+		if text == "" {
+			continue
+		}
+		start := max(cue.Start, 0).Milliseconds()
+		file.Events = append(file.Events, json3Event{
+			StartMs:    start,
+			DurationMs: max(cue.End.Milliseconds()-start, 0),
+			Segs:       []json3Seg{{UTF8: text}},
+		})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(file)
+}
@@ -0,0 +1,11 @@
+package subtitle
+
+import "testing"
+
+func FuzzParseJSON3(f *testing.F) {
+	f.Add([]byte(`{"wireMagic":"pb3","events":[{"tStartMs":0,"dDurationMs":2000,"segs":[{"utf8":"hello"},{"utf8":" world"}]},{"tStartMs":1500,"dDurationMs":1000,"segs":[{"utf8":"\n"}]},{"tStartMs":1500,"dDurationMs":1000,"segs":[{"utf8":"again"}]}]}`))
+	f.Add([]byte(`{"events":[{"tStartMs":-5,"dDurationMs":-10,"segs":[{"utf8":"<a> & b"}]}]}`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkRoundTrip(t, data, FormatJSON3)
+	})
+}
@@ -0,0 +1,313 @@
+package subtitle
+
+import (
+	"math"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SegmentOptions 重新分段的限制，时间单位为秒，为 0 的项使用默认值
+type SegmentOptions struct {
+	MaxLineChars int     `toml:"max_line_chars"` // 每行最多字符数，默认 42
+	MaxLines     int     `toml:"max_lines"`      // 每条字幕最多行数，默认 2
+	MinDuration  float64 `toml:"min_duration"`   // 最短显示时长，默认 1 秒
+	MaxDuration  float64 `toml:"max_duration"`   // 最长显示时长，默认 7 秒
+	MaxCPS       float64 `toml:"max_cps"`        // 每秒最多字符数，显示时间不够时向后延长，默认 20
+}
+
+// SegmentConfig 字幕重新分段配置
+// 翻译前合并碎片、拆分过长的字幕得到完整的句子再翻译，翻译后按译文的阅读速度再分段一次
+type SegmentConfig struct {
+	Enabled    bool           `toml:"enabled"`
+	Source     SegmentOptions `toml:"Source"`     // 翻译前的原语言字幕
+	Translated SegmentOptions `toml:"Translated"` // 翻译后的字幕
+}
+
+const (
+	segmentPause  = 300 * time.Millisecond  // 词之间超过这一时长的停顿可以作为分段位置
+	segmentMaxGap = 1500 * time.Millisecond // 词之间超过这一时长的停顿必须分段
+)
+
+// segmentToken 分段的最小单位：有逐词时间时为单词，否则为按空格拆分的单词或单个汉字，时间按字数分配
+type segmentToken struct {
+	text       string
+	start, end time.Duration
+	gap        time.Duration // 到下一个单位开始的间隔
+	style      string
+	speaker    string
+}
+
+// withDefaults 为 0 的项使用默认值
+func (o SegmentOptions) withDefaults() SegmentOptions {
+	if o.MaxLineChars <= 0 {
+		o.MaxLineChars = 42
+	}
+	if o.MaxLines <= 0 {
+		o.MaxLines = 2
+	}
+	if o.MinDuration <= 0 {
+		o.MinDuration = 1
+	}
+	if o.MaxDuration <= 0 {
+		o.MaxDuration = 7
+	}
+	if o.MaxDuration < o.MinDuration {
+		o.MaxDuration = o.MinDuration
+	}
+	if o.MaxCPS <= 0 {
+		o.MaxCPS = 20
+	}
+	return o
+}
+
+// Resegment 按行长、行数、显示时长和阅读速度重新合并和拆分字幕
+// 优先在句末标点处分段，其次是逗号等标点和停顿处；字幕条目带有逐词时间时按单词的时间分段，否则按字数分配时间
+func Resegment(cues []Cue, opts SegmentOptions) []Cue {
+	opts = opts.withDefaults()
+	maxChars := opts.MaxLineChars * opts.MaxLines
+	minDuration, maxDuration := Seconds(opts.MinDuration), Seconds(opts.MaxDuration)
+
+	// exceeds 加上下一个单位后超过最长时长，或者无法排成不超过每行字数的行
+	exceeds := func(tokens []segmentToken, next segmentToken) bool {
+		if next.end-tokens[0].start > maxDuration {
+			return true
+		}
+		tokens = append(tokens[:len(tokens):len(tokens)], next)
+		if textLength(tokens) > maxChars {
+			return true
+		}
+		for _, line := range strings.Split(wrapLines(tokens, opts.MaxLineChars, opts.MaxLines), "\n") {
+			if utf8.RuneCountInString(line) > opts.MaxLineChars {
+				return true
+			}
+		}
+		return false
+	}
+
+	var groups [][]segmentToken
+	var current []segmentToken
+	for _, token := range segmentTokens(cues) {
+		for len(current) > 0 && exceeds(current, token) {
+			n := breakPoint(current, maxChars)
+			groups = append(groups, current[:n])
+			current = append([]segmentToken(nil), current[n:]...)
+		}
+		current = append(current, token)
+		if token.gap >= segmentMaxGap || (sentenceEnd(token.text) && token.end-current[0].start >= minDuration) {
+			groups = append(groups, current)
+			current = nil
+		}
+	}
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+
+	result := make([]Cue, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, Cue{
+			Start:   group[0].start,
+			End:     group[len(group)-1].end,
+			Text:    wrapLines(group, opts.MaxLineChars, opts.MaxLines),
+			Style:   group[0].style,
+			Speaker: group[0].speaker,
+		})
+	}
+	retime(result, minDuration, maxDuration, opts.MaxCPS)
+	return result
+}
+
+// segmentTokens 将字幕条目拆分为分段的单位
+func segmentTokens(cues []Cue) []segmentToken {
+	var tokens []segmentToken
+	for _, cue := range cues {
+		if len(cue.Words) > 0 {
+			for _, word := range cue.Words {
+				if text := strings.TrimSpace(word.Text); text != "" {
+					tokens = append(tokens, segmentToken{text: text, start: word.Start, end: word.End, style: cue.Style, speaker: cue.Speaker})
+				}
+			}
+			continue
+		}
+
+		pieces := textPieces(cue.Text)
+		total := 0
+		for _, piece := range pieces {
+			total += utf8.RuneCountInString(piece)
+		}
+		offset := 0
+		for _, piece := range pieces {
+			length := utf8.RuneCountInString(piece)
+			tokens = append(tokens, segmentToken{
+				text:    piece,
+				start:   (cue.Start + cue.Duration()*time.Duration(offset)/time.Duration(total)).Round(time.Millisecond),
+				end:     (cue.Start + cue.Duration()*time.Duration(offset+length)/time.Duration(total)).Round(time.Millisecond),
+				style:   cue.Style,
+				speaker: cue.Speaker,
+			})
+			offset += length
+		}
+	}
+	for i := range tokens {
+		if i+1 < len(tokens) {
+			tokens[i].gap = tokens[i+1].start - tokens[i].end
+		} else {
+			tokens[i].gap = math.MaxInt64
+		}
+	}
+	return tokens
+}
+
+// textPieces 按空格拆分单词，汉字和假名每个字为一个单位，标点附在前一个单位上
+func textPieces(text string) []string {
+	var pieces []string
+	for _, field := range strings.Fields(text) {
+		var current []rune
+		for _, r := range field {
+			if n := len(current); n > 0 && !unicode.IsPunct(r) && (isWide(r) || isWide(current[n-1])) {
+				pieces = append(pieces, string(current))
+				current = nil
+			}
+			current = append(current, r)
+		}
+		if len(current) > 0 {
+			pieces = append(pieces, string(current))
+		}
+	}
+	return pieces
+}
+
+// breakPoint 选择拆分的位置，返回第一部分的单位数
+// 优先选择句末标点，其次是逗号等标点和停顿，同等情况下选择靠后的位置，第一部分不能太短
+func breakPoint(tokens []segmentToken, maxChars int) int {
+	best, bestScore := len(tokens), 0
+	for i := 1; i <= len(tokens); i++ {
+		if i < len(tokens) && textLength(tokens[:i]) < maxChars/3 {
+			continue
+		}
+		score := breakScore(tokens[i-1])
+		if i == len(tokens) {
+			score = max(score, 1)
+		}
+		if score >= bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// breakScore 在单位之后分段的优先级：句末 3，逗号等标点或停顿 2，其他 0
+func breakScore(token segmentToken) int {
+	switch {
+	case sentenceEnd(token.text):
+		return 3
+	case clauseEnd(token.text) || token.gap >= segmentPause:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// wrapLines 将单位连接为文本，超过每行字数时拆为长度接近的多行
+func wrapLines(tokens []segmentToken, maxLineChars, maxLines int) string {
+	total := textLength(tokens)
+	lines := min(maxLines, (total+maxLineChars-1)/maxLineChars)
+	if lines <= 1 {
+		return joinTokens(tokens)
+	}
+
+	target := float64(total) / float64(lines)
+	var result []string
+	start := 0
+	for len(result) < lines-1 && start < len(tokens)-1 {
+		end := lineBreak(tokens[start:], target, maxLineChars)
+		result = append(result, joinTokens(tokens[start:start+end]))
+		start += end
+	}
+	return strings.Join(append(result, joinTokens(tokens[start:])), "\n")
+}
+
+// lineBreak 选择一行的结束位置，返回这一行的单位数，不超过每行字数
+// 长度达到目标的六成以上时优先在标点后换行，否则选择长度最接近目标的位置
+func lineBreak(tokens []segmentToken, target float64, maxLineChars int) int {
+	best, bestDistance := 1, math.Inf(1)
+	punctuation := 0
+	for end := 1; end < len(tokens); end++ {
+		length := float64(textLength(tokens[:end]))
+		if end > 1 && length > float64(maxLineChars) {
+			break
+		}
+		if distance := math.Abs(length - target); distance < bestDistance {
+			best, bestDistance = end, distance
+		}
+		if length >= target*0.6 && (sentenceEnd(tokens[end-1].text) || clauseEnd(tokens[end-1].text)) {
+			punctuation = end
+		}
+	}
+	if punctuation > 0 {
+		return punctuation
+	}
+	return best
+}
+
+// retime 调整显示时长：不短于最短时长和阅读所需的时间，最多延长到下一条字幕开始，不超过最长时长
+func retime(cues []Cue, minDuration, maxDuration time.Duration, maxCPS float64) {
+	for i := range cues {
+		cue := &cues[i]
+		chars := utf8.RuneCountInString(strings.ReplaceAll(cue.Text, "\n", ""))
+		need := max(minDuration, Seconds(float64(chars)/maxCPS))
+		end := max(cue.End, min(cue.Start+need, cue.Start+maxDuration))
+		if i+1 < len(cues) {
+			end = min(end, max(cue.End, cues[i+1].Start))
+		}
+		cue.End = end
+	}
+}
+
+// joinTokens 连接单位，汉字和全角标点之间、右侧标点之前不加空格
+func joinTokens(tokens []segmentToken) string {
+	var b strings.Builder
+	for i, token := range tokens {
+		if i > 0 && needsSpace(tokens[i-1].text, token.text) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(token.text)
+	}
+	return b.String()
+}
+
+// textLength 单位连接后的字符数
+func textLength(tokens []segmentToken) int {
+	return utf8.RuneCountInString(joinTokens(tokens))
+}
+
+// needsSpace 两个单位之间是否需要空格
+func needsSpace(prev, next string) bool {
+	last, _ := utf8.DecodeLastRuneInString(prev)
+	first, _ := utf8.DecodeRuneInString(next)
+	return !isWide(last) && !isWide(first) && !strings.ContainsRune(",.!?;:%)]}…", first)
+}
+
+// isWide 汉字、假名和全角标点
+func isWide(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) ||
+		(r >= 0x3000 && r <= 0x303F) || (r >= 0xFF00 && r <= 0xFFEF)
+}
+
+// sentenceEnd 单位是否以句末标点结尾，忽略之后的引号和括号
+func sentenceEnd(text string) bool {
+	return endsWith(text, ".?!。？！…")
+}
+
+// clauseEnd 单位是否以逗号、分号等标点结尾
+func clauseEnd(text string) bool {
+	return endsWith(text, ",;:，；：、—")
+}
+
+// endsWith 去掉末尾的引号和括号后，最后一个字符是否为指定的标点之一
+func endsWith(text, punctuation string) bool {
+	text = strings.TrimRight(text, "\"'”’」』)）]】")
+	last, _ := utf8.DecodeLastRuneInString(text)
+	return last != utf8.RuneError && strings.ContainsRune(punctuation, last)
+}
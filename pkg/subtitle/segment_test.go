@@ -0,0 +1,144 @@
+package subtitle
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestResegment(t *testing.T) {
+	tests := []struct {
+		name string
+		cues []Cue
+		opts SegmentOptions
+		want []Cue
+	}{
+		{
+			name: "合并碎片到句末",
+			cues: []Cue{
+				{Start: 0, End: Seconds(0.5), Text: "Hello"},
+				{Start: Seconds(0.5), End: Seconds(1), Text: "world,"},
+				{Start: Seconds(1), End: Seconds(1.5), Text: "how are"},
+				{Start: Seconds(1.5), End: Seconds(2), Text: "you?"},
+			},
+			want: []Cue{{Start: 0, End: Seconds(2), Text: "Hello world, how are you?"}},
+		},
+		{
+			name: "中文没有空格，按句末和逗号拆分",
+			cues: []Cue{{Start: Seconds(1), End: Seconds(7), Text: "今天天气很好。我们去公园散步吧，然后一起吃午饭。"}},
+			opts: SegmentOptions{MaxLineChars: 16, MaxLines: 1, MaxCPS: 9},
+			want: []Cue{
+				{Start: Seconds(1), End: Seconds(2.75), Text: "今天天气很好。"},
+				{Start: Seconds(2.75), End: Seconds(5), Text: "我们去公园散步吧，"},
+				{Start: Seconds(5), End: Seconds(7), Text: "然后一起吃午饭。"},
+			},
+		},
+		{
+			name: "中英混排不加多余的空格",
+			cues: []Cue{{Start: 0, End: Seconds(2), Text: "我用 Go 写代码。"}},
+			want: []Cue{{Start: 0, End: Seconds(2), Text: "我用Go写代码。"}},
+		},
+		{
+			name: "长停顿必须分段，过短的字幕延长到最短时长",
+			cues: []Cue{
+				{Start: Seconds(1), End: Seconds(1.2), Text: "Hi"},
+				{Start: Seconds(5), End: Seconds(9), Text: "Bye."},
+			},
+			want: []Cue{
+				{Start: Seconds(1), End: Seconds(2), Text: "Hi"},
+				{Start: Seconds(5), End: Seconds(9), Text: "Bye."},
+			},
+		},
+		{
+			name: "延长不超过下一条字幕的开始",
+			cues: []Cue{
+				{Start: 0, End: Seconds(0.2), Text: "Stop."},
+				{Start: Seconds(1.8), End: Seconds(4), Text: "Go on."},
+			},
+			opts: SegmentOptions{MinDuration: 3},
+			want: []Cue{
+				{Start: 0, End: Seconds(1.8), Text: "Stop."},
+				{Start: Seconds(1.8), End: Seconds(4.8), Text: "Go on."},
+			},
+		},
+		{
+			name: "按逐词时间分段",
+			cues: []Cue{{Start: 0, End: Seconds(4), Text: "one two three", Words: []Word{
+				{Start: 0, End: Seconds(1), Text: "one"},
+				{Start: Seconds(2.6), End: Seconds(3.2), Text: "two"},
+				{Start: Seconds(3.2), End: Seconds(4), Text: "three"},
+			}}},
+			want: []Cue{
+				{Start: 0, End: Seconds(1), Text: "one"},
+				{Start: Seconds(2.6), End: Seconds(4), Text: "two three"},
+			},
+		},
+		{
+			name: "保留样式和说话人",
+			cues: []Cue{
+				{Start: 0, End: Seconds(1), Text: "Yes,", Style: "Top", Speaker: "Bob"},
+				{Start: Seconds(1), End: Seconds(2), Text: "sure.", Style: "Top", Speaker: "Bob"},
+			},
+			want: []Cue{{Start: 0, End: Seconds(2), Text: "Yes, sure.", Style: "Top", Speaker: "Bob"}},
+		},
+		{
+			name: "没有字幕",
+			cues: nil,
+			want: []Cue{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Resegment(tt.cues, tt.opts)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d cues %+v, want %d", len(got), got, len(tt.want))
+			}
+			for i := range got {
+				if got[i].Start != tt.want[i].Start || got[i].End != tt.want[i].End || got[i].Text != tt.want[i].Text ||
+					got[i].Style != tt.want[i].Style || got[i].Speaker != tt.want[i].Speaker {
+					t.Errorf("cue %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestResegmentLongCue 拆分过长的字幕：每条不超过行长、行数和最长时长，文本不丢失，首尾时间不变
+func TestResegmentLongCue(t *testing.T) {
+	text := "This is a fairly long sentence that keeps going for a while. And here is another sentence, which also goes on and on until it is too long."
+	opts := SegmentOptions{MaxLineChars: 42, MaxLines: 2, MaxDuration: 7}
+	got := Resegment([]Cue{{Start: Seconds(3), End: Seconds(15), Text: text}}, opts)
+	if len(got) < 2 {
+		t.Fatalf("got %d cues, want the cue to be split", len(got))
+	}
+	if got[0].Start != Seconds(3) || got[len(got)-1].End != Seconds(15) {
+		t.Errorf("got %v --> %v, want 3s --> 15s", got[0].Start, got[len(got)-1].End)
+	}
+
+	var words []string
+	for i, cue := range got {
+		lines := strings.Split(cue.Text, "\n")
+		if len(lines) > opts.MaxLines {
+			t.Errorf("cue %d has %d lines: %q", i, len(lines), cue.Text)
+		}
+		for _, line := range lines {
+			if utf8.RuneCountInString(line) > opts.MaxLineChars {
+				t.Errorf("cue %d line too long: %q", i, line)
+			}
+		}
+		if cue.Duration() > 7*time.Second {
+			t.Errorf("cue %d lasts %v", i, cue.Duration())
+		}
+		if i > 0 && cue.Start < got[i-1].End {
+			t.Errorf("cue %d starts at %v before the previous cue ends at %v", i, cue.Start, got[i-1].End)
+		}
+		words = append(words, strings.Fields(cue.Text)...)
+	}
+	if joined := strings.Join(words, " "); joined != text {
+		t.Errorf("text = %q, want %q", joined, text)
+	}
+	if !strings.HasSuffix(got[0].Text, "while.") {
+		t.Errorf("first cue %q should end at the sentence end", got[0].Text)
+	}
+}
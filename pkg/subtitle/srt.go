@@ -0,0 +1,78 @@
+package subtitle
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// srtTimePattern SRT 时间轴，如 "00:01:02,345 --> 00:01:04,000"，兼容使用点号分隔毫秒和省略毫秒位数的文件
+var srtTimePattern = regexp.MustCompile(`^\s*(\d+):(\d{1,2}):(\d{1,2})[,.](\d{1,3})\s*-->\s*(\d+):(\d{1,2}):(\d{1,2})[,.](\d{1,3})`)
+
+// srtIndexPattern SRT 序号行
+var srtIndexPattern = regexp.MustCompile(`^\s*\d+\s*$`)
+
+// ParseSRT 解析 SRT 字幕
+// 序号可以省略，条目之间缺少空行时把时间轴前的序号行从上一条字幕中去掉，跳过没有文本的条目
+func ParseSRT(data []byte) (*Subtitle, error) {
+	content := normalizeNewlines(string(data))
+	sub := &Subtitle{}
+
+	var cue *Cue
+	var lines []string
+	flush := func() {
+		if cue != nil {
+			if text := strings.Join(lines, "\n"); text != "" {
+				cue.Text = text
+				sub.Cues = append(sub.Cues, *cue)
+			}
+		}
+		cue, lines = nil, nil
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		if match := srtTimePattern.FindStringSubmatch(line); match != nil {
+			if n := len(lines); n > 0 && srtIndexPattern.MatchString(lines[n-1]) {
+				lines = lines[:n-1]
+			}
+			flush()
+			cue = &Cue{Start: clock(match[1], match[2], match[3], match[4]), End: clock(match[5], match[6], match[7], match[8])}
+			continue
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			flush()
+			continue
+		}
+		if cue != nil {
+			lines = append(lines, line)
+		}
+	}
+	flush()
+
+	if len(sub.Cues) == 0 && strings.TrimSpace(content) != "" {
+		return nil, fmt.Errorf("不是有效的 SRT 字幕")
+	}
+	return sub, nil
+}
+
+// WriteSRT 写入 SRT 字幕，序号从 1 开始
+// SRT 没有转义，文本中的 --> 写为 ->，避免被解析为时间轴
+func WriteSRT(w io.Writer, sub *Subtitle) error {
+	var b strings.Builder
+	index := 0
+	for _, cue := range sub.Cues {
+		text := cleanText(cue.Text)
+		if text == "" {
+			continue
+		}
+		for strings.Contains(text, "-->") {
+			text = strings.ReplaceAll(text, "-->", "->")
+		}
+		index++
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", index, formatClock(cue.Start, ","), formatClock(cue.End, ","), text)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
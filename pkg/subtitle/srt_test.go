@@ -0,0 +1,22 @@
+package subtitle
+
+import "testing"
+
+func TestParseSRTMissingBlankLine(t *testing.T) {
+	sub, err := ParseSRT([]byte("1\n00:00:01,000 --> 00:00:02,000\nhello\n2\n00:00:03,000 --> 00:00:04,000\nworld\n"))
+	if err != nil {
+		t.Fatalf("ParseSRT: %v", err)
+	}
+	if len(sub.Cues) != 2 || sub.Cues[0].Text != "hello" || sub.Cues[1].Text != "world" {
+		t.Errorf("got %+v", sub.Cues)
+	}
+}
+
+func FuzzParseSRT(f *testing.F) {
+	f.Add([]byte("1\n00:00:01,000 --> 00:00:02,000\nhello\n\n2\n00:00:03,000 --> 00:00:04,000\nworld\nagain\n"))
+	f.Add([]byte("\ufeff00:00:01.5 --> 00:00:02.25\r\na --> b\r\n"))
+	f.Add([]byte("1\n123:00:00,000 --> 123:00:01,000\n2\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkRoundTrip(t, data, FormatSRT)
+	})
+}
@@ -0,0 +1,186 @@
+// Package subtitle 字幕的统一模型，以及 SRT、WebVTT、ASS/SSA、YouTube json3 和 B站 BCC 格式的读写，
+// 并通过 yt-dlp 下载视频平台的字幕
+package subtitle
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format 字幕文件格式，与文件扩展名相同
+type Format string
+
+const (
+	FormatSRT   Format = "srt"   // SubRip
+	FormatVTT   Format = "vtt"   // WebVTT
+	FormatASS   Format = "ass"   // Advanced SubStation Alpha
+	FormatSSA   Format = "ssa"   // SubStation Alpha v4
+	FormatJSON3 Format = "json3" // YouTube json3
+	FormatBCC   Format = "bcc"   // B站字幕 JSON
+)
+
+// Cue 一条字幕
+type Cue struct {
+	Start   time.Duration // 开始时间
+	End     time.Duration // 结束时间
+	Text    string        // 纯文本，多行以 \n 分隔，不含各格式的样式标签
+	Style   string        // 样式名称，对应 ASS 的 Style 字段
+	Speaker string        // 说话人，对应 ASS 的 Name 字段和 WebVTT 的 <v> 标签
+	Words   []Word        // 逐词时间，来自语音识别，用于重新分段，字幕格式不保存
+}
+
+// Word 带时间的单词
+type Word struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// Duration 字幕的显示时长
+func (c Cue) Duration() time.Duration {
+	return c.End - c.Start
+}
+
+// Subtitle 字幕文件，字幕条目按开始时间排序
+type Subtitle struct {
+	Cues     []Cue
+	Styles   []Style // ASS 样式，为空时写入 ASS 使用 DefaultStyle
+	PlayResX int     // ASS 脚本分辨率，为 0 时使用 1920x1080
+	PlayResY int
+}
+
+// Seconds 将秒数转换为时间，精确到毫秒
+func Seconds(seconds float64) time.Duration {
+	return time.Duration(math.Round(seconds*1000)) * time.Millisecond
+}
+
+// FormatOf 根据文件扩展名判断字幕格式
+func FormatOf(path string) (Format, error) {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	switch format := Format(ext); format {
+	case FormatSRT, FormatVTT, FormatASS, FormatSSA, FormatJSON3, FormatBCC:
+		return format, nil
+	default:
+		return "", fmt.Errorf("不支持的字幕格式: %s", filepath.Ext(path))
+	}
+}
+
+// Decode 按格式解析字幕内容
+func Decode(data []byte, format Format) (*Subtitle, error) {
+	switch format {
+	case FormatSRT:
+		return ParseSRT(data)
+	case FormatVTT:
+		return ParseVTT(data)
+	case FormatASS, FormatSSA:
+		return ParseASS(data)
+	case FormatJSON3:
+		return ParseJSON3(data)
+	case FormatBCC:
+		return ParseBCC(data)
+	default:
+		return nil, fmt.Errorf("不支持的字幕格式: %s", format)
+	}
+}
+
+// Encode 按格式生成字幕内容，没有文本的字幕条目不会写入
+func Encode(sub *Subtitle, format Format) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case FormatSRT:
+		err = WriteSRT(&buf, sub)
+	case FormatVTT:
+		err = WriteVTT(&buf, sub)
+	case FormatASS:
+		err = WriteASS(&buf, sub)
+	case FormatSSA:
+		err = WriteSSA(&buf, sub)
+	case FormatJSON3:
+		err = WriteJSON3(&buf, sub)
+	case FormatBCC:
+		err = WriteBCC(&buf, sub)
+	default:
+		return nil, fmt.Errorf("不支持的字幕格式: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ReadFile 读取字幕文件并按扩展名解析
+func ReadFile(path string) (*Subtitle, error) {
+	format, err := FormatOf(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(data, format)
+}
+
+// WriteFile 按扩展名的格式写入字幕文件
+func WriteFile(path string, sub *Subtitle) error {
+	format, err := FormatOf(path)
+	if err != nil {
+		return err
+	}
+	data, err := Encode(sub, format)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// normalizeNewlines 去掉 UTF-8 BOM，统一换行符为 \n
+func normalizeNewlines(content string) string {
+	content = strings.TrimPrefix(content, "\ufeff")
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	return strings.ReplaceAll(content, "\r", "\n")
+}
+
+// textLines 字幕文本去掉首尾空白后的非空行，用空行分隔条目的格式不能在文本中包含空行
+func textLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(normalizeNewlines(text), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// cleanText 整理字幕文本：去掉空行和每行首尾的空白
+func cleanText(text string) string {
+	return strings.Join(textLines(text), "\n")
+}
+
+// clock 将时、分、秒和秒的小数部分转换为时间，小数部分按位数对齐到毫秒，如 "5" 为 500 毫秒
+func clock(hours, minutes, seconds, fraction string) time.Duration {
+	h, _ := strconv.ParseInt(hours, 10, 64)
+	m, _ := strconv.ParseInt(minutes, 10, 64)
+	s, _ := strconv.ParseInt(seconds, 10, 64)
+	if len(fraction) > 3 {
+		fraction = fraction[:3]
+	}
+	ms, _ := strconv.ParseInt(fraction+strings.Repeat("0", 3-len(fraction)), 10, 64)
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second + time.Duration(ms)*time.Millisecond
+}
+
+// formatClock 格式化为 HH:MM:SS 加上分隔符和毫秒，负数按 0 处理
+func formatClock(d time.Duration, separator string) string {
+	if d < 0 {
+		d = 0
+	}
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, separator, ms%1000)
+}
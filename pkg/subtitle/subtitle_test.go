@@ -0,0 +1,97 @@
+package subtitle
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// checkRoundTrip 解析不能 panic，能解析的内容写入后再解析和写入，两次写入的结果应该相同
+func checkRoundTrip(t *testing.T, data []byte, format Format) {
+	t.Helper()
+	sub, err := Decode(data, format)
+	if err != nil {
+		return
+	}
+	first, err := Encode(sub, format)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	sub, err = Decode(first, format)
+	if err != nil {
+		t.Fatalf("Decode(%q): %v", first, err)
+	}
+	second, err := Encode(sub, format)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if !bytes.Equal(first, second) {
+		t.Fatalf("parse -> write -> parse 不稳定\nfirst:  %q\nsecond: %q", first, second)
+	}
+}
+
+func TestDecodeTimestamps(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		data   string
+		start  time.Duration
+		end    time.Duration
+		text   string
+	}{
+		{"SRT 逗号", FormatSRT, "1\n00:00:01,500 --> 00:00:02,250\nhello\n", 1500 * time.Millisecond, 2250 * time.Millisecond, "hello"},
+		{"SRT 点号", FormatSRT, "1\n00:00:01.500 --> 00:00:02.250\nhello\n", 1500 * time.Millisecond, 2250 * time.Millisecond, "hello"},
+		{"SRT 省略毫秒位数", FormatSRT, "1\n00:00:01,5 --> 00:00:02,25\nhello\n", 1500 * time.Millisecond, 2250 * time.Millisecond, "hello"},
+		{"SRT 小时超过 99", FormatSRT, "1\n123:04:05,678 --> 123:04:06,000\nhello\n", 123*time.Hour + 4*time.Minute + 5678*time.Millisecond, 123*time.Hour + 4*time.Minute + 6*time.Second, "hello"},
+		{"SRT 没有序号", FormatSRT, "00:00:01,000 --> 00:00:02,000\nhello\n", time.Second, 2 * time.Second, "hello"},
+		{"SRT BOM", FormatSRT, "\ufeff1\n00:00:01,000 --> 00:00:02,000\nhello\n", time.Second, 2 * time.Second, "hello"},
+		{"SRT CRLF", FormatSRT, "1\r\n00:00:01,000 --> 00:00:02,000\r\nhello\r\nworld\r\n\r\n", time.Second, 2 * time.Second, "hello\nworld"},
+		{"VTT 省略小时", FormatVTT, "WEBVTT\n\n00:01.000 --> 00:02.500\nhello\n", time.Second, 2500 * time.Millisecond, "hello"},
+		{"VTT 小时超过 99", FormatVTT, "WEBVTT\n\n100:00:01.000 --> 100:00:02.000\nhello\n", 100*time.Hour + time.Second, 100*time.Hour + 2*time.Second, "hello"},
+		{"VTT 有标识行", FormatVTT, "WEBVTT\n\ncue-1\n00:00:01.000 --> 00:00:02.000 align:start\nhello\n", time.Second, 2 * time.Second, "hello"},
+		{"VTT BOM 和 CRLF", FormatVTT, "\ufeffWEBVTT\r\n\r\n00:00:01.000 --> 00:00:02.000\r\nhello\r\n", time.Second, 2 * time.Second, "hello"},
+		{"ASS 百分之一秒", FormatASS, "[Events]\nFormat: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\nDialogue: 0,0:00:01.50,0:00:02.25,Default,,0,0,0,,hello\n", 1500 * time.Millisecond, 2250 * time.Millisecond, "hello"},
+		{"ASS 小时超过 9", FormatASS, "[Events]\nFormat: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\nDialogue: 0,123:00:01.00,123:00:02.00,Default,,0,0,0,,hello\n", 123*time.Hour + time.Second, 123*time.Hour + 2*time.Second, "hello"},
+		{"ASS BOM 和 CRLF", FormatASS, "\ufeff[Events]\r\nFormat: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\r\nDialogue: 0,0:00:01.00,0:00:02.00,Default,,0,0,0,,hello\r\n", time.Second, 2 * time.Second, "hello"},
+		{"json3 毫秒", FormatJSON3, `{"events":[{"tStartMs":1500,"dDurationMs":750,"segs":[{"utf8":"hello"}]}]}`, 1500 * time.Millisecond, 2250 * time.Millisecond, "hello"},
+		{"BCC 秒", FormatBCC, `{"body":[{"from":1.5,"to":2.25,"content":"hello"}]}`, 1500 * time.Millisecond, 2250 * time.Millisecond, "hello"},
+		{"BCC 超过 99 小时", FormatBCC, `{"body":[{"from":360000.001,"to":360001,"content":"hello"}]}`, 100*time.Hour + time.Millisecond, 100*time.Hour + time.Second, "hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, err := Decode([]byte(tt.data), tt.format)
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if len(sub.Cues) != 1 {
+				t.Fatalf("got %d cues, want 1", len(sub.Cues))
+			}
+			cue := sub.Cues[0]
+			if cue.Start != tt.start || cue.End != tt.end || cue.Text != tt.text {
+				t.Errorf("got %v --> %v %q, want %v --> %v %q", cue.Start, cue.End, cue.Text, tt.start, tt.end, tt.text)
+			}
+			checkRoundTrip(t, []byte(tt.data), tt.format)
+		})
+	}
+}
+
+func TestEncodeLongTimestamps(t *testing.T) {
+	sub := &Subtitle{Cues: []Cue{{Start: 123*time.Hour + 4*time.Minute + 5678*time.Millisecond, End: 123*time.Hour + 4*time.Minute + 6*time.Second, Text: "hello"}}}
+	for _, format := range []Format{FormatSRT, FormatVTT, FormatASS, FormatSSA, FormatJSON3, FormatBCC} {
+		data, err := Encode(sub, format)
+		if err != nil {
+			t.Fatalf("Encode(%s): %v", format, err)
+		}
+		got, err := Decode(data, format)
+		if err != nil {
+			t.Fatalf("Decode(%s): %v", format, err)
+		}
+		want := sub.Cues[0]
+		if format == FormatASS || format == FormatSSA {
+			want.Start = want.Start.Round(10 * time.Millisecond)
+		}
+		if len(got.Cues) != 1 || got.Cues[0].Start != want.Start || got.Cues[0].End != want.End {
+			t.Errorf("%s: got %+v, want %v --> %v", format, got.Cues, want.Start, want.End)
+		}
+	}
+}
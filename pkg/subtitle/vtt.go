@@ -0,0 +1,123 @@
+package subtitle
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// vttTimePattern VTT 时间轴，小时可以省略，如 "00:01.000 --> 00:02.500 align:start"
+var vttTimePattern = regexp.MustCompile(`^\s*(?:(\d+):)?(\d{1,2}):(\d{1,2})\.(\d{3})\s*-->\s*(?:(\d+):)?(\d{1,2}):(\d{1,2})\.(\d{3})`)
+
+// vttTagPattern VTT 文本中的样式和逐字时间标签，如 <c>、</c>、<00:00:01.520>
+var vttTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// vttVoicePattern 说话人标签，如 <v Bob> 或 <v.loud Bob>
+var vttVoicePattern = regexp.MustCompile(`^<v(?:\.[^\s>]*)?\s+([^>]*)>`)
+
+// vttTimestampTagPattern 逐字时间标签，YouTube 自动字幕用它实现逐字滚动显示
+var vttTimestampTagPattern = regexp.MustCompile(`<(?:\d+:)?\d{2}:\d{2}\.\d{3}>`)
+
+// vttMinRollingDuration 滚动字幕中过渡条目的最长时长，YouTube 插入的过渡条目约 10 毫秒
+const vttMinRollingDuration = 50 * time.Millisecond
+
+// ParseVTT 解析 WebVTT 字幕，跳过 NOTE、STYLE 和 REGION 块
+// YouTube 自动字幕（带逐字时间标签）的每条字幕会重复上一条的最后一行，并插入持续约 10 毫秒的过渡条目，解析时去掉这些重复
+func ParseVTT(data []byte) (*Subtitle, error) {
+	content := normalizeNewlines(string(data))
+	if !strings.HasPrefix(strings.TrimLeft(content, " \t\n"), "WEBVTT") {
+		return nil, fmt.Errorf("不是有效的 VTT 字幕")
+	}
+	rolling := vttTimestampTagPattern.MatchString(content)
+
+	sub := &Subtitle{}
+	lastLine := ""
+	for _, block := range vttBlocks(content) {
+		if first := block[0]; strings.HasPrefix(first, "WEBVTT") || strings.HasPrefix(first, "NOTE") ||
+			strings.HasPrefix(first, "STYLE") || strings.HasPrefix(first, "REGION") {
+			continue
+		}
+		for i, line := range block {
+			match := vttTimePattern.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			cue := Cue{Start: clock(match[1], match[2], match[3], match[4]), End: clock(match[5], match[6], match[7], match[8])}
+			if rolling && cue.Duration() < vttMinRollingDuration {
+				break
+			}
+
+			var lines []string
+			for _, text := range block[i+1:] {
+				if voice := vttVoicePattern.FindStringSubmatch(text); voice != nil && len(lines) == 0 {
+					cue.Speaker = strings.TrimSpace(voice[1])
+				}
+				text = strings.TrimSpace(unescapeVTT(vttTagPattern.ReplaceAllString(text, "")))
+				if text == "" || (rolling && len(lines) == 0 && text == lastLine) {
+					continue
+				}
+				lines = append(lines, text)
+			}
+			if len(lines) > 0 {
+				lastLine = lines[len(lines)-1]
+				cue.Text = strings.Join(lines, "\n")
+				sub.Cues = append(sub.Cues, cue)
+			}
+			break
+		}
+	}
+	return sub, nil
+}
+
+// vttBlocks 按空行分隔的块，每块为非空的行
+func vttBlocks(content string) [][]string {
+	var blocks [][]string
+	var block []string
+	for _, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) == "" {
+			if len(block) > 0 {
+				blocks = append(blocks, block)
+				block = nil
+			}
+			continue
+		}
+		block = append(block, line)
+	}
+	if len(block) > 0 {
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+// unescapeVTT 还原 VTT 文本中的字符实体
+func unescapeVTT(text string) string {
+	return strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&nbsp;", " ", "&lrm;", "", "&rlm;", "").Replace(text)
+}
+
+// escapeVTT 转义 VTT 文本中的特殊字符
+func escapeVTT(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// WriteVTT 写入 WebVTT 字幕，说话人写为 <v> 标签
+func WriteVTT(w io.Writer, sub *Subtitle) error {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	for _, cue := range sub.Cues {
+		lines := textLines(cue.Text)
+		if len(lines) == 0 {
+			continue
+		}
+		for i, line := range lines {
+			lines[i] = escapeVTT(line)
+		}
+		if speaker := strings.Join(strings.Fields(strings.NewReplacer("<", "", ">", "").Replace(cue.Speaker)), " "); speaker != "" {
+			lines[0] = "<v " + speaker + ">" + lines[0]
+		}
+		fmt.Fprintf(&b, "%s --> %s\n%s\n\n", formatClock(cue.Start, "."), formatClock(cue.End, "."), strings.Join(lines, "\n"))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
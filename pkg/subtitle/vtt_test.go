@@ -0,0 +1,12 @@
+package subtitle
+
+import "testing"
+
+func FuzzParseVTT(f *testing.F) {
+	f.Add([]byte("WEBVTT\n\nNOTE comment\n\n00:01.000 --> 00:02.500 align:start\n<v Bob>hello &amp; <b>world</b>\n"))
+	f.Add([]byte("\ufeffWEBVTT\r\n\r\n100:00:01.000 --> 100:00:02.000\r\na &lt; b\r\n"))
+	f.Add([]byte("WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nhello<00:00:01.500><c> world</c>\n\n00:00:02.000 --> 00:00:02.010\nhello world\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkRoundTrip(t, data, FormatVTT)
+	})
+}
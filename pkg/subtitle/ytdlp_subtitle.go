@@ -1,11 +1,13 @@
 package subtitle
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"go.uber.org/zap"
@@ -14,6 +16,15 @@ import (
 // YtdlpSubtitleDownloader yt-dlp字幕下载器
 type YtdlpSubtitleDownloader struct {
 	logger *zap.SugaredLogger
+	opts   Options
+}
+
+// Options yt-dlp 路径、cookies 和代理设置
+type Options struct {
+	Binary             string // yt-dlp 可执行文件路径，为空时使用 PATH 中的 yt-dlp
+	CookiesFile        string // Netscape 格式的 cookies 文件
+	CookiesFromBrowser string // 没有 cookies 文件时从浏览器读取 cookies，如 chrome
+	Proxy              string // 代理地址，为空时不使用代理
 }
 
 // SubtitleInfo 字幕信息
@@ -41,12 +52,38 @@ func NewYtdlpSubtitleDownloader(logger *zap.SugaredLogger) *YtdlpSubtitleDownloa
 	}
 }
 
+// NewYtdlpSubtitleDownloaderWithOptions 创建使用指定 yt-dlp、cookies 和代理的字幕下载器
+func NewYtdlpSubtitleDownloaderWithOptions(logger *zap.SugaredLogger, opts Options) *YtdlpSubtitleDownloader {
+	return &YtdlpSubtitleDownloader{
+		logger: logger,
+		opts:   opts,
+	}
+}
+
+// command 创建 yt-dlp 命令，加上 cookies 和代理参数
+func (d *YtdlpSubtitleDownloader) command(ctx context.Context, args ...string) *exec.Cmd {
+	binary := d.opts.Binary
+	if binary == "" {
+		binary = "yt-dlp"
+	}
+	var common []string
+	if d.opts.CookiesFile != "" {
+		common = append(common, "--cookies", d.opts.CookiesFile)
+	} else if d.opts.CookiesFromBrowser != "" {
+		common = append(common, "--cookies-from-browser", d.opts.CookiesFromBrowser)
+	}
+	if d.opts.Proxy != "" {
+		common = append(common, "--proxy", d.opts.Proxy)
+	}
+	return exec.CommandContext(ctx, binary, append(common, args...)...)
+}
+
 // ListSubtitles 列出视频所有可用字幕
 func (d *YtdlpSubtitleDownloader) ListSubtitles(videoURL string) (*VideoSubtitles, error) {
 	d.logger.Infof("获取视频字幕列表: %s", videoURL)
 
 	// 使用yt-dlp获取视频信息（包含字幕列表）
-	cmd := exec.Command("yt-dlp",
+	cmd := d.command(context.Background(),
 		"--dump-json",
 		"--skip-download",
 		videoURL,
@@ -138,7 +175,7 @@ func (d *YtdlpSubtitleDownloader) DownloadSubtitle(videoURL, language, format, o
 		videoURL,
 	}
 
-	cmd := exec.Command("yt-dlp", args...)
+	cmd := d.command(context.Background(), args...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return "", fmt.Errorf("下载字幕失败: %w, 输出: %s", err, string(output))
@@ -169,6 +206,50 @@ func (d *YtdlpSubtitleDownloader) DownloadSubtitle(videoURL, language, format, o
 	return subtitleFile, nil
 }
 
+// trackFormats 下载单个字幕时优先使用的格式，json3 的时间轴比 vtt 更准确，且自动字幕没有重复的行
+const trackFormats = "json3/vtt/srt/best"
+
+// DownloadTrack 下载指定语言的字幕，保留平台提供的原始格式（json3、vtt 或 srt），不依赖 ffmpeg 转换
+// automatic 为 true 时下载自动生成的字幕，否则下载上传者提供的字幕
+// outputPath: 输出路径（不含扩展名），返回下载的文件路径
+func (d *YtdlpSubtitleDownloader) DownloadTrack(ctx context.Context, videoURL, language string, automatic bool, outputPath string) (string, error) {
+	d.logger.Infof("下载字幕: 语言=%s, 自动生成=%v", language, automatic)
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return "", fmt.Errorf("创建输出目录失败: %w", err)
+	}
+
+	writeFlag := "--write-subs"
+	if automatic {
+		writeFlag = "--write-auto-subs"
+	}
+	cmd := d.command(ctx,
+		"--skip-download",
+		"--no-playlist",
+		writeFlag,
+		"--sub-langs", regexp.QuoteMeta(language),
+		"--sub-format", trackFormats,
+		"-o", outputPath+".%(ext)s",
+		"--", videoURL,
+	)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", fmt.Errorf("下载字幕失败: %w, 输出: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	for _, ext := range []string{"json3", "vtt", "srt"} {
+		file := fmt.Sprintf("%s.%s.%s", outputPath, language, ext)
+		if _, err := os.Stat(file); err == nil {
+			d.logger.Infof("字幕已下载: %s", file)
+			return file, nil
+		}
+	}
+	return "", fmt.Errorf("字幕文件未生成: %s.%s.*", outputPath, language)
+}
+
 // DownloadAllSubtitles 下载所有可用字幕
 func (d *YtdlpSubtitleDownloader) DownloadAllSubtitles(videoURL, format, outputPath string) ([]string, error) {
 	d.logger.Infof("下载所有字幕: 格式=%s", format)
@@ -191,7 +272,7 @@ func (d *YtdlpSubtitleDownloader) DownloadAllSubtitles(videoURL, format, outputP
 		videoURL,
 	}
 
-	cmd := exec.Command("yt-dlp", args...)
+	cmd := d.command(context.Background(), args...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return nil, fmt.Errorf("下载字幕失败: %w, 输出: %s", err, string(output))
@@ -2,17 +2,18 @@ package utils
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 )
 
 // TranscodeVideo 使用 H.264 编码器转码视频文件
-func TranscodeVideo(inputVideoPath, outputVideoPath, preset string, crf int, audioBitrate string, fps int) error {
+func TranscodeVideo(ctx context.Context, inputVideoPath, outputVideoPath, preset string, crf int, audioBitrate string, fps int) error {
 	// 构建 ffmpeg 命令参数
 	cmd := []string{
 		"-y",
@@ -27,7 +28,7 @@ func TranscodeVideo(inputVideoPath, outputVideoPath, preset string, crf int, aud
 	}
 
 	// 创建 ffmpeg 命令对象
-	ffmpegCmd := exec.Command("ffmpeg", cmd...)
+	ffmpegCmd := CommandContext(ctx, "ffmpeg", cmd...)
 
 	// 执行命令并捕获输出
 	output, err := ffmpegCmd.CombinedOutput()
@@ -41,9 +42,9 @@ func TranscodeVideo(inputVideoPath, outputVideoPath, preset string, crf int, aud
 }
 
 // ExtractWaveAudio 从视频文件中分离出WAV格式的音频
-func ExtractWaveAudio(inputFile, outputFile string) error {
+func ExtractWaveAudio(ctx context.Context, inputFile, outputFile string) error {
 	// 构造 ffmpeg 命令，提取音频并转换为WAV格式
-	cmd := exec.Command(
+	cmd := CommandContext(ctx,
 		"ffmpeg",
 		"-y",                    // 覆盖输出文件
 		"-i", inputFile,         // 输入文件
@@ -69,9 +70,9 @@ func ExtractWaveAudio(inputFile, outputFile string) error {
 }
 
 // ExtractAudio 从视频文件中分离出音频
-func ExtractAudio(inputFile, outputFile string) error {
+func ExtractAudio(ctx context.Context, inputFile, outputFile string) error {
 	// 构造 ffmpeg 命令
-	cmd := exec.Command(
+	cmd := CommandContext(ctx,
 		"ffmpeg",
 		"-y",
 		"-i", inputFile, // 输入文件
@@ -95,9 +96,9 @@ func ExtractAudio(inputFile, outputFile string) error {
 
 //测试不能使用
 
-func Split_audio_byray(inputFile, outputFile string) error {
+func Split_audio_byray(ctx context.Context, inputFile, outputFile string) error {
 	// 构造 ffmpeg 命令
-	cmd := exec.Command(
+	cmd := CommandContext(ctx,
 		"ffmpeg",
 		"-y",
 		"-i", inputFile, // 输入文件
@@ -142,9 +143,9 @@ func CheckAudioFile(filePath string) (bool, error) {
 }
 
 // ExtractVideoWithoutAudio 从视频中分离无音视频并编码为 H.264
-func ExtractVideoWithoutAudio(inputVideoPath, outputVideoPath string) error {
+func ExtractVideoWithoutAudio(ctx context.Context, inputVideoPath, outputVideoPath string) error {
 	// 构建 ffmpeg 命令及其参数
-	cmd := exec.Command(
+	cmd := CommandContext(ctx,
 		"ffmpeg",
 		"-y",
 		"-i", inputVideoPath,
@@ -168,9 +169,9 @@ func ExtractVideoWithoutAudio(inputVideoPath, outputVideoPath string) error {
 	return nil
 }
 
-func ExtractThumbnail(videoPath, outputPath string) error {
+func ExtractThumbnail(ctx context.Context, videoPath, outputPath string) error {
 	// 构建 ffmpeg 命令
-	cmd := exec.Command("ffmpeg", "-y", "-i", videoPath, "-ss", "00:00:01", "-vframes", "1", outputPath)
+	cmd := CommandContext(ctx, "ffmpeg", "-y", "-i", videoPath, "-ss", "00:00:01", "-vframes", "1", outputPath)
 
 	// 执行命令
 	err := cmd.Run()
@@ -181,7 +182,7 @@ func ExtractThumbnail(videoPath, outputPath string) error {
 	return nil
 }
 
-func ConvertToHLS(inputPath, outputDir string) error {
+func ConvertToHLS(ctx context.Context, inputPath, outputDir string) error {
 	// 确保输出目录存在
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		return err
@@ -193,7 +194,7 @@ func ConvertToHLS(inputPath, outputDir string) error {
 	//}
 
 	// FFmpeg 命令：将 MP4 转为 HLS
-	cmd := exec.Command(
+	cmd := CommandContext(ctx,
 		"ffmpeg",
 		"-i", inputPath, // 输入文件
 		"-c:v", "libx264", // 视频编码 H.264
@@ -268,3 +269,95 @@ func ParseM3U8File(filePath string) ([]string, error) {
 
 	return fullPaths, nil
 }
+
+// Silence 音频中的一段静音，单位为秒
+type Silence struct {
+	Start float64
+	End   float64
+}
+
+var (
+	silenceStartPattern = regexp.MustCompile(`silence_start: (-?[\d.]+)`)
+	silenceEndPattern   = regexp.MustCompile(`silence_end: ([\d.]+)`)
+	durationPattern     = regexp.MustCompile(`Duration: (\d+):(\d{2}):(\d{2}(?:\.\d+)?)`)
+)
+
+// DetectSilences 使用 ffmpeg silencedetect 检测音频中的静音，同时返回音频时长（秒）
+// noiseDB 为静音的音量阈值（如 -35），minDuration 为最短的静音时长（秒）
+func DetectSilences(ctx context.Context, inputFile string, noiseDB, minDuration float64) ([]Silence, float64, error) {
+	cmd := CommandContext(ctx,
+		"ffmpeg",
+		"-hide_banner",
+		"-nostats",
+		"-i", inputFile,
+		"-af", fmt.Sprintf("silencedetect=noise=%gdB:d=%g", noiseDB, minDuration),
+		"-f", "null",
+		"-",
+	)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, 0, fmt.Errorf("ffmpeg 检测静音失败: %v", err)
+	}
+
+	duration := parseDuration(output)
+	if duration <= 0 {
+		return nil, 0, fmt.Errorf("无法获取音频时长: %s", inputFile)
+	}
+
+	var silences []Silence
+	for _, line := range strings.Split(string(output), "\n") {
+		if match := silenceStartPattern.FindStringSubmatch(line); match != nil {
+			start, _ := strconv.ParseFloat(match[1], 64)
+			// 静音持续到音频结束时没有 silence_end
+			silences = append(silences, Silence{Start: max(start, 0), End: duration})
+		} else if match := silenceEndPattern.FindStringSubmatch(line); match != nil && len(silences) > 0 {
+			silences[len(silences)-1].End, _ = strconv.ParseFloat(match[1], 64)
+		}
+	}
+	return silences, duration, nil
+}
+
+// AudioDuration 读取音频的时长（秒），只读取文件头，不解码音频
+func AudioDuration(ctx context.Context, inputFile string) (float64, error) {
+	// 没有指定输出文件时 ffmpeg 以错误退出，但已输出文件信息
+	output, _ := CommandContext(ctx, "ffmpeg", "-hide_banner", "-i", inputFile).CombinedOutput()
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+	duration := parseDuration(output)
+	if duration <= 0 {
+		return 0, fmt.Errorf("无法获取音频时长: %s", inputFile)
+	}
+	return duration, nil
+}
+
+// parseDuration 从 ffmpeg 输出的文件信息中解析时长（秒），没有时返回 0
+func parseDuration(output []byte) float64 {
+	match := durationPattern.FindSubmatch(output)
+	if match == nil {
+		return 0
+	}
+	hours, _ := strconv.Atoi(string(match[1]))
+	minutes, _ := strconv.Atoi(string(match[2]))
+	seconds, _ := strconv.ParseFloat(string(match[3]), 64)
+	return float64(hours*3600+minutes*60) + seconds
+}
+
+// CutAudio 截取音频中从 start 开始、长度为 duration 的一段（秒），不重新编码
+func CutAudio(ctx context.Context, inputFile, outputFile string, start, duration float64) error {
+	cmd := CommandContext(ctx,
+		"ffmpeg",
+		"-y",
+		"-hide_banner",
+		"-loglevel", "error",
+		"-ss", strconv.FormatFloat(start, 'f', 3, 64),
+		"-t", strconv.FormatFloat(duration, 'f', 3, 64),
+		"-i", inputFile,
+		"-c", "copy",
+		outputFile,
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg 截取音频失败: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
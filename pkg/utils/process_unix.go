@@ -0,0 +1,23 @@
+//go:build !windows
+
+package utils
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// CommandContext 创建可随上下文取消的外部命令
+// 命令在独立的进程组中运行，取消时结束整个进程组，避免 yt-dlp 调用的 ffmpeg 等子进程残留
+func CommandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	// 进程结束后最多等待输出管道关闭的时间
+	cmd.WaitDelay = 5 * time.Second
+	return cmd
+}
@@ -0,0 +1,17 @@
+//go:build windows
+
+package utils
+
+import (
+	"context"
+	"os/exec"
+	"time"
+)
+
+// CommandContext 创建可随上下文取消的外部命令，取消时结束该进程
+func CommandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	// 进程结束后最多等待输出管道关闭的时间
+	cmd.WaitDelay = 5 * time.Second
+	return cmd
+}
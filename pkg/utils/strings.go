@@ -92,6 +92,18 @@ func Stamp2str(timestamp int64) string {
 	return time.Unix(timestamp, 0).Format("2006-01-02 15:04:05")
 }
 
+// FormatClock 将秒数格式化为 03:25 或 1:02:03 形式，用于章节时间和视频时长
+func FormatClock(seconds float64) string {
+	total := int(seconds)
+	if total < 0 {
+		total = 0
+	}
+	if total >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", total/3600, total/60%60, total%60)
+	}
+	return fmt.Sprintf("%02d:%02d", total/60, total%60)
+}
+
 // Str2stamp 字符串转时间戳
 func Str2stamp(str string) int64 {
 	if len(str) == 0 {
@@ -1,9 +1,6 @@
 package utils
 
 import (
-	"errors"
-	"fmt"
-	"net/url"
 	"regexp"
 	"strings"
 )
@@ -38,60 +35,3 @@ func ExtractBvidFromURL(url string) string {
 
 	return ""
 }
-
-func extractBiliVideoID(videoURL string) string {
-	// 去掉前缀 ":"
-	videoURL = strings.TrimPrefix(videoURL, ":")
-
-	// 解析 URL
-	parsedURL, err := url.Parse(videoURL)
-	if err != nil {
-		fmt.Printf("URL 解析失败: %v\n", err)
-		return ""
-	}
-
-	// 提取路径中的视频 ID
-	pathSegments := strings.Split(parsedURL.Path, "/")
-	if len(pathSegments) > 2 {
-		videoID := pathSegments[2]
-		// 检查是否有分页参数
-		if page := parsedURL.Query().Get("p"); page != "" {
-			videoID += "_p" + page
-		}
-		return videoID
-	}
-
-	return ""
-}
-
-// ExtractVideoID 从 YouTube URL 中提取视频 Id
-func extractYoutTuBeVideoID(url string) (string, error) {
-	pattern := `(?:v=|\/)([0-9A-Za-z_-]{11})`
-	re, err := regexp.Compile(pattern)
-	if err != nil {
-		return "", err
-	}
-	match := re.FindStringSubmatch(url)
-	if len(match) > 1 {
-		return match[1], nil
-	}
-	return "", errors.New("Invalid YouTube URL")
-}
-
-func ExtractVideoID(videoURL string) string {
-
-	parsedURL, err := url.Parse(videoURL)
-	if err != nil {
-		fmt.Printf("URL 解析失败: %v\n", err)
-		return "unknown"
-	}
-
-	host := parsedURL.Host
-	if strings.Contains(host, "youtube.com") || strings.Contains(host, "youtu.be") {
-		videoId, _ := extractYoutTuBeVideoID(videoURL)
-		return videoId
-	} else if strings.Contains(host, "bilibili.com") || strings.Contains(host, "b23.tv") {
-		return extractBiliVideoID(videoURL)
-	}
-	return RandString(12)
-}
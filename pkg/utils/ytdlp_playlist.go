@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PlaylistEntry yt-dlp --flat-playlist 列出的视频
+type PlaylistEntry struct {
+	ID            string  `json:"id"`
+	Title         string  `json:"title"`
+	URL           string  `json:"url"`
+	Duration      float64 `json:"duration"`
+	LiveStatus    string  `json:"live_status"` // is_live、is_upcoming 表示直播中或尚未开始
+	PlaylistID    string  `json:"playlist_id"`
+	PlaylistTitle string  `json:"playlist_title"`
+	Channel       string  `json:"channel"`
+	ChannelID     string  `json:"channel_id"`
+}
+
+// ListPlaylistEntries 使用 yt-dlp 列出频道或播放列表中最新的 limit 个视频，不下载视频
+// extraArgs 会添加到 URL 之前，例如 --cookies、--proxy
+func ListPlaylistEntries(ctx context.Context, ytdlpPath, url string, limit int, extraArgs ...string) ([]PlaylistEntry, error) {
+	args := []string{"--flat-playlist", "--dump-json", "--ignore-errors"}
+	if limit > 0 {
+		args = append(args, "--playlist-end", strconv.Itoa(limit))
+	}
+	args = append(args, extraArgs...)
+	args = append(args, "--", url)
+
+	var stdout, stderr bytes.Buffer
+	cmd := CommandContext(ctx, ytdlpPath, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+
+	var entries []PlaylistEntry
+	scanner := bufio.NewScanner(&stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var entry PlaylistEntry
+		if err := json.Unmarshal(line, &entry); err != nil || entry.ID == "" {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	// --ignore-errors 时部分视频不可用也会返回错误码，只要列出了视频就视为成功
+	if runErr != nil && len(entries) == 0 {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("执行 yt-dlp 失败: %v, 输出: %s", runErr, strings.TrimSpace(stderr.String()))
+	}
+	return entries, nil
+}
@@ -15,6 +15,7 @@ interface Video {
 }
 
 interface TaskStep {
+  step_key: string;
   step_name: string;
   step_order: number;
   status: string;
@@ -96,9 +97,9 @@ export default function TaskQueueStats({ onVideoSelect }: TaskQueueStatsProps) {
     }
   };
 
-  const handleRetryStep = async (videoId: number, stepName: string) => {
+  const handleRetryStep = async (videoId: number, stepKey: string) => {
     try {
-      const response = await fetch(`/api/v1/videos/${videoId}/steps/${stepName}/retry`, {
+      const response = await fetch(`/api/v1/videos/${videoId}/steps/${stepKey}/retry`, {
         method: 'POST',
       });
       const data = await response.json();
@@ -139,6 +140,8 @@ export default function TaskQueueStats({ onVideoSelect }: TaskQueueStatsProps) {
       '301': { label: '上传字幕中', color: 'bg-indigo-100 text-indigo-700', icon: Upload, category: 'uploading' },
       '399': { label: '字幕上传失败', color: 'bg-orange-100 text-orange-700', icon: AlertCircle, category: 'failed' },
       '400': { label: '全部完成', color: 'bg-emerald-100 text-emerald-700', icon: CheckCircle, category: 'completed' },
+      '900': { label: '需要处理', color: 'bg-yellow-100 text-yellow-700', icon: AlertCircle, category: 'failed' },
+      '997': { label: '已过滤', color: 'bg-gray-100 text-gray-500', icon: AlertCircle, category: 'all' },
       '999': { label: '任务失败', color: 'bg-red-100 text-red-700', icon: AlertCircle, category: 'failed' },
     };
     return statusMap[status] || { label: '未知', color: 'bg-gray-100 text-gray-700', icon: AlertCircle, category: 'all' };
@@ -156,6 +159,8 @@ export default function TaskQueueStats({ onVideoSelect }: TaskQueueStatsProps) {
       '301': '正在上传字幕到Bilibili',
       '399': '字幕上传失败，需要重试',
       '400': '所有任务已完成',
+      '900': '登录状态、API Key 失效或额度不足，处理后重试失败的步骤',
+      '997': '视频不符合过滤规则，不再处理',
       '999': '准备阶段失败，需要检查任务步骤',
     };
     return stageMap[status] || '未知状态';
@@ -168,7 +173,7 @@ export default function TaskQueueStats({ onVideoSelect }: TaskQueueStatsProps) {
       uploading: videos.filter(v => ['201', '301'].includes(v.status)),
       uploaded: videos.filter(v => v.status === '300'),
       completed: videos.filter(v => v.status === '400'),
-      failed: videos.filter(v => ['299', '399', '999'].includes(v.status)),
+      failed: videos.filter(v => ['299', '399', '900', '999'].includes(v.status)),
     };
   };
 
@@ -315,7 +320,7 @@ export default function TaskQueueStats({ onVideoSelect }: TaskQueueStatsProps) {
                         ) : detailedVideo && detailedVideo.task_steps ? (
                           <TaskStepDetail 
                             steps={detailedVideo.task_steps} 
-                            onRetry={(stepName) => handleRetryStep(video.id, stepName)}
+                            onRetry={(stepKey) => handleRetryStep(video.id, stepKey)}
                           />
                         ) : (
                           <div className="text-center text-gray-500">无任务步骤信息</div>
@@ -445,7 +450,7 @@ export default function TaskQueueStats({ onVideoSelect }: TaskQueueStatsProps) {
   );
 }
 
-const TaskStepDetail = ({ steps, onRetry }: { steps: TaskStep[], onRetry: (stepName: string) => void }) => {
+const TaskStepDetail = ({ steps, onRetry }: { steps: TaskStep[], onRetry: (stepKey: string) => void }) => {
   const getStatusColor = (status: string) => {
     switch (status) {
       case 'completed':
@@ -465,7 +470,7 @@ const TaskStepDetail = ({ steps, onRetry }: { steps: TaskStep[], onRetry: (stepN
       <h5 className="font-semibold text-gray-800">任务步骤</h5>
       <ul className="space-y-2">
         {steps.sort((a, b) => a.step_order - b.step_order).map(step => (
-          <li key={step.step_name} className="p-3 bg-white rounded-lg border border-gray-200">
+          <li key={step.step_key} className="p-3 bg-white rounded-lg border border-gray-200">
             <div className="flex items-center justify-between">
               <div className="flex-1">
                 <div className="flex items-center space-x-2">
@@ -480,7 +485,7 @@ const TaskStepDetail = ({ steps, onRetry }: { steps: TaskStep[], onRetry: (stepN
               </div>
               {step.can_retry && (
                 <button
-                  onClick={() => onRetry(step.step_name)}
+                  onClick={() => onRetry(step.step_key)}
                   className="px-3 py-1 text-xs text-blue-600 bg-blue-100 hover:bg-blue-200 rounded"
                 >
                   重试
@@ -6,7 +6,7 @@ import { TaskStep, TaskStepStatus, TASK_STEP_STATUS_MAP, TASK_STEP_NAMES } from
 
 interface TaskStepListProps {
   steps: TaskStep[];
-  onRetryStep: (stepName: string) => Promise<void>;
+  onRetryStep: (stepKey: string) => Promise<void>;
   isRetrying?: boolean;
 }
 
@@ -57,12 +57,12 @@ export default function TaskStepList({ steps, onRetryStep, isRetrying = false }:
     });
   };
 
-  const handleRetry = async (stepName: string) => {
+  const handleRetry = async (stepKey: string) => {
     if (retryingStep || isRetrying) return;
     
-    setRetryingStep(stepName);
+    setRetryingStep(stepKey);
     try {
-      await onRetryStep(stepName);
+      await onRetryStep(stepKey);
     } finally {
       setRetryingStep(null);
     }
@@ -87,8 +87,8 @@ export default function TaskStepList({ steps, onRetryStep, isRetrying = false }:
       <div className="divide-y divide-gray-200">
         {sortedSteps.map((step, index) => {
           const statusInfo = TASK_STEP_STATUS_MAP[step.status] || TASK_STEP_STATUS_MAP['pending'];
-          const stepName = TASK_STEP_NAMES[step.step_name as keyof typeof TASK_STEP_NAMES] || step.step_name;
-          const isCurrentlyRetrying = retryingStep === step.step_name;
+          const stepName = TASK_STEP_NAMES[step.step_key as keyof typeof TASK_STEP_NAMES] || step.step_name;
+          const isCurrentlyRetrying = retryingStep === step.step_key;
 
           return (
             <div key={step.id} className="px-6 py-4 hover:bg-gray-50 transition-colors">
@@ -159,7 +159,7 @@ export default function TaskStepList({ steps, onRetryStep, isRetrying = false }:
                 <div className="flex-shrink-0 ml-4">
                   {canRetryStep(step) && (
                     <button
-                      onClick={() => handleRetry(step.step_name)}
+                      onClick={() => handleRetry(step.step_key)}
                       disabled={isCurrentlyRetrying || retryingStep !== null}
                       className="inline-flex items-center px-3 py-1.5 border border-gray-300 shadow-sm text-xs font-medium rounded text-gray-700 bg-white hover:bg-gray-50 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-indigo-500 disabled:opacity-50 disabled:cursor-not-allowed"
                     >
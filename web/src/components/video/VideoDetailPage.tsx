@@ -44,9 +44,9 @@ export default function VideoDetailPage({ videoId, onBack }: VideoDetailPageProp
     fetchVideoDetail();
   }, [videoId, fetchVideoDetail]);
 
-  const handleRetryStep = async (stepName: string) => {
+  const handleRetryStep = async (stepKey: string) => {
     try {
-      const response = await videoApi.retryTaskStep(videoId, stepName);
+      const response = await videoApi.retryTaskStep(videoId, stepKey);
       if (response.code === 200 || response.code === 0) {
         // 重新获取视频详情以更新状态
         setTimeout(() => fetchVideoDetail(true), 1000);
@@ -3,8 +3,10 @@ import type {
   ApiResponse, 
   Video, 
   VideoDetail,
+  VideoListQuery,
   TaskStep,
   VideoFile,
+  VideoStatusHistory,
   QRCodeResponse, 
   LoginStatus, 
   VideoSubmissionRequest,
@@ -103,8 +105,8 @@ export const authApi = {
 // 视频相关 API
 export const videoApi = {
   // 获取视频列表
-  getVideos: (page = 1, limit = 10): Promise<ApiResponse<{ videos: Video[], total: number }>> => {
-    return api.get(`/videos?page=${page}&limit=${limit}`);
+  getVideos: (page = 1, limit = 10, query: VideoListQuery = {}): Promise<ApiResponse<{ videos: Video[], total: number }>> => {
+    return api.get('/videos', { params: { ...query, page, limit } });
   },
 
   // 获取单个视频详情
@@ -122,9 +124,14 @@ export const videoApi = {
     return api.get(`/videos/${id}/files`);
   },
 
+  // 获取视频状态变更历史
+  getVideoHistory: (id: string): Promise<ApiResponse<{ video_id: string; status: string; history: VideoStatusHistory[] }>> => {
+    return api.get(`/videos/${id}/history`);
+  },
+
   // 重试任务步骤
-  retryTaskStep: (videoId: string, stepName: string): Promise<ApiResponse> => {
-    return api.post(`/videos/${videoId}/steps/${stepName}/retry`);
+  retryTaskStep: (videoId: string, stepKey: string): Promise<ApiResponse> => {
+    return api.post(`/videos/${videoId}/steps/${stepKey}/retry`);
   },
 
   // 提交新视频
@@ -51,9 +51,17 @@ export interface VIPProduct {
 export interface Video {
   id: number;
   video_id: string;
+  canonical_id?: string;
+  subtitle_source?: string; // 原语言字幕的来源: manual_target、manual_source、auto、extension、asr
   title: string;
   url: string;
   status: VideoStatus;
+  pipeline?: string; // 处理流程名称，为空表示默认流程
+  uploader?: string; // 原视频作者
+  upload_date?: string; // 原视频发布日期，格式 2006-01-02
+  duration?: number; // 时长（秒）
+  view_count?: number; // 播放量
+  language?: string; // 原视频语言
   created_at: string;
   updated_at: string;
   subtitles?: Subtitle[];
@@ -63,6 +71,7 @@ export interface Video {
 export interface TaskStep {
   id: number;
   video_id: string;
+  step_key: string;
   step_name: string;
   step_order: number;
   status: TaskStepStatus;
@@ -70,6 +79,8 @@ export interface TaskStep {
   end_time?: string;
   duration?: number; // 持续时间，毫秒
   error_msg?: string;
+  error_class?: 'transient' | 'auth' | 'quota' | 'permanent';
+  attempts?: number;
   result_data?: any;
   can_retry: boolean;
   created_at: string;
@@ -85,9 +96,51 @@ export interface TaskProgress {
   is_running: boolean;
 }
 
+// 原视频章节
+export interface Chapter {
+  title: string;
+  translated_title?: string; // 翻译后的标题
+  start_time: number; // 开始时间（秒）
+  end_time: number; // 结束时间（秒）
+}
+
+// 视频列表的筛选和排序参数
+export interface VideoListQuery {
+  status?: VideoStatus;
+  keyword?: string; // 标题包含的文字
+  uploader?: string;
+  channel_id?: string;
+  language?: string;
+  tag?: string;
+  category?: string;
+  uploaded_after?: string; // 格式 2006-01-02
+  uploaded_before?: string;
+  min_duration?: number; // 秒
+  max_duration?: number;
+  min_views?: number;
+  sort?: 'created_at' | 'upload_date' | 'duration' | 'view_count' | 'title' | 'uploader';
+  order?: 'asc' | 'desc';
+}
+
 export interface VideoDetail {
   id: number;
   video_id: string;
+  canonical_id?: string;
+  subtitle_source?: string; // 原语言字幕的来源: manual_target、manual_source、auto、extension、asr
+  format_id?: string; // 下载时选择的 yt-dlp 格式ID，如 137+140
+  resolution?: string; // 下载的视频分辨率，如 1920x1080
+  codec?: string; // 下载的视频编码，如 avc1.640028
+  uploader?: string; // 原视频作者
+  channel_id?: string; // 原视频的频道ID
+  upload_date?: string; // 原视频发布日期，格式 2006-01-02
+  duration?: number; // 时长（秒）
+  view_count?: number; // 播放量
+  language?: string; // 原视频语言
+  thumbnail_url?: string; // 原视频封面地址
+  source_tags?: string[]; // 原视频标签
+  categories?: string[]; // 原视频分类
+  chapters?: Chapter[]; // 原视频章节
+  chapter_source?: 'platform' | 'description' | 'ai'; // 章节的来源
   title: string;
   url: string;
   status: VideoStatus;
@@ -112,7 +165,19 @@ export interface VideoFile {
 
 export type TaskStepStatus = 'pending' | 'running' | 'completed' | 'failed' | 'skipped';
 
-export type VideoStatus = '001' | '002' | '200' | '999';
+export type VideoStatus =
+  | '001' | '002' | '200' | '201' | '299' | '300' | '301' | '399' | '400'
+  | '900' | '997' | '998' | '999';
+
+export interface VideoStatusHistory {
+  id: number;
+  video_id: string;
+  from_status: VideoStatus | '';
+  to_status: VideoStatus;
+  actor: string;
+  reason: string;
+  created_at: string;
+}
 
 export interface Subtitle {
   id?: number;
@@ -181,10 +246,14 @@ export const TASK_STEP_STATUS_MAP = {
 } as const;
 
 export const TASK_STEP_NAMES = {
-  'download_video': '下载视频',
+  'download': '下载视频',
+  'extract_audio': '分离音频',
+  'asr': '语音转录',
   'generate_subtitles': '生成字幕',
-  'translate_subtitles': '翻译字幕',
-  'generate_metadata': '生成元数据',
-  'upload_to_bilibili': '上传到B站',
-  'upload_subtitles': '上传字幕',
+  'cover': '下载封面',
+  'chapters': '生成章节',
+  'translate': '翻译字幕',
+  'metadata': '生成视频元数据',
+  'upload_video': '上传到B站',
+  'upload_subtitle': '上传字幕',
 } as const;
\ No newline at end of file